@@ -0,0 +1,754 @@
+// Package admin serves a web interface for managing a blog's posts under
+// /admin: a searchable table of posts, a Markdown editor with a live preview,
+// publishing, revision history and the trash. Its pages are rendered on the
+// server from embedded templates and backed by the same service.Service as
+// the API. Admins and editors sign in with their email and password.
+package admin
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"example/auth"
+	"example/models"
+	"example/ratelimit"
+	"example/service"
+	"example/site"
+	"example/tenant"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultSessionTTL is how long a session lasts unless configured.
+const DefaultSessionTTL = 12 * time.Hour
+
+// DefaultAttemptLimit is how many times a client IP, and separately an
+// email, may try to sign in unless configured.
+var DefaultAttemptLimit = ratelimit.Limit{Burst: 10, Per: 15 * time.Minute}
+
+// pageSize is the number of posts per page of the posts table.
+const pageSize = 25
+
+// Roles lists the roles of the users who may sign in.
+var Roles = []string{models.RoleAdmin, models.RoleEditor}
+
+//go:embed templates static
+var embedded embed.FS
+
+// pages are the page templates, each parsed along with layout.html.
+var pages = []string{"login.html", "posts.html", "edit.html", "revisions.html", "trash.html", "error.html"}
+
+// notices are the messages shown after a form was handled, by the code
+// passed in the notice query parameter of the page redirected to.
+var notices = map[string]string{
+	"created":           "Post created.",
+	"saved":             "Post saved.",
+	"published":         "Post published.",
+	"unpublished":       "Post unpublished; it is a draft again.",
+	"trashed":           "Post moved to the trash.",
+	"restored":          "Post restored from the trash.",
+	"purged":            "Post deleted for good.",
+	"revision_restored": "Revision restored.",
+	"signed_out":        "You have signed out.",
+}
+
+// Config configures an Admin.
+type Config struct {
+	// Login returns the user with an email and password, or
+	// auth.ErrInvalidCredentials, as auth.Login does.
+	Login func(ctx context.Context, email, password string) (*models.User, error)
+	// User returns the user with an email, or auth.ErrUnknownUser.
+	User func(ctx context.Context, email string) (*models.User, error)
+	// Secret signs sessions. It must be the same on every instance serving
+	// the admin; changing it signs everyone out.
+	Secret []byte
+	// SessionTTL is how long a session lasts; zero is DefaultSessionTTL.
+	SessionTTL time.Duration
+	// RawHTML renders the HTML in previews, as site.Config.RawHTML does.
+	RawHTML bool
+	// Attempts counts sign-in attempts by client IP and by email, each
+	// allowed AttemptLimit, zero being DefaultAttemptLimit. Nil doesn't
+	// limit them.
+	Attempts     ratelimit.Store
+	AttemptLimit ratelimit.Limit
+	// Audit records sign-ins and failed attempts, as audit.Record does; nil
+	// doesn't.
+	Audit func(ctx context.Context, actor *models.User, e *models.AuditEntry) error
+}
+
+// Admin serves the admin interface for the posts of a service.Service.
+type Admin struct {
+	service    service.Service
+	login      func(ctx context.Context, email, password string) (*models.User, error)
+	user       func(ctx context.Context, email string) (*models.User, error)
+	secret     []byte
+	sessionTTL time.Duration
+	attempts   ratelimit.Store
+	limit      ratelimit.Limit
+	audit      func(ctx context.Context, actor *models.User, e *models.AuditEntry) error
+	markdown   *site.Markdown
+	pages      map[string]*template.Template
+	static     fs.FS
+}
+
+// New returns an Admin managing the posts of svc.
+func New(svc service.Service, cfg Config) (*Admin, error) {
+	if len(cfg.Secret) == 0 {
+		return nil, errors.New("admin: no session secret")
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = DefaultSessionTTL
+	}
+	if cfg.AttemptLimit.Burst <= 0 || cfg.AttemptLimit.Per <= 0 {
+		cfg.AttemptLimit = DefaultAttemptLimit
+	}
+	md := site.NewMarkdown(cfg.RawHTML)
+	a := &Admin{service: svc, login: cfg.Login, user: cfg.User, secret: cfg.Secret,
+		sessionTTL: cfg.SessionTTL, attempts: cfg.Attempts, limit: cfg.AttemptLimit, audit: cfg.Audit,
+		markdown: md, pages: map[string]*template.Template{}}
+
+	funcs := template.FuncMap{
+		"markdown": md.Render,
+		"date":     func(t time.Time) string { return t.Format("2 Jan 2006 15:04") },
+		"field":    func(errs map[string]string, name string) string { return errs[name] },
+	}
+	for _, page := range pages {
+		tmpl, err := template.New("").Funcs(funcs).ParseFS(embedded, "templates/layout.html", "templates/"+page)
+		if err != nil {
+			return nil, err
+		}
+		a.pages[page] = tmpl
+	}
+	var err error
+	if a.static, err = fs.Sub(embedded, "static"); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Register adds the admin's pages to r, which must be mounted at /admin,
+// each behind the before handlers, such as a request timeout.
+func (a *Admin) Register(r fiber.Router, before ...fiber.Handler) {
+	add := func(r fiber.Router, method, path string, h fiber.Handler) {
+		r.Add(method, path, append(slices.Clip(before), h)...)
+	}
+	add(r, fiber.MethodGet, "/static/*", a.Static)
+	r.Use(a.errorPage, a.checkCSRF)
+	add(r, fiber.MethodGet, "/login", a.LoginPage)
+	add(r, fiber.MethodPost, "/login", a.Login)
+	add(r, fiber.MethodPost, "/logout", a.Logout)
+
+	in := r.Group("", a.authenticate)
+	add(in, fiber.MethodGet, "/", a.Posts)
+	add(in, fiber.MethodPost, "/preview", a.Preview)
+	// Registered before /posts/:id so that "new" isn't taken for an ID.
+	add(in, fiber.MethodGet, "/posts/new", a.NewPost)
+	add(in, fiber.MethodPost, "/posts", a.CreatePost)
+	add(in, fiber.MethodGet, "/posts/:id", a.EditPost)
+	add(in, fiber.MethodPost, "/posts/:id", a.UpdatePost)
+	add(in, fiber.MethodPost, "/posts/:id/trash", a.TrashPost)
+	add(in, fiber.MethodGet, "/posts/:id/revisions", a.Revisions)
+	add(in, fiber.MethodPost, "/posts/:id/revisions/:rev/restore", a.RestoreRevision)
+	add(in, fiber.MethodGet, "/trash", a.Trash)
+	add(in, fiber.MethodPost, "/trash/:id/restore", a.RestorePost)
+	add(in, fiber.MethodPost, "/trash/:id/purge", a.PurgePost)
+}
+
+// Page is what page templates are executed with.
+type Page struct {
+	Blog  *models.Tenant
+	Title string
+	// Base starts the paths of the admin's pages, and Site those of the
+	// blog's; both take the blog's path prefix, if any.
+	Base, Site string
+	User       *models.User
+	CSRF       string
+	Notice     string
+	// Error explains why a form wasn't saved, or the page wasn't shown.
+	Error string
+	// Next is the page to go to after signing in.
+	Next string
+	// Email is the one last tried on the login form.
+	Email string
+
+	Filter     Filter
+	Posts      []models.BlogPost
+	Pagination *site.Pagination
+
+	Post *models.BlogPost
+	Form Form
+	// Errors holds the editor's invalid fields, by name.
+	Errors  map[string]string
+	Preview template.HTML
+	// Lock is held by someone else, who alone may save the post.
+	Lock *models.PostLock
+
+	Revisions []models.PostRevision
+	Revision  *models.PostRevision
+
+	template string
+}
+
+// Filter narrows down the posts table.
+type Filter struct {
+	Query, Status, Tag string
+}
+
+// url returns the path of a page of the posts table under this filter.
+func (f Filter) url(base string, page int) string {
+	q := url.Values{}
+	if f.Query != "" {
+		q.Set("q", f.Query)
+	}
+	if f.Status != "" {
+		q.Set("status", f.Status)
+	}
+	if f.Tag != "" {
+		q.Set("tag", f.Tag)
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if len(q) == 0 {
+		return base + "/"
+	}
+	return base + "/?" + q.Encode()
+}
+
+// Form holds the fields of the post editor.
+type Form struct {
+	Title, Description, Body, Locale string
+}
+
+// LoginPage shows the login form, or goes on to the admin if already signed
+// in.
+func (a *Admin) LoginPage(c *fiber.Ctx) error {
+	user, err := a.session(c)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		return c.Redirect(next(c, c.Query("next")), fiber.StatusSeeOther)
+	}
+	p := a.page(c, "login.html", "Sign in")
+	p.Next = c.Query("next")
+	return a.render(c, p)
+}
+
+// Login signs a user in with their email and password. Clients trying too
+// often, from one IP or for one email, are turned away before the password
+// is checked.
+func (a *Admin) Login(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	email := strings.TrimSpace(c.FormValue("email"))
+	p := a.page(c, "login.html", "Sign in")
+	p.Next, p.Email = c.FormValue("next"), email
+	if wait := a.throttle(c, email); wait > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		p.Error = "Too many attempts to sign in. Try again later."
+		return a.render(c.Status(fiber.StatusTooManyRequests), p)
+	}
+
+	user, err := a.login(ctx, email, c.FormValue("password"))
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		if err := a.record(ctx, nil, email, "invalid_credentials"); err != nil {
+			return err
+		}
+		p.Error = "The email or password is wrong."
+		return a.render(c.Status(fiber.StatusUnauthorized), p)
+	case err != nil:
+		return err
+	case !allowed(user):
+		if err := a.record(ctx, user, email, "role"); err != nil {
+			return err
+		}
+		p.Error = "Only admins and editors may sign in here."
+		return a.render(c.Status(fiber.StatusForbidden), p)
+	}
+	if err := a.record(ctx, user, email, ""); err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(a.sessionTTL)
+	a.setCookie(c, sessionCookie, a.newSession(c, user, expires), expires)
+	slog.InfoContext(c.UserContext(), "Signed in to the admin", "user_id", user.ID)
+	return c.Redirect(next(c, p.Next), fiber.StatusSeeOther)
+}
+
+// throttle counts an attempt to sign in against the client's IP and the
+// email tried, and returns how long to wait if either made too many. If the
+// store fails, attempts are let through rather than locking everyone out.
+func (a *Admin) throttle(c *fiber.Ctx, email string) time.Duration {
+	if a.attempts == nil {
+		return 0
+	}
+	ctx := c.UserContext()
+	var blog uint
+	if t := tenant.From(ctx); t != nil {
+		blog = t.ID
+	}
+	var wait time.Duration
+	for _, key := range []string{"ip:" + c.IP(), fmt.Sprintf("email:%d:%s", blog, strings.ToLower(email))} {
+		res, err := a.attempts.Take(ctx, "admin-login:"+key, a.limit)
+		if err != nil {
+			slog.WarnContext(ctx, "sign-in throttle failed", "error", err)
+			continue
+		}
+		wait = max(wait, res.RetryAfter)
+	}
+	return wait
+}
+
+// record audits an attempt to sign in, which failed for reason unless it is
+// empty. user is nil when the credentials were wrong.
+func (a *Admin) record(ctx context.Context, user *models.User, email, reason string) error {
+	if a.audit == nil {
+		return nil
+	}
+	e := &models.AuditEntry{Action: models.AuditSignedIn, Entity: "user"}
+	if user != nil {
+		e.EntityID = user.ID
+	}
+	if reason != "" {
+		details, err := json.Marshal(map[string]string{"email": email, "reason": reason})
+		if err != nil {
+			return err
+		}
+		e.Action, e.Details = models.AuditSignInFailed, details
+	}
+	return a.audit(ctx, user, e)
+}
+
+// Logout ends the session.
+func (a *Admin) Logout(c *fiber.Ctx) error {
+	a.setCookie(c, sessionCookie, "", time.Unix(1, 0))
+	return c.Redirect(base(c)+"/login?notice=signed_out", fiber.StatusSeeOther)
+}
+
+// Posts lists the blog's posts, filtered by a search query, status and tag.
+func (a *Admin) Posts(c *fiber.Ctx) error {
+	f := Filter{Query: strings.TrimSpace(c.Query("q")), Status: c.Query("status"), Tag: c.Query("tag")}
+	if f.Status != models.StatusDraft && f.Status != models.StatusPublished {
+		f.Status = ""
+	}
+	n := max(c.QueryInt("page", 1), 1)
+	list, err := a.service.ListPosts(c.UserContext(), models.PostFilter{
+		Status: f.Status, Tag: f.Tag, Query: f.Query, Offset: (n - 1) * pageSize, Limit: pageSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	p := a.page(c, "posts.html", "Posts")
+	p.Filter, p.Posts = f, list.Posts
+	if pages := int((list.Total + pageSize - 1) / pageSize); pages > 1 {
+		p.Pagination = &site.Pagination{Page: n, Pages: pages}
+		if n > 1 {
+			p.Pagination.Prev = f.url(p.Base, min(n-1, pages))
+		}
+		if n < pages {
+			p.Pagination.Next = f.url(p.Base, n+1)
+		}
+	}
+	return a.render(c, p)
+}
+
+// NewPost shows the editor for a new post.
+func (a *Admin) NewPost(c *fiber.Ctx) error {
+	return a.render(c, a.page(c, "edit.html", "New post"))
+}
+
+// CreatePost saves a new post, as a draft unless published right away.
+func (a *Admin) CreatePost(c *fiber.Ctx) error {
+	p := a.page(c, "edit.html", "New post")
+	p.Form = readForm(c)
+	if c.FormValue("action") == "preview" {
+		return a.preview(c, p)
+	}
+
+	req := models.CreateBlogRequest{Title: p.Form.Title, Description: p.Form.Description, Body: p.Form.Body,
+		Status: c.FormValue("status", models.StatusDraft), Locale: p.Form.Locale}
+	req.Normalize()
+	if err := models.Validate.Struct(req); err != nil {
+		return a.invalid(c, p, service.InvalidFields(models.FieldErrors(err, models.DefaultLocale), err))
+	}
+	id, err := a.service.Create(c.UserContext(), req)
+	if err != nil {
+		return a.invalid(c, p, err)
+	}
+	notice := "created"
+	if req.Status == models.StatusPublished {
+		notice = "published"
+	}
+	return c.Redirect(fmt.Sprintf("%s/posts/%d?notice=%s", p.Base, id, notice), fiber.StatusSeeOther)
+}
+
+// EditPost shows the editor for a post.
+func (a *Admin) EditPost(c *fiber.Ctx) error {
+	post, err := a.post(c)
+	if err != nil {
+		return err
+	}
+	p := a.page(c, "edit.html", post.Title)
+	p.Post = post
+	p.Form = Form{Title: post.Title, Description: post.Description, Body: post.Body, Locale: post.Locale}
+	if p.Lock, err = a.lock(c, post.ID); err != nil {
+		return err
+	}
+	return a.preview(c, p)
+}
+
+// UpdatePost saves a post, publishing or unpublishing it if asked to.
+func (a *Admin) UpdatePost(c *fiber.Ctx) error {
+	post, err := a.post(c)
+	if err != nil {
+		return err
+	}
+	p := a.page(c, "edit.html", post.Title)
+	p.Post, p.Form = post, readForm(c)
+	p.Form.Locale = post.Locale
+	if p.Lock, err = a.lock(c, post.ID); err != nil {
+		return err
+	}
+	if c.FormValue("action") == "preview" {
+		return a.preview(c, p)
+	}
+
+	req := models.UpdateBlogRequest{Title: &p.Form.Title, Description: &p.Form.Description, Body: &p.Form.Body}
+	status := c.FormValue("status")
+	if status != "" {
+		req.Status = &status
+	}
+	req.Normalize()
+	if err := models.Validate.Struct(req); err != nil {
+		return a.invalid(c, p, service.InvalidFields(models.FieldErrors(err, models.DefaultLocale), err))
+	}
+	if _, err := a.service.Update(c.UserContext(), post.ID, &req); err != nil {
+		return a.invalid(c, p, err)
+	}
+	notice := "saved"
+	switch {
+	case status == post.Status || status == "":
+	case status == models.StatusPublished:
+		notice = "published"
+	default:
+		notice = "unpublished"
+	}
+	return c.Redirect(fmt.Sprintf("%s/posts/%d?notice=%s", p.Base, post.ID, notice), fiber.StatusSeeOther)
+}
+
+// Preview renders the Markdown of a post's body, for the editor's live
+// preview.
+func (a *Admin) Preview(c *fiber.Ctx) error {
+	html, err := a.markdown.Render(c.FormValue("body"))
+	if err != nil {
+		return err
+	}
+	a.secure(c)
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(string(html))
+}
+
+// TrashPost moves a post to the trash.
+func (a *Admin) TrashPost(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := a.service.Delete(c.UserContext(), id); err != nil {
+		return err
+	}
+	return c.Redirect(base(c)+"/?notice=trashed", fiber.StatusSeeOther)
+}
+
+// Revisions lists a post's revisions, showing the one picked by the rev
+// query parameter or else the latest.
+func (a *Admin) Revisions(c *fiber.Ctx) error {
+	post, err := a.post(c)
+	if err != nil {
+		return err
+	}
+	revs, err := a.service.GetRevisions(c.UserContext(), post.ID)
+	if err != nil {
+		return err
+	}
+	p := a.page(c, "revisions.html", "Revisions of "+post.Title)
+	p.Post, p.Revisions = post, revs
+	if len(revs) > 0 {
+		i := 0
+		if rev := c.QueryInt("rev"); rev > 0 {
+			i = slices.IndexFunc(revs, func(r models.PostRevision) bool { return r.ID == uint(rev) })
+			if i < 0 {
+				return service.NotFound("revision_not_found", fmt.Sprintf("Post %d has no revision %d.", post.ID, rev), nil)
+			}
+		}
+		p.Revision = &revs[i]
+	}
+	return a.render(c, p)
+}
+
+// RestoreRevision sets a post's content back to that of a revision.
+func (a *Admin) RestoreRevision(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	rev, err := parseID(c, "rev")
+	if err != nil {
+		return err
+	}
+	if _, err := a.service.RestoreRevision(c.UserContext(), id, rev); err != nil {
+		return err
+	}
+	return c.Redirect(fmt.Sprintf("%s/posts/%d?notice=revision_restored", base(c), id), fiber.StatusSeeOther)
+}
+
+// Trash lists the posts in the trash.
+func (a *Admin) Trash(c *fiber.Ctx) error {
+	posts, err := a.service.ListTrash(c.UserContext())
+	if err != nil {
+		return err
+	}
+	p := a.page(c, "trash.html", "Trash")
+	p.Posts = posts
+	return a.render(c, p)
+}
+
+// RestorePost takes a post out of the trash.
+func (a *Admin) RestorePost(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	if _, err := a.service.RestorePost(c.UserContext(), id); err != nil {
+		return err
+	}
+	return c.Redirect(fmt.Sprintf("%s/posts/%d?notice=restored", base(c), id), fiber.StatusSeeOther)
+}
+
+// PurgePost deletes a post in the trash for good.
+func (a *Admin) PurgePost(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := a.service.PurgePost(c.UserContext(), id); err != nil {
+		return err
+	}
+	return c.Redirect(base(c)+"/trash?notice=purged", fiber.StatusSeeOther)
+}
+
+// Static serves the admin's stylesheet and script.
+func (a *Admin) Static(c *fiber.Ctx) error {
+	name := c.Params("*")
+	if !fs.ValidPath(name) {
+		return fiber.ErrNotFound
+	}
+	data, err := fs.ReadFile(a.static, name)
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+	if typ := mime.TypeByExtension(path.Ext(name)); typ != "" {
+		c.Set(fiber.HeaderContentType, typ)
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Send(data)
+}
+
+// authenticate sends visitors who aren't signed in to the login form, and
+// acts as the signed-in user otherwise, so that their changes are audited
+// and make revisions under their name.
+func (a *Admin) authenticate(c *fiber.Ctx) error {
+	user, err := a.session(c)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		login := base(c) + "/login"
+		if c.Method() == fiber.MethodGet {
+			target := tenant.BasePath(c.UserContext()) + c.Path()
+			if q := c.Request().URI().QueryString(); len(q) > 0 {
+				target += "?" + string(q)
+			}
+			login += "?next=" + url.QueryEscape(target)
+		}
+		return c.Redirect(login, fiber.StatusSeeOther)
+	}
+	c.SetUserContext(auth.WithUser(c.UserContext(), user))
+	return c.Next()
+}
+
+// errorPage renders the error page for the errors the admin's handlers return.
+func (a *Admin) errorPage(c *fiber.Ctx) error {
+	err := c.Next()
+	if err == nil {
+		return nil
+	}
+	status, msg := fiber.StatusInternalServerError, "Something went wrong. Please try again."
+	var de *service.Error
+	var fe *fiber.Error
+	switch {
+	case errors.As(err, &de):
+		status, msg = statusFor(de.Kind), de.Message
+	case errors.As(err, &fe) && fe.Code == fiber.StatusNotFound:
+		status, msg = fe.Code, "There is no such page."
+	case errors.As(err, &fe):
+		status, msg = fe.Code, fe.Message
+	case errors.Is(err, context.DeadlineExceeded):
+		status, msg = fiber.StatusGatewayTimeout, "The request timed out. Please try again."
+	}
+	if status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "request failed", "status", status, "error", err)
+	}
+	p := a.page(c, "error.html", http.StatusText(status))
+	p.Error = msg
+	return a.render(c.Status(status), p)
+}
+
+// invalid shows the editor again with why the post couldn't be saved, or
+// returns err if it isn't the user's to fix.
+func (a *Admin) invalid(c *fiber.Ctx, p *Page, err error) error {
+	var de *service.Error
+	if !errors.As(err, &de) || de.Kind != service.ErrValidation && de.Kind != service.ErrLocked && de.Kind != service.ErrConflict {
+		return err
+	}
+	p.Error = de.Message
+	if len(de.Fields) > 0 {
+		p.Error = "The post wasn't saved; correct the fields below."
+		p.Errors = map[string]string{}
+		for _, f := range de.Fields {
+			p.Errors[f.Field] = f.Message
+		}
+	}
+	return a.render(c.Status(statusFor(de.Kind)), p)
+}
+
+// preview shows the editor with the rendered body, which the live preview's
+// script keeps up to date as it is edited.
+func (a *Admin) preview(c *fiber.Ctx, p *Page) error {
+	var err error
+	if p.Preview, err = a.markdown.Render(p.Form.Body); err != nil {
+		return err
+	}
+	return a.render(c, p)
+}
+
+// post returns the post named by the id route parameter.
+func (a *Admin) post(c *fiber.Ctx) (*models.BlogPost, error) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return nil, err
+	}
+	return a.service.GetByID(c.UserContext(), id)
+}
+
+// lock returns the lock on a post if someone other than the signed-in user
+// holds it.
+func (a *Admin) lock(c *fiber.Ctx, id uint) (*models.PostLock, error) {
+	lock, err := a.service.GetLock(c.UserContext(), id)
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user := auth.User(c.UserContext()); user != nil && lock.UserID == user.ID {
+		return nil, nil
+	}
+	return lock, nil
+}
+
+// page returns the Page for a template with what every page shows.
+func (a *Admin) page(c *fiber.Ctx, tmpl, title string) *Page {
+	ctx := c.UserContext()
+	token, _ := c.Locals(csrfKey{}).(string)
+	return &Page{Blog: tenant.From(ctx), Title: title, Base: base(c), Site: tenant.BasePath(ctx),
+		User: auth.User(ctx), CSRF: token, Notice: notices[c.Query("notice")], template: tmpl}
+}
+
+// render sends the page p.
+func (a *Admin) render(c *fiber.Ctx, p *Page) error {
+	var buf bytes.Buffer
+	if err := a.pages[p.template].ExecuteTemplate(&buf, "layout.html", p); err != nil {
+		return fmt.Errorf("unable to render %s: %w", p.template, err)
+	}
+	a.secure(c)
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(buf.Bytes())
+}
+
+// secure sets the headers of every admin page: they aren't cached, framed
+// by other sites or allowed to run scripts but the admin's own.
+func (a *Admin) secure(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'self'; img-src * data:; frame-ancestors 'none'")
+	c.Set(fiber.HeaderReferrerPolicy, "same-origin")
+}
+
+// readForm reads the editor's fields from the submitted form.
+func readForm(c *fiber.Ctx) Form {
+	return Form{Title: c.FormValue("title"), Description: c.FormValue("description"),
+		Body: c.FormValue("body"), Locale: strings.TrimSpace(c.FormValue("locale"))}
+}
+
+// base returns the path of the admin, under the blog's path prefix if any.
+func base(c *fiber.Ctx) string {
+	return tenant.BasePath(c.UserContext()) + "/admin"
+}
+
+// next returns where to go after signing in: target if it is one of the
+// admin's pages, so that the login form can't send anyone to another site,
+// or else the posts table.
+func next(c *fiber.Ctx, target string) string {
+	b := base(c)
+	if target == b || strings.HasPrefix(target, b+"/") || strings.HasPrefix(target, b+"?") {
+		return target
+	}
+	return b + "/"
+}
+
+// allowed reports whether user may sign in.
+func allowed(user *models.User) bool {
+	return slices.Contains(Roles, user.Role)
+}
+
+func parseID(c *fiber.Ctx, param string) (uint, error) {
+	id, err := strconv.ParseUint(c.Params(param), 10, 0)
+	if err != nil || id == 0 {
+		return 0, fiber.ErrNotFound
+	}
+	return uint(id), nil
+}
+
+func statusFor(kind error) int {
+	switch kind {
+	case service.ErrNotFound:
+		return fiber.StatusNotFound
+	case service.ErrConflict:
+		return fiber.StatusConflict
+	case service.ErrValidation:
+		return fiber.StatusUnprocessableEntity
+	case service.ErrForbidden:
+		return fiber.StatusForbidden
+	case service.ErrLocked:
+		return fiber.StatusLocked
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
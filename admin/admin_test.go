@@ -0,0 +1,420 @@
+package admin
+
+import (
+	"context"
+	"example/auth"
+	"example/mocks"
+	"example/models"
+	"example/ratelimit"
+	"example/service"
+	"example/tenant"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var (
+	editor = &models.User{ID: 7, Email: "ed@example.com", Name: "Ed", Role: models.RoleEditor}
+	author = &models.User{ID: 8, Email: "al@example.com", Role: models.RoleAuthor}
+)
+
+// client is a browser of the admin of blog 1, served under /t/acme, whose
+// users have the password "password1". It keeps the cookies it is sent.
+type client struct {
+	t       *testing.T
+	app     *fiber.App
+	cookies map[string]string
+}
+
+func newClient(t *testing.T, se service.Service, configure ...func(*Config)) *client {
+	t.Helper()
+	return newClientBehind(t, se, nil, configure...)
+}
+
+// newClientBehind is newClient with the admin's routes behind the before
+// handlers.
+func newClientBehind(t *testing.T, se service.Service, before []fiber.Handler, configure ...func(*Config)) *client {
+	t.Helper()
+	hash, err := auth.HashPassword("password1")
+	require.NoError(t, err)
+	users := map[string]*models.User{}
+	for _, u := range []*models.User{editor, author} {
+		u.PasswordHash = hash
+		users[u.Email] = u
+	}
+	cfg := Config{
+		Login: func(_ context.Context, email, password string) (*models.User, error) {
+			if u, ok := users[email]; ok && auth.CheckPassword(u.PasswordHash, password) {
+				return u, nil
+			}
+			return nil, auth.ErrInvalidCredentials
+		},
+		User: func(_ context.Context, email string) (*models.User, error) {
+			if u, ok := users[email]; ok {
+				return u, nil
+			}
+			return nil, auth.ErrUnknownUser
+		},
+		Secret: []byte("test secret"),
+	}
+	for _, f := range configure {
+		f(&cfg)
+	}
+	a, err := New(se, cfg)
+	require.NoError(t, err)
+
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := tenant.WithTenant(c.UserContext(), &models.Tenant{ID: 1, Slug: "acme", Title: "Acme"})
+		c.SetUserContext(tenant.WithBasePath(ctx, "/t/acme"))
+		return c.Next()
+	})
+	a.Register(app.Group("/admin"), before...)
+	return &client{t: t, app: app, cookies: map[string]string{}}
+}
+
+func (cl *client) do(method, target string, form url.Values, header ...string) (*http.Response, string) {
+	cl.t.Helper()
+	req := httptest.NewRequest(method, "http://blog.example"+target, strings.NewReader(form.Encode()))
+	if form != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	for name, value := range cl.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	resp, err := cl.app.Test(req)
+	require.NoError(cl.t, err)
+	for _, c := range resp.Cookies() {
+		if c.Value == "" || !c.Expires.IsZero() && c.Expires.Before(time.Now()) {
+			delete(cl.cookies, c.Name)
+		} else {
+			cl.cookies[c.Name] = c.Value
+		}
+	}
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(cl.t, err)
+	return resp, string(body)
+}
+
+var tokenField = regexp.MustCompile(`name="_csrf" value="([^"]+)"`)
+
+// token returns the CSRF token of the client's forms, from the login page
+// or, once signed in, a page that needs no posts.
+func (cl *client) token() string {
+	cl.t.Helper()
+	resp, body := cl.do(http.MethodGet, "/admin/login", nil)
+	if resp.StatusCode == fiber.StatusSeeOther {
+		_, body = cl.do(http.MethodGet, "/admin/missing", nil)
+	}
+	m := tokenField.FindStringSubmatch(body)
+	require.NotNil(cl.t, m, "no CSRF token in %s", body)
+	return m[1]
+}
+
+// submit posts form with the client's CSRF token.
+func (cl *client) submit(target string, form url.Values) (*http.Response, string) {
+	cl.t.Helper()
+	form.Set(csrfField, cl.token())
+	return cl.do(http.MethodPost, target, form)
+}
+
+func (cl *client) signIn(user *models.User) {
+	cl.t.Helper()
+	resp, _ := cl.submit("/admin/login", url.Values{"email": {user.Email}, "password": {"password1"}})
+	require.Equal(cl.t, fiber.StatusSeeOther, resp.StatusCode)
+}
+
+// asEditor matches contexts of requests made by the signed-in editor.
+func asEditor() any {
+	return mock.MatchedBy(func(ctx context.Context) bool { return auth.User(ctx) == editor })
+}
+
+func TestAdmin_signIn(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("ListPosts", asEditor(), mock.Anything).Return(&models.PostList{
+		Posts: []models.BlogPost{{ID: 1, Title: "Hello <world>", Status: models.StatusDraft}}, Total: 1,
+	}, nil)
+	cl := newClient(t, se)
+
+	resp, _ := cl.do(http.MethodGet, "/admin/?status=draft", nil)
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/t/acme/admin/login?next=%2Ft%2Facme%2Fadmin%2F%3Fstatus%3Ddraft", resp.Header.Get(fiber.HeaderLocation))
+
+	resp, body := cl.do(http.MethodGet, "/admin/login", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `action="/t/acme/admin/login"`)
+	assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+	// Without the CSRF token.
+	resp, _ = cl.do(http.MethodPost, "/admin/login", url.Values{"email": {editor.Email}, "password": {"password1"}})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp, body = cl.submit("/admin/login", url.Values{"email": {editor.Email}, "password": {"wrong"}})
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, body, "The email or password is wrong.")
+	assert.Contains(t, body, `value="ed@example.com"`)
+
+	resp, body = cl.submit("/admin/login", url.Values{"email": {author.Email}, "password": {"password1"}})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, body, "Only admins and editors may sign in here.")
+	assert.NotContains(t, cl.cookies, sessionCookie)
+
+	// Only the admin's own pages are gone to after signing in.
+	resp, _ = cl.submit("/admin/login", url.Values{"email": {editor.Email}, "password": {"password1"}, "next": {"https://evil.example/"}})
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/t/acme/admin/", resp.Header.Get(fiber.HeaderLocation))
+	resp, _ = cl.submit("/admin/login", url.Values{"email": {editor.Email}, "password": {"password1"}, "next": {"/t/acme/admin/trash"}})
+	assert.Equal(t, "/t/acme/admin/trash", resp.Header.Get(fiber.HeaderLocation))
+
+	resp, body = cl.do(http.MethodGet, "/admin/", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Hello &lt;world&gt;")
+	assert.Contains(t, body, `href="/t/acme/admin/posts/1"`)
+
+	// A tampered session is no session.
+	session := cl.cookies[sessionCookie]
+	cl.cookies[sessionCookie] = strings.Replace(session, ".", ".1", 1)
+	resp, _ = cl.do(http.MethodGet, "/admin/", nil)
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+	cl.cookies[sessionCookie] = session
+
+	resp, _ = cl.submit("/admin/logout", url.Values{})
+	assert.Equal(t, "/t/acme/admin/login?notice=signed_out", resp.Header.Get(fiber.HeaderLocation))
+	resp, _ = cl.do(http.MethodGet, "/admin/", nil)
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+}
+
+func TestAdmin_Login_throttled(t *testing.T) {
+	cl := newClient(t, new(mocks.BlogService), func(cfg *Config) {
+		cfg.Attempts = ratelimit.NewMemory()
+		cfg.AttemptLimit = ratelimit.Limit{Burst: 2, Per: time.Hour}
+	})
+	login := func(ip, email, password string) *http.Response {
+		form := url.Values{"email": {email}, "password": {password}, csrfField: {cl.token()}}
+		resp, _ := cl.do(http.MethodPost, "/admin/login", form, fiber.HeaderXForwardedFor, ip)
+		return resp
+	}
+
+	assert.Equal(t, fiber.StatusUnauthorized, login("203.0.113.1", editor.Email, "wrong").StatusCode)
+	assert.Equal(t, fiber.StatusUnauthorized, login("203.0.113.1", editor.Email, "guess").StatusCode)
+	resp := login("203.0.113.2", "ED@example.com", "password1")
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode, "the email is limited from any IP")
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.NotContains(t, cl.cookies, sessionCookie)
+
+	assert.Equal(t, fiber.StatusForbidden, login("203.0.113.3", author.Email, "password1").StatusCode)
+	assert.Equal(t, fiber.StatusTooManyRequests, login("203.0.113.1", author.Email, "password1").StatusCode,
+		"the IP is limited for any email")
+}
+
+func TestAdmin_Login_audited(t *testing.T) {
+	type recorded struct {
+		actor *models.User
+		entry *models.AuditEntry
+	}
+	var entries []recorded
+	cl := newClient(t, new(mocks.BlogService), func(cfg *Config) {
+		cfg.Audit = func(_ context.Context, actor *models.User, e *models.AuditEntry) error {
+			entries = append(entries, recorded{actor, e})
+			return nil
+		}
+	})
+
+	cl.submit("/admin/login", url.Values{"email": {"nobody@example.com"}, "password": {"password1"}})
+	cl.submit("/admin/login", url.Values{"email": {author.Email}, "password": {"password1"}})
+	cl.signIn(editor)
+
+	require.Len(t, entries, 3)
+	assert.Nil(t, entries[0].actor)
+	assert.Equal(t, models.AuditSignInFailed, entries[0].entry.Action)
+	assert.JSONEq(t, `{"email":"nobody@example.com","reason":"invalid_credentials"}`, string(entries[0].entry.Details))
+	assert.Equal(t, author, entries[1].actor)
+	assert.Equal(t, models.AuditSignInFailed, entries[1].entry.Action)
+	assert.JSONEq(t, `{"email":"al@example.com","reason":"role"}`, string(entries[1].entry.Details))
+	assert.Equal(t, editor, entries[2].actor)
+	assert.Equal(t, &models.AuditEntry{Action: models.AuditSignedIn, Entity: "user", EntityID: editor.ID}, entries[2].entry)
+}
+
+func TestAdmin_Register_before(t *testing.T) {
+	// Handlers such as timeouts are keyed by the route they run for.
+	se := new(mocks.BlogService)
+	se.On("ListTrash", mock.Anything).Return(nil, nil)
+	var routes []string
+	cl := newClientBehind(t, se, []fiber.Handler{func(c *fiber.Ctx) error {
+		routes = append(routes, c.Route().Method+" "+c.Route().Path)
+		return c.Next()
+	}})
+	cl.signIn(editor)
+	cl.do(http.MethodGet, "/admin/trash", nil)
+	assert.Equal(t, []string{"GET /admin/login", "POST /admin/login", "GET /admin/trash"}, routes)
+}
+
+func TestAdmin_session_passwordChanged(t *testing.T) {
+	cl := newClient(t, new(mocks.BlogService))
+	cl.signIn(editor)
+
+	hash, err := auth.HashPassword("password2")
+	require.NoError(t, err)
+	editor.PasswordHash = hash
+	resp, _ := cl.do(http.MethodGet, "/admin/trash", nil)
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+}
+
+func TestAdmin_Posts(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, models.PostFilter{Status: "draft", Tag: "go", Query: "fiber", Offset: 25, Limit: 25}).
+		Return(&models.PostList{Posts: []models.BlogPost{{ID: 3, Title: "Fiber tips", Status: models.StatusDraft,
+			Tags: []models.Tag{{Name: "Go", Slug: "go"}}}}, Total: 60}, nil)
+	cl := newClient(t, se)
+	cl.signIn(editor)
+
+	resp, body := cl.do(http.MethodGet, "/admin/?q=+fiber&status=draft&tag=go&page=2", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Fiber tips")
+	assert.Contains(t, body, `value="fiber"`)
+	assert.Contains(t, body, `<option value="draft" selected>`)
+	assert.Contains(t, body, "Page 2 of 3")
+	assert.Contains(t, body, `href="/t/acme/admin/?q=fiber&amp;status=draft&amp;tag=go"`)
+	assert.Contains(t, body, `href="/t/acme/admin/?page=3&amp;q=fiber&amp;status=draft&amp;tag=go"`)
+}
+
+func TestAdmin_CreatePost(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("Create", asEditor(), models.CreateBlogRequest{Title: "Hello", Description: "A first post",
+		Body: "Some **bold** text", Status: models.StatusPublished, Locale: "en"}).Return(uint(5), nil)
+	cl := newClient(t, se)
+	cl.signIn(editor)
+
+	form := url.Values{"title": {"  "}, "description": {"A first post"}, "body": {"Some **bold** text"}, "status": {"published"}}
+	resp, body := cl.submit("/admin/posts", form)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Contains(t, body, `class="field-error"`)
+	assert.Contains(t, body, "Some **bold** text")
+
+	form = url.Values{"title": {"Hello"}, "description": {"A first post"}, "body": {"Some **bold** text"}, "action": {"preview"}}
+	resp, body = cl.submit("/admin/posts", form)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "<strong>bold</strong>")
+	se.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	form = url.Values{"title": {"Hello"}, "description": {"A first post"}, "body": {"Some **bold** text"},
+		"locale": {"en"}, "status": {"published"}}
+	resp, _ = cl.submit("/admin/posts", form)
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/t/acme/admin/posts/5?notice=published", resp.Header.Get(fiber.HeaderLocation))
+	se.AssertExpectations(t)
+}
+
+func TestAdmin_UpdatePost(t *testing.T) {
+	se := new(mocks.BlogService)
+	post := &models.BlogPost{ID: 5, Title: "Hello", Description: "A first post", Body: "Some text",
+		Locale: "en", Status: models.StatusDraft}
+	se.On("GetByID", mock.Anything, uint(5)).Return(post, nil)
+	se.On("GetLock", mock.Anything, uint(5)).Return(nil, service.NotFound("post_not_locked", "not locked", nil)).Once()
+	cl := newClient(t, se)
+	cl.signIn(editor)
+
+	resp, body := cl.do(http.MethodGet, "/admin/posts/5?notice=saved", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Post saved.")
+	assert.Contains(t, body, `<div class="rendered" id="preview"><p>Some text</p>`)
+	assert.Contains(t, body, `name="status" value="published">Save and publish`)
+
+	title, description, body2, published := "Hello again", "A first post", "More text", models.StatusPublished
+	se.On("GetLock", mock.Anything, uint(5)).Return(nil, service.NotFound("post_not_locked", "not locked", nil)).Once()
+	se.On("Update", asEditor(), uint(5), &models.UpdateBlogRequest{Title: &title, Description: &description,
+		Body: &body2, Status: &published}).Return(post, nil).Once()
+	form := url.Values{"title": {title}, "description": {description}, "body": {body2}, "status": {published}}
+	resp, _ = cl.submit("/admin/posts/5", form)
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/t/acme/admin/posts/5?notice=published", resp.Header.Get(fiber.HeaderLocation))
+
+	// Someone else has checked the post out.
+	lock := &models.PostLock{PostID: 5, UserID: 9, User: &models.User{ID: 9, Name: "Lou"}, ExpiresAt: time.Now().Add(time.Minute)}
+	se.On("GetLock", mock.Anything, uint(5)).Return(lock, nil)
+	se.On("Update", mock.Anything, uint(5), mock.Anything).Return(nil, service.Locked("post_locked", "Lou is editing post 5", nil))
+	resp, body = cl.submit("/admin/posts/5", url.Values{"title": {title}, "description": {description}, "body": {body2}})
+	assert.Equal(t, fiber.StatusLocked, resp.StatusCode)
+	assert.Contains(t, body, "Lou is editing post 5")
+	assert.Contains(t, body, "Lou is editing this post until")
+	assert.Contains(t, body, "More text")
+}
+
+func TestAdmin_Preview(t *testing.T) {
+	cl := newClient(t, new(mocks.BlogService))
+	cl.signIn(editor)
+
+	resp, _ := cl.do(http.MethodPost, "/admin/preview", url.Values{"body": {"# Title"}})
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp, body := cl.do(http.MethodPost, "/admin/preview", url.Values{"body": {"# Title\n\n<script>x()</script>"}},
+		csrfHeader, cl.token())
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<h1>Title</h1>\n<!-- raw HTML omitted -->\n", body)
+}
+
+func TestAdmin_Revisions(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetByID", mock.Anything, uint(5)).Return(&models.BlogPost{ID: 5, Title: "Hello"}, nil)
+	se.On("GetRevisions", mock.Anything, uint(5)).Return([]models.PostRevision{
+		{ID: 12, PostID: 5, Title: "Hello", Body: "Second", Author: "ed@example.com", CreatedAt: time.Now()},
+		{ID: 11, PostID: 5, Title: "Hi", Body: "*First*", CreatedAt: time.Now().Add(-time.Hour)},
+	}, nil)
+	se.On("RestoreRevision", asEditor(), uint(5), uint(11)).Return(&models.BlogPost{ID: 5}, nil)
+	cl := newClient(t, se)
+	cl.signIn(editor)
+
+	resp, body := cl.do(http.MethodGet, "/admin/posts/5/revisions?rev=11", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "<em>First</em>")
+	assert.Contains(t, body, `action="/t/acme/admin/posts/5/revisions/11/restore"`)
+
+	resp, _ = cl.do(http.MethodGet, "/admin/posts/5/revisions?rev=10", nil)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, _ = cl.submit("/admin/posts/5/revisions/11/restore", url.Values{})
+	assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/t/acme/admin/posts/5?notice=revision_restored", resp.Header.Get(fiber.HeaderLocation))
+	se.AssertExpectations(t)
+}
+
+func TestAdmin_Trash(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("Delete", asEditor(), uint(5)).Return(nil)
+	se.On("ListTrash", mock.Anything).Return([]models.BlogPost{{ID: 5, Title: "Hello",
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}}, nil)
+	se.On("RestorePost", asEditor(), uint(5)).Return(&models.BlogPost{ID: 5}, nil)
+	se.On("PurgePost", asEditor(), uint(6)).Return(service.NotFound("post_not_in_trash", "post 6 is not in the trash", nil))
+	cl := newClient(t, se)
+	cl.signIn(editor)
+
+	resp, _ := cl.submit("/admin/posts/5/trash", url.Values{})
+	assert.Equal(t, "/t/acme/admin/?notice=trashed", resp.Header.Get(fiber.HeaderLocation))
+
+	resp, body := cl.do(http.MethodGet, "/admin/trash?notice=trashed", nil)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Post moved to the trash.")
+	assert.Contains(t, body, `action="/t/acme/admin/trash/5/purge"`)
+
+	resp, _ = cl.submit("/admin/trash/5/restore", url.Values{})
+	assert.Equal(t, "/t/acme/admin/posts/5?notice=restored", resp.Header.Get(fiber.HeaderLocation))
+
+	resp, body = cl.submit("/admin/trash/6/purge", url.Values{})
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, body, "post 6 is not in the trash")
+	se.AssertExpectations(t)
+}
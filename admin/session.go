@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"example/auth"
+	"example/models"
+	"example/tenant"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The cookies the admin sets, both scoped to its pages.
+const (
+	// sessionCookie holds a signed-in user's session.
+	sessionCookie = "admin_session"
+	// csrfCookie holds a random value that CSRF tokens are derived from.
+	csrfCookie = "admin_csrf"
+)
+
+// csrfField is the form field carrying the CSRF token; scripts send it in
+// the X-CSRF-Token header instead.
+const (
+	csrfField  = "_csrf"
+	csrfHeader = "X-CSRF-Token"
+)
+
+// errCSRF is returned for unsafe requests without a valid CSRF token.
+var errCSRF = fiber.NewError(fiber.StatusForbidden, "The form has expired. Go back, reload the page and try again.")
+
+// newSession returns the value of a session cookie for user, expiring at
+// expires: their email and the expiry, signed. The signature also covers
+// the blog and the user's password hash, so that a session is only good on
+// the blog it was opened on, and changing the password ends every session.
+func (a *Admin) newSession(c *fiber.Ctx, user *models.User, expires time.Time) string {
+	email := base64.RawURLEncoding.EncodeToString([]byte(user.Email))
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return email + "." + exp + "." + a.sessionMAC(c, user, exp)
+}
+
+func (a *Admin) sessionMAC(c *fiber.Ctx, user *models.User, exp string) string {
+	var tenantID string
+	if t := tenant.From(c.UserContext()); t != nil {
+		tenantID = strconv.FormatUint(uint64(t.ID), 10)
+	}
+	return a.mac("session", tenantID, user.Email, exp, user.PasswordHash)
+}
+
+// session returns the user signed in by the request's session cookie, or
+// nil if there is no valid session.
+func (a *Admin) session(c *fiber.Ctx) (*models.User, error) {
+	parts := strings.Split(c.Cookies(sessionCookie), ".")
+	if len(parts) != 3 {
+		return nil, nil
+	}
+	email, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(exp, 0)) {
+		return nil, nil
+	}
+	user, err := a.user(c.UserContext(), string(email))
+	if errors.Is(err, auth.ErrUnknownUser) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(a.sessionMAC(c, user, parts[1]))) || !allowed(user) {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// csrfToken returns the token the request's forms must send back. It is
+// derived from the CSRF cookie, which is set if missing, and from the
+// session, so that a token from before signing in is no use after.
+func (a *Admin) csrfToken(c *fiber.Ctx) string {
+	nonce := c.Cookies(csrfCookie)
+	if nonce == "" {
+		nonce = randomToken()
+		a.setCookie(c, csrfCookie, nonce, time.Time{})
+	}
+	return a.mac("csrf", nonce, c.Cookies(sessionCookie))
+}
+
+// checkCSRF rejects requests that change anything unless they carry the
+// CSRF token, which other sites can't read, and makes the token available to
+// the page rendered.
+func (a *Admin) checkCSRF(c *fiber.Ctx) error {
+	safe := c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead
+	if !safe && c.Cookies(csrfCookie) == "" {
+		return errCSRF
+	}
+	token := a.csrfToken(c)
+	if !safe {
+		got := c.FormValue(csrfField)
+		if got == "" {
+			got = c.Get(csrfHeader)
+		}
+		if !hmac.Equal([]byte(got), []byte(token)) {
+			return errCSRF
+		}
+	}
+	c.Locals(csrfKey{}, token)
+	return c.Next()
+}
+
+type csrfKey struct{}
+
+// mac signs parts with the admin's secret.
+func (a *Admin) mac(parts ...string) string {
+	h := hmac.New(sha256.New, a.secret)
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// setCookie sets a cookie for the admin's pages only, which scripts can't
+// read. A zero expiry makes it last until the browser is closed.
+func (a *Admin) setCookie(c *fiber.Ctx, name, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     base(c),
+		Expires:  expires,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// randomToken returns 32 random bytes, base64-encoded.
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,51 @@
+// Renders the editor's preview as the body is typed, and asks before
+// destructive forms are submitted. The admin works without it, only less
+// conveniently.
+(function () {
+  "use strict";
+
+  document.querySelectorAll("form[data-confirm]").forEach(function (form) {
+    form.addEventListener("submit", function (event) {
+      if (!window.confirm(form.dataset.confirm)) {
+        event.preventDefault();
+      }
+    });
+  });
+
+  var editor = document.querySelector("form[data-preview]");
+  if (!editor) {
+    return;
+  }
+  var body = editor.elements.body;
+  var csrf = editor.elements._csrf.value;
+  var target = document.getElementById("preview");
+  var timer, pending;
+
+  function render() {
+    if (pending) {
+      pending.abort();
+    }
+    pending = new AbortController();
+    fetch(editor.dataset.preview, {
+      method: "POST",
+      headers: { "X-CSRF-Token": csrf },
+      body: new URLSearchParams({ body: body.value }),
+      credentials: "same-origin",
+      signal: pending.signal,
+    }).then(function (resp) {
+      if (!resp.ok || resp.redirected) {
+        throw new Error("preview failed with status " + resp.status);
+      }
+      return resp.text();
+    }).then(function (html) {
+      target.innerHTML = html;
+    }).catch(function () {
+      // Keep the last preview; saving still works.
+    });
+  }
+
+  body.addEventListener("input", function () {
+    clearTimeout(timer);
+    timer = setTimeout(render, 300);
+  });
+})();
@@ -0,0 +1,182 @@
+// Package audit keeps the append-only, hash-chained log of who changed what
+// and from where.
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/repo"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Source is where a request came from.
+type Source struct {
+	IP        string
+	UserAgent string
+}
+
+type sourceKey struct{}
+
+// WithSource returns a copy of ctx carrying the request's source.
+func WithSource(ctx context.Context, s Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, s)
+}
+
+// SourceFrom returns the source stored in ctx. It is empty outside of HTTP
+// requests, for instance for commands run from the CLI.
+func SourceFrom(ctx context.Context) Source {
+	s, _ := ctx.Value(sourceKey{}).(Source)
+	return s
+}
+
+// Record appends e to the audit log, stamped with the actor, who is nil for
+// anonymous callers and the CLI, and the source and ID of the request in
+// ctx.
+func Record(ctx context.Context, r repo.Repository, actor *models.User, e *models.AuditEntry) error {
+	if actor != nil {
+		e.ActorID, e.Actor = &actor.ID, actor.Email
+	}
+	src := SourceFrom(ctx)
+	e.IP, e.UserAgent = src.IP, src.UserAgent
+	e.RequestID = logging.RequestID(ctx)
+	return r.AppendAuditEntry(ctx, e)
+}
+
+// Diff returns the fields whose values differ between before and after.
+// Either may be nil, for entities created or deleted.
+func Diff(before, after map[string]any) models.Changes {
+	changes := models.Changes{}
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !reflect.DeepEqual(v, w) {
+			changes[k] = models.Change{Before: v, After: w}
+		}
+	}
+	for k, w := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = models.Change{After: w}
+		}
+	}
+	return changes
+}
+
+// Export formats.
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// ErrUnknownFormat is returned when exporting to an unsupported format.
+var ErrUnknownFormat = errors.New("unknown format, expected jsonl or csv")
+
+// exportBatch is the number of entries read at a time when exporting or
+// verifying.
+const exportBatch = 500
+
+// csvHeader lists the columns of CSV exports.
+var csvHeader = []string{"id", "created_at", "action", "actor_id", "actor", "ip", "user_agent", "request_id",
+	"entity", "entity_id", "changes", "details", "prev_hash", "hash"}
+
+// Export writes the entries matching f to w, oldest first, and returns how
+// many there were.
+func Export(ctx context.Context, r repo.Repository, w io.Writer, f models.AuditFilter, format string) (int, error) {
+	var write func(models.AuditEntry) error
+	var flush func() error
+	switch format {
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		write = func(e models.AuditEntry) error { return enc.Encode(e) }
+		flush = func() error { return nil }
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return 0, err
+		}
+		write = func(e models.AuditEntry) error { return cw.Write(csvRecord(e)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	default:
+		return 0, ErrUnknownFormat
+	}
+
+	n := 0
+	err := r.AuditEntriesInBatches(ctx, f, exportBatch, func(entries []models.AuditEntry) error {
+		for _, e := range entries {
+			if err := write(e); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+func csvRecord(e models.AuditEntry) []string {
+	actorID := ""
+	if e.ActorID != nil {
+		actorID = strconv.FormatUint(uint64(*e.ActorID), 10)
+	}
+	changes := ""
+	if len(e.Changes) > 0 {
+		data, _ := json.Marshal(e.Changes)
+		changes = string(data)
+	}
+	return []string{
+		strconv.FormatUint(uint64(e.ID), 10), e.CreatedAt.UTC().Format(time.RFC3339Nano), e.Action, actorID, e.Actor,
+		e.IP, e.UserAgent, e.RequestID, e.Entity, strconv.FormatUint(uint64(e.EntityID), 10),
+		changes, string(e.Details), e.PrevHash, e.Hash,
+	}
+}
+
+// Report is the outcome of verifying the audit log.
+type Report struct {
+	// Checked is the number of entries found intact.
+	Checked int  `json:"checked"`
+	Valid   bool `json:"valid"`
+	// BrokenAt is the first entry that was altered, or follows a removed
+	// one.
+	BrokenAt *uint  `json:"broken_at,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+}
+
+// errBroken stops the walk of the log at the first broken link.
+var errBroken = errors.New("broken chain")
+
+// Verify walks the whole log checking that every entry's hash matches its
+// contents and that it is chained to the entry before it.
+func Verify(ctx context.Context, r repo.Repository) (*Report, error) {
+	report := &Report{Valid: true}
+	prev := ""
+	err := r.AuditEntriesInBatches(ctx, models.AuditFilter{}, exportBatch, func(entries []models.AuditEntry) error {
+		for _, e := range entries {
+			switch {
+			case e.PrevHash != prev:
+				report.Problem = "not chained to the entry before it, which was altered or removed"
+			case e.ComputeHash() != e.Hash:
+				report.Problem = "contents don't match the hash"
+			default:
+				prev = e.Hash
+				report.Checked++
+				continue
+			}
+			id := e.ID
+			report.Valid, report.BrokenAt = false, &id
+			return errBroken
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBroken) {
+		return nil, fmt.Errorf("verify audit log: %w", err)
+	}
+	return report, nil
+}
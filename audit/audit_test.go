@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"example/logging"
+	"example/models"
+	"example/repo"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memLog keeps the audit log in memory, chained as the database does.
+// Unused Repository methods panic.
+type memLog struct {
+	repo.Repository
+	entries []models.AuditEntry
+}
+
+func (m *memLog) AppendAuditEntry(_ context.Context, e *models.AuditEntry) error {
+	prev := ""
+	if n := len(m.entries); n > 0 {
+		prev = m.entries[n-1].Hash
+	}
+	e.ID = uint(len(m.entries) + 1)
+	e.Seal(prev, time.Now())
+	m.entries = append(m.entries, *e)
+	return nil
+}
+
+func (m *memLog) AuditEntriesInBatches(_ context.Context, f models.AuditFilter, size int, fn func([]models.AuditEntry) error) error {
+	var matching []models.AuditEntry
+	for _, e := range m.entries {
+		if f.Action == "" || e.Action == f.Action {
+			matching = append(matching, e)
+		}
+	}
+	for len(matching) > 0 {
+		n := min(size, len(matching))
+		if err := fn(matching[:n]); err != nil {
+			return err
+		}
+		matching = matching[n:]
+	}
+	return nil
+}
+
+// filled returns a log of n entries, alternating between two actions.
+func filled(t *testing.T, n int) *memLog {
+	m := &memLog{}
+	for i := range n {
+		action := models.AuditPostCreated
+		if i%2 == 1 {
+			action = models.AuditPostUpdated
+		}
+		require.NoError(t, m.AppendAuditEntry(context.Background(), &models.AuditEntry{
+			Action: action, Entity: "post", EntityID: uint(i + 1),
+			Changes: models.Changes{"title": {Before: "old", After: "new"}},
+		}))
+	}
+	return m
+}
+
+func TestRecord(t *testing.T) {
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	ctx = WithSource(ctx, Source{IP: "203.0.113.7", UserAgent: "curl/8.0"})
+	m := &memLog{}
+
+	user := &models.User{ID: 4, Email: "alice@example.com"}
+	require.NoError(t, Record(ctx, m, user, &models.AuditEntry{Action: models.AuditPostDeleted, Entity: "post", EntityID: 2}))
+	require.NoError(t, Record(context.Background(), m, nil, &models.AuditEntry{Action: models.AuditUserCreated}))
+
+	e := m.entries[0]
+	assert.Equal(t, uint(4), *e.ActorID)
+	assert.Equal(t, "alice@example.com", e.Actor)
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, "curl/8.0", e.UserAgent)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.Nil(t, m.entries[1].ActorID, "from the CLI")
+	assert.Equal(t, e.Hash, m.entries[1].PrevHash)
+}
+
+func TestDiff(t *testing.T) {
+	before := map[string]any{"title": "a", "body": "b", "status": "draft"}
+	after := map[string]any{"title": "a", "body": "c", "status": "published"}
+	assert.Equal(t, models.Changes{
+		"body":   {Before: "b", After: "c"},
+		"status": {Before: "draft", After: "published"},
+	}, Diff(before, after))
+
+	assert.Equal(t, models.Changes{"title": {After: "a"}}, Diff(nil, map[string]any{"title": "a"}), "created")
+	assert.Equal(t, models.Changes{"title": {Before: "a"}}, Diff(map[string]any{"title": "a"}, nil), "deleted")
+	assert.Empty(t, Diff(before, before))
+}
+
+func TestExport(t *testing.T) {
+	m := filled(t, 3)
+
+	var buf bytes.Buffer
+	n, err := Export(context.Background(), m, &buf, models.AuditFilter{Action: models.AuditPostCreated}, FormatJSONL)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var e models.AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, uint(3), e.ID)
+	assert.Equal(t, m.entries[2].Hash, e.Hash)
+
+	buf.Reset()
+	n, err = Export(context.Background(), m, &buf, models.AuditFilter{}, FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{"2", models.AuditPostUpdated}, []string{records[2][0], records[2][2]})
+	assert.Equal(t, `{"title":{"before":"old","after":"new"}}`, records[2][10])
+
+	_, err = Export(context.Background(), m, &buf, models.AuditFilter{}, "xml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(m *memLog)
+		brokenAt uint
+		checked  int
+	}{
+		{name: "intact", tamper: func(*memLog) {}},
+		{name: "altered", tamper: func(m *memLog) { m.entries[3].Changes["title"] = models.Change{Before: "old", After: "forged"} }, brokenAt: 4, checked: 3},
+		{name: "removed", tamper: func(m *memLog) { m.entries = append(m.entries[:2], m.entries[3:]...) }, brokenAt: 4, checked: 2},
+		{name: "rehashed", tamper: func(m *memLog) {
+			m.entries[1].Actor = "someone@else.com"
+			m.entries[1].Hash = m.entries[1].ComputeHash()
+		}, brokenAt: 3, checked: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := filled(t, exportBatch+5)
+			tt.tamper(m)
+
+			report, err := Verify(context.Background(), m)
+			require.NoError(t, err)
+			if tt.brokenAt == 0 {
+				assert.True(t, report.Valid)
+				assert.Equal(t, len(m.entries), report.Checked)
+				return
+			}
+			assert.False(t, report.Valid)
+			require.NotNil(t, report.BrokenAt)
+			assert.Equal(t, tt.brokenAt, *report.BrokenAt)
+			assert.Equal(t, tt.checked, report.Checked)
+			assert.NotEmpty(t, report.Problem)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package auth manages users and the API keys issued to them.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"example/audit"
+	"example/models"
+	"example/repo"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserExists is returned when creating a user whose email is taken.
+	ErrUserExists = errors.New("a user with this email already exists")
+	// ErrUnknownUser is returned when no user has the given email.
+	ErrUnknownUser = errors.New("no user with this email")
+	// ErrInvalidAPIKey is returned for malformed, unknown or revoked keys.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrInvalidCredentials is returned when signing in with an unknown
+	// email or a wrong password, without telling which.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// apiKeyPrefix starts every API key so that leaked keys are easy to spot.
+const apiKeyPrefix = "blog_"
+
+// CreateUser validates and stores a new user. If password is empty a random
+// one is generated; the password used is returned so that it can be handed
+// to the user.
+func CreateUser(ctx context.Context, r repo.Repository, email, name, role, password string) (*models.User, string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if err := models.Validate.Var(email, "required,email"); err != nil {
+		return nil, "", fmt.Errorf("invalid email %q", email)
+	}
+	if !slices.Contains(models.Roles, role) {
+		return nil, "", fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(models.Roles, ", "))
+	}
+
+	_, err := r.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		return nil, "", ErrUserExists
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", err
+	}
+
+	if password == "" {
+		password = randomString(18)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, "", err
+	}
+	user := &models.User{Email: email, Name: strings.TrimSpace(name), Role: role, PasswordHash: hash}
+	if err := r.CreateUser(ctx, user); err != nil {
+		return nil, "", err
+	}
+	err = audit.Record(ctx, r, User(ctx), &models.AuditEntry{
+		Action: models.AuditUserCreated, Entity: "user", EntityID: user.ID,
+		Changes: audit.Diff(nil, map[string]any{"email": user.Email, "name": user.Name, "role": user.Role}),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// HashPassword hashes a password for storage.
+func HashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", errors.New("password must be at least 8 characters long")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword reports whether password matches the stored hash.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Login returns the user with the given email if password is theirs.
+func Login(ctx context.Context, r repo.Repository, email, password string) (*models.User, error) {
+	user, err := r.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Checking a password anyway takes as long as for a known email, so
+		// that timing doesn't tell which emails are.
+		CheckPassword(unknownUserHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// unknownUserHash is a password hash no password matches.
+var unknownUserHash = sync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(randomString(18)), bcrypt.DefaultCost)
+	return string(hash)
+})
+
+// IssueAPIKey creates an API key for the user with the given email. The key
+// itself is only returned here; it can't be recovered later.
+func IssueAPIKey(ctx context.Context, r repo.Repository, email, name string) (string, *models.APIKey, error) {
+	user, err := r.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, ErrUnknownUser
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	prefix := randomHex(6)
+	token := apiKeyPrefix + prefix + "_" + randomString(32)
+	key := &models.APIKey{UserID: user.ID, Name: name, Prefix: prefix, Hash: hashToken(token)}
+	if err := r.CreateAPIKey(ctx, key); err != nil {
+		return "", nil, err
+	}
+	err = audit.Record(ctx, r, User(ctx), &models.AuditEntry{
+		Action: models.AuditAPIKeyIssued, Entity: "api_key", EntityID: key.ID,
+		Changes: audit.Diff(nil, map[string]any{"user_id": user.ID, "name": key.Name, "prefix": key.Prefix}),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	key.User = *user
+	return token, key, nil
+}
+
+// VerifyAPIKey returns the stored key, with its user, matching token.
+func VerifyAPIKey(ctx context.Context, r repo.Repository, token string) (*models.APIKey, error) {
+	rest, ok := strings.CutPrefix(token, apiKeyPrefix)
+	prefix, _, ok2 := strings.Cut(rest, "_")
+	if !ok || !ok2 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := r.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashToken(token))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil && !key.RevokedAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// hashToken hashes an API key. Keys are long and random, so unlike
+// passwords they don't need a slow hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"example/mocks"
+	"example/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCreateUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success case - generated password", func(t *testing.T) {
+		r := new(mocks.Repository)
+		r.On("GetUserByEmail", ctx, "ops@example.com").Return(nil, gorm.ErrRecordNotFound)
+		r.On("CreateUser", ctx, mock.AnythingOfType("*models.User")).Return(nil)
+		r.On("AppendAuditEntry", ctx, mock.MatchedBy(func(e *models.AuditEntry) bool {
+			return e.Action == models.AuditUserCreated && e.ActorID == nil &&
+				e.Changes["role"] == models.Change{After: models.RoleAdmin}
+		})).Return(nil)
+
+		user, password, err := CreateUser(ctx, r, " Ops@Example.com ", "Ops", models.RoleAdmin, "")
+		require.NoError(t, err)
+		assert.Equal(t, "ops@example.com", user.Email)
+		assert.Equal(t, models.RoleAdmin, user.Role)
+		assert.NotEmpty(t, password)
+		assert.True(t, CheckPassword(user.PasswordHash, password))
+		r.AssertExpectations(t)
+	})
+
+	t.Run("failure case - email taken", func(t *testing.T) {
+		r := new(mocks.Repository)
+		r.On("GetUserByEmail", ctx, "ops@example.com").Return(&models.User{ID: 1}, nil)
+
+		_, _, err := CreateUser(ctx, r, "ops@example.com", "", models.RoleAdmin, "long enough")
+		assert.ErrorIs(t, err, ErrUserExists)
+	})
+
+	t.Run("failure case - invalid input", func(t *testing.T) {
+		r := new(mocks.Repository)
+		_, _, err := CreateUser(ctx, r, "not an email", "", models.RoleAdmin, "")
+		assert.Error(t, err)
+		_, _, err = CreateUser(ctx, r, "ops@example.com", "", "root", "")
+		assert.Error(t, err)
+		r.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failure case - short password", func(t *testing.T) {
+		r := new(mocks.Repository)
+		r.On("GetUserByEmail", ctx, "ops@example.com").Return(nil, gorm.ErrRecordNotFound)
+		_, _, err := CreateUser(ctx, r, "ops@example.com", "", models.RoleAdmin, "short")
+		assert.Error(t, err)
+		r.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 7, Email: "ops@example.com"}
+
+	var stored *models.APIKey
+	r := new(mocks.Repository)
+	r.On("GetUserByEmail", ctx, "ops@example.com").Return(user, nil)
+	r.On("CreateAPIKey", ctx, mock.AnythingOfType("*models.APIKey")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.APIKey) }).
+		Return(nil)
+	r.On("AppendAuditEntry", ctx, mock.MatchedBy(func(e *models.AuditEntry) bool {
+		return e.Action == models.AuditAPIKeyIssued && e.Changes["user_id"] == models.Change{After: uint(7)}
+	})).Return(nil)
+
+	token, key, err := IssueAPIKey(ctx, r, "ops@example.com", "deploy")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "blog_"+key.Prefix+"_"))
+	assert.Equal(t, uint(7), key.UserID)
+	assert.NotContains(t, stored.Hash, token)
+
+	r.On("GetAPIKeyByPrefix", ctx, key.Prefix).Return(stored, nil)
+	got, err := VerifyAPIKey(ctx, r, token)
+	require.NoError(t, err)
+	assert.Equal(t, stored, got)
+
+	_, err = VerifyAPIKey(ctx, r, token+"x")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = VerifyAPIKey(ctx, r, "not a key")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	revoked := time.Now().Add(-time.Minute)
+	stored.RevokedAt = &revoked
+	_, err = VerifyAPIKey(ctx, r, token)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	r.On("GetUserByEmail", ctx, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+	_, _, err = IssueAPIKey(ctx, r, "nobody@example.com", "deploy")
+	assert.ErrorIs(t, err, ErrUnknownUser)
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	r := new(mocks.Repository)
+	r.On("GetUserByEmail", ctx, "ops@example.com").Return(&models.User{ID: 1, Email: "ops@example.com", PasswordHash: hash}, nil)
+	r.On("GetUserByEmail", ctx, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	user, err := Login(ctx, r, " Ops@Example.com", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), user.ID)
+
+	_, err = Login(ctx, r, "ops@example.com", "wrong horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = Login(ctx, r, "nobody@example.com", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
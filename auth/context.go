@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"context"
+	"example/models"
+)
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User returns the authenticated user stored in ctx, or nil for anonymous
+// callers.
+func User(ctx context.Context) *models.User {
+	user, _ := ctx.Value(userKey{}).(*models.User)
+	return user
+}
@@ -0,0 +1,34 @@
+// Package cache adds read-through caching to service.Service, backed by an
+// in-process LRU or by Redis.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a byte cache with per-entry expiry.
+type Store interface {
+	// Get returns the value stored under key, and whether there was one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+	// DeletePrefix removes every key starting with prefix.
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Entities whose TTL can be configured.
+const (
+	// EntityPost is a single post, as returned by GetByID.
+	EntityPost = "post"
+	// EntityPosts is the list of every post, as returned by GetAll.
+	EntityPosts = "posts"
+)
+
+// DefaultTTLs apply to entities without a configured TTL.
+var DefaultTTLs = map[string]time.Duration{
+	EntityPost:  time.Minute,
+	EntityPosts: 10 * time.Second,
+}
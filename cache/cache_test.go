@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore runs the behaviour every Store must share. advance moves the
+// store's clock forward.
+func testStore(t *testing.T, s Store, advance func(time.Duration)) {
+	ctx := context.Background()
+
+	_, ok, err := s.Get(ctx, "blog:post:1")
+	require.NoError(t, err)
+	assert.False(t, ok, "empty store")
+
+	require.NoError(t, s.Set(ctx, "blog:post:1", []byte("one"), time.Minute))
+	require.NoError(t, s.Set(ctx, "blog:post:2", []byte("two"), time.Minute))
+	require.NoError(t, s.Set(ctx, "blog:posts", []byte("all"), 10*time.Second))
+	require.NoError(t, s.Set(ctx, "other:key", []byte("kept"), time.Minute))
+
+	v, ok, err := s.Get(ctx, "blog:post:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "one", string(v))
+
+	advance(11 * time.Second)
+	_, ok, _ = s.Get(ctx, "blog:posts")
+	assert.False(t, ok, "expired")
+	_, ok, _ = s.Get(ctx, "blog:post:2")
+	assert.True(t, ok, "not yet expired")
+
+	require.NoError(t, s.Delete(ctx, "blog:post:1", "missing"))
+	_, ok, _ = s.Get(ctx, "blog:post:1")
+	assert.False(t, ok, "deleted")
+
+	require.NoError(t, s.DeletePrefix(ctx, "blog:"))
+	_, ok, _ = s.Get(ctx, "blog:post:2")
+	assert.False(t, ok, "deleted by prefix")
+	v, ok, _ = s.Get(ctx, "other:key")
+	assert.True(t, ok, "outside the prefix")
+	assert.Equal(t, "kept", string(v))
+}
+
+func TestLRU(t *testing.T) {
+	s := NewLRU(10).(*lru)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	testStore(t, s, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestLRU_evictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewLRU(2)
+	require.NoError(t, s.Set(ctx, "a", []byte("a"), time.Minute))
+	require.NoError(t, s.Set(ctx, "b", []byte("b"), time.Minute))
+	_, _, _ = s.Get(ctx, "a")
+	require.NoError(t, s.Set(ctx, "c", []byte("c"), time.Minute))
+
+	_, ok, _ := s.Get(ctx, "b")
+	assert.False(t, ok, "b was least recently used")
+	_, ok, _ = s.Get(ctx, "a")
+	assert.True(t, ok)
+	_, ok, _ = s.Get(ctx, "c")
+	assert.True(t, ok)
+}
+
+func TestRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	testStore(t, NewRedis(client), mr.FastForward)
+}
+
+func TestRedis_unavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	mr.Close()
+
+	_, ok, err := NewRedis(client).Get(context.Background(), "blog:post:1")
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
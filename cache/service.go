@@ -0,0 +1,292 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"example/metrics"
+	"example/models"
+	"example/seed"
+	"example/service"
+	"example/tenant"
+	"example/transfer"
+	"example/wxr"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// prefix namespaces every key, so that a shared Redis can be flushed of this
+// service's entries alone.
+const prefix = "blog:"
+
+// blogPrefix namespaces the keys of the blog ctx is scoped to.
+func blogPrefix(ctx context.Context) string {
+	if t := tenant.From(ctx); t != nil {
+		return prefix + "t" + strconv.FormatUint(uint64(t.ID), 10) + ":"
+	}
+	return prefix
+}
+
+func postKey(ctx context.Context, id uint) string {
+	return blogPrefix(ctx) + EntityPost + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
+func postsKey(ctx context.Context) string {
+	return blogPrefix(ctx) + EntityPosts
+}
+
+// cachedService answers reads from a Store, falling through to the wrapped
+// service.Service on a miss, and invalidates entries on writes.
+type cachedService struct {
+	next  service.Service
+	store Store
+	ttls  map[string]time.Duration
+	group singleflight.Group
+}
+
+// NewService decorates next with read-through caching of GetByID and GetAll.
+// ttls overrides DefaultTTLs per entity.
+func NewService(next service.Service, store Store, ttls map[string]time.Duration) service.Service {
+	merged := map[string]time.Duration{}
+	for entity, ttl := range DefaultTTLs {
+		merged[entity] = ttl
+	}
+	for entity, ttl := range ttls {
+		merged[entity] = ttl
+	}
+	return &cachedService{next: next, store: store, ttls: merged}
+}
+
+func (s *cachedService) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	var post models.BlogPost
+	err := s.read(ctx, EntityPost, postKey(ctx, id), &post, func(ctx context.Context) (any, error) {
+		return s.next.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (s *cachedService) GetAll(ctx context.Context) ([]models.BlogPost, error) {
+	var posts []models.BlogPost
+	err := s.read(ctx, EntityPosts, postsKey(ctx), &posts, func(ctx context.Context) (any, error) {
+		return s.next.GetAll(ctx)
+	})
+	if err != nil {
+		return []models.BlogPost{}, err
+	}
+	return posts, nil
+}
+
+// read decodes the entry under key into dst, calling fetch on a miss.
+// Concurrent misses on the same key share a single fetch. Each caller decodes
+// its own copy, so callers never share a post.
+//
+// The cache is an optimisation: a failing store is logged and bypassed.
+func (s *cachedService) read(ctx context.Context, entity, key string, dst any, fetch func(context.Context) (any, error)) error {
+	data, ok, err := s.store.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "cache read failed", "key", key, "error", err)
+	}
+	if ok && json.Unmarshal(data, dst) == nil {
+		metrics.ObserveCache(entity, true)
+		return nil
+	}
+	metrics.ObserveCache(entity, false)
+
+	// The shared fetch outlives any one caller, so it must not be cancelled
+	// when the caller that started it goes away.
+	fctx := context.WithoutCancel(ctx)
+	ch := s.group.DoChan(key, func() (any, error) {
+		v, err := fetch(fctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.store.Set(fctx, key, data, s.ttls[entity]); err != nil {
+			slog.WarnContext(fctx, "cache write failed", "key", key, "error", err)
+		}
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), dst)
+	}
+}
+
+// invalidate drops keys after a write. Entries are dropped whether or not the
+// write succeeded, since a failed write may still have changed something.
+// A read that started before the write may store the old value again; TTLs
+// bound how long that lasts.
+func (s *cachedService) invalidate(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		s.group.Forget(key)
+	}
+	if err := s.store.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		slog.ErrorContext(ctx, "cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+func (s *cachedService) Create(ctx context.Context, req models.CreateBlogRequest) (uint, error) {
+	id, err := s.next.Create(ctx, req)
+	s.invalidate(ctx, postsKey(ctx))
+	return id, err
+}
+
+func (s *cachedService) Update(ctx context.Context, id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error) {
+	post, err := s.next.Update(ctx, id, req)
+	s.invalidate(ctx, postKey(ctx, id), postsKey(ctx))
+	return post, err
+}
+
+func (s *cachedService) Delete(ctx context.Context, id uint) error {
+	err := s.next.Delete(ctx, id)
+	s.invalidate(ctx, postKey(ctx, id), postsKey(ctx))
+	return err
+}
+
+func (s *cachedService) CreateBatch(ctx context.Context, reqs []models.CreateBlogRequest, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	out, err := s.next.CreateBatch(ctx, reqs, mode)
+	s.invalidate(ctx, postsKey(ctx))
+	return out, err
+}
+
+func (s *cachedService) UpdateBatch(ctx context.Context, items []models.BulkUpdateItem, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	out, err := s.next.UpdateBatch(ctx, items, mode)
+	keys := []string{postsKey(ctx)}
+	for _, item := range items {
+		keys = append(keys, postKey(ctx, item.ID))
+	}
+	s.invalidate(ctx, keys...)
+	return out, err
+}
+
+func (s *cachedService) DeleteBatch(ctx context.Context, ids []uint, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	out, err := s.next.DeleteBatch(ctx, ids, mode)
+	keys := []string{postsKey(ctx)}
+	for _, id := range ids {
+		keys = append(keys, postKey(ctx, id))
+	}
+	s.invalidate(ctx, keys...)
+	return out, err
+}
+
+func (s *cachedService) Export(ctx context.Context, w io.Writer, f transfer.Format) (int, error) {
+	return s.next.Export(ctx, w, f)
+}
+
+// Import may overwrite any post of the blog, so it drops every entry of it.
+func (s *cachedService) Import(ctx context.Context, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error) {
+	report, err := s.next.Import(ctx, rd, f, opts)
+	if report == nil || !report.DryRun {
+		p := blogPrefix(ctx)
+		if err := s.store.DeletePrefix(context.WithoutCancel(ctx), p); err != nil {
+			slog.ErrorContext(ctx, "cache invalidation failed", "prefix", p, "error", err)
+		}
+	}
+	return report, err
+}
+
+// ImportWordPress creates posts, tags and categories, so it drops every
+// entry of the blog, even if it fails part way.
+func (s *cachedService) ImportWordPress(ctx context.Context, rd io.Reader, name string, opts wxr.Options) (*wxr.Report, error) {
+	report, err := s.next.ImportWordPress(ctx, rd, name, opts)
+	p := blogPrefix(ctx)
+	if err := s.store.DeletePrefix(context.WithoutCancel(ctx), p); err != nil {
+		slog.ErrorContext(ctx, "cache invalidation failed", "prefix", p, "error", err)
+	}
+	return report, err
+}
+
+// Seed creates posts, users and tags, so it drops every entry of the blog.
+func (s *cachedService) Seed(ctx context.Context, ds *seed.Dataset) (*seed.Report, error) {
+	report, err := s.next.Seed(ctx, ds)
+	if err == nil {
+		p := blogPrefix(ctx)
+		if err := s.store.DeletePrefix(context.WithoutCancel(ctx), p); err != nil {
+			slog.ErrorContext(ctx, "cache invalidation failed", "prefix", p, "error", err)
+		}
+	}
+	return report, err
+}
+
+// Locks are checked on every update, so they aren't cached.
+func (s *cachedService) GetLock(ctx context.Context, id uint) (*models.PostLock, error) {
+	return s.next.GetLock(ctx, id)
+}
+
+func (s *cachedService) Lock(ctx context.Context, id uint, ttl time.Duration) (*models.PostLock, error) {
+	return s.next.Lock(ctx, id, ttl)
+}
+
+func (s *cachedService) Unlock(ctx context.Context, id uint, force bool) error {
+	return s.next.Unlock(ctx, id, force)
+}
+
+// Translations are fetched per request, so a cached post never carries
+// another language's text.
+func (s *cachedService) GetTranslations(ctx context.Context, id uint) ([]models.PostTranslation, error) {
+	return s.next.GetTranslations(ctx, id)
+}
+
+func (s *cachedService) PutTranslation(ctx context.Context, id uint, locale string, req models.TranslationRequest) (*models.PostTranslation, bool, error) {
+	return s.next.PutTranslation(ctx, id, locale, req)
+}
+
+func (s *cachedService) UpdateTranslation(ctx context.Context, id uint, locale string, req *models.UpdateTranslationRequest) (*models.PostTranslation, error) {
+	return s.next.UpdateTranslation(ctx, id, locale, req)
+}
+
+func (s *cachedService) DeleteTranslation(ctx context.Context, id uint, locale string) error {
+	return s.next.DeleteTranslation(ctx, id, locale)
+}
+
+func (s *cachedService) Localize(ctx context.Context, posts []models.BlogPost, prefs []string) error {
+	return s.next.Localize(ctx, posts, prefs)
+}
+
+// Listings are filtered too many ways to be worth caching.
+func (s *cachedService) ListPosts(ctx context.Context, f models.PostFilter) (*models.PostList, error) {
+	return s.next.ListPosts(ctx, f)
+}
+
+func (s *cachedService) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	return s.next.GetTag(ctx, slug)
+}
+
+func (s *cachedService) GetRevisions(ctx context.Context, id uint) ([]models.PostRevision, error) {
+	return s.next.GetRevisions(ctx, id)
+}
+
+func (s *cachedService) RestoreRevision(ctx context.Context, id, revisionID uint) (*models.BlogPost, error) {
+	post, err := s.next.RestoreRevision(ctx, id, revisionID)
+	s.invalidate(ctx, postKey(ctx, id), postsKey(ctx))
+	return post, err
+}
+
+func (s *cachedService) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	return s.next.ListTrash(ctx)
+}
+
+func (s *cachedService) RestorePost(ctx context.Context, id uint) (*models.BlogPost, error) {
+	post, err := s.next.RestorePost(ctx, id)
+	s.invalidate(ctx, postKey(ctx, id), postsKey(ctx))
+	return post, err
+}
+
+func (s *cachedService) PurgePost(ctx context.Context, id uint) error {
+	return s.next.PurgePost(ctx, id)
+}
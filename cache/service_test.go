@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"example/mocks"
+	"example/models"
+	"example/service"
+	"example/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GetByID_readThrough(t *testing.T) {
+	next := new(mocks.BlogService)
+	next.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "Hello"}, nil).Once()
+	s := NewService(next, NewLRU(10), nil)
+
+	for range 3 {
+		post, err := s.GetByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", post.Title)
+	}
+	next.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestService_GetByID_callersGetTheirOwnCopy(t *testing.T) {
+	next := new(mocks.BlogService)
+	next.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "Hello"}, nil).Once()
+	s := NewService(next, NewLRU(10), nil)
+
+	first, err := s.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	first.Title = "changed by the caller"
+
+	second, err := s.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", second.Title)
+}
+
+func TestService_blogsDontShareEntries(t *testing.T) {
+	next := new(mocks.BlogService)
+	next.On("GetAll", mock.Anything).Return([]models.BlogPost{{ID: 1}}, nil).Twice()
+	s := NewService(next, NewLRU(10), nil)
+
+	for _, id := range []uint{1, 2, 1, 2} {
+		ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: id})
+		_, err := s.GetAll(ctx)
+		require.NoError(t, err)
+	}
+	next.AssertNumberOfCalls(t, "GetAll", 2)
+}
+
+func TestService_errorsAreNotCached(t *testing.T) {
+	next := new(mocks.BlogService)
+	notFound := service.NotFound("post_not_found", "post 7 not found", nil)
+	next.On("GetByID", mock.Anything, uint(7)).Return(nil, notFound).Twice()
+	s := NewService(next, NewLRU(10), nil)
+
+	for range 2 {
+		_, err := s.GetByID(context.Background(), 7)
+		assert.ErrorIs(t, err, notFound)
+	}
+	next.AssertNumberOfCalls(t, "GetByID", 2)
+}
+
+func TestService_writesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	title := "Updated"
+	tests := []struct {
+		name  string
+		setup func(next *mocks.BlogService)
+		write func(s service.Service)
+		post  bool // whether post 1 is invalidated, besides the list
+	}{
+		{
+			name:  "create",
+			setup: func(next *mocks.BlogService) { next.On("Create", ctx, mock.Anything).Return(uint(2), nil) },
+			write: func(s service.Service) { _, _ = s.Create(ctx, models.CreateBlogRequest{}) },
+		},
+		{
+			name: "update",
+			setup: func(next *mocks.BlogService) {
+				next.On("Update", ctx, uint(1), mock.Anything).Return(&models.BlogPost{ID: 1}, nil)
+			},
+			write: func(s service.Service) { _, _ = s.Update(ctx, 1, &models.UpdateBlogRequest{Title: &title}) },
+			post:  true,
+		},
+		{
+			name:  "failed delete",
+			setup: func(next *mocks.BlogService) { next.On("Delete", ctx, uint(1)).Return(errors.New("boom")) },
+			write: func(s service.Service) { _ = s.Delete(ctx, 1) },
+			post:  true,
+		},
+		{
+			name: "bulk delete",
+			setup: func(next *mocks.BlogService) {
+				next.On("DeleteBatch", ctx, []uint{3, 1}, models.BulkAtomic).Return(nil, nil)
+			},
+			write: func(s service.Service) { _, _ = s.DeleteBatch(ctx, []uint{3, 1}, models.BulkAtomic) },
+			post:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := new(mocks.BlogService)
+			next.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1}, nil)
+			next.On("GetAll", mock.Anything).Return([]models.BlogPost{{ID: 1}}, nil)
+			tt.setup(next)
+			s := NewService(next, NewLRU(10), nil)
+
+			_, _ = s.GetByID(ctx, 1)
+			_, _ = s.GetAll(ctx)
+			tt.write(s)
+			_, _ = s.GetByID(ctx, 1)
+			_, _ = s.GetAll(ctx)
+
+			next.AssertNumberOfCalls(t, "GetAll", 2)
+			calls := 1
+			if tt.post {
+				calls = 2
+			}
+			next.AssertNumberOfCalls(t, "GetByID", calls)
+		})
+	}
+}
+
+func TestService_concurrentMissesShareOneFetch(t *testing.T) {
+	next := new(mocks.BlogService)
+	release := make(chan struct{})
+	next.On("GetAll", mock.Anything).
+		Run(func(mock.Arguments) { <-release }).
+		Return([]models.BlogPost{{ID: 1}}, nil)
+	s := NewService(next, NewLRU(10), nil)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			posts, err := s.GetAll(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, posts, 1)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	next.AssertNumberOfCalls(t, "GetAll", 1)
+}
+
+func TestService_callerCancellationDoesNotAbortSharedFetch(t *testing.T) {
+	next := new(mocks.BlogService)
+	release := make(chan struct{})
+	next.On("GetByID", mock.Anything, uint(1)).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&models.BlogPost{ID: 1}, nil).Once()
+	s := NewService(next, NewLRU(10), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := s.GetByID(ctx, 1)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		post, err := s.GetByID(context.Background(), 1)
+		return err == nil && post.ID == 1
+	}, time.Second, 10*time.Millisecond)
+	next.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+// failingStore is a cache that is down.
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("down")
+}
+func (failingStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (failingStore) Delete(context.Context, ...string) error    { return errors.New("down") }
+func (failingStore) DeletePrefix(context.Context, string) error { return errors.New("down") }
+
+func TestService_unavailableStoreFallsThrough(t *testing.T) {
+	next := new(mocks.BlogService)
+	next.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1}, nil)
+	s := NewService(next, failingStore{}, nil)
+
+	post, err := s.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), post.ID)
+}
+
+func TestNewService_ttls(t *testing.T) {
+	s := NewService(new(mocks.BlogService), NewLRU(1), map[string]time.Duration{EntityPost: time.Hour}).(*cachedService)
+	assert.Equal(t, time.Hour, s.ttls[EntityPost])
+	assert.Equal(t, DefaultTTLs[EntityPosts], s.ttls[EntityPosts])
+}
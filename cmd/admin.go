@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	engin "example/cmd/app"
+	"example/config"
+	"example/models"
+	"example/seed"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// runMigrate implements "migrate", which creates or updates the database
+// schema whatever DB_AUTO_MIGRATE says.
+func runMigrate(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg.AutoMigrate = true
+	engin.Init(cfg)
+	slog.Info("Database schema is up to date")
+	return nil
+}
+
+// runSeed implements "seed [-profile name] [-seed n] [-n posts] [-json]",
+// which loads a generated dataset, or prints it with -json.
+func runSeed(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	profile := fs.String("profile", seed.DefaultProfile, "dataset shape: "+strings.Join(seed.ProfileNames(), ", "))
+	seedValue := fs.Uint64("seed", 1, "random seed; the same seed always generates the same data")
+	n := fs.Int("n", 0, "number of posts, overriding the profile")
+	asJSON := fs.Bool("json", false, "print the dataset as JSON instead of loading it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, err := seed.LookupProfile(*profile)
+	if err != nil {
+		return err
+	}
+	if *n < 0 {
+		return usageError("seed [-profile name] [-seed n] [-n posts] [-json]")
+	}
+	if *n > 0 {
+		p.Posts = *n
+	}
+
+	ds := seed.Generate(p, *seedValue)
+	if *asJSON {
+		return printJSON(ds)
+	}
+	ctx, err := engin.InitTenant(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	report, err := engin.Service().Seed(ctx, ds)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
+
+// validate normalises and validates a request the way the API does.
+func validate(req interface{ Normalize() }) error {
+	req.Normalize()
+	if err := models.Validate.Struct(req); err != nil {
+		var msgs []string
+		for _, fe := range models.FieldErrors(err, models.DefaultLocale) {
+			msgs = append(msgs, fe.Field+": "+fe.Message)
+		}
+		return fmt.Errorf("invalid input: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// printJSON writes v to standard output as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
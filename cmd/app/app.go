@@ -1,27 +1,139 @@
 package app
 
 import (
+	"context"
+	"example/cache"
+	"example/collab"
+	"example/config"
 	"example/database"
+	"example/logging"
+	"example/metrics"
 	"example/repo"
 	"example/service"
+	"example/stream"
+	"example/tenant"
+	"example/tracing"
+	"log/slog"
+	"os"
 
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 var application Application
 
-func Init() {
-	db := database.NewDB()
+func Init(cfg config.Config) {
+	db := database.NewDB(logging.NewGormLogger(slog.Default(), cfg.SlowQueryThreshold))
+	if cfg.AutoMigrate {
+		if err := database.Migrate(db); err != nil {
+			fatal("Failed to migrate the database", err)
+		}
+	}
+	if err := db.Use(metrics.GormPlugin{DBName: os.Getenv("DB_NAME")}); err != nil {
+		fatal("Failed to register metrics plugin", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		fatal("Failed to register tracing plugin", err)
+	}
+	if err := db.Use(tenant.GormPlugin{}); err != nil {
+		fatal("Failed to register tenant plugin", err)
+	}
 	re := repo.NewRepo(db)
-	se := service.NewService(re)
+	broker := stream.NewBroker(newStreamLog(cfg, re))
+	var se service.Service = service.NewService(re, service.WithNotifier(broker))
+	if store := newCacheStore(cfg); store != nil {
+		se = cache.NewService(se, store, cfg.CacheTTLs)
+	}
+	se = tracing.NewService(metrics.NewService(se))
 	application.db = db
 	application.service = se
 	application.repo = re
-
+	application.broker = broker
+	application.hub = collab.NewHub(se, cfg.CollabSaveInterval)
+	fallback := cfg.DefaultTenant
+	if fallback == "none" {
+		fallback = ""
+	}
+	application.tenants = tenant.NewResolver(re, fallback, cfg.TenantRefresh)
 }
 
 type Application struct {
 	db      *gorm.DB
 	service service.Service
 	repo    repo.Repository
+	redis   *redis.Client
+	broker  *stream.Broker
+	hub     *collab.Hub
+	tenants *tenant.Resolver
+}
+
+// redisClient returns the client shared by everything backed by Redis,
+// connecting on first use.
+func redisClient(cfg config.Config) *redis.Client {
+	if application.redis == nil {
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			fatal("Invalid REDIS_URL", err)
+		}
+		application.redis = redis.NewClient(opts)
+	}
+	return application.redis
+}
+
+// newCacheStore returns the configured response cache, or nil when caching is
+// disabled.
+func newCacheStore(cfg config.Config) cache.Store {
+	switch cfg.CacheBackend {
+	case "none":
+		return nil
+	case "memory":
+		return cache.NewLRU(cfg.CacheSize)
+	case "redis":
+		return cache.NewRedis(redisClient(cfg))
+	default:
+		slog.Error("Unknown CACHE_BACKEND, expected memory, redis or none", "backend", cfg.CacheBackend)
+		os.Exit(1)
+		return nil
+	}
+}
+
+// newStreamLog returns the log event stream clients resume from.
+func newStreamLog(cfg config.Config, r repo.Repository) stream.Log {
+	switch cfg.StreamLog {
+	case "memory":
+		return stream.NewMemoryLog(cfg.StreamLogSize)
+	case "db":
+		return stream.NewDBLog(r)
+	default:
+		slog.Error("Unknown SSE_LOG, expected memory or db", "log", cfg.StreamLog)
+		os.Exit(1)
+		return nil
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// Service returns the service built by Init, for commands that work on posts
+// without serving HTTP.
+func Service() service.Service {
+	return application.service
+}
+
+// Repository returns the repository built by Init.
+func Repository() repo.Repository {
+	return application.repo
+}
+
+// InitTenant calls Init and returns a copy of ctx scoped to the blog CLI
+// commands act on, set by TENANT.
+func InitTenant(ctx context.Context, cfg config.Config) (context.Context, error) {
+	Init(cfg)
+	t, err := tenant.Get(ctx, application.repo, cfg.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	return tenant.WithTenant(ctx, t), nil
 }
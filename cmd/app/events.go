@@ -0,0 +1,56 @@
+package app
+
+import (
+	"example/config"
+	"example/events"
+	"example/webhook"
+	"fmt"
+	"os"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Relay returns the outbox relay publishing to the configured sinks, or nil
+// if there are none. Init must have been called.
+func Relay(cfg config.Config) (*events.Relay, error) {
+	var sinks []events.Sink
+	for _, name := range cfg.EventSinks {
+		switch name {
+		case "stdout":
+			sinks = append(sinks, events.NewWriterSink(os.Stdout))
+		case "webhook":
+			if cfg.EventWebhookURL == "" {
+				return nil, fmt.Errorf("the webhook event sink needs EVENT_WEBHOOK_URL")
+			}
+			sinks = append(sinks, events.NewWebhookSink(cfg.EventWebhookURL, nil))
+		case "nats":
+			conn, err := nats.Connect(cfg.NATSURL, nats.Name(cfg.ServiceName), nats.MaxReconnects(-1))
+			if err != nil {
+				return nil, fmt.Errorf("unable to connect to NATS: %w", err)
+			}
+			sinks = append(sinks, events.NewNATSSink(conn, cfg.NATSSubjectPrefix))
+		default:
+			return nil, fmt.Errorf("unknown event sink %q, expected stdout, webhook or nats", name)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return &events.Relay{
+		Repo:      application.repo,
+		Sinks:     sinks,
+		Interval:  cfg.OutboxPollInterval,
+		Retention: cfg.OutboxRetention,
+	}, nil
+}
+
+// Dispatcher returns the webhook dispatcher. Init must have been called.
+func Dispatcher(cfg config.Config) *webhook.Dispatcher {
+	return &webhook.Dispatcher{
+		Repo:         application.repo,
+		Client:       webhook.NewClient(cfg.WebhookTimeout),
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		DisableAfter: cfg.WebhookDisableAfter,
+		Retention:    cfg.WebhookRetention,
+	}
+}
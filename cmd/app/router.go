@@ -1,20 +1,261 @@
 package app
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"example/admin"
+	"example/audit"
+	"example/auth"
+	"example/config"
 	"example/controller"
+	"example/idempotency"
+	"example/middleware"
+	"example/models"
+	"example/ratelimit"
+	"example/site"
+	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"gorm.io/gorm"
 )
 
-func SetupRoutes(app *fiber.App) {
+// SetupRoutes registers every route. Cancelling ctx ends the long-lived
+// connections, such as event streams, for the server to shut down.
+func SetupRoutes(ctx context.Context, app *fiber.App, cfg config.Config) {
 
-	Init()
-	con := controller.NewController(application.service)
+	Init(cfg)
+	app.Use(middleware.Tenant(application.tenants.Resolve))
+	app.Use(middleware.CORS(cors.Config{
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, traceparent, tracestate, X-Request-ID, Idempotency-Key, Last-Event-ID",
+		ExposeHeaders: "X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed",
+	}))
+	con := controller.NewController(application.service,
+		controller.WithBulkMaxItems(cfg.BulkMaxItems),
+		controller.WithImportMaxSize(cfg.ImportMaxSize), controller.WithLockTTL(cfg.LockTTL))
+	timeouts := middleware.Timeouts{Default: cfg.RequestTimeout, Routes: cfg.RouteTimeouts}
+	verify := func(ctx context.Context, token string) (*models.APIKey, error) {
+		return auth.VerifyAPIKey(ctx, application.repo, token)
+	}
 	api := app.Group("/api")
+	rl, limited := rateLimits(cfg)
+	if limited {
+		api.Use(middleware.RateLimitIP(rl))
+	}
+	api.Use(middleware.Authenticate(verify))
+	if limited {
+		api.Use(middleware.RateLimit(rl))
+	}
+	if store := idempotencyStore(cfg); store != nil {
+		api.Use(middleware.Idempotency(middleware.IdempotencyConfig{
+			Store: store, TTL: cfg.IdempotencyTTL, Lock: cfg.IdempotencyLock,
+		}))
+	}
 
-	api.Post("/blog-post", con.CreatePost)
-	api.Get("/blog-post", con.GetPosts)
-	api.Get("/blog-post/:id", con.GetPost)
-	api.Patch("/blog-post/:id", con.UpdatePost)
-	api.Delete("/blog-post/:id", con.DeletePost)
+	api.Get("/blog", controller.GetBlog)
+	api.Get("/sitemap", timeouts.Handler, con.GetSitemap)
+	api.Post("/blog-post", timeouts.Handler, con.CreatePost)
+	api.Get("/blog-post", timeouts.Handler, con.GetPosts)
+	// Registered before the :id routes so that "bulk", "export" and "import"
+	// aren't taken for an ID.
+	api.Post("/blog-post/bulk", timeouts.Handler, con.CreatePosts)
+	api.Patch("/blog-post/bulk", timeouts.Handler, con.UpdatePosts)
+	api.Delete("/blog-post/bulk", timeouts.Handler, con.DeletePosts)
+	// Exports and editing sessions include drafts, which only those who can
+	// write posts may see.
+	api.Get("/blog-post/export", middleware.RequireRole(models.Roles...), timeouts.Handler, con.ExportPosts)
+	// Editing sessions are long-lived, so no request timeout applies.
+	cc := controller.NewCollabController(application.service, application.hub, editorName)
+	api.Get("/blog-post/:id/collab", middleware.RequireRole(models.Roles...), cc.EditPost)
+	api.Post("/blog-post/import", timeouts.Handler, con.ImportPosts)
+	api.Get("/blog-post/:id", timeouts.Handler, con.GetPost)
+	api.Patch("/blog-post/:id", timeouts.Handler, con.UpdatePost)
+	api.Delete("/blog-post/:id", timeouts.Handler, con.DeletePost)
+	api.Get("/blog-post/:id/lock", timeouts.Handler, con.GetLock)
+	api.Post("/blog-post/:id/lock", middleware.RequireRole(models.Roles...),
+		timeouts.Handler, con.LockPost)
+	api.Delete("/blog-post/:id/lock", middleware.RequireRole(models.Roles...),
+		timeouts.Handler, con.UnlockPost)
+	api.Get("/blog-post/:id/translations", timeouts.Handler, con.GetTranslations)
+	api.Put("/blog-post/:id/translations/:locale",
+		timeouts.Handler, con.PutTranslation)
+	api.Patch("/blog-post/:id/translations/:locale",
+		timeouts.Handler, con.UpdateTranslation)
+	api.Delete("/blog-post/:id/translations/:locale",
+		timeouts.Handler, con.DeleteTranslation)
+
+	// Streams are long-lived, so no request timeout applies.
+	sc := controller.NewStreamController(ctx, application.broker, cfg.StreamHeartbeat)
+	api.Get("/events", sc.StreamEvents)
+
+	wc := controller.NewWebhookController(application.repo)
+	hooks := api.Group("/webhooks", middleware.RequireRole(models.RoleAdmin))
+	hooks.Post("/", timeouts.Handler, wc.CreateWebhook)
+	hooks.Get("/", timeouts.Handler, wc.GetWebhooks)
+	hooks.Get("/:id", timeouts.Handler, wc.GetWebhook)
+	hooks.Patch("/:id", timeouts.Handler, wc.UpdateWebhook)
+	hooks.Delete("/:id", timeouts.Handler, wc.DeleteWebhook)
+	hooks.Get("/:id/deliveries", timeouts.Handler, wc.GetDeliveries)
+	hooks.Post("/:id/deliveries/:delivery_id/redeliver",
+		timeouts.Handler, wc.Redeliver)
+
+	ac := controller.NewAuditController(application.repo)
+	audit := app.Group("/admin/audit", middleware.Authenticate(verify), middleware.RequireRole(models.RoleAdmin))
+	audit.Get("/", timeouts.Handler, ac.GetAuditEntries)
+	audit.Get("/export", timeouts.Handler, ac.ExportAuditEntries)
+	audit.Get("/verify", timeouts.Handler, ac.VerifyAuditLog)
+
+	if cfg.AdminUI {
+		setupAdmin(app, cfg)
+	}
+
+	if cfg.Development() {
+		dev := controller.NewDevController(application.service)
+		api.Post("/dev/seed", dev.Seed)
+	}
+
+	if cfg.Site {
+		setupSite(app, cfg)
+	}
+}
+
+// setupAdmin serves the admin interface. It must come after the audit
+// endpoints, which share its /admin prefix.
+func setupAdmin(app *fiber.App, cfg config.Config) {
+	secret := []byte(cfg.AdminSecret)
+	if len(secret) == 0 {
+		if !cfg.Development() {
+			slog.Error("ADMIN_SECRET must be set to serve the admin interface outside development")
+			os.Exit(1)
+		}
+		slog.Warn("ADMIN_SECRET is not set; admin sessions end when the server restarts and only work on this instance")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			fatal("Failed to generate an admin session secret", err)
+		}
+	}
+	attempts, err := ratelimit.ParseLimit(cfg.AdminLoginLimit)
+	if err != nil {
+		fatal("Invalid ADMIN_LOGIN_LIMIT", err)
+	}
+	a, err := admin.New(application.service, admin.Config{
+		Login: func(ctx context.Context, email, password string) (*models.User, error) {
+			return auth.Login(ctx, application.repo, email, password)
+		},
+		User: func(ctx context.Context, email string) (*models.User, error) {
+			user, err := application.repo.GetUserByEmail(ctx, email)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, auth.ErrUnknownUser
+			}
+			return user, err
+		},
+		Secret: secret, SessionTTL: cfg.AdminSessionTTL, RawHTML: cfg.SiteRawHTML,
+		Attempts: newRateLimitStore(cfg), AttemptLimit: attempts,
+		Audit: func(ctx context.Context, actor *models.User, e *models.AuditEntry) error {
+			return audit.Record(ctx, application.repo, actor, e)
+		},
+	})
+	if err != nil {
+		fatal("Failed to load the admin interface", err)
+	}
+	timeouts := middleware.Timeouts{Default: cfg.RequestTimeout, Routes: cfg.RouteTimeouts}
+	a.Register(app.Group("/admin"), timeouts.Handler)
+}
+
+// setupSite serves the blog's HTML pages, and the site's 404 page for any
+// path outside the API that nothing else matched. It must come last.
+func setupSite(app *fiber.App, cfg config.Config) {
+	s, err := site.New(application.service, site.Config{
+		ThemeDir: cfg.SiteThemeDir, PageSize: cfg.SitePageSize, RawHTML: cfg.SiteRawHTML,
+		Cache: newCacheStore(cfg), CacheTTL: cfg.SiteCacheTTL,
+	})
+	if err != nil {
+		fatal("Failed to load the site's theme", err)
+	}
+	timeouts := middleware.Timeouts{Default: cfg.RequestTimeout, Routes: cfg.RouteTimeouts}
+	s.Register(app, timeouts.Handler)
+	app.Use(func(c *fiber.Ctx) error {
+		if strings.HasPrefix(c.Path(), "/api/") || strings.HasPrefix(c.Path(), "/admin/") {
+			return c.Next()
+		}
+		return s.NotFound(c)
+	})
+}
+
+// editorName names collaborative editors after the user of their API key.
+func editorName(c *fiber.Ctx) string {
+	key := middleware.APIKey(c)
+	switch {
+	case key == nil:
+		return "Anonymous"
+	case key.User.Name != "":
+		return key.User.Name
+	default:
+		return key.User.Email
+	}
+}
+
+// rateLimits builds the configured rate limits; ok is false when rate
+// limiting is disabled.
+func rateLimits(cfg config.Config) (rl middleware.RateLimits, ok bool) {
+	if rl.Store = newRateLimitStore(cfg); rl.Store == nil {
+		return rl, false
+	}
+
+	var err error
+	if rl.Read, err = ratelimit.ParseLimit(cfg.RateLimitRead); err != nil {
+		fatal("Invalid RATE_LIMIT_READ", err)
+	}
+	if rl.Write, err = ratelimit.ParseLimit(cfg.RateLimitWrite); err != nil {
+		fatal("Invalid RATE_LIMIT_WRITE", err)
+	}
+	if rl.IP, err = ratelimit.ParseLimit(cfg.RateLimitIP); err != nil {
+		fatal("Invalid RATE_LIMIT_IP", err)
+	}
+	switch cfg.RateLimitBy {
+	case middleware.RateLimitByIP, middleware.RateLimitByUser, middleware.RateLimitByAPIKey:
+		rl.By = cfg.RateLimitBy
+	default:
+		slog.Error("Unknown RATE_LIMIT_BY, expected ip, user or api_key", "by", cfg.RateLimitBy)
+		os.Exit(1)
+	}
+	return rl, true
+}
+
+// newRateLimitStore returns the configured store for rate limits, or nil when
+// rate limiting is disabled.
+func newRateLimitStore(cfg config.Config) ratelimit.Store {
+	switch cfg.RateLimitBackend {
+	case "none":
+		return nil
+	case "memory":
+		return ratelimit.NewMemory()
+	case "redis":
+		return ratelimit.NewRedis(redisClient(cfg))
+	default:
+		slog.Error("Unknown RATE_LIMIT_BACKEND, expected memory, redis or none", "backend", cfg.RateLimitBackend)
+		os.Exit(1)
+		return nil
+	}
+}
+
+// idempotencyStore returns the configured store for idempotent responses, or
+// nil when Idempotency-Key headers are ignored.
+func idempotencyStore(cfg config.Config) idempotency.Store {
+	switch cfg.IdempotencyStore {
+	case "none":
+		return nil
+	case "memory":
+		return idempotency.NewMemory()
+	case "db":
+		return idempotency.NewGormStore(application.db)
+	default:
+		slog.Error("Unknown IDEMPOTENCY_STORE, expected db, memory or none", "store", cfg.IdempotencyStore)
+		os.Exit(1)
+		return nil
+	}
 }
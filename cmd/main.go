@@ -1,45 +1,116 @@
 package main
 
 import (
-	engin "example/cmd/app"
+	"errors"
+	"example/config"
 	_ "example/docs" // Import the generated docs
-	"log"
+	"example/logging"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"strings"
 
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/swagger" // Import Fiber Swagger
 	"github.com/joho/godotenv"
 )
 
+// command is a subcommand of the blog binary.
+type command struct {
+	name    string
+	summary string
+	run     func(cfg config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "Start the HTTP server (the default)", runServe},
+	{"migrate", "Create or update the database schema", runMigrate},
+	{"tenants", "Manage the blogs hosted by the deployment", runTenants},
+	{"seed", "Create sample posts", runSeed},
+	{"posts", "Manage blog posts", runPosts},
+	{"users", "Manage users", runUsers},
+	{"apikeys", "Manage API keys", runAPIKeys},
+	{"export", "Export every post to a file", runExport},
+	{"import", "Import posts from a file", runImport},
+	{"import-wordpress", "Import posts from a WordPress export", runImportWordPress},
+	{"relay", "Publish outbox events and send webhooks", runRelay},
+	{"build-site", "Render the public blog to static files", runBuildSite},
+}
+
 // @title Blog CRUD API
 // @version 1.0
 // @description Simple Blog API using Go-Fiber, PostgreSQL, and Swagger
 // @host assissment-xpx7.onrender.com
 // @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description An API key as "Bearer <key>".
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	// Settings may come from the environment alone, as in production.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Error("Error loading .env file", "error", err)
+		os.Exit(1)
 	}
+	cfg := config.Load()
 
-	app := fiber.New()
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // Allow all origins (or specify frontend URL)
-		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-	}))
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "10000" // Default port if not set
+	if name == "serve" {
+		slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat))
+	} else {
+		// Commands may write their output to standard output, so log to
+		// standard error instead.
+		slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat))
 	}
-	// ✅ Swagger Route
-	app.Get("/swagger/*", swagger.HandlerDefault) // This serves Swagger UI
-	engin.SetupRoutes(app)
 
-	log.Printf("Starting server on port %s...", port)
-	if err := app.Listen(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(cfg, args)
+		var uerr usageErr
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return
+		case errors.As(err, &uerr):
+			fmt.Fprintln(os.Stderr, uerr)
+			os.Exit(2)
+		case err != nil:
+			slog.Error("Command failed", "command", name, "error", err)
+			os.Exit(1)
+		}
+		return
 	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+func usage(w *os.File) {
+	fmt.Fprintf(w, "Usage: %s <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-18s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s <command> -h\" for the flags of a command.\n", os.Args[0])
+}
+
+// usageErr reports a command called with the wrong arguments. It is printed
+// as is rather than logged.
+type usageErr string
+
+func (e usageErr) Error() string {
+	return "usage: " + string(e)
+}
+
+func usageError(usage string) error {
+	return usageErr(strings.TrimSpace(usage))
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"example/models"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const postsUsage = `posts list [-json]
+       posts get <id>
+       posts create -title title -description text (-body text | -body-file file)
+       posts delete <id>`
+
+// runPosts implements the "posts" subcommands.
+func runPosts(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return usageError(postsUsage)
+	}
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("posts "+sub, flag.ContinueOnError)
+	ctx := context.Background()
+
+	switch sub {
+	case "list":
+		asJSON := fs.Bool("json", false, "print the posts as JSON")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		ctx, err := engin.InitTenant(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		posts, err := engin.Service().GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(posts)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTITLE\tUPDATED")
+		for _, p := range posts {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", p.ID, p.Title, p.UpdatedAt.Format(time.DateTime))
+		}
+		return tw.Flush()
+
+	case "get":
+		id, err := parsePostID(fs, args)
+		if err != nil {
+			return err
+		}
+		ctx, err := engin.InitTenant(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		post, err := engin.Service().GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printJSON(post)
+
+	case "create":
+		var req models.CreateBlogRequest
+		fs.StringVar(&req.Title, "title", "", "title of the post")
+		fs.StringVar(&req.Description, "description", "", "short description of the post")
+		fs.StringVar(&req.Body, "body", "", "body of the post")
+		bodyFile := fs.String("body-file", "", "read the body from a file, or - for standard input")
+		fs.StringVar(&req.Status, "status", models.StatusPublished, "draft or published")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *bodyFile != "" {
+			body, err := readFile(*bodyFile)
+			if err != nil {
+				return err
+			}
+			req.Body = string(body)
+		}
+		if err := validate(&req); err != nil {
+			return err
+		}
+		ctx, err := engin.InitTenant(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		id, err := engin.Service().Create(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Println(id)
+		return nil
+
+	case "delete":
+		id, err := parsePostID(fs, args)
+		if err != nil {
+			return err
+		}
+		ctx, err := engin.InitTenant(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		if err := engin.Service().Delete(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "deleted post %d\n", id)
+		return nil
+
+	default:
+		return usageError(postsUsage)
+	}
+}
+
+// parsePostID parses the flags of a subcommand taking a single post ID.
+func parsePostID(fs *flag.FlagSet, args []string) (uint, error) {
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+	if fs.NArg() != 1 {
+		return 0, usageError(fs.Name() + " <id>")
+	}
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 0)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid post ID %q", fs.Arg(0))
+	}
+	return uint(id), nil
+}
+
+// readFile reads a file, or standard input if name is "-".
+func readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// runRelay implements "relay", which publishes outbox events and sends
+// webhooks until interrupted. It is for deployments running them apart from
+// the server, with OUTBOX_RELAY=false.
+func runRelay(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("relay", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	engin.Init(cfg)
+	relay, err := engin.Relay(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	if relay != nil {
+		slog.Info("Relaying outbox events", "sinks", cfg.EventSinks)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay.Run(ctx)
+		}()
+	} else {
+		slog.Info("No event sinks configured, only sending webhooks")
+	}
+	slog.Info("Sending webhooks")
+	err = engin.Dispatcher(cfg).Run(ctx)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"example/controller"
+	"example/metrics"
+	"example/middleware"
+	"example/tracing"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/swagger" // Import Fiber Swagger
+)
+
+// shutdownTimeout is how long requests in flight are given to finish once
+// the server is asked to stop.
+const shutdownTimeout = 30 * time.Second
+
+// runServe starts the HTTP API and the metrics server.
+func runServe(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), cfg.TraceExporter, cfg.ServiceName)
+	if err != nil {
+		return err
+	}
+	defer shutdown(context.Background())
+
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler, ProxyHeader: cfg.ProxyHeader})
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Source())
+	app.Use(tracing.Middleware())
+	app.Use(middleware.AccessLog(slog.Default()))
+	app.Use(metrics.Middleware())
+
+	go func() {
+		slog.Info("Serving metrics", "port", cfg.MetricsPort)
+		if err := metrics.ListenAndServe(cfg.MetricsPort); err != nil {
+			slog.Error("Failed to start metrics server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// ✅ Swagger Route
+	app.Get("/swagger/*", swagger.HandlerDefault) // This serves Swagger UI
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	engin.SetupRoutes(ctx, app, cfg)
+
+	// Background workers stop with the server, which waits for them.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	if cfg.OutboxRelay {
+		relay, err := engin.Relay(cfg)
+		if err != nil {
+			return err
+		}
+		if relay != nil {
+			slog.Info("Relaying outbox events", "sinks", cfg.EventSinks)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				relay.Run(ctx)
+			}()
+		}
+		slog.Info("Sending webhooks")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			engin.Dispatcher(cfg).Run(ctx)
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		slog.Info("Shutting down")
+		if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+			slog.Error("Failed to shut down cleanly", "error", err)
+		}
+	}()
+
+	slog.Info("Starting server", "port", cfg.Port)
+	err = app.Listen(":" + cfg.Port)
+	stop()
+	return err
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"example/site"
+	"flag"
+)
+
+// runBuildSite implements "build-site -o dir -url base-url [-full]". Only the
+// posts changed since the last build into dir are rendered unless -full is
+// given. The report is printed as JSON.
+func runBuildSite(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("build-site", flag.ContinueOnError)
+	out := fs.String("o", "", "directory to write the site to")
+	url := fs.String("url", "", "URL the site is served from, such as https://blog.example.com")
+	full := fs.Bool("full", false, "render every post, even those unchanged since the last build")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" || *url == "" || fs.NArg() != 0 {
+		return usageError("build-site -o dir -url base-url [-full]")
+	}
+
+	ctx, err := engin.InitTenant(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	s, err := site.New(engin.Service(), site.Config{
+		ThemeDir: cfg.SiteThemeDir, PageSize: cfg.SitePageSize, RawHTML: cfg.SiteRawHTML,
+	})
+	if err != nil {
+		return err
+	}
+	report, err := s.Build(ctx, *out, *url, *full)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
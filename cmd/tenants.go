@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"example/models"
+	"example/tenant"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const tenantsUsage = `tenants list [-json]
+       tenants create -slug slug [-title title] [-description text] [-host name] [-cors origins] [-feed-items n] [-feed-full-text]
+       tenants update -slug slug [-title title] [-description text] [-host name] [-cors origins] [-feed-items n] [-feed-full-text]`
+
+// runTenants implements the "tenants" subcommands, which provision the blogs
+// hosted by the deployment.
+func runTenants(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return usageError(tenantsUsage)
+	}
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("tenants "+sub, flag.ContinueOnError)
+	ctx := context.Background()
+
+	switch sub {
+	case "list":
+		asJSON := fs.Bool("json", false, "print the blogs as JSON")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		engin.Init(cfg)
+		tenants, err := engin.Repository().ListTenants(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(tenants)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSLUG\tHOST\tTITLE")
+		for _, t := range tenants {
+			host := "-"
+			if t.Host != nil {
+				host = *t.Host
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", t.ID, t.Slug, host, t.Title)
+		}
+		return tw.Flush()
+
+	case "create", "update":
+		slug := fs.String("slug", "", "name of the blog in /t/{slug} paths")
+		title := fs.String("title", "", "title of the blog")
+		description := fs.String("description", "", "description of the blog")
+		host := fs.String("host", "", "domain name the blog is served on; - for none")
+		origins := fs.String("cors", "", "comma-separated origins browsers may call the API from; - for any")
+		feedItems := fs.Int("feed-items", 0, fmt.Sprintf("number of posts in feeds; 0 for %d", models.DefaultFeedItems))
+		fullText := fs.Bool("feed-full-text", false, "put whole posts in feeds rather than their descriptions")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *slug == "" || fs.NArg() != 0 {
+			return usageError(tenantsUsage)
+		}
+		// Only the flags given change an existing blog.
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		engin.Init(cfg)
+		t := &models.Tenant{Slug: *slug}
+		if sub == "update" {
+			var err error
+			if t, err = tenant.Get(ctx, engin.Repository(), *slug); err != nil {
+				return err
+			}
+		}
+		if set["title"] {
+			t.Title = *title
+		}
+		if set["description"] {
+			t.Description = *description
+		}
+		if set["host"] {
+			t.Host = nil
+			if *host != "-" && *host != "" {
+				t.Host = host
+			}
+		}
+		if set["cors"] {
+			t.CORSOrigins = nil
+			if *origins != "-" {
+				for _, o := range strings.Split(*origins, ",") {
+					if o = strings.TrimSpace(o); o != "" {
+						t.CORSOrigins = append(t.CORSOrigins, o)
+					}
+				}
+			}
+		}
+		if set["feed-items"] {
+			t.FeedItems = *feedItems
+		}
+		if set["feed-full-text"] {
+			t.FeedFullText = *fullText
+		}
+
+		var err error
+		if sub == "create" {
+			err = tenant.Create(ctx, engin.Repository(), t)
+		} else {
+			err = tenant.Update(ctx, engin.Repository(), t)
+		}
+		if err != nil {
+			return err
+		}
+		return printJSON(t)
+
+	default:
+		return usageError(tenantsUsage)
+	}
+}
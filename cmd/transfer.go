@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"example/transfer"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runExport implements "export [-format jsonl|csv|markdown] [-o file]".
+// Posts are written to standard output unless -o is given.
+func runExport(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", string(transfer.JSONL), "file format: jsonl, csv or markdown")
+	out := fs.String("o", "", "output file (default standard output)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := transfer.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	bw := bufio.NewWriter(w)
+
+	ctx, err := engin.InitTenant(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	n, err := engin.Service().Export(ctx, bw, f)
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d posts\n", n)
+	return nil
+}
+
+// runImport implements "import [-format ...] [-dry-run] [-ids preserve|remap]
+// [-conflict skip|overwrite|fail] file". The report is printed as JSON.
+func runImport(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", string(transfer.JSONL), "file format: jsonl, csv or markdown")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	ids := fs.String("ids", string(transfer.PreserveIDs), "keep the IDs in the file (preserve) or assign new ones (remap)")
+	conflict := fs.String("conflict", string(transfer.ConflictSkip), "when a preserved ID exists: skip, overwrite or fail")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("import [flags] file")
+	}
+	f, err := transfer.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	opts, err := transfer.ParseOptions(*dryRun, *ids, *conflict)
+	if err != nil {
+		return err
+	}
+	opts.MaxSize = cfg.ImportMaxSize
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ctx, err := engin.InitTenant(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	report, err := engin.Service().Import(ctx, bufio.NewReader(file), f, opts)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
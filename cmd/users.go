@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"example/auth"
+	engin "example/cmd/app"
+	"example/config"
+	"example/models"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	usersUsage   = "users create -email address [-name name] [-role admin|editor|author] [-password-stdin]"
+	apiKeysUsage = "apikeys issue -user email -name name"
+)
+
+// runUsers implements the "users" subcommands.
+func runUsers(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return usageError(usersUsage)
+	}
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	email := fs.String("email", "", "email address, used to sign in")
+	name := fs.String("name", "", "display name")
+	role := fs.String("role", models.RoleAuthor, "one of "+strings.Join(models.Roles, ", "))
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input instead of generating one")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *email == "" {
+		return usageError(usersUsage)
+	}
+
+	var password string
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("unable to read the password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	ctx, err := engin.InitTenant(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	user, password, err := auth.CreateUser(ctx, engin.Repository(), *email, *name, *role, password)
+	if err != nil {
+		return err
+	}
+	if !*passwordStdin {
+		fmt.Fprintf(os.Stderr, "generated password (shown once): %s\n", password)
+	}
+	return printJSON(user)
+}
+
+// runAPIKeys implements the "apikeys" subcommands.
+func runAPIKeys(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "issue" {
+		return usageError(apiKeysUsage)
+	}
+	fs := flag.NewFlagSet("apikeys issue", flag.ContinueOnError)
+	email := fs.String("user", "", "email address of the user the key acts for")
+	name := fs.String("name", "", "what the key is for, such as the name of a deployment")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *email == "" || *name == "" {
+		return usageError(apiKeysUsage)
+	}
+
+	ctx, err := engin.InitTenant(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	token, key, err := auth.IssueAPIKey(ctx, engin.Repository(), *email, *name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "issued key %q (prefix %s) for %s; it won't be shown again\n", key.Name, key.Prefix, key.User.Email)
+	fmt.Println(token)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	engin "example/cmd/app"
+	"example/config"
+	"example/wxr"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// runImportWordPress implements "import-wordpress [-name name]
+// [-link-template tmpl] [-statuses list] export.xml". Progress is printed to
+// standard error and the report to standard output. The progress of the
+// import is saved in the database under its name, so running it again with
+// the same name resumes an interrupted import.
+func runImportWordPress(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("import-wordpress", flag.ContinueOnError)
+	name := fs.String("name", "", "name the progress is saved under, to resume an interrupted import (default the export's file name)")
+	linkTemplate := fs.String("link-template", wxr.DefaultLinkTemplate, "target of rewritten internal links; {id} is the new post ID")
+	statuses := fs.String("statuses", "publish", "comma-separated WordPress post statuses to import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("import-wordpress [flags] export.xml")
+	}
+	if *name == "" {
+		*name = filepath.Base(fs.Arg(0))
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Stop between posts on Ctrl-C so that the state stays consistent.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ctx, err = engin.InitTenant(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	report, err := engin.Service().ImportWordPress(ctx, file, *name, wxr.Options{
+		LinkTemplate: *linkTemplate,
+		Statuses:     strings.Split(*statuses, ","),
+		Progress:     printProgress,
+	})
+	if err != nil {
+		return fmt.Errorf("%w (run again with -name %q to resume)", err, *name)
+	}
+	return printJSON(report)
+}
+
+func printProgress(p wxr.Progress) {
+	switch {
+	case p.Err != nil:
+		fmt.Fprintf(os.Stderr, "[%d] %-8s wp:%d %q: %v\n", p.Item, p.Action, p.WordPressID, p.Title, p.Err)
+	case p.PostID != 0:
+		fmt.Fprintf(os.Stderr, "[%d] %-8s wp:%d %q -> post %d\n", p.Item, p.Action, p.WordPressID, p.Title, p.PostID)
+	default:
+		fmt.Fprintf(os.Stderr, "[%d] %-8s wp:%d %q\n", p.Item, p.Action, p.WordPressID, p.Title)
+	}
+}
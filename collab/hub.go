@@ -0,0 +1,300 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Message types.
+const (
+	// Sent by the server.
+	TypeInit     = "init"     // the text, revision and editors on joining
+	TypeAck      = "ack"      // the sender's operation was applied
+	TypePresence = "presence" // an editor joined or moved their cursor
+	TypeLeave    = "leave"    // an editor left
+	TypeSaved    = "saved"    // the text up to a revision was saved
+	TypeError    = "error"
+	// Sent by the server, and by editors.
+	TypeOp     = "op"
+	TypeCursor = "cursor"
+)
+
+// Message is what editors and the server exchange, one JSON object per
+// WebSocket message. Only the fields relevant to its type are set.
+type Message struct {
+	Type     string  `json:"type"`
+	Revision int     `json:"revision"`
+	Op       Op      `json:"op,omitempty"`
+	Cursor   *Cursor `json:"cursor,omitempty"`
+	// Client is the editor an op, presence or leave message is about, and
+	// You the editor receiving an init message.
+	Client string `json:"client,omitempty"`
+	You    string `json:"you,omitempty"`
+	Body   string `json:"body,omitempty"`
+	Peers  []Peer `json:"peers,omitempty"`
+	Peer   *Peer  `json:"peer,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Conn sends messages to one editor. Send must not block; a connection that
+// can't keep up should be closed.
+type Conn interface {
+	Send(Message)
+	Close()
+}
+
+// Posts loads and saves posts, as service.Service does.
+type Posts interface {
+	GetByID(ctx context.Context, id uint) (*models.BlogPost, error)
+	Update(ctx context.Context, id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error)
+}
+
+// DefaultSaveInterval is how often a post being edited is saved.
+const DefaultSaveInterval = 10 * time.Second
+
+// Hub holds a session per post being edited. Edits are saved periodically
+// through Posts, and once more when the last editor leaves.
+//
+// Sessions live in the memory of the process, so every editor of a post must
+// reach the same one: behind a load balancer, route the collab endpoint by
+// post ID, for instance by hashing the request path. Editors of one post on
+// different instances would overwrite each other's saves.
+type Hub struct {
+	posts        Posts
+	saveInterval time.Duration
+	ids          atomic.Uint64
+
+	mu    sync.Mutex
+	rooms map[uint]*room
+}
+
+// NewHub returns a Hub saving posts every saveInterval.
+func NewHub(posts Posts, saveInterval time.Duration) *Hub {
+	if saveInterval <= 0 {
+		saveInterval = DefaultSaveInterval
+	}
+	return &Hub{posts: posts, saveInterval: saveInterval, rooms: map[uint]*room{}}
+}
+
+// room is a session with its editors' connections.
+type room struct {
+	hub    *Hub
+	postID uint
+
+	mu      sync.Mutex
+	session *Session
+	conns   map[string]Conn
+	saved   int // revision last saved
+	// author is the context of the editor who last changed the text, which
+	// saves are made with, so that they are attributed to them.
+	author context.Context
+
+	// closing is set, under hub.mu, once the last editor has left; done is
+	// closed once the final save is over.
+	closing bool
+	done    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// Editor is an editor's membership of a session.
+type Editor struct {
+	id   string
+	ctx  context.Context
+	room *room
+	conn Conn
+}
+
+// Join adds an editor called name to the session of a post, starting one
+// from the saved post if nobody is editing it, and sends them an init
+// message. The changes the editor makes are saved with the values of ctx,
+// such as the user and the blog.
+func (h *Hub) Join(ctx context.Context, postID uint, name string, conn Conn) (*Editor, error) {
+	var post *models.BlogPost
+	for {
+		h.mu.Lock()
+		r := h.rooms[postID]
+		if r != nil && r.closing {
+			// Wait for the final save, so as to start from it.
+			h.mu.Unlock()
+			<-r.done
+			post = nil
+			continue
+		}
+		if r == nil && post == nil {
+			// Load the post without holding up other sessions, then look
+			// again in case someone else started this one meanwhile.
+			h.mu.Unlock()
+			var err error
+			if post, err = h.posts.GetByID(ctx, postID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if r == nil {
+			r = &room{hub: h, postID: postID, session: NewSession(post.Body), conns: map[string]Conn{},
+				done: make(chan struct{}), stop: make(chan struct{}), stopped: make(chan struct{})}
+			h.rooms[postID] = r
+			go r.saveEvery(h.saveInterval)
+		}
+
+		e := &Editor{id: "e" + strconv.FormatUint(h.ids.Add(1), 10), ctx: context.WithoutCancel(ctx), room: r, conn: conn}
+		r.mu.Lock()
+		r.session.Join(e.id, name)
+		r.conns[e.id] = conn
+		conn.Send(Message{Type: TypeInit, Revision: r.session.Revision(), Body: r.session.Text(),
+			You: e.id, Peers: r.session.Peers()})
+		r.broadcast(e.id, Message{Type: TypePresence, Peer: &Peer{ID: e.id, Name: name}})
+		r.mu.Unlock()
+		h.mu.Unlock()
+		return e, nil
+	}
+}
+
+// Handle processes a message from the editor. An operation that can't be
+// applied means the editor is out of step; they are sent an error and
+// disconnected, to rejoin from the current text.
+func (e *Editor) Handle(data []byte) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		e.conn.Send(Message{Type: TypeError, Code: "invalid_message", Error: err.Error()})
+		return
+	}
+
+	r := e.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch msg.Type {
+	case TypeOp:
+		op, err := r.session.Apply(msg.Revision, msg.Op)
+		if err != nil {
+			e.conn.Send(Message{Type: TypeError, Code: errorCode(err), Error: err.Error()})
+			e.conn.Close()
+			return
+		}
+		rev := r.session.Revision()
+		r.author = e.ctx
+		e.conn.Send(Message{Type: TypeAck, Revision: rev})
+		r.broadcast(e.id, Message{Type: TypeOp, Revision: rev, Client: e.id, Op: op})
+	case TypeCursor:
+		if msg.Cursor == nil {
+			e.conn.Send(Message{Type: TypeError, Code: "invalid_message", Error: "cursor missing"})
+			return
+		}
+		peer, err := r.session.Move(e.id, msg.Revision, *msg.Cursor)
+		if err != nil {
+			e.conn.Send(Message{Type: TypeError, Code: errorCode(err), Error: err.Error()})
+			return
+		}
+		r.broadcast(e.id, Message{Type: TypePresence, Revision: r.session.Revision(), Peer: &peer})
+	default:
+		e.conn.Send(Message{Type: TypeError, Code: "invalid_message", Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+// Leave removes the editor from the session. When the last editor leaves,
+// the text is saved and the session ends.
+func (e *Editor) Leave() {
+	r, h := e.room, e.room.hub
+	h.mu.Lock()
+	r.mu.Lock()
+	if _, ok := r.conns[e.id]; !ok {
+		r.mu.Unlock()
+		h.mu.Unlock()
+		return
+	}
+	delete(r.conns, e.id)
+	r.session.Leave(e.id)
+	r.broadcast(e.id, Message{Type: TypeLeave, Client: e.id})
+	last := len(r.conns) == 0
+	r.mu.Unlock()
+	if last {
+		r.closing = true
+	}
+	h.mu.Unlock()
+	if !last {
+		return
+	}
+
+	close(r.stop)
+	<-r.stopped
+	r.save()
+	h.mu.Lock()
+	delete(h.rooms, r.postID)
+	h.mu.Unlock()
+	close(r.done)
+}
+
+// broadcast sends msg to every editor but the one with the given ID. r.mu
+// must be held.
+func (r *room) broadcast(except string, msg Message) {
+	for id, conn := range r.conns {
+		if id != except {
+			conn.Send(msg)
+		}
+	}
+}
+
+func (r *room) saveEvery(interval time.Duration) {
+	defer close(r.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.save()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// save writes the text to the post if it changed since the last save, as
+// the editor who last changed it. Changes by several editors between two
+// saves are saved together, as the last of them.
+func (r *room) save() {
+	r.mu.Lock()
+	rev, body, ctx := r.session.Revision(), r.session.Text(), r.author
+	r.mu.Unlock()
+	if rev == r.saved {
+		return
+	}
+	if strings.TrimSpace(body) == "" {
+		// Bodies may not be blank; keep the last saved one.
+		slog.WarnContext(ctx, "not saving a blank post body", "post_id", r.postID, "revision", rev)
+		return
+	}
+
+	if _, err := r.hub.posts.Update(ctx, r.postID, &models.UpdateBlogRequest{Body: &body}); err != nil {
+		slog.ErrorContext(ctx, "unable to save edited post", "post_id", r.postID, "revision", rev, "error", err)
+		r.mu.Lock()
+		r.broadcast("", Message{Type: TypeError, Code: "save_failed", Error: "the post could not be saved"})
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Lock()
+	r.saved = rev
+	r.broadcast("", Message{Type: TypeSaved, Revision: rev})
+	r.mu.Unlock()
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrRevision):
+		return "unknown_revision"
+	case errors.Is(err, ErrTooLong):
+		return "too_long"
+	default:
+		return "invalid_op"
+	}
+}
@@ -0,0 +1,267 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn records the messages it is sent.
+type fakeConn struct {
+	mu     sync.Mutex
+	got    []Message
+	closed bool
+}
+
+func (c *fakeConn) Send(m Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.got = append(c.got, m)
+}
+
+func (c *fakeConn) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+// take returns the messages received since the last call.
+func (c *fakeConn) take() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	got := c.got
+	c.got = nil
+	return got
+}
+
+// fakePosts keeps post bodies in memory.
+type fakePosts struct {
+	mu     sync.Mutex
+	bodies map[uint]string
+	saves  int
+	fail   bool
+	// savedBy lists the userKey of the context of each save.
+	savedBy []string
+	// loading, if set, is sent to when post 2 starts loading, which is held
+	// up until it is closed.
+	loading chan struct{}
+}
+
+// userKey carries the name of whoever a context is for.
+type userKey struct{}
+
+func (p *fakePosts) GetByID(_ context.Context, id uint) (*models.BlogPost, error) {
+	if id == 2 && p.loading != nil {
+		p.loading <- struct{}{}
+		<-p.loading
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	body, ok := p.bodies[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &models.BlogPost{ID: id, Body: body}, nil
+}
+
+func (p *fakePosts) Update(ctx context.Context, id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		return nil, errors.New("unavailable")
+	}
+	p.bodies[id] = *req.Body
+	p.saves++
+	user, _ := ctx.Value(userKey{}).(string)
+	p.savedBy = append(p.savedBy, user)
+	return &models.BlogPost{ID: id, Body: *req.Body}, nil
+}
+
+func (p *fakePosts) body(id uint) (string, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.bodies[id], p.saves
+}
+
+func TestHub(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello"}}
+	h := NewHub(posts, time.Hour)
+	ctx := context.Background()
+
+	a, b := &fakeConn{}, &fakeConn{}
+	alice, err := h.Join(ctx, 1, "Alice", a)
+	require.NoError(t, err)
+	assert.Equal(t, []Message{{Type: TypeInit, Body: "hello", You: alice.id, Peers: []Peer{{ID: alice.id, Name: "Alice"}}}}, a.take())
+
+	bob, err := h.Join(ctx, 1, "Bob", b)
+	require.NoError(t, err)
+	init := b.take()
+	require.Len(t, init, 1)
+	assert.Equal(t, "hello", init[0].Body)
+	assert.Len(t, init[0].Peers, 2)
+	assert.Equal(t, []Message{{Type: TypePresence, Peer: &Peer{ID: bob.id, Name: "Bob"}}}, a.take())
+
+	// Both edit revision 0 at once.
+	alice.Handle([]byte(`{"type":"op","revision":0,"op":[5," world"]}`))
+	bob.Handle([]byte(`{"type":"op","revision":0,"op":[-1,"H",4]}`))
+	assert.Equal(t, []Message{
+		{Type: TypeAck, Revision: 1},
+		{Type: TypeOp, Revision: 2, Client: bob.id, Op: Op{{Insert: "H"}, {Delete: 1}, {Retain: 10}}},
+	}, a.take())
+	assert.Equal(t, []Message{
+		{Type: TypeOp, Revision: 1, Client: alice.id, Op: Op{{Retain: 5}, {Insert: " world"}}},
+		{Type: TypeAck, Revision: 2},
+	}, b.take())
+
+	bob.Handle([]byte(`{"type":"cursor","revision":1,"cursor":{"anchor":11,"head":11}}`))
+	assert.Equal(t, []Message{{Type: TypePresence, Revision: 2, Peer: &Peer{ID: bob.id, Name: "Bob", Cursor: &Cursor{Anchor: 11, Head: 11}}}}, a.take())
+
+	alice.Handle([]byte(`{"type":"op","revision":7,"op":[11]}`))
+	assert.Equal(t, "unknown_revision", a.take()[0].Code)
+	assert.True(t, a.closed, "out of step editors are disconnected")
+
+	alice.Leave()
+	assert.Equal(t, []Message{{Type: TypeLeave, Client: alice.id}}, b.take())
+	_, saves := posts.body(1)
+	assert.Zero(t, saves, "saved when the last editor leaves")
+
+	bob.Leave()
+	body, saves := posts.body(1)
+	assert.Equal(t, "Hello world", body)
+	assert.Equal(t, 1, saves)
+	assert.Empty(t, b.take(), "nobody is left to tell")
+
+	h.mu.Lock()
+	assert.Empty(t, h.rooms)
+	h.mu.Unlock()
+}
+
+func TestHub_savesPeriodically(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello"}}
+	h := NewHub(posts, time.Millisecond)
+	ctx := context.Background()
+
+	conn := &fakeConn{}
+	e, err := h.Join(ctx, 1, "Alice", conn)
+	require.NoError(t, err)
+	e.Handle([]byte(`{"type":"op","revision":0,"op":[5,"!"]}`))
+	require.Eventually(t, func() bool {
+		body, _ := posts.body(1)
+		return body == "hello!"
+	}, time.Second, time.Millisecond)
+
+	// Nothing changed since, so leaving doesn't save again.
+	time.Sleep(5 * time.Millisecond)
+	_, saves := posts.body(1)
+	e.Leave()
+	_, after := posts.body(1)
+	assert.Equal(t, 1, saves)
+	assert.Equal(t, saves, after)
+}
+
+func TestHub_saveFailures(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello"}, fail: true}
+	h := NewHub(posts, time.Hour)
+	ctx := context.Background()
+
+	conn := &fakeConn{}
+	e, err := h.Join(ctx, 1, "Alice", conn)
+	require.NoError(t, err)
+	e.Handle([]byte(`{"type":"op","revision":0,"op":[-5]}`))
+	e.Handle([]byte(`{"type":"op","revision":1,"op":["bye"]}`))
+	conn.take()
+
+	e.room.save()
+	assert.Equal(t, "save_failed", conn.take()[0].Code)
+
+	e.Handle([]byte(`{"type":"op","revision":2,"op":[-3]}`))
+	conn.take()
+	posts.fail = false
+	e.room.save()
+	assert.Empty(t, conn.take(), "blank bodies are not saved")
+	body, _ := posts.body(1)
+	assert.Equal(t, "hello", body)
+}
+
+func TestHub_rejoinAfterSave(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello"}}
+	h := NewHub(posts, time.Hour)
+	ctx := context.Background()
+
+	e, err := h.Join(ctx, 1, "Alice", &fakeConn{})
+	require.NoError(t, err)
+	e.Handle([]byte(`{"type":"op","revision":0,"op":[5,"!"]}`))
+	e.Leave()
+	e.Leave() // leaving twice is harmless
+
+	conn := &fakeConn{}
+	_, err = h.Join(ctx, 1, "Alice", conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello!", conn.take()[0].Body, "a new session starts from the saved text")
+
+	_, err = h.Join(ctx, 2, "Alice", &fakeConn{})
+	assert.Error(t, err)
+}
+
+func TestHub_savesAsTheLastEditor(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello"}}
+	h := NewHub(posts, time.Hour)
+	ctx := context.Background()
+
+	alice, err := h.Join(context.WithValue(ctx, userKey{}, "alice"), 1, "Alice", &fakeConn{})
+	require.NoError(t, err)
+	bob, err := h.Join(context.WithValue(ctx, userKey{}, "bob"), 1, "Bob", &fakeConn{})
+	require.NoError(t, err)
+
+	bob.Handle([]byte(`{"type":"op","revision":0,"op":[5,"!"]}`))
+	alice.room.save()
+	alice.Handle([]byte(`{"type":"op","revision":1,"op":["Oh, ",6]}`))
+	bob.Leave()
+	alice.Leave()
+	assert.Equal(t, []string{"bob", "alice"}, posts.savedBy)
+}
+
+func TestHub_Join_loadsPostsOutsideTheLock(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello", 2: "slow"}, loading: make(chan struct{})}
+	h := NewHub(posts, time.Hour)
+	ctx := context.Background()
+
+	joined := make(chan error)
+	go func() {
+		_, err := h.Join(ctx, 2, "Alice", &fakeConn{})
+		joined <- err
+	}()
+	// Post 2 is still loading; post 1 can be joined meanwhile.
+	<-posts.loading
+	_, err := h.Join(ctx, 1, "Bob", &fakeConn{})
+	require.NoError(t, err)
+	close(posts.loading)
+	require.NoError(t, <-joined)
+}
+
+func TestEditor_Handle_invalid(t *testing.T) {
+	posts := &fakePosts{bodies: map[uint]string{1: "hello"}}
+	h := NewHub(posts, time.Hour)
+	conn := &fakeConn{}
+	e, err := h.Join(context.Background(), 1, "Alice", conn)
+	require.NoError(t, err)
+	conn.take()
+
+	for msg, code := range map[string]string{
+		`nope`:                                "invalid_message",
+		`{"type":"shout"}`:                    "invalid_message",
+		`{"type":"cursor","revision":0}`:      "invalid_message",
+		`{"type":"op","revision":0,"op":[4]}`: "invalid_op",
+	} {
+		e.Handle([]byte(msg))
+		got := conn.take()
+		require.Len(t, got, 1, msg)
+		assert.Equal(t, code, got[0].Code, msg)
+	}
+}
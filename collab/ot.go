@@ -0,0 +1,270 @@
+// Package collab lets several editors change the body of a post at once.
+// Edits are operational transforms of the text, serialised by the server:
+// an edit made against an older revision is transformed past the edits
+// applied since, so that every editor converges on the same text.
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrInvalidOp is returned for operations that don't fit the text they are
+// applied to or transformed against.
+var ErrInvalidOp = errors.New("operation does not match the text")
+
+// Op is an edit of a text: a sequence of components covering it from start
+// to end, each retaining, inserting or deleting characters. Positions and
+// lengths count Unicode code points.
+//
+// In JSON an Op is an array where a positive number retains that many
+// characters, a string inserts it and a negative number deletes that many
+// characters, as in ot.js: [3, "abc", -2] keeps the first three characters,
+// inserts "abc" and deletes the next two.
+type Op []Component
+
+// Component is one step of an Op. Exactly one field is set.
+type Component struct {
+	Retain int
+	Insert string
+	Delete int
+}
+
+func (c Component) isRetain() bool { return c.Retain > 0 }
+func (c Component) isInsert() bool { return c.Insert != "" }
+func (c Component) isDelete() bool { return c.Delete > 0 }
+
+// BaseLen is the length of the text the operation applies to.
+func (o Op) BaseLen() int {
+	n := 0
+	for _, c := range o {
+		n += c.Retain + c.Delete
+	}
+	return n
+}
+
+// TargetLen is the length of the text the operation produces.
+func (o Op) TargetLen() int {
+	n := 0
+	for _, c := range o {
+		n += c.Retain + utf8.RuneCountInString(c.Insert)
+	}
+	return n
+}
+
+// IsNoop reports whether the operation leaves the text unchanged.
+func (o Op) IsNoop() bool {
+	for _, c := range o {
+		if !c.isRetain() {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns text with the operation applied.
+func (o Op) Apply(text []rune) ([]rune, error) {
+	if o.BaseLen() != len(text) {
+		return nil, fmt.Errorf("%w: it applies to %d characters, not %d", ErrInvalidOp, o.BaseLen(), len(text))
+	}
+	out := make([]rune, 0, o.TargetLen())
+	i := 0
+	for _, c := range o {
+		switch {
+		case c.isRetain():
+			out = append(out, text[i:i+c.Retain]...)
+			i += c.Retain
+		case c.isInsert():
+			out = append(out, []rune(c.Insert)...)
+		case c.isDelete():
+			i += c.Delete
+		}
+	}
+	return out, nil
+}
+
+// builder appends components, merging them with the last one where possible
+// so that equal edits have equal representations.
+type builder struct{ op Op }
+
+func (b *builder) retain(n int) {
+	if n <= 0 {
+		return
+	}
+	if last := len(b.op) - 1; last >= 0 && b.op[last].isRetain() {
+		b.op[last].Retain += n
+		return
+	}
+	b.op = append(b.op, Component{Retain: n})
+}
+
+// insert keeps inserts ahead of an adjacent delete, which has the same
+// effect either way.
+func (b *builder) insert(s string) {
+	if s == "" {
+		return
+	}
+	last := len(b.op) - 1
+	switch {
+	case last >= 0 && b.op[last].isInsert():
+		b.op[last].Insert += s
+	case last >= 0 && b.op[last].isDelete():
+		if last > 0 && b.op[last-1].isInsert() {
+			b.op[last-1].Insert += s
+			return
+		}
+		b.op = append(b.op, b.op[last])
+		b.op[last] = Component{Insert: s}
+	default:
+		b.op = append(b.op, Component{Insert: s})
+	}
+}
+
+func (b *builder) delete(n int) {
+	if n <= 0 {
+		return
+	}
+	if last := len(b.op) - 1; last >= 0 && b.op[last].isDelete() {
+		b.op[last].Delete += n
+		return
+	}
+	b.op = append(b.op, Component{Delete: n})
+}
+
+// Transform takes two operations a and b made concurrently on the same text
+// and returns a' and b' such that applying a then b' gives the same text as
+// applying b then a'. Where both insert at the same place, a's insert comes
+// first.
+func Transform(a, b Op) (Op, Op, error) {
+	if a.BaseLen() != b.BaseLen() {
+		return nil, nil, fmt.Errorf("%w: concurrent operations apply to %d and %d characters", ErrInvalidOp, a.BaseLen(), b.BaseLen())
+	}
+	var ap, bp builder
+	i, j := 0, 0
+	var ca, cb Component
+	next := func(o Op, k *int) Component {
+		if *k < len(o) {
+			*k++
+			return o[*k-1]
+		}
+		return Component{}
+	}
+	ca, cb = next(a, &i), next(b, &j)
+	for ca != (Component{}) || cb != (Component{}) {
+		if ca.isInsert() {
+			ap.insert(ca.Insert)
+			bp.retain(utf8.RuneCountInString(ca.Insert))
+			ca = next(a, &i)
+			continue
+		}
+		if cb.isInsert() {
+			ap.retain(utf8.RuneCountInString(cb.Insert))
+			bp.insert(cb.Insert)
+			cb = next(b, &j)
+			continue
+		}
+		// Equal base lengths mean neither runs out before the other, unless
+		// one holds empty components.
+		n := min(ca.Retain+ca.Delete, cb.Retain+cb.Delete)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("%w: empty component", ErrInvalidOp)
+		}
+		switch {
+		case ca.isRetain() && cb.isRetain():
+			ap.retain(n)
+			bp.retain(n)
+		case ca.isDelete() && cb.isRetain():
+			ap.delete(n)
+		case ca.isRetain() && cb.isDelete():
+			bp.delete(n)
+		}
+		// Where both delete, the characters are gone for either.
+		ca, cb = consume(ca, n), consume(cb, n)
+		if ca == (Component{}) {
+			ca = next(a, &i)
+		}
+		if cb == (Component{}) {
+			cb = next(b, &j)
+		}
+	}
+	return ap.op, bp.op, nil
+}
+
+// consume shortens a retain or delete by n.
+func consume(c Component, n int) Component {
+	if c.isRetain() {
+		c.Retain -= n
+	} else {
+		c.Delete -= n
+	}
+	return c
+}
+
+// TransformIndex moves a position in a text to where it is once o has been
+// applied. A position where o inserts moves past the insert.
+func TransformIndex(o Op, pos int) int {
+	moved := pos
+	for _, c := range o {
+		switch {
+		case c.isRetain():
+			pos -= c.Retain
+		case c.isInsert():
+			moved += utf8.RuneCountInString(c.Insert)
+		case c.isDelete():
+			moved -= min(pos, c.Delete)
+			pos -= c.Delete
+		}
+		if pos < 0 {
+			break
+		}
+	}
+	return moved
+}
+
+// MarshalJSON encodes o in the ot.js form.
+func (o Op) MarshalJSON() ([]byte, error) {
+	out := make([]any, len(o))
+	for i, c := range o {
+		switch {
+		case c.isRetain():
+			out[i] = c.Retain
+		case c.isInsert():
+			out[i] = c.Insert
+		default:
+			out[i] = -c.Delete
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the ot.js form, normalising the operation.
+func (o *Op) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var b builder
+	for _, r := range raw {
+		var s string
+		if err := json.Unmarshal(r, &s); err == nil {
+			if s == "" {
+				return errors.New("empty insert")
+			}
+			b.insert(s)
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(r, &n); err != nil || n == 0 {
+			return fmt.Errorf("invalid operation component %s", r)
+		}
+		if n > 0 {
+			b.retain(n)
+		} else {
+			b.delete(-n)
+		}
+	}
+	*o = b.op
+	return nil
+}
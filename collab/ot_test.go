@@ -0,0 +1,133 @@
+package collab
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apply(t *testing.T, o Op, text string) string {
+	t.Helper()
+	out, err := o.Apply([]rune(text))
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestOp_Apply(t *testing.T) {
+	o := Op{{Retain: 6}, {Insert: "brave"}, {Delete: 5}, {Retain: 1}}
+	assert.Equal(t, "Hello brave!", apply(t, o, "Hello world!"))
+	assert.Equal(t, 12, o.BaseLen())
+	assert.Equal(t, 12, o.TargetLen())
+
+	_, err := o.Apply([]rune("too short"))
+	assert.ErrorIs(t, err, ErrInvalidOp)
+
+	// Lengths count code points, not bytes.
+	assert.Equal(t, "héllo wörld", apply(t, Op{{Retain: 6}, {Delete: 1}, {Insert: "w"}, {Retain: 4}}, "héllo Wörld"))
+}
+
+func TestOp_JSON(t *testing.T) {
+	var o Op
+	require.NoError(t, json.Unmarshal([]byte(`[3, -1, "ab", 2, 2, -1]`), &o), "adjacent components merge")
+	assert.Equal(t, Op{{Retain: 3}, {Insert: "ab"}, {Delete: 1}, {Retain: 4}, {Delete: 1}}, o)
+
+	data, err := json.Marshal(o)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[3, "ab", -1, 4, -1]`, string(data))
+
+	for _, bad := range []string{`[0]`, `[""]`, `[1.5]`, `[true]`, `{}`} {
+		assert.Error(t, json.Unmarshal([]byte(bad), &o), bad)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		a, b Op
+		want string
+	}{
+		{
+			name: "inserts at different places",
+			text: "abc",
+			a:    Op{{Insert: "X"}, {Retain: 3}},
+			b:    Op{{Retain: 3}, {Insert: "Y"}},
+			want: "XabcY",
+		},
+		{
+			name: "inserts at the same place, a first",
+			text: "abc",
+			a:    Op{{Retain: 1}, {Insert: "X"}, {Retain: 2}},
+			b:    Op{{Retain: 1}, {Insert: "Y"}, {Retain: 2}},
+			want: "aXYbc",
+		},
+		{
+			name: "overlapping deletes",
+			text: "abcdef",
+			a:    Op{{Retain: 1}, {Delete: 3}, {Retain: 2}},
+			b:    Op{{Retain: 2}, {Delete: 3}, {Retain: 1}},
+			want: "af",
+		},
+		{
+			name: "insert inside a deleted range",
+			text: "abcdef",
+			a:    Op{{Retain: 1}, {Delete: 4}, {Retain: 1}},
+			b:    Op{{Retain: 3}, {Insert: "X"}, {Retain: 3}},
+			want: "aXf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap, bp, err := Transform(tt.a, tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, apply(t, bp, apply(t, tt.a, tt.text)))
+			assert.Equal(t, tt.want, apply(t, ap, apply(t, tt.b, tt.text)))
+		})
+	}
+
+	_, _, err := Transform(Op{{Retain: 2}}, Op{{Retain: 3}})
+	assert.ErrorIs(t, err, ErrInvalidOp)
+}
+
+// randomOp returns a random operation on a text of length n.
+func randomOp(r *rand.Rand, n int) Op {
+	var b builder
+	for n > 0 {
+		k := 1 + r.IntN(min(n, 4))
+		switch r.IntN(3) {
+		case 0:
+			b.retain(k)
+			n -= k
+		case 1:
+			b.delete(k)
+			n -= k
+		default:
+			b.insert(string([]rune("xyzé🙂")[r.IntN(5):][:1]))
+		}
+	}
+	if r.IntN(2) == 0 {
+		b.insert("!")
+	}
+	return b.op
+}
+
+func TestTransform_converges(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range 2000 {
+		text := string([]rune("the quick brown fox")[:r.IntN(20)])
+		a, b := randomOp(r, len([]rune(text))), randomOp(r, len([]rune(text)))
+		ap, bp, err := Transform(a, b)
+		require.NoError(t, err, "case %d: %v %v", i, a, b)
+		require.Equal(t, apply(t, bp, apply(t, a, text)), apply(t, ap, apply(t, b, text)), "case %d: %q %v %v", i, text, a, b)
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	o := Op{{Retain: 2}, {Insert: "XY"}, {Retain: 2}, {Delete: 3}, {Retain: 1}}
+	for pos, want := range map[int]int{0: 0, 1: 1, 2: 4, 3: 5, 4: 6, 5: 6, 7: 6, 8: 7} {
+		assert.Equal(t, want, TransformIndex(o, pos), "position %d", pos)
+	}
+}
@@ -0,0 +1,151 @@
+package collab
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxLen is the longest body an edit may produce, matching the limit on
+// bodies saved through the API.
+const MaxLen = 100000
+
+// DefaultHistory is the number of past operations a Session keeps for
+// transforming edits made against older revisions.
+const DefaultHistory = 1000
+
+var (
+	// ErrRevision is returned for edits made against a revision the session
+	// doesn't have: one from the future, or one too old to transform.
+	ErrRevision = errors.New("unknown revision")
+	// ErrTooLong is returned for edits making the text longer than MaxLen.
+	ErrTooLong = errors.New("text too long")
+)
+
+// Cursor is an editor's selection. Anchor and Head are equal for a plain
+// caret.
+type Cursor struct {
+	Anchor int `json:"anchor"`
+	Head   int `json:"head"`
+}
+
+func (c Cursor) transform(o Op) Cursor {
+	return Cursor{Anchor: TransformIndex(o, c.Anchor), Head: TransformIndex(o, c.Head)}
+}
+
+// Peer is an editor present in a session.
+type Peer struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Cursor *Cursor `json:"cursor,omitempty"`
+}
+
+func (p *Peer) copy() Peer {
+	cp := *p
+	if p.Cursor != nil {
+		c := *p.Cursor
+		cp.Cursor = &c
+	}
+	return cp
+}
+
+// Session is the shared state of a text being edited: its current
+// revision, the operations that led to it and who is editing. It holds no
+// locks; callers serialise access.
+type Session struct {
+	text     []rune
+	revision int
+	// history holds the operations from revision base to revision.
+	history    []Op
+	base       int
+	maxHistory int
+	peers      map[string]*Peer
+}
+
+// NewSession starts a session on text at revision 0.
+func NewSession(text string) *Session {
+	return &Session{text: []rune(text), maxHistory: DefaultHistory, peers: map[string]*Peer{}}
+}
+
+// Text returns the current text.
+func (s *Session) Text() string { return string(s.text) }
+
+// Revision returns the number of operations applied so far.
+func (s *Session) Revision() int { return s.revision }
+
+// Apply applies an operation made against the given revision, transforming
+// it past the operations applied since. It returns the operation as applied
+// to the current text, which is what other editors need to receive.
+func (s *Session) Apply(revision int, o Op) (Op, error) {
+	if revision > s.revision || revision < s.base {
+		return nil, fmt.Errorf("%w %d, the session is at %d", ErrRevision, revision, s.revision)
+	}
+	for _, concurrent := range s.history[revision-s.base:] {
+		var err error
+		if o, _, err = Transform(o, concurrent); err != nil {
+			return nil, err
+		}
+	}
+	if o.TargetLen() > MaxLen {
+		return nil, fmt.Errorf("%w: at most %d characters", ErrTooLong, MaxLen)
+	}
+	text, err := o.Apply(s.text)
+	if err != nil {
+		return nil, err
+	}
+
+	s.text = text
+	s.revision++
+	s.history = append(s.history, o)
+	if len(s.history) > s.maxHistory {
+		drop := len(s.history) - s.maxHistory
+		s.history = append(s.history[:0:0], s.history[drop:]...)
+		s.base += drop
+	}
+	for _, p := range s.peers {
+		if p.Cursor != nil {
+			c := p.Cursor.transform(o)
+			p.Cursor = &c
+		}
+	}
+	return o, nil
+}
+
+// Join adds an editor to the session.
+func (s *Session) Join(id, name string) {
+	s.peers[id] = &Peer{ID: id, Name: name}
+}
+
+// Leave removes an editor from the session.
+func (s *Session) Leave(id string) {
+	delete(s.peers, id)
+}
+
+// Move sets an editor's cursor, given against the given revision, and
+// returns the editor as seen at the current revision.
+func (s *Session) Move(id string, revision int, c Cursor) (Peer, error) {
+	p, ok := s.peers[id]
+	if !ok {
+		return Peer{}, fmt.Errorf("no editor %q", id)
+	}
+	if revision > s.revision || revision < s.base {
+		return Peer{}, fmt.Errorf("%w %d, the session is at %d", ErrRevision, revision, s.revision)
+	}
+	for _, o := range s.history[revision-s.base:] {
+		c = c.transform(o)
+	}
+	c.Anchor = max(0, min(c.Anchor, len(s.text)))
+	c.Head = max(0, min(c.Head, len(s.text)))
+	p.Cursor = &c
+	return p.copy(), nil
+}
+
+// Peers lists the editors in the session, ordered by ID.
+func (s *Session) Peers() []Peer {
+	peers := make([]Peer, 0, len(s.peers))
+	for _, p := range s.peers {
+		peers = append(peers, p.copy())
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	return peers
+}
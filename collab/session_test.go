@@ -0,0 +1,235 @@
+package collab
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// client simulates an editor the way ot.js does: at most one operation
+// awaits acknowledgement, further edits are buffered, and incoming
+// operations are transformed past both. An outstanding operation may be
+// transformed into a no-op, so waiting rather than outstanding tells whether
+// one was sent.
+type client struct {
+	text        []rune
+	revision    int
+	waiting     bool
+	outstanding Op
+	buffered    bool
+	buffer      Op
+}
+
+func (c *client) edit(t *testing.T, o Op) {
+	var err error
+	c.text, err = o.Apply(c.text)
+	require.NoError(t, err)
+	switch {
+	case !c.waiting:
+		c.outstanding, c.waiting = o, true
+	case !c.buffered:
+		c.buffer, c.buffered = o, true
+	default:
+		c.buffer = compose(t, c.buffer, o)
+	}
+}
+
+// send returns the operation to send, if any is ready.
+func (c *client) send() (Op, int, bool) {
+	return c.outstanding, c.revision, c.waiting
+}
+
+func (c *client) ack() {
+	c.revision++
+	c.outstanding, c.waiting = c.buffer, c.buffered
+	c.buffer, c.buffered = nil, false
+}
+
+func (c *client) receive(t *testing.T, o Op) {
+	var err error
+	if c.waiting {
+		c.outstanding, o, err = Transform(c.outstanding, o)
+		require.NoError(t, err)
+	}
+	if c.buffered {
+		c.buffer, o, err = Transform(c.buffer, o)
+		require.NoError(t, err)
+	}
+	c.text, err = o.Apply(c.text)
+	require.NoError(t, err)
+	c.revision++
+}
+
+// compose returns an operation with the effect of a then b. Clients need
+// it to buffer edits; the server doesn't.
+func compose(t *testing.T, a, b Op) Op {
+	var out builder
+	i, j := 0, 0
+	var ca, cb Component
+	next := func(o Op, k *int) Component {
+		if *k < len(o) {
+			*k++
+			return o[*k-1]
+		}
+		return Component{}
+	}
+	ca, cb = next(a, &i), next(b, &j)
+	for ca != (Component{}) || cb != (Component{}) {
+		switch {
+		case ca.isDelete():
+			out.delete(ca.Delete)
+			ca = next(a, &i)
+		case cb.isInsert():
+			out.insert(cb.Insert)
+			cb = next(b, &j)
+		case ca.isInsert() && cb.isDelete():
+			ins := []rune(ca.Insert)
+			n := min(len(ins), cb.Delete)
+			ca, cb = Component{Insert: string(ins[n:])}, Component{Delete: cb.Delete - n}
+		case ca.isInsert() && cb.isRetain():
+			ins := []rune(ca.Insert)
+			n := min(len(ins), cb.Retain)
+			out.insert(string(ins[:n]))
+			ca, cb = Component{Insert: string(ins[n:])}, Component{Retain: cb.Retain - n}
+		case ca.isRetain() && cb.isRetain():
+			n := min(ca.Retain, cb.Retain)
+			out.retain(n)
+			ca, cb = consume(ca, n), consume(cb, n)
+		case ca.isRetain() && cb.isDelete():
+			n := min(ca.Retain, cb.Delete)
+			out.delete(n)
+			ca, cb = consume(ca, n), consume(cb, n)
+		default:
+			t.Fatalf("compose: lengths don't match: %v (%d) %v (%d) at %v %v", a, a.TargetLen(), b, b.BaseLen(), ca, cb)
+		}
+		if ca == (Component{}) {
+			ca = next(a, &i)
+		}
+		if cb == (Component{}) {
+			cb = next(b, &j)
+		}
+	}
+	return out.op
+}
+
+func TestSession_converges(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	const start = "Once upon a time"
+	s := NewSession(start)
+	clients := []*client{{text: []rune(start)}, {text: []rune(start)}, {text: []rune(start)}}
+	// inbox[i] holds the server's operations not yet received by client i.
+	inbox := make([][]Op, len(clients))
+
+	for range 3000 {
+		i := r.IntN(len(clients))
+		c := clients[i]
+		switch r.IntN(3) {
+		case 0:
+			c.edit(t, randomOp(r, len(c.text)))
+		case 1:
+			if len(inbox[i]) > 0 {
+				c.receive(t, inbox[i][0])
+				inbox[i] = inbox[i][1:]
+			}
+		case 2:
+			// Only send once caught up with the revision the outstanding
+			// operation was made against.
+			if o, rev, ok := c.send(); ok && len(inbox[i]) == 0 {
+				applied, err := s.Apply(rev, o)
+				require.NoError(t, err)
+				c.ack()
+				for j := range clients {
+					if j != i {
+						inbox[j] = append(inbox[j], applied)
+					}
+				}
+			}
+		}
+	}
+
+	// Flush everything.
+	for pending := true; pending; {
+		pending = false
+		for i, c := range clients {
+			for _, o := range inbox[i] {
+				c.receive(t, o)
+			}
+			inbox[i] = nil
+			if o, rev, ok := c.send(); ok {
+				pending = true
+				applied, err := s.Apply(rev, o)
+				require.NoError(t, err)
+				c.ack()
+				for j := range clients {
+					if j != i {
+						inbox[j] = append(inbox[j], applied)
+					}
+				}
+			}
+		}
+	}
+	for i, c := range clients {
+		assert.Equal(t, s.Text(), string(c.text), "client %d", i)
+	}
+}
+
+func TestSession_Apply(t *testing.T) {
+	s := NewSession("abc")
+	_, err := s.Apply(0, Op{{Insert: "X"}, {Retain: 3}})
+	require.NoError(t, err)
+
+	// Made against revision 0, without seeing the X.
+	applied, err := s.Apply(0, Op{{Retain: 3}, {Insert: "Y"}})
+	require.NoError(t, err)
+	assert.Equal(t, Op{{Retain: 4}, {Insert: "Y"}}, applied)
+	assert.Equal(t, "XabcY", s.Text())
+	assert.Equal(t, 2, s.Revision())
+
+	_, err = s.Apply(3, Op{{Retain: 5}})
+	assert.ErrorIs(t, err, ErrRevision)
+	_, err = s.Apply(2, Op{{Retain: 4}})
+	assert.ErrorIs(t, err, ErrInvalidOp)
+	_, err = s.Apply(2, Op{{Retain: 5}, {Insert: string(make([]rune, MaxLen))}})
+	assert.ErrorIs(t, err, ErrTooLong)
+	assert.Equal(t, "XabcY", s.Text(), "failed operations change nothing")
+}
+
+func TestSession_history(t *testing.T) {
+	s := NewSession("")
+	s.maxHistory = 2
+	for i := range 3 {
+		_, err := s.Apply(i, Op{{Retain: i}, {Insert: "x"}})
+		require.NoError(t, err)
+	}
+	_, err := s.Apply(0, Op{{Insert: "y"}})
+	assert.ErrorIs(t, err, ErrRevision, "too old to transform")
+	_, err = s.Apply(1, Op{{Retain: 1}, {Insert: "y"}})
+	assert.NoError(t, err)
+}
+
+func TestSession_presence(t *testing.T) {
+	s := NewSession("hello")
+	s.Join("e1", "Alice")
+	s.Join("e2", "Bob")
+
+	alice, err := s.Move("e1", 0, Cursor{Anchor: 1, Head: 4})
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{Anchor: 1, Head: 4}, *alice.Cursor)
+
+	_, err = s.Apply(0, Op{{Insert: ">> "}, {Retain: 5}})
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{Anchor: 4, Head: 7}, *s.Peers()[0].Cursor, "moved by the edit")
+
+	// Bob's cursor was placed before he saw the edit.
+	bob, err := s.Move("e2", 0, Cursor{Anchor: 5, Head: 5})
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{Anchor: 8, Head: 8}, *bob.Cursor)
+
+	s.Leave("e1")
+	assert.Equal(t, []string{"e2"}, []string{s.Peers()[0].ID})
+	assert.Len(t, s.Peers(), 1)
+	_, err = s.Move("e1", 1, Cursor{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings read from the environment.
+type Config struct {
+	// Environment is "development" or "production". Development enables
+	// endpoints that must never be exposed in production.
+	Environment string
+
+	Port        string
+	MetricsPort string
+
+	// TraceExporter selects where spans are sent: "otlp", "stdout" or "none".
+	TraceExporter string
+	ServiceName   string
+
+	// RequestTimeout is the default per-request deadline; RouteTimeouts
+	// overrides it for individual "METHOD /route" keys.
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	// LogLevel is one of debug, info, warn or error; LogFormat is text or json.
+	LogLevel           string
+	LogFormat          string
+	SlowQueryThreshold time.Duration
+
+	// AutoMigrate migrates the database schema on startup.
+	AutoMigrate bool
+
+	// BulkMaxItems caps the number of items in one bulk request.
+	BulkMaxItems int
+	// ImportMaxSize caps the size in bytes of a Markdown archive to import
+	// and of each file in it.
+	ImportMaxSize int64
+
+	// CacheBackend selects the response cache: "memory", "redis" or "none".
+	// CacheTTLs overrides the TTL of individual entities ("post", "posts").
+	CacheBackend string
+	CacheSize    int
+	CacheTTLs    map[string]time.Duration
+	RedisURL     string
+
+	// RateLimitBackend selects where token buckets live: "memory", "redis" or
+	// "none". Limits are written as requests/period, such as "300/1m".
+	RateLimitBackend string
+	RateLimitRead    string
+	RateLimitWrite   string
+	// RateLimitBy keys authenticated clients by "ip", "user" or "api_key".
+	RateLimitBy string
+	// RateLimitIP caps the requests of each IP address before they are
+	// authenticated, so that invalid API keys are limited too.
+	RateLimitIP string
+	// ProxyHeader is the header holding the client IP when running behind a
+	// proxy that sets it, such as X-Real-IP. Empty uses the peer address.
+	ProxyHeader string
+
+	// IdempotencyStore selects where responses to requests with an
+	// Idempotency-Key are kept: "db", "memory" or "none".
+	IdempotencyStore string
+	IdempotencyTTL   time.Duration
+	IdempotencyLock  time.Duration
+
+	// EventSinks lists where outbox events are published: any of "stdout",
+	// "webhook" and "nats". With none, events stay in the outbox.
+	EventSinks        []string
+	EventWebhookURL   string
+	NATSURL           string
+	NATSSubjectPrefix string
+	// OutboxRelay runs the relay and the webhook dispatcher inside the
+	// server; disable it to run "relay" as a separate process instead.
+	OutboxRelay        bool
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+
+	// WebhookTimeout bounds each delivery attempt. A delivery is marked
+	// failed after WebhookMaxAttempts, and a subscription is disabled after
+	// WebhookDisableAfter consecutive failed attempts.
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookDisableAfter int
+	WebhookRetention    time.Duration
+
+	// StreamLog selects where /api/events clients resume from: "memory",
+	// holding the last StreamLogSize events of this process, or "db", the
+	// outbox.
+	StreamLog       string
+	StreamLogSize   int
+	StreamHeartbeat time.Duration
+
+	// LockTTL is how long a post lock lasts unless renewed.
+	LockTTL time.Duration
+
+	// CollabSaveInterval is how often posts being edited collaboratively
+	// are saved.
+	CollabSaveInterval time.Duration
+
+	// DefaultTenant is the slug of the blog serving requests that name no
+	// other by host or path prefix; "none" rejects them instead. Tenant is
+	// the blog CLI commands work on. TenantRefresh is how often the list of
+	// blogs is reloaded.
+	DefaultTenant string
+	Tenant        string
+	TenantRefresh time.Duration
+
+	// Site serves the blog as HTML pages besides the API, with the templates
+	// of SiteThemeDir overriding the embedded ones. SiteRawHTML renders the
+	// HTML in post bodies, which is only safe if every author is trusted.
+	// Rendered pages are cached for SiteCacheTTL in the response cache.
+	Site         bool
+	SiteThemeDir string
+	SitePageSize int
+	SiteRawHTML  bool
+	SiteCacheTTL time.Duration
+
+	// AdminUI serves the admin interface under /admin, where admins and
+	// editors sign in with their email and password. Sessions are signed
+	// with AdminSecret and last AdminSessionTTL. Only in development may the
+	// secret be left out, for a random one, so that restarting signs
+	// everyone out. Each client IP, and each email, may try to sign in
+	// AdminLoginLimit times, such as "10/15m", counted in the rate limit
+	// store.
+	AdminUI         bool
+	AdminSecret     string
+	AdminSessionTTL time.Duration
+	AdminLoginLimit string
+}
+
+// Load reads the configuration from environment variables, falling back to
+// defaults for anything that is not set.
+func Load() Config {
+	return Config{
+		Environment: getEnv("APP_ENV", "production"),
+
+		Port:        getEnv("PORT", "10000"),
+		MetricsPort: getEnv("METRICS_PORT", "9090"),
+
+		TraceExporter: getEnv("TRACE_EXPORTER", "none"),
+		ServiceName:   getEnv("SERVICE_NAME", "blog-api"),
+
+		RequestTimeout: getDuration("REQUEST_TIMEOUT", 10*time.Second),
+		RouteTimeouts:  getDurationMap("ROUTE_TIMEOUTS"),
+
+		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		LogFormat:          getEnv("LOG_FORMAT", "text"),
+		SlowQueryThreshold: getDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+
+		AutoMigrate: getBool("DB_AUTO_MIGRATE", true),
+
+		BulkMaxItems:  getInt("BULK_MAX_ITEMS", 1000),
+		ImportMaxSize: int64(getInt("IMPORT_MAX_SIZE", 64<<20)),
+
+		CacheBackend: getEnv("CACHE_BACKEND", "memory"),
+		CacheSize:    getInt("CACHE_SIZE", 10000),
+		CacheTTLs:    getDurationMap("CACHE_TTLS"),
+		RedisURL:     getEnv("REDIS_URL", "redis://localhost:6379/0"),
+
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitRead:    getEnv("RATE_LIMIT_READ", "300/1m"),
+		RateLimitWrite:   getEnv("RATE_LIMIT_WRITE", "60/1m"),
+		RateLimitBy:      getEnv("RATE_LIMIT_BY", "api_key"),
+		RateLimitIP:      getEnv("RATE_LIMIT_IP", "1200/1m"),
+		ProxyHeader:      getEnv("PROXY_HEADER", ""),
+
+		IdempotencyStore: getEnv("IDEMPOTENCY_STORE", "db"),
+		IdempotencyTTL:   getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyLock:  getDuration("IDEMPOTENCY_LOCK", time.Minute),
+
+		EventSinks:         getList("EVENT_SINKS"),
+		EventWebhookURL:    getEnv("EVENT_WEBHOOK_URL", ""),
+		NATSURL:            getEnv("NATS_URL", "nats://localhost:4222"),
+		NATSSubjectPrefix:  getEnv("NATS_SUBJECT_PREFIX", "blog"),
+		OutboxRelay:        getBool("OUTBOX_RELAY", true),
+		OutboxPollInterval: getDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:    getDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+
+		WebhookTimeout:      getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:  getInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookDisableAfter: getInt("WEBHOOK_DISABLE_AFTER", 20),
+		WebhookRetention:    getDuration("WEBHOOK_RETENTION", 30*24*time.Hour),
+
+		StreamLog:       getEnv("SSE_LOG", "memory"),
+		StreamLogSize:   getInt("SSE_LOG_SIZE", 1000),
+		StreamHeartbeat: getDuration("SSE_HEARTBEAT", 15*time.Second),
+
+		LockTTL: getDuration("POST_LOCK_TTL", 5*time.Minute),
+
+		CollabSaveInterval: getDuration("COLLAB_SAVE_INTERVAL", 10*time.Second),
+
+		DefaultTenant: getEnv("DEFAULT_TENANT", "default"),
+		Tenant:        getEnv("TENANT", getEnv("DEFAULT_TENANT", "default")),
+		TenantRefresh: getDuration("TENANT_REFRESH", 30*time.Second),
+
+		Site:         getBool("SITE", false),
+		SiteThemeDir: getEnv("SITE_THEME_DIR", ""),
+		SitePageSize: getInt("SITE_PAGE_SIZE", 10),
+		SiteRawHTML:  getBool("SITE_RAW_HTML", false),
+		SiteCacheTTL: getDuration("SITE_CACHE_TTL", time.Minute),
+
+		AdminUI:         getBool("ADMIN_UI", false),
+		AdminSecret:     getEnv("ADMIN_SECRET", ""),
+		AdminSessionTTL: getDuration("ADMIN_SESSION_TTL", 12*time.Hour),
+		AdminLoginLimit: getEnv("ADMIN_LOGIN_LIMIT", "10/15m"),
+	}
+}
+
+// Development reports whether development-only features are enabled.
+func (c Config) Development() bool {
+	return c.Environment == "development"
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// getList parses a comma-separated list, dropping empty entries.
+func getList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// getDurationMap parses a comma-separated list of key=duration pairs, such as
+// "GET /api/blog-post=2s,PATCH /api/blog-post/:id=5s". Malformed entries are
+// skipped.
+func getDurationMap(key string) map[string]time.Duration {
+	m := map[string]time.Duration{}
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		m[strings.TrimSpace(k)] = d
+	}
+	return m
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"example/audit"
+	"example/models"
+	"example/repo"
+	"example/service"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Audit log page sizes.
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// AuditController serves the audit log to admins. Its routes live under
+// /admin rather than /api, so they aren't part of the API documentation.
+type AuditController struct {
+	repo repo.Repository
+}
+
+func NewAuditController(repo repo.Repository) AuditController {
+	return AuditController{repo: repo}
+}
+
+// GetAuditEntries pages through the audit log, newest first, narrowed down
+// by the action, actor_id, entity, entity_id, request_id, from and to query
+// parameters. Pass the smallest ID of a page as before to get the next one.
+func (ac *AuditController) GetAuditEntries(c *fiber.Ctx) error {
+	f, err := parseAuditFilter(c)
+	if err != nil {
+		return err
+	}
+	limit := c.QueryInt("limit", defaultAuditLimit)
+	if limit <= 0 || limit > maxAuditLimit {
+		return service.Validation("invalid_query", "limit must be between 1 and "+strconv.Itoa(maxAuditLimit), nil)
+	}
+	before := c.QueryInt("before")
+	if before < 0 {
+		return service.Validation("invalid_query", "before must not be negative", nil)
+	}
+
+	entries, err := ac.repo.ListAuditEntries(c.UserContext(), f, uint(before), limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(entries)
+}
+
+// ExportAuditEntries streams the audit entries matching the same filters as
+// GetAuditEntries, oldest first, as JSON Lines or CSV.
+func (ac *AuditController) ExportAuditEntries(c *fiber.Ctx) error {
+	f, err := parseAuditFilter(c)
+	if err != nil {
+		return err
+	}
+	format := c.Query("format", audit.FormatJSONL)
+	var contentType string
+	switch format {
+	case audit.FormatJSONL:
+		contentType = "application/x-ndjson"
+	case audit.FormatCSV:
+		contentType = "text/csv"
+	default:
+		return service.Validation("invalid_format", audit.ErrUnknownFormat.Error(), audit.ErrUnknownFormat)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="audit.%s"`, format))
+
+	// As with post exports, the body is written after the handler returns.
+	ctx := context.WithoutCancel(c.UserContext())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := audit.Export(ctx, ac.repo, w, f, format); err != nil {
+			slog.ErrorContext(ctx, "audit export aborted", "error", err)
+		}
+		if err := w.Flush(); err != nil {
+			slog.ErrorContext(ctx, "audit export aborted", "error", err)
+		}
+	})
+	return nil
+}
+
+// VerifyAuditLog checks the hash chain of the whole audit log and reports
+// the first entry that was tampered with, if any. A broken chain is still a
+// 200: the check itself succeeded.
+func (ac *AuditController) VerifyAuditLog(c *fiber.Ctx) error {
+	report, err := audit.Verify(c.UserContext(), ac.repo)
+	if err != nil {
+		return err
+	}
+	if !report.Valid {
+		slog.WarnContext(c.UserContext(), "audit log tampered with", "broken_at", *report.BrokenAt, "problem", report.Problem)
+	}
+	return c.JSON(report)
+}
+
+func parseAuditFilter(c *fiber.Ctx) (models.AuditFilter, error) {
+	f := models.AuditFilter{
+		Action:    c.Query("action"),
+		Entity:    c.Query("entity"),
+		RequestID: c.Query("request_id"),
+	}
+	ids := []struct {
+		name string
+		dst  *uint
+	}{{"actor_id", &f.ActorID}, {"entity_id", &f.EntityID}}
+	for _, id := range ids {
+		if v := c.Query(id.name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 0)
+			if err != nil {
+				return f, service.Validation("invalid_query", id.name+" must be a positive integer", err)
+			}
+			*id.dst = uint(n)
+		}
+	}
+	times := []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}}
+	for _, at := range times {
+		if v := c.Query(at.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return f, service.Validation("invalid_query", at.name+" must be an RFC 3339 time", err)
+			}
+			*at.dst = t
+		}
+	}
+	return f, nil
+}
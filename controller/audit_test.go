@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example/audit"
+	"example/mocks"
+	"example/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditApp(r *mocks.Repository) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	ac := NewAuditController(r)
+	app.Get("/admin/audit", ac.GetAuditEntries)
+	app.Get("/admin/audit/export", ac.ExportAuditEntries)
+	app.Get("/admin/audit/verify", ac.VerifyAuditLog)
+	return app
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	r := new(mocks.Repository)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.On("ListAuditEntries", mock.Anything, models.AuditFilter{
+		Action: models.AuditPostUpdated, ActorID: 2, Entity: "post", EntityID: 3, RequestID: "req-1", From: from,
+	}, uint(40), 10).Return([]models.AuditEntry{{ID: 39, Action: models.AuditPostUpdated}}, nil)
+
+	resp, err := newAuditApp(r).Test(httptest.NewRequest(http.MethodGet,
+		"/admin/audit?action=post.updated&actor_id=2&entity=post&entity_id=3&request_id=req-1&from=2026-01-01T00:00:00Z&before=40&limit=10", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []models.AuditEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 1)
+	assert.Equal(t, uint(39), got[0].ID)
+
+	for _, query := range []string{"limit=1000", "actor_id=alice", "from=yesterday", "before=-1"} {
+		resp, err := newAuditApp(r).Test(httptest.NewRequest(http.MethodGet, "/admin/audit?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestExportAuditEntries(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("AuditEntriesInBatches", mock.Anything, models.AuditFilter{Entity: "post"}, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ models.AuditFilter, _ int, fn func([]models.AuditEntry) error) error {
+			return fn([]models.AuditEntry{{ID: 1, Action: models.AuditPostCreated}, {ID: 2, Action: models.AuditPostDeleted}})
+		})
+
+	resp, err := newAuditApp(r).Test(httptest.NewRequest(http.MethodGet, "/admin/audit/export?format=csv&entity=post", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get(fiber.HeaderContentType))
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentDisposition), `filename="audit.csv"`)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "post.deleted")
+
+	resp, err = newAuditApp(r).Test(httptest.NewRequest(http.MethodGet, "/admin/audit/export?format=xml", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	var good, bad models.AuditEntry
+	good.ID, bad.ID = 1, 2
+	good.Seal("", time.Now())
+	bad.Seal(good.Hash, time.Now())
+	bad.Action = "forged"
+
+	r := new(mocks.Repository)
+	r.On("AuditEntriesInBatches", mock.Anything, models.AuditFilter{}, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ models.AuditFilter, _ int, fn func([]models.AuditEntry) error) error {
+			return fn([]models.AuditEntry{good, bad})
+		})
+
+	resp, err := newAuditApp(r).Test(httptest.NewRequest(http.MethodGet, "/admin/audit/verify", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var report audit.Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.False(t, report.Valid)
+	assert.Equal(t, 1, report.Checked)
+	require.NotNil(t, report.BrokenAt)
+	assert.Equal(t, uint(2), *report.BrokenAt)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"example/service"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// normalizer is implemented by request bodies that clean up their own input
+// before validation.
+type normalizer interface {
+	Normalize()
+}
+
+// bind strictly decodes the JSON body into req, rejecting unknown fields,
+// normalises it and validates it. Validation failures are reported per field
+// in the client's preferred language.
+func bind(c *fiber.Ctx, req normalizer) error {
+	dec := json.NewDecoder(bytes.NewReader(c.Body()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		if field, ok := unknownField(err); ok {
+			return service.InvalidFields([]models.FieldError{{
+				Field:   field,
+				Code:    "unknown_field",
+				Message: field + " is not a recognised field",
+			}}, err)
+		}
+		return errInvalidBody(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errInvalidBody(errors.New("unexpected data after JSON body"))
+	}
+
+	req.Normalize()
+	return validate(c, req)
+}
+
+// validate runs the struct validation rules on v.
+func validate(c *fiber.Ctx, v interface{}) error {
+	if err := models.Validate.Struct(v); err != nil {
+		return service.InvalidFields(models.FieldErrors(err, locale(c)), err)
+	}
+	return nil
+}
+
+// locale picks the validation message language from Accept-Language.
+func locale(c *fiber.Ctx) string {
+	if l := c.AcceptsLanguages(models.Locales...); l != "" {
+		return l
+	}
+	return models.DefaultLocale
+}
+
+// unknownField extracts the field name from encoding/json's error for
+// decoders with DisallowUnknownFields set.
+func unknownField(err error) (string, bool) {
+	const prefix = `json: unknown field "`
+	msg := err.Error()
+	if !strings.HasPrefix(msg, prefix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(msg, prefix), `"`), true
+}
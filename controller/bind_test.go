@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example/mocks"
+	"example/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBind(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockService := new(mocks.BlogService)
+	bc := &BlogController{service: mockService}
+	app.Post("/blog-post", bc.CreatePost)
+
+	tests := []struct {
+		description    string
+		body           string
+		acceptLanguage string
+		expected       []models.FieldError
+	}{
+		{
+			description: "missing and whitespace-only fields",
+			body:        `{"title":"   ","body":"body"}`,
+			expected: []models.FieldError{
+				{Field: "title", Code: "required", Message: "title is a required field"},
+				{Field: "description", Code: "required", Message: "description is a required field"},
+			},
+		},
+		{
+			description: "title too long",
+			body:        `{"title":"` + strings.Repeat("a", 201) + `","description":"d","body":"b"}`,
+			expected: []models.FieldError{
+				{Field: "title", Code: "too_long", Message: "title must be a maximum of 200 characters in length"},
+			},
+		},
+		{
+			description: "unknown field",
+			body:        `{"title":"t","description":"d","body":"b","author":"me"}`,
+			expected: []models.FieldError{
+				{Field: "author", Code: "unknown_field", Message: "author is not a recognised field"},
+			},
+		},
+		{
+			description:    "translated message",
+			body:           `{"title":"t","body":"b"}`,
+			acceptLanguage: "fr-CH, fr;q=0.9, en;q=0.8",
+			expected: []models.FieldError{
+				{Field: "description", Code: "required", Message: "description est un champ obligatoire"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/blog-post", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			if test.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", test.acceptLanguage)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			var p models.Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+			assert.Equal(t, "invalid_fields", p.Code)
+			assert.Equal(t, test.expected, p.Errors)
+		})
+	}
+	mockService.AssertNotCalled(t, "Create")
+
+	t.Run("normalises before calling the service", func(t *testing.T) {
+		mockService.On("Create", mock.Anything, models.CreateBlogRequest{
+			Title:       "Hello world",
+			Description: "d",
+			Body:        "line one\n  line two",
+		}).Return(uint(1), nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/blog-post",
+			strings.NewReader(`{"title":"  Hello \t world ","description":" d ","body":"\nline one\n  line two\n"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"example/models"
+	"example/service"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultBulkMaxItems is the largest batch accepted when no limit is set with
+// WithBulkMaxItems.
+const DefaultBulkMaxItems = 1000
+
+// Create blog posts in bulk
+// CreatePosts creates many blog posts at once
+// @Summary Create blog posts in bulk
+// @Description Create up to the configured maximum number of posts in one request. In atomic mode (the default) either every post is created or none is; in best_effort mode every valid post is created. The response lists the outcome of each item in request order.
+// @Tags Blog
+// @Accept json
+// @Produce json
+// @Param posts body models.BulkCreateRequest true "Posts to create"
+// @Success 201 {object} models.BulkResponse
+// @Success 207 {object} models.BulkResponse "Some items failed (best_effort)"
+// @Failure 400 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.BulkResponse "Nothing was applied (atomic)"
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
+// @Router /blog-post/bulk [post]
+func (bc *BlogController) CreatePosts(c *fiber.Ctx) error {
+	var req models.BulkCreateRequest
+	if err := bc.bindBulk(c, &req, &req.Mode, func() int { return len(req.Items) }); err != nil {
+		return err
+	}
+
+	results, valid := validateItems(c, len(req.Items), func(i int) interface{} { return req.Items[i] })
+	if req.Mode == models.BulkAtomic && len(valid) < len(req.Items) {
+		return bulkResponse(c, req.Mode, results, fiber.StatusCreated)
+	}
+
+	items := make([]models.CreateBlogRequest, len(valid))
+	for j, i := range valid {
+		items[j] = req.Items[i]
+	}
+	out, err := bc.service.CreateBatch(c.UserContext(), items, req.Mode)
+	if err := applyOutcomes(results, valid, out, err, fiber.StatusCreated); err != nil {
+		return err
+	}
+	return bulkResponse(c, req.Mode, results, fiber.StatusCreated)
+}
+
+// Update blog posts in bulk
+// UpdatePosts applies partial updates to many blog posts at once
+// @Summary Update blog posts in bulk
+// @Description Apply partial updates to many posts in one request, in atomic (default) or best_effort mode. The response lists the outcome of each item in request order.
+// @Tags Blog
+// @Accept json
+// @Produce json
+// @Param posts body models.BulkUpdateRequest true "Updates to apply"
+// @Success 200 {object} models.BulkResponse
+// @Success 207 {object} models.BulkResponse "Some items failed (best_effort)"
+// @Failure 400 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.BulkResponse "Nothing was applied (atomic)"
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
+// @Router /blog-post/bulk [patch]
+func (bc *BlogController) UpdatePosts(c *fiber.Ctx) error {
+	var req models.BulkUpdateRequest
+	if err := bc.bindBulk(c, &req, &req.Mode, func() int { return len(req.Items) }); err != nil {
+		return err
+	}
+
+	results, valid := validateItems(c, len(req.Items), func(i int) interface{} { return req.Items[i] })
+	for i, item := range req.Items {
+		results[i].ID = item.ID
+	}
+	if req.Mode == models.BulkAtomic && len(valid) < len(req.Items) {
+		return bulkResponse(c, req.Mode, results, fiber.StatusOK)
+	}
+
+	items := make([]models.BulkUpdateItem, len(valid))
+	for j, i := range valid {
+		items[j] = req.Items[i]
+	}
+	out, err := bc.service.UpdateBatch(c.UserContext(), items, req.Mode)
+	if err := applyOutcomes(results, valid, out, err, fiber.StatusOK); err != nil {
+		return err
+	}
+	return bulkResponse(c, req.Mode, results, fiber.StatusOK)
+}
+
+// Delete blog posts in bulk
+// DeletePosts deletes many blog posts at once
+// @Summary Delete blog posts in bulk
+// @Description Delete many posts by ID in one request, in atomic (default) or best_effort mode. The response lists the outcome of each ID in request order.
+// @Tags Blog
+// @Accept json
+// @Produce json
+// @Param ids body models.BulkDeleteRequest true "IDs to delete"
+// @Success 200 {object} models.BulkResponse
+// @Success 207 {object} models.BulkResponse "Some items failed (best_effort)"
+// @Failure 400 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.BulkResponse "Nothing was applied (atomic)"
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
+// @Router /blog-post/bulk [delete]
+func (bc *BlogController) DeletePosts(c *fiber.Ctx) error {
+	var req models.BulkDeleteRequest
+	if err := bc.bindBulk(c, &req, &req.Mode, func() int { return len(req.IDs) }); err != nil {
+		return err
+	}
+
+	results := make([]models.BulkResult, len(req.IDs))
+	valid := make([]int, len(req.IDs))
+	for i, id := range req.IDs {
+		results[i] = models.BulkResult{Index: i, ID: id}
+		valid[i] = i
+	}
+	out, err := bc.service.DeleteBatch(c.UserContext(), req.IDs, req.Mode)
+	if err := applyOutcomes(results, valid, out, err, fiber.StatusNoContent); err != nil {
+		return err
+	}
+	return bulkResponse(c, req.Mode, results, fiber.StatusOK)
+}
+
+// bindBulk binds a bulk request, defaults its mode to atomic and enforces the
+// maximum batch size.
+func (bc *BlogController) bindBulk(c *fiber.Ctx, req normalizer, mode *models.BulkMode, size func() int) error {
+	if err := bind(c, req); err != nil {
+		return err
+	}
+	if *mode == "" {
+		*mode = models.BulkAtomic
+	}
+	if n := size(); n > bc.bulkMaxItems {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch has %d items, the maximum is %d", n, bc.bulkMaxItems))
+	}
+	return nil
+}
+
+// validateItems validates each item on its own. It returns a result per item,
+// with the invalid ones already filled in, and the indexes of the valid ones.
+func validateItems(c *fiber.Ctx, n int, item func(i int) interface{}) ([]models.BulkResult, []int) {
+	results := make([]models.BulkResult, n)
+	valid := make([]int, 0, n)
+	for i := range results {
+		results[i].Index = i
+		if err := validate(c, item(i)); err != nil {
+			setError(&results[i], err)
+			continue
+		}
+		valid = append(valid, i)
+	}
+	if len(valid) < n {
+		// Should the batch be atomic, the valid items won't be applied.
+		for _, i := range valid {
+			setError(&results[i], service.RolledBack())
+		}
+	}
+	return results, valid
+}
+
+// applyOutcomes copies the service outcomes of the valid items into their
+// results. An error from the service aborts the whole batch unless it is
+// attributable to individual items.
+func applyOutcomes(results []models.BulkResult, valid []int, out []models.BulkOutcome, err error, okStatus int) error {
+	if err != nil && len(out) != len(valid) {
+		return err
+	}
+	for j, i := range valid {
+		results[i].Error, results[i].Status = nil, okStatus
+		if out[j].ID != 0 {
+			results[i].ID = out[j].ID
+		}
+		if out[j].Err != nil {
+			setError(&results[i], out[j].Err)
+		}
+	}
+	return nil
+}
+
+func setError(r *models.BulkResult, err error) {
+	p := toProblem(err)
+	r.Status = p.Status
+	r.Error = &p
+}
+
+// bulkResponse writes the per-item results. Partial success is reported with
+// 207 Multi-Status; an atomic batch that was not applied with 422.
+func bulkResponse(c *fiber.Ctx, mode models.BulkMode, results []models.BulkResult, okStatus int) error {
+	resp := models.BulkResponse{Mode: mode, Results: results}
+	for _, r := range results {
+		if r.Error == nil {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	status := okStatus
+	switch {
+	case resp.Failed > 0 && mode == models.BulkAtomic:
+		status = fiber.StatusUnprocessableEntity
+		resp.Succeeded = 0
+	case resp.Failed > 0:
+		status = fiber.StatusMultiStatus
+	}
+	return c.Status(status).JSON(resp)
+}
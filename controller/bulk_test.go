@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example/mocks"
+	"example/models"
+	"example/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePosts(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockService := new(mocks.BlogService)
+	bc := NewController(mockService, WithBulkMaxItems(3))
+	app.Post("/blog-post/bulk", bc.CreatePosts)
+
+	valid := `{"title":"t","description":"d","body":"b"}`
+	invalid := `{"title":"","description":"d","body":"b"}`
+
+	tests := []struct {
+		description    string
+		body           string
+		mockOutcomes   []models.BulkOutcome
+		mockErr        error
+		mockCalled     bool
+		expectedCode   int
+		expectedStatus []int
+	}{
+		{
+			description:    "success case - all created",
+			body:           `{"items":[` + valid + `,` + valid + `]}`,
+			mockOutcomes:   []models.BulkOutcome{{ID: 1}, {ID: 2}},
+			mockCalled:     true,
+			expectedCode:   http.StatusCreated,
+			expectedStatus: []int{201, 201},
+		},
+		{
+			description:    "failure case - atomic with an invalid item",
+			body:           `{"items":[` + valid + `,` + invalid + `]}`,
+			mockCalled:     false,
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedStatus: []int{409, 400},
+		},
+		{
+			description:    "partial case - best effort with an invalid item",
+			body:           `{"mode":"best_effort","items":[` + invalid + `,` + valid + `]}`,
+			mockOutcomes:   []models.BulkOutcome{{ID: 5}},
+			mockCalled:     true,
+			expectedCode:   http.StatusMultiStatus,
+			expectedStatus: []int{400, 201},
+		},
+		{
+			description:    "failure case - atomic insert fails",
+			body:           `{"items":[` + valid + `]}`,
+			mockOutcomes:   []models.BulkOutcome{{Err: errors.New("duplicate key")}},
+			mockErr:        errors.New("duplicate key"),
+			mockCalled:     true,
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedStatus: []int{500},
+		},
+		{
+			description:  "failure case - too many items",
+			body:         `{"items":[` + strings.Repeat(valid+",", 3) + valid + `]}`,
+			mockCalled:   false,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			description:  "failure case - unknown mode",
+			body:         `{"mode":"yolo","items":[` + valid + `]}`,
+			mockCalled:   false,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if test.mockCalled {
+				mockService.On("CreateBatch", mock.Anything, mock.Anything, mock.Anything).
+					Return(test.mockOutcomes, test.mockErr).
+					Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/blog-post/bulk", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
+
+			if test.expectedStatus != nil {
+				var body models.BulkResponse
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				var statuses []int
+				for _, r := range body.Results {
+					statuses = append(statuses, r.Status)
+				}
+				assert.Equal(t, test.expectedStatus, statuses)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeletePosts(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockService := new(mocks.BlogService)
+	bc := NewController(mockService)
+	app.Delete("/blog-post/bulk", bc.DeletePosts)
+
+	mockService.On("DeleteBatch", mock.Anything, []uint{1, 2}, models.BulkBestEffort).
+		Return([]models.BulkOutcome{{ID: 1}, {ID: 2, Err: service.NotFound("post_not_found", "post 2 not found", nil)}}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/blog-post/bulk", strings.NewReader(`{"mode":"best_effort","ids":[1,2]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+
+	var body models.BulkResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 1, body.Succeeded)
+	assert.Equal(t, 1, body.Failed)
+	require.Len(t, body.Results, 2)
+	assert.Equal(t, http.StatusNoContent, body.Results[0].Status)
+	assert.Equal(t, "post_not_found", body.Results[1].Error.Code)
+	mockService.AssertExpectations(t)
+}
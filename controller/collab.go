@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"example/collab"
+	"example/service"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// collabSendBuffer is the number of messages queued for an editor
+	// before they are deemed too slow and disconnected.
+	collabSendBuffer = 256
+	// collabPing is how often editors are pinged; one that doesn't answer
+	// within two pings is disconnected.
+	collabPing = 30 * time.Second
+	// collabWriteTimeout bounds writing one message to an editor.
+	collabWriteTimeout = 10 * time.Second
+	// collabReadLimit caps the size of a message from an editor, enough for
+	// an operation inserting a whole body.
+	collabReadLimit = 4*collab.MaxLen + 4096
+)
+
+// CollabController lets several editors edit a post's body at once over
+// WebSocket.
+type CollabController struct {
+	service service.Service
+	hub     *collab.Hub
+	name    func(c *fiber.Ctx) string
+}
+
+// NewCollabController returns a controller for editing sessions kept by
+// hub. name tells the other editors who joined, typically from the API key
+// the request was authenticated with.
+func NewCollabController(service service.Service, hub *collab.Hub, name func(c *fiber.Ctx) string) CollabController {
+	return CollabController{service: service, hub: hub, name: name}
+}
+
+// Edit a post collaboratively
+// EditPost joins the editing session of a post
+// @Summary Edit a post collaboratively
+// @Description WebSocket endpoint carrying operational-transform edits of a post's body and the editors' cursors; see the README for the protocol. Edits are saved every few seconds and when the last editor leaves. Only authors, editors and admins may join. Browsers, which can't set headers on WebSocket requests, may pass their API key as the access_token parameter.
+// @Tags Blog
+// @Security BearerAuth
+// @Param id path int true "Blog Post ID"
+// @Param access_token query string false "API key, for clients that can't set Authorization"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 426 {object} models.Problem
+// @Router /blog-post/{id}/collab [get]
+func (cc *CollabController) EditPost(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.NewError(fiber.StatusUpgradeRequired, "expected a WebSocket upgrade")
+	}
+	// Check the post exists while an HTTP error can still be returned.
+	if _, err := cc.service.GetByID(c.UserContext(), id); err != nil {
+		return err
+	}
+	name := cc.name(c)
+	// The connection outlives the request; keep its values, such as the blog.
+	ctx := context.WithoutCancel(c.UserContext())
+
+	return websocket.New(func(ws *websocket.Conn) {
+		conn := newCollabConn(ws)
+		defer conn.wait()
+		editor, err := cc.hub.Join(ctx, id, name, conn)
+		if err != nil {
+			slog.Error("unable to join editing session", "post_id", id, "error", err)
+			conn.Send(collab.Message{Type: collab.TypeError, Code: "join_failed", Error: "the post could not be opened"})
+			conn.Close()
+			return
+		}
+		defer editor.Leave()
+
+		ws.SetReadLimit(collabReadLimit)
+		_ = ws.SetReadDeadline(time.Now().Add(2 * collabPing))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(2 * collabPing))
+		})
+		for {
+			kind, data, err := ws.ReadMessage()
+			if err != nil {
+				conn.Close()
+				return
+			}
+			if kind == websocket.TextMessage {
+				editor.Handle(data)
+			}
+		}
+	})(c)
+}
+
+// collabConn queues messages for an editor, written by its own goroutine so
+// that a slow editor doesn't hold up the others.
+type collabConn struct {
+	ws      *websocket.Conn
+	send    chan collab.Message
+	closing chan struct{}
+	once    sync.Once
+	done    chan struct{}
+}
+
+func newCollabConn(ws *websocket.Conn) *collabConn {
+	c := &collabConn{ws: ws, send: make(chan collab.Message, collabSendBuffer),
+		closing: make(chan struct{}), done: make(chan struct{})}
+	go c.write()
+	return c
+}
+
+func (c *collabConn) Send(msg collab.Message) {
+	select {
+	case <-c.closing:
+		return
+	default:
+	}
+	select {
+	case c.send <- msg:
+	default:
+		slog.Warn("disconnecting an editor who can't keep up")
+		c.Close()
+	}
+}
+
+func (c *collabConn) Close() {
+	c.once.Do(func() { close(c.closing) })
+}
+
+// wait waits for the connection to be closed and the queued messages to be
+// written.
+func (c *collabConn) wait() {
+	c.Close()
+	<-c.done
+}
+
+func (c *collabConn) write() {
+	defer close(c.done)
+	defer c.ws.Close()
+	ping := time.NewTicker(collabPing)
+	defer ping.Stop()
+	for {
+		select {
+		case msg := <-c.send:
+			if !c.writeJSON(msg) {
+				c.Close()
+				return
+			}
+		case <-ping.C:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.closing:
+			// Flush what was queued, such as the error explaining why the
+			// connection is closed.
+			for {
+				select {
+				case msg := <-c.send:
+					if !c.writeJSON(msg) {
+						return
+					}
+				default:
+					_ = c.ws.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *collabConn) writeJSON(msg collab.Message) bool {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+	return c.ws.WriteJSON(msg) == nil
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example/collab"
+	"example/mocks"
+	"example/models"
+	"example/service"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// serveCollab serves editing sessions on a real listener and returns the
+// WebSocket URL of post 1's.
+func serveCollab(t *testing.T, se service.Service) (*fiber.App, string) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler, DisableStartupMessage: true})
+	cc := NewCollabController(se, collab.NewHub(se, time.Hour), func(c *fiber.Ctx) string { return c.Query("name") })
+	app.Get("/blog-post/:id/collab", cc.EditPost)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(100 * time.Millisecond) })
+	return app, "ws://" + ln.Addr().String() + "/blog-post/1/collab"
+}
+
+func dialCollab(t *testing.T, url string) *websocket.Conn {
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func readMessage(t *testing.T, ws *websocket.Conn) collab.Message {
+	var msg collab.Message
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, ws.ReadJSON(&msg))
+	return msg
+}
+
+func TestEditPost(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Body: "hello"}, nil)
+	saved := make(chan string, 1)
+	se.On("Update", mock.Anything, uint(1), mock.Anything).Run(func(args mock.Arguments) {
+		saved <- *args.Get(2).(*models.UpdateBlogRequest).Body
+	}).Return(&models.BlogPost{}, nil)
+	_, url := serveCollab(t, se)
+
+	alice := dialCollab(t, url+"?name=Alice")
+	init := readMessage(t, alice)
+	assert.Equal(t, collab.TypeInit, init.Type)
+	assert.Equal(t, "hello", init.Body)
+
+	bob := dialCollab(t, url+"?name=Bob")
+	assert.Len(t, readMessage(t, bob).Peers, 2)
+	assert.Equal(t, "Bob", readMessage(t, alice).Peer.Name)
+
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage, []byte(`{"type":"op","revision":0,"op":[5,"!"]}`)))
+	assert.Equal(t, collab.Message{Type: collab.TypeAck, Revision: 1}, readMessage(t, alice))
+	op := readMessage(t, bob)
+	assert.Equal(t, collab.Op{{Retain: 5}, {Insert: "!"}}, op.Op)
+	assert.Equal(t, init.You, op.Client)
+
+	require.NoError(t, alice.Close())
+	assert.Equal(t, collab.TypeLeave, readMessage(t, bob).Type)
+	require.NoError(t, bob.Close())
+	select {
+	case body := <-saved:
+		assert.Equal(t, "hello!", body)
+	case <-time.After(time.Second):
+		t.Fatal("not saved when the last editor left")
+	}
+}
+
+func TestEditPost_outOfStep(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Body: "hello"}, nil)
+	_, url := serveCollab(t, se)
+
+	ws := dialCollab(t, url)
+	readMessage(t, ws)
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"op","revision":3,"op":[5]}`)))
+	assert.Equal(t, "unknown_revision", readMessage(t, ws).Code)
+	_, _, err := ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), "disconnected: %v", err)
+}
+
+func TestEditPost_errors(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetByID", mock.Anything, uint(1)).Return(nil, service.NotFound("not_found", "Blog post not found", nil))
+	app, url := serveCollab(t, se)
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/1/collab", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/x/collab", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -4,20 +4,55 @@ import (
 	"example/models"
 	"example/service"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type BlogController struct {
-	service service.Service
+	service       service.Service
+	bulkMaxItems  int
+	importMaxSize int64
+	lockTTL       time.Duration
 }
 
-func NewController(service service.Service) BlogController {
-	return BlogController{
-		service: service,
+// Option configures a BlogController.
+type Option func(*BlogController)
+
+// WithBulkMaxItems caps the number of items accepted by the bulk endpoints.
+func WithBulkMaxItems(n int) Option {
+	return func(bc *BlogController) {
+		bc.bulkMaxItems = n
 	}
 }
 
+// WithImportMaxSize caps the size of Markdown archives accepted by the
+// import endpoint, and of each file in them.
+func WithImportMaxSize(n int64) Option {
+	return func(bc *BlogController) {
+		bc.importMaxSize = n
+	}
+}
+
+// WithLockTTL sets how long a post lock lasts unless renewed.
+func WithLockTTL(d time.Duration) Option {
+	return func(bc *BlogController) {
+		bc.lockTTL = d
+	}
+}
+
+func NewController(service service.Service, opts ...Option) BlogController {
+	bc := BlogController{
+		service:      service,
+		bulkMaxItems: DefaultBulkMaxItems,
+		lockTTL:      DefaultLockTTL,
+	}
+	for _, opt := range opts {
+		opt(&bc)
+	}
+	return bc
+}
+
 // Create a blog post
 // CreatePost creates a new blog post
 // @Summary Create a new blog post
@@ -27,24 +62,20 @@ func NewController(service service.Service) BlogController {
 // @Produce  json
 // @Param post body models.CreateBlogRequest true "Blog Post Data"
 // @Success 201 {object} models.BlogPost
-// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
 // @Router /blog-post [post]
 func (bc *BlogController) CreatePost(c *fiber.Ctx) error {
 	var req models.CreateBlogRequest
 
-	// Parse request body
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(models.ErrorResponse{Error: "Invalid request body"})
-	}
-
-	// Validate the struct
-	if err := models.Validate.Struct(req); err != nil {
-		return c.Status(400).JSON(models.ErrorResponse{Error: "All fields are required"})
+	// Parse, normalise and validate the request body
+	if err := bind(c, &req); err != nil {
+		return err
 	}
-	id, err := bc.service.Create(req)
+	id, err := bc.service.Create(c.UserContext(), req)
 	if err != nil {
-		return c.Status(500).JSON(models.ErrorResponse{Error: "unable to create blog"})
-
+		return err
 	}
 	return c.Status(201).JSON(fiber.Map{"id": id})
 }
@@ -52,17 +83,26 @@ func (bc *BlogController) CreatePost(c *fiber.Ctx) error {
 // Get all blog posts
 // GetPosts retrieves all blog posts
 // @Summary Get all blog posts
-// @Description Retrieve a list of all blog posts
+// @Description Retrieve a list of all blog posts, drafts only for signed-in users, each in the first language of the client's preferences it is translated into, or its own.
 // @Tags Blog
 // @Produce json
+// @Param lang query string false "Language to serve posts in, overriding Accept-Language"
+// @Param Accept-Language header string false "Preferred languages"
 // @Success 200 {array} models.BlogPost
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
 // @Router /blog-post [get]
 func (bc *BlogController) GetPosts(c *fiber.Ctx) error {
 
-	posts, err := bc.service.GetAll()
-
+	posts, err := bc.service.GetAll(c.UserContext())
 	if err != nil {
-		return c.Status(404).JSON(models.ErrorResponse{Error: "unable to find blog"})
+		return err
+	}
+	if !seesDrafts(c) {
+		posts = published(posts)
+	}
+	if err := bc.localize(c, posts); err != nil {
+		return err
 	}
 	return c.JSON(posts)
 
@@ -71,55 +111,66 @@ func (bc *BlogController) GetPosts(c *fiber.Ctx) error {
 // Get a single blog post
 // GetPost retrieves a single blog post by ID
 // @Summary Get a single blog post
-// @Description Get details of a blog post by ID
+// @Description Get details of a blog post by ID, in the first language of the client's preferences it is translated into, or its own. Content-Language tells which.
 // @Tags Blog
 // @Produce json
 // @Param id path int true "Blog Post ID"
+// @Param lang query string false "Language to serve the post in, overriding Accept-Language"
+// @Param Accept-Language header string false "Preferred languages"
 // @Success 200 {object} models.BlogPost
-// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
 // @Router /blog-post/{id} [get]
 func (bc *BlogController) GetPost(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	id, err := strconv.Atoi(idParam)
-	if err != nil || id <= 0 {
-		return c.Status(400).JSON(models.ErrorResponse{Error: "Invalid ID parameter"})
+	id, err := parseID(c)
+	if err != nil {
+		return err
 	}
 
-	post, err := bc.service.GetByID(uint(id))
+	post, err := bc.post(c, id)
 	if err != nil {
-		return c.Status(404).JSON(models.ErrorResponse{Error: "Post not found"})
+		return err
 	}
-	return c.JSON(post)
+	posts := []models.BlogPost{*post}
+	if err := bc.localize(c, posts); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentLanguage, posts[0].Locale)
+	return c.JSON(posts[0])
 }
 
 // Update a blog post
 // UpdatePost updates a blog post by ID
 // @Summary Update a blog post
-// @Description Update a blog post's title, description, or body by ID
+// @Description Update a blog post's title, description, or body by ID. Fails with 423 while another user holds a lock on the post.
 // @Tags Blog
 // @Accept json
 // @Produce json
 // @Param id path int true "Blog Post ID"
 // @Param post body models.UpdateBlogRequest  true "Updated Blog Post Data"
 // @Success 200 {object} models.BlogPost
-// @Failure 400 {object} models.ErrorResponse
-// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 423 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
 // @Router /blog-post/{id} [patch]
 func (bc *BlogController) UpdatePost(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	id, err := strconv.Atoi(idParam)
-	if err != nil || id <= 0 {
-		return c.Status(400).JSON(models.ErrorResponse{Error: "Invalid ID parameter"})
+	id, err := parseID(c)
+	if err != nil {
+		return err
 	}
 	var req models.UpdateBlogRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid request body"})
+	if err := bind(c, &req); err != nil {
+		return err
 	}
 
-	post, err := bc.service.Update(uint(id), &req)
+	post, err := bc.service.Update(c.UserContext(), id, &req)
 	if err != nil {
-		return c.Status(500).JSON(models.ErrorResponse{Error: "unabel to update post"})
+		return err
 	}
 	return c.JSON(post)
 
@@ -128,25 +179,36 @@ func (bc *BlogController) UpdatePost(c *fiber.Ctx) error {
 // Delete a blog post
 // DeletePost deletes a blog post by ID
 // @Summary Delete a blog post
-// @Description Delete a blog post by ID
+// @Description Move a blog post to the trash by ID. Trashed posts can be restored in the admin interface.
 // @Tags Blog
 // @Param id path int true "Blog Post ID"
 // @Success 204 "No Content"
-// @Failure 404 {object} models.ErrorResponse
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
 // @Router /blog-post/{id} [delete]
 func (bc *BlogController) DeletePost(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	id, err := strconv.Atoi(idParam)
-	if err != nil || id <= 0 {
-		return c.Status(400).JSON(models.ErrorResponse{Error: "Invalid ID parameter"})
-	}
-
-	_, err = bc.service.GetByID(uint(id))
+	id, err := parseID(c)
 	if err != nil {
-		return c.Status(404).JSON(models.ErrorResponse{Error: "Post not found"})
+		return err
 	}
-	if err := bc.service.Delete(uint(id)); err != nil {
-		return c.Status(500).JSON(models.ErrorResponse{Error: "unable to delete error"})
+
+	if err := bc.service.Delete(c.UserContext(), id); err != nil {
+		return err
 	}
 	return c.Status(204).Send(nil)
 }
+
+// parseID reads the positive integer :id route parameter.
+func parseID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id <= 0 {
+		return 0, service.Validation("invalid_id", "Invalid ID parameter", err)
+	}
+	return uint(id), nil
+}
+
+func errInvalidBody(err error) error {
+	return service.Validation("invalid_body", "Invalid request body", err)
+}
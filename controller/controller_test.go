@@ -10,6 +10,7 @@ import (
 
 	"example/mocks"
 	"example/models"
+	"example/service"
 
 	"github.com/c2fo/testify/require"
 	"github.com/gofiber/fiber/v2"
@@ -19,13 +20,14 @@ import (
 
 func TestGetPosts(t *testing.T) {
 	// Create a Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 
 	// Create a mock service
 	mockService := new(mocks.BlogService)
 
 	// Create a BlogController with the mock service
 	bc := &BlogController{service: mockService}
+	mockService.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 
 	// Register the handler
 	app.Get("/blog-posts", bc.GetPosts)
@@ -41,8 +43,8 @@ func TestGetPosts(t *testing.T) {
 		{
 			description: "success case - retrieved posts",
 			mockReturn: []models.BlogPost{
-				{ID: 1, Title: "First Blog", Description: "This is the first blog", Body: "Body content"},
-				{ID: 2, Title: "Second Blog", Description: "This is the second blog", Body: "Body content"},
+				{ID: 1, Title: "First Blog", Description: "This is the first blog", Body: "Body content", Status: models.StatusPublished},
+				{ID: 2, Title: "Second Blog", Description: "This is the second blog", Body: "Body content", Status: models.StatusPublished},
 			},
 			mockReturnErr: nil,
 			expectedCode:  http.StatusOK,
@@ -52,7 +54,7 @@ func TestGetPosts(t *testing.T) {
 			description:   "failure case - unable to fetch posts",
 			mockReturn:    nil,
 			mockReturnErr: errors.New("unable to find blog"),
-			expectedCode:  http.StatusNotFound,
+			expectedCode:  http.StatusInternalServerError,
 			mockCalled:    true,
 		},
 	}
@@ -62,7 +64,7 @@ func TestGetPosts(t *testing.T) {
 		t.Run(test.description, func(t *testing.T) {
 			if test.mockCalled {
 				// Mock the service method
-				mockService.On("GetAll").Return(test.mockReturn, test.mockReturnErr).Once()
+				mockService.On("GetAll", mock.Anything).Return(test.mockReturn, test.mockReturnErr).Once()
 			}
 
 			// Create test request
@@ -86,13 +88,14 @@ func TestGetPosts(t *testing.T) {
 
 func TestGetPost(t *testing.T) {
 	// Create a Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 
 	// Create a mock service
 	mockService := new(mocks.BlogService)
 
 	// Create a BlogController with the mock service
 	bc := &BlogController{service: mockService}
+	mockService.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 
 	// Register the handler
 	app.Get("/blog-post/:id", bc.GetPost)
@@ -114,6 +117,7 @@ func TestGetPost(t *testing.T) {
 				Title:       "Test Blog",
 				Description: "This is a test blog",
 				Body:        "Blog content",
+				Status:      models.StatusPublished,
 			},
 			mockReturnErr: nil,
 			expectedCode:  http.StatusOK,
@@ -123,7 +127,7 @@ func TestGetPost(t *testing.T) {
 			description:   "failure case - post not found",
 			paramID:       "2",
 			mockReturn:    nil,
-			mockReturnErr: errors.New("Post not found"),
+			mockReturnErr: service.NotFound("post_not_found", "Post not found", nil),
 			expectedCode:  http.StatusNotFound,
 			mockCalled:    true,
 		},
@@ -150,7 +154,7 @@ func TestGetPost(t *testing.T) {
 		t.Run(test.description, func(t *testing.T) {
 			if test.mockCalled {
 				// Mock only if the service is expected to be called
-				mockService.On("GetByID", mock.AnythingOfType("uint")).
+				mockService.On("GetByID", mock.Anything, mock.AnythingOfType("uint")).
 					Return(test.mockReturn, test.mockReturnErr).
 					Once()
 			}
@@ -178,7 +182,7 @@ func TestGetPost(t *testing.T) {
 
 func TestUpdatePost(t *testing.T) {
 	// Create a Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 
 	// Create a mock service
 	mockService := new(mocks.BlogService)
@@ -188,7 +192,8 @@ func TestUpdatePost(t *testing.T) {
 
 	// Register the handler
 	app.Put("/blog-post/:id", bc.UpdatePost)
-	ss := ""
+	ss := "Updated"
+	blank := "   "
 	// Define test cases
 	tests := []struct {
 		description   string
@@ -235,6 +240,17 @@ func TestUpdatePost(t *testing.T) {
 			expectedCode:  http.StatusBadRequest,
 			mockCalled:    false,
 		},
+		{
+			description: "failure case - blank title",
+			paramID:     "1",
+			requestBody: models.UpdateBlogRequest{
+				Title: &blank,
+			},
+			mockReturn:    nil,
+			mockReturnErr: nil, // Should not call the service
+			expectedCode:  http.StatusBadRequest,
+			mockCalled:    false,
+		},
 		{
 			description:   "failure case - invalid request body",
 			paramID:       "1",
@@ -257,6 +273,17 @@ func TestUpdatePost(t *testing.T) {
 			expectedCode:  http.StatusInternalServerError,
 			mockCalled:    true,
 		},
+		{
+			description: "failure case - post not found",
+			paramID:     "2",
+			requestBody: models.UpdateBlogRequest{
+				Title: &ss,
+			},
+			mockReturn:    nil,
+			mockReturnErr: service.NotFound("post_not_found", "post 2 not found", nil),
+			expectedCode:  http.StatusNotFound,
+			mockCalled:    true,
+		},
 	}
 
 	// Iterate over test cases
@@ -267,7 +294,7 @@ func TestUpdatePost(t *testing.T) {
 
 			if test.mockCalled {
 				// Mock only if the service is expected to be called
-				mockService.On("Update", mock.AnythingOfType("uint"), mock.AnythingOfType("*models.UpdateBlogRequest")).
+				mockService.On("Update", mock.Anything, mock.AnythingOfType("uint"), mock.AnythingOfType("*models.UpdateBlogRequest")).
 					Return(test.mockReturn, test.mockReturnErr).
 					Once()
 			}
@@ -300,7 +327,7 @@ func TestUpdatePost(t *testing.T) {
 
 func TestDeletePost(t *testing.T) {
 	// Create a Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 
 	// Create a mock service
 	mockService := new(mocks.BlogService)
@@ -315,61 +342,43 @@ func TestDeletePost(t *testing.T) {
 	tests := []struct {
 		description   string
 		paramID       string
-		mockGetReturn *models.BlogPost
-		mockGetErr    error
 		mockDelErr    error
 		expectedCode  int
-		mockGetCalled bool
 		mockDelCalled bool
 	}{
 		{
 			description:   "success case - post deleted",
 			paramID:       "1",
-			mockGetReturn: &models.BlogPost{ID: 1, Title: "Test", Description: "Desc", Body: "Body"},
-			mockGetErr:    nil,
 			mockDelErr:    nil,
 			expectedCode:  http.StatusNoContent, // 204 No Content
-			mockGetCalled: true,
 			mockDelCalled: true,
 		},
 		{
 			description:   "failure case - invalid ID parameter",
 			paramID:       "abc",
-			mockGetReturn: nil,
-			mockGetErr:    nil, // Should not call the service
 			mockDelErr:    nil,
 			expectedCode:  http.StatusBadRequest,
-			mockGetCalled: false,
 			mockDelCalled: false,
 		},
 		{
 			description:   "failure case - negative ID",
 			paramID:       "-1",
-			mockGetReturn: nil,
-			mockGetErr:    nil, // Should not call the service
 			mockDelErr:    nil,
 			expectedCode:  http.StatusBadRequest,
-			mockGetCalled: false,
 			mockDelCalled: false,
 		},
 		{
 			description:   "failure case - post not found",
 			paramID:       "1",
-			mockGetReturn: nil,
-			mockGetErr:    errors.New("post not found"),
-			mockDelErr:    nil,
+			mockDelErr:    service.NotFound("post_not_found", "post 1 not found", nil),
 			expectedCode:  http.StatusNotFound,
-			mockGetCalled: true,
-			mockDelCalled: false,
+			mockDelCalled: true,
 		},
 		{
 			description:   "failure case - unable to delete post",
 			paramID:       "1",
-			mockGetReturn: &models.BlogPost{ID: 1, Title: "Test", Description: "Desc", Body: "Body"},
-			mockGetErr:    nil,
 			mockDelErr:    errors.New("unable to delete post"),
 			expectedCode:  http.StatusInternalServerError,
-			mockGetCalled: true,
 			mockDelCalled: true,
 		},
 	}
@@ -377,15 +386,9 @@ func TestDeletePost(t *testing.T) {
 	// Iterate over test cases
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			if test.mockGetCalled {
-				// Mock GetByID if expected
-				mockService.On("GetByID", mock.AnythingOfType("uint")).
-					Return(test.mockGetReturn, test.mockGetErr).
-					Once()
-			}
 			if test.mockDelCalled {
 				// Mock Delete if expected
-				mockService.On("Delete", mock.AnythingOfType("uint")).
+				mockService.On("Delete", mock.Anything, mock.AnythingOfType("uint")).
 					Return(test.mockDelErr).
 					Once()
 			}
@@ -401,14 +404,8 @@ func TestDeletePost(t *testing.T) {
 			assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
 
 			// Assert the mock was called only if expected
-			if test.mockGetCalled {
-				mockService.AssertCalled(t, "GetByID", mock.AnythingOfType("uint"))
-			} else {
-				mockService.AssertNotCalled(t, "GetByID")
-			}
-
 			if test.mockDelCalled {
-				mockService.AssertCalled(t, "Delete", mock.AnythingOfType("uint"))
+				mockService.AssertCalled(t, "Delete", mock.Anything, mock.AnythingOfType("uint"))
 			} else {
 				mockService.AssertNotCalled(t, "Delete")
 			}
@@ -418,7 +415,7 @@ func TestDeletePost(t *testing.T) {
 
 func TestCreatePost(t *testing.T) {
 	// Create a Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 
 	// Create a mock service
 	mockService := new(mocks.BlogService)
@@ -500,7 +497,7 @@ func TestCreatePost(t *testing.T) {
 
 			if test.mockCalled {
 				// Mock only for cases where service should be called
-				mockService.On("Create", mock.AnythingOfType("models.CreateBlogRequest")).
+				mockService.On("Create", mock.Anything, mock.AnythingOfType("models.CreateBlogRequest")).
 					Return(test.mockReturnID, test.mockReturnErr).
 					Once()
 			}
@@ -518,7 +515,7 @@ func TestCreatePost(t *testing.T) {
 
 			// Assert the mock was called only if expected
 			if test.mockCalled {
-				mockService.AssertCalled(t, "Create", mock.AnythingOfType("models.CreateBlogRequest"))
+				mockService.AssertCalled(t, "Create", mock.Anything, mock.AnythingOfType("models.CreateBlogRequest"))
 			} else {
 				mockService.AssertNotCalled(t, "Create")
 			}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"example/seed"
+	"example/service"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSeedPosts caps the posts a single seed request may generate.
+const maxSeedPosts = 100000
+
+// DevController serves endpoints that only exist in development.
+type DevController struct {
+	service service.Service
+}
+
+func NewDevController(service service.Service) DevController {
+	return DevController{service: service}
+}
+
+// Seed the database
+// Seed loads a generated dataset
+// @Summary Seed the database (development only)
+// @Description Generate a reproducible dataset and load it. The same profile and seed always generate the same posts, users, tags and comments. Only available when APP_ENV is development.
+// @Tags Development
+// @Produce json
+// @Param profile query string false "Dataset shape" Enums(small, large, pathological-unicode) default(small)
+// @Param seed query int false "Random seed" default(1)
+// @Param posts query int false "Number of posts, overriding the profile"
+// @Success 201 {object} seed.Report
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
+// @Router /dev/seed [post]
+func (dc *DevController) Seed(c *fiber.Ctx) error {
+	p, err := seed.LookupProfile(c.Query("profile"))
+	if err != nil {
+		return service.Validation("invalid_profile", err.Error(), err)
+	}
+	seedValue := c.QueryInt("seed", 1)
+	if seedValue < 0 {
+		return service.Validation("invalid_query", "seed must not be negative", nil)
+	}
+	if n := c.QueryInt("posts"); n != 0 {
+		if n < 0 || n > maxSeedPosts {
+			return service.Validation("invalid_query", fmt.Sprintf("posts must be between 1 and %d", maxSeedPosts), nil)
+		}
+		p.Posts = n
+	}
+
+	report, err := dc.service.Seed(c.UserContext(), seed.Generate(p, uint64(seedValue)))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example/mocks"
+	"example/seed"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeed(t *testing.T) {
+	tests := []struct {
+		description  string
+		query        string
+		mockCalled   bool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			description:  "success case - defaults",
+			mockCalled:   true,
+			expectedCode: http.StatusCreated,
+			expectedBody: `"profile":"small","seed":1`,
+		},
+		{
+			description:  "success case - profile and seed",
+			query:        "?profile=pathological-unicode&seed=7&posts=3",
+			mockCalled:   true,
+			expectedCode: http.StatusCreated,
+			expectedBody: `"profile":"pathological-unicode","seed":7`,
+		},
+		{
+			description:  "failure case - unknown profile",
+			query:        "?profile=huge",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"code":"invalid_profile"`,
+		},
+		{
+			description:  "failure case - too many posts",
+			query:        "?posts=1000000",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"code":"invalid_query"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			mockService := new(mocks.Service)
+			dc := NewDevController(mockService)
+			app.Post("/dev/seed", dc.Seed)
+
+			if test.mockCalled {
+				mockService.On("Seed", mock.Anything, mock.AnythingOfType("*seed.Dataset")).
+					Return(func(_ context.Context, ds *seed.Dataset) (*seed.Report, error) {
+						return &seed.Report{Profile: ds.Profile.Name, Seed: ds.Seed, Posts: len(ds.Posts)}, nil
+					})
+			}
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/dev/seed"+test.query, nil))
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+
+			var body json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Contains(t, string(body), test.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/service"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProblemContentType is the media type of every error response.
+const ProblemContentType = "application/problem+json"
+
+// problemTypeBase prefixes the stable error code to form the problem type URI.
+const problemTypeBase = "/problems/"
+
+// ErrorHandler is the Fiber error handler for the API. Handlers and
+// middleware return errors instead of writing error responses themselves;
+// this maps them to RFC 9457 problem details.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	p := toProblem(err)
+	p.Instance = c.Path()
+	p.RequestID = logging.RequestID(c.UserContext())
+
+	if p.Status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "request failed", "status", p.Status, "error", err)
+	}
+	return c.Status(p.Status).JSON(p, ProblemContentType)
+}
+
+func toProblem(err error) models.Problem {
+	var de *service.Error
+	if errors.As(err, &de) {
+		p := newProblem(statusFor(de.Kind), de.Code, de.Message)
+		p.Errors = de.Fields
+		return p
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return newProblem(fe.Code, codeFor(fe.Code), fe.Message)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return newProblem(fiber.StatusGatewayTimeout, codeFor(fiber.StatusGatewayTimeout), "request timed out")
+	}
+
+	// Anything else is unexpected; don't leak its message to the client.
+	return newProblem(fiber.StatusInternalServerError, "internal_error", "")
+}
+
+func newProblem(status int, code, detail string) models.Problem {
+	return models.Problem{
+		Type:   problemTypeBase + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func statusFor(kind error) int {
+	switch kind {
+	case service.ErrNotFound:
+		return fiber.StatusNotFound
+	case service.ErrConflict:
+		return fiber.StatusConflict
+	case service.ErrValidation:
+		return fiber.StatusBadRequest
+	case service.ErrForbidden:
+		return fiber.StatusForbidden
+	case service.ErrLocked:
+		return fiber.StatusLocked
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// codeFor derives a snake_case code from an HTTP status, e.g. 504 becomes
+// "gateway_timeout".
+func codeFor(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
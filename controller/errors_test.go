@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example/models"
+	"example/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		description  string
+		err          error
+		expectedCode int
+		expected     string
+	}{
+		{
+			description:  "wrapped not found",
+			err:          fmt.Errorf("failed to fetch post : %w", service.NotFound("post_not_found", "post 3 not found", gorm.ErrRecordNotFound)),
+			expectedCode: http.StatusNotFound,
+			expected:     "post_not_found",
+		},
+		{
+			description:  "conflict",
+			err:          service.Conflict("post_exists", "post already exists", nil),
+			expectedCode: http.StatusConflict,
+			expected:     "post_exists",
+		},
+		{
+			description:  "validation",
+			err:          service.Validation("invalid_id", "Invalid ID parameter", nil),
+			expectedCode: http.StatusBadRequest,
+			expected:     "invalid_id",
+		},
+		{
+			description:  "forbidden",
+			err:          service.Forbidden("not_owner", "not your post", nil),
+			expectedCode: http.StatusForbidden,
+			expected:     "not_owner",
+		},
+		{
+			description:  "locked",
+			err:          service.Locked("post_locked", "post 1 is locked", nil),
+			expectedCode: http.StatusLocked,
+			expected:     "post_locked",
+		},
+		{
+			description:  "fiber error",
+			err:          fiber.ErrMethodNotAllowed,
+			expectedCode: http.StatusMethodNotAllowed,
+			expected:     "method_not_allowed",
+		},
+		{
+			description:  "unexpected error",
+			err:          errors.New("pq: connection refused"),
+			expectedCode: http.StatusInternalServerError,
+			expected:     "internal_error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/fail", func(c *fiber.Ctx) error { return test.err })
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/fail", nil))
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+			assert.Equal(t, ProblemContentType, resp.Header.Get(fiber.HeaderContentType))
+
+			var p models.Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+			assert.Equal(t, test.expected, p.Code)
+			assert.Equal(t, test.expectedCode, p.Status)
+			assert.Equal(t, "/fail", p.Instance)
+			assert.NotContains(t, p.Detail, "connection refused", "internal errors must not leak")
+		})
+	}
+}
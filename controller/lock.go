@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultLockTTL is how long a post lock lasts unless renewed, when no
+// duration is set with WithLockTTL.
+const DefaultLockTTL = 5 * time.Minute
+
+// Get a post's lock
+// GetLock shows who is editing a post
+// @Summary Get a post's lock
+// @Description Returns the lock held on a post, if any.
+// @Tags Blog
+// @Produce json
+// @Param id path int true "Blog Post ID"
+// @Success 200 {object} models.PostLock
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem "The post doesn't exist or isn't locked"
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/lock [get]
+func (bc *BlogController) GetLock(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	lock, err := bc.service.GetLock(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(lock)
+}
+
+// Lock a post
+// LockPost checks a post out for editing
+// @Summary Lock a post
+// @Description Checks a post out to the caller so that nobody else can update it until the lock expires or is released. Calling it again while holding the lock renews it; editors should do so periodically as a heartbeat.
+// @Tags Blog
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Blog Post ID"
+// @Success 200 {object} models.PostLock
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 423 {object} models.Problem "Locked by someone else"
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/lock [post]
+func (bc *BlogController) LockPost(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	lock, err := bc.service.Lock(c.UserContext(), id, bc.lockTTL)
+	if err != nil {
+		return err
+	}
+	return c.JSON(lock)
+}
+
+// Unlock a post
+// UnlockPost releases a post's lock
+// @Summary Unlock a post
+// @Description Releases the caller's lock on a post. Admins can release anyone's lock with force=true, which is recorded in the audit log.
+// @Tags Blog
+// @Security BearerAuth
+// @Param id path int true "Blog Post ID"
+// @Param force query bool false "Release another user's lock (admin)"
+// @Success 204 "No Content"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 423 {object} models.Problem "Locked by someone else"
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/lock [delete]
+func (bc *BlogController) UnlockPost(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := bc.service.Unlock(c.UserContext(), id, c.QueryBool("force")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
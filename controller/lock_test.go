@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example/mocks"
+	"example/models"
+	"example/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newLockApp(se service.Service) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	bc := NewController(se, WithLockTTL(time.Minute))
+	app.Get("/blog-post/:id/lock", bc.GetLock)
+	app.Post("/blog-post/:id/lock", bc.LockPost)
+	app.Delete("/blog-post/:id/lock", bc.UnlockPost)
+	app.Patch("/blog-post/:id", bc.UpdatePost)
+	return app
+}
+
+func TestLockPost(t *testing.T) {
+	se := new(mocks.BlogService)
+	expires := time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)
+	se.On("Lock", mock.Anything, uint(1), time.Minute).Return(&models.PostLock{PostID: 1, UserID: 2, ExpiresAt: expires}, nil)
+	se.On("Lock", mock.Anything, uint(2), time.Minute).Return(nil, service.Locked("post_locked", "post 2 is locked by Alice", nil))
+	app := newLockApp(se)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/blog-post/1/lock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var lock models.PostLock
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&lock))
+	assert.Equal(t, expires, lock.ExpiresAt)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, "/blog-post/2/lock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusLocked, resp.StatusCode)
+}
+
+func TestUnlockPost(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("Unlock", mock.Anything, uint(1), false).Return(nil)
+	se.On("Unlock", mock.Anything, uint(1), true).Return(service.Forbidden("admin_required", "admins only", nil))
+	app := newLockApp(se)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/blog-post/1/lock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/blog-post/1/lock?force=true", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestGetLock(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetLock", mock.Anything, uint(1)).Return(nil, service.NotFound("post_not_locked", "post 1 is not locked", nil))
+	app := newLockApp(se)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/1/lock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/x/lock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUpdatePost_locked(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil, service.Locked("post_locked", "post 1 is locked by Alice", nil))
+	app := newLockApp(se)
+
+	req := httptest.NewRequest(http.MethodPatch, "/blog-post/1", strings.NewReader(`{"title":"x"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusLocked, resp.StatusCode)
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"example/models"
+	"example/service"
+	"example/stream"
+	"example/tenant"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultHeartbeat is how often an idle event stream sends a comment, which
+// keeps proxies from closing it and notices clients that went away.
+const DefaultHeartbeat = 15 * time.Second
+
+// replayPage is the number of logged events read at a time when a client
+// resumes.
+const replayPage = 500
+
+// reconnectDelay is the retry time sent to clients, in milliseconds.
+const reconnectDelay = 3000
+
+// eventTypes lists the event types clients may filter on.
+var eventTypes = []string{models.EventPostCreated, models.EventPostUpdated, models.EventPostPublished, models.EventPostDeleted}
+
+// StreamController serves live post events as server-sent events.
+type StreamController struct {
+	// shutdown is cancelled when the server stops, ending every stream.
+	shutdown  context.Context
+	broker    *stream.Broker
+	heartbeat time.Duration
+}
+
+// NewStreamController returns a controller whose streams end when shutdown
+// is cancelled.
+func NewStreamController(shutdown context.Context, broker *stream.Broker, heartbeat time.Duration) StreamController {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	return StreamController{shutdown: shutdown, broker: broker, heartbeat: heartbeat}
+}
+
+// Stream post events
+// StreamEvents pushes post events as they happen
+// @Summary Stream post events
+// @Description Server-sent events stream of post.created, post.updated, post.published and post.deleted events as they are committed. Each message's id is the event ID; clients reconnecting with a Last-Event-ID header, or the last_event_id parameter, first receive the events they missed that are still in the log. Idle streams receive a comment every few seconds. Clients that fall too far behind are disconnected and should resume.
+// @Tags Blog
+// @Produce text/event-stream
+// @Param type query string false "Comma-separated event types to receive"
+// @Param post_id query string false "Comma-separated post IDs to receive events of"
+// @Param last_event_id query int false "Resume after this event, for clients that can't set Last-Event-ID"
+// @Param Last-Event-ID header int false "Resume after this event"
+// @Success 200 {string} string "text/event-stream"
+// @Failure 400 {object} models.Problem
+// @Router /events [get]
+func (sc *StreamController) StreamEvents(c *fiber.Ctx) error {
+	f, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+	lastID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	var after uint
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 0)
+		if err != nil {
+			return service.Validation("invalid_last_event_id", "Last-Event-ID must be an event ID", err)
+		}
+		after = uint(id)
+	}
+
+	// Subscribe before reading the log so that no event falls in between.
+	sub := sc.broker.Subscribe(f)
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	// The stream outlives the handler, like an export, until the server
+	// shuts down.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.UserContext()))
+	stop := context.AfterFunc(sc.shutdown, cancel)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+		defer stop()
+		defer cancel()
+		fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+
+		replayed := map[uint]bool{}
+		if lastID != "" {
+			for {
+				events, err := sc.broker.Since(ctx, after, f, replayPage)
+				if err != nil {
+					slog.ErrorContext(ctx, "unable to replay events", "error", err)
+					return
+				}
+				for _, e := range events {
+					writeEvent(w, e)
+					replayed[e.ID] = true
+					after = e.ID
+				}
+				if len(events) < replayPage {
+					break
+				}
+			}
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(sc.heartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case e, ok := <-sub.Events():
+				if !ok {
+					// Dropped for falling behind; the client resumes.
+					return
+				}
+				if replayed[e.ID] {
+					continue
+				}
+				writeEvent(w, e)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			case <-ctx.Done():
+				return
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// writeEvent writes e as a server-sent event.
+func writeEvent(w *bufio.Writer, e models.OutboxEvent) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("unable to encode event", "event_id", e.ID, "error", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+// parseFilter reads the type and post_id query parameters. Only events of
+// the request's blog are selected.
+func parseFilter(c *fiber.Ctx) (stream.Filter, error) {
+	var f stream.Filter
+	if t := tenant.From(c.UserContext()); t != nil {
+		f.TenantID = t.ID
+	}
+	for _, t := range splitList(c.Query("type")) {
+		if !slices.Contains(eventTypes, t) {
+			return f, service.Validation("invalid_query", fmt.Sprintf("unknown event type %q, expected one of %s", t, strings.Join(eventTypes, ", ")), nil)
+		}
+		f.Types = append(f.Types, t)
+	}
+	for _, s := range splitList(c.Query("post_id")) {
+		id, err := strconv.ParseUint(s, 10, 0)
+		if err != nil || id == 0 {
+			return f, service.Validation("invalid_query", fmt.Sprintf("invalid post ID %q", s), err)
+		}
+		f.PostIDs = append(f.PostIDs, uint(id))
+	}
+	return f, nil
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
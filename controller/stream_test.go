@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"example/models"
+	"example/stream"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveStream serves the event stream on a real listener, since app.Test
+// waits for the body to end.
+func serveStream(t *testing.T, shutdown context.Context, broker *stream.Broker, heartbeat time.Duration) string {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler, DisableStartupMessage: true})
+	sc := NewStreamController(shutdown, broker, heartbeat)
+	app.Get("/events", sc.StreamEvents)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(100 * time.Millisecond) })
+	return "http://" + ln.Addr().String() + "/events"
+}
+
+// openStream connects and returns a function reading the next message, with
+// its lines joined by "|".
+func openStream(t *testing.T, url, lastEventID string) (*http.Response, func() string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		var msg []string
+		for lines.Scan() {
+			if lines.Text() == "" {
+				return strings.Join(msg, "|")
+			}
+			msg = append(msg, lines.Text())
+		}
+		return strings.Join(msg, "|")
+	}
+	return resp, next
+}
+
+func TestStreamEvents(t *testing.T) {
+	broker := stream.NewBroker(stream.NewMemoryLog(10))
+	url := serveStream(t, context.Background(), broker, time.Hour)
+
+	resp, next := openStream(t, url+"?type=post.published,post.deleted", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "retry: 3000", next())
+
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+	broker.Notify(context.Background(), []models.OutboxEvent{
+		{ID: 1, PostID: 7, Type: models.EventPostCreated},
+		{ID: 2, PostID: 7, Type: models.EventPostPublished},
+	})
+	assert.Equal(t, `id: 2|event: post.published|data: {"id":2,"type":"post.published","post_id":7,"data":null,"occurred_at":"0001-01-01T00:00:00Z"}`, next())
+}
+
+func TestStreamEvents_resume(t *testing.T) {
+	broker := stream.NewBroker(stream.NewMemoryLog(10))
+	broker.Notify(context.Background(), []models.OutboxEvent{
+		{ID: 1, PostID: 7, Type: models.EventPostCreated},
+		{ID: 2, PostID: 8, Type: models.EventPostCreated},
+		{ID: 3, PostID: 7, Type: models.EventPostUpdated},
+	})
+	url := serveStream(t, context.Background(), broker, time.Hour)
+
+	_, next := openStream(t, url+"?post_id=7", "1")
+	next() // retry
+	assert.True(t, strings.HasPrefix(next(), "id: 3|"), "missed events are replayed")
+
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+	broker.Notify(context.Background(), []models.OutboxEvent{{ID: 4, PostID: 7, Type: models.EventPostDeleted}})
+	assert.True(t, strings.HasPrefix(next(), "id: 4|"))
+}
+
+func TestStreamEvents_heartbeat(t *testing.T) {
+	broker := stream.NewBroker(stream.NewMemoryLog(10))
+	url := serveStream(t, context.Background(), broker, 20*time.Millisecond)
+
+	_, next := openStream(t, url, "")
+	next() // retry
+	assert.Equal(t, ": heartbeat", next())
+}
+
+func TestStreamEvents_endsAtShutdown(t *testing.T) {
+	broker := stream.NewBroker(stream.NewMemoryLog(10))
+	shutdown, stop := context.WithCancel(context.Background())
+	url := serveStream(t, shutdown, broker, time.Hour)
+
+	_, next := openStream(t, url, "")
+	next() // retry
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+	stop()
+	assert.Equal(t, "", next(), "the stream ends")
+	assert.Eventually(t, func() bool { return broker.Subscribers() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStreamEvents_invalid(t *testing.T) {
+	broker := stream.NewBroker(stream.NewMemoryLog(10))
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	sc := NewStreamController(context.Background(), broker, 0)
+	app.Get("/events", sc.StreamEvents)
+
+	for _, query := range []string{"?type=post.liked", "?post_id=abc", "?last_event_id=x"} {
+		req, _ := http.NewRequest(http.MethodGet, "/events"+query, nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Zero(t, broker.Subscribers())
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"example/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Get the blog's settings
+// GetBlog returns the settings of the blog the request is for
+// @Summary Get the blog's settings
+// @Description The blog is the one served on the request's host, or named by a /t/{slug} path prefix.
+// @Tags Blog
+// @Produce json
+// @Success 200 {object} models.Tenant
+// @Failure 404 {object} models.Problem
+// @Router /blog [get]
+func GetBlog(c *fiber.Ctx) error {
+	t := tenant.From(c.UserContext())
+	if t == nil {
+		return fiber.NewError(fiber.StatusNotFound, "there is no blog here")
+	}
+	return c.JSON(t)
+}
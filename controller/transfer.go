@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"example/service"
+	"example/transfer"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Export blog posts
+// ExportPosts streams every blog post as a file download
+// @Summary Export blog posts
+// @Description Stream every post, drafts included, in ID order, as JSON Lines, CSV, or a zip archive of Markdown files with YAML frontmatter. Only authors, editors and admins may export.
+// @Tags Blog
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Produce application/zip
+// @Security BearerAuth
+// @Param format query string false "File format" Enums(jsonl, csv, markdown) default(jsonl)
+// @Success 200 {file} file
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Router /blog-post/export [get]
+func (bc *BlogController) ExportPosts(c *fiber.Ctx) error {
+	f, err := parseFormat(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, f.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="blog-posts%s"`, f.Extension()))
+
+	// The body is written after the handler returns, so the export must not
+	// be cut short by the request deadline. Errors past this point can only
+	// be logged: the status line has already been sent.
+	ctx := context.WithoutCancel(c.UserContext())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := bc.service.Export(ctx, w, f); err != nil {
+			slog.ErrorContext(ctx, "export aborted", "error", err)
+		}
+		if err := w.Flush(); err != nil {
+			slog.ErrorContext(ctx, "export aborted", "error", err)
+		}
+	})
+	return nil
+}
+
+// Import blog posts
+// ImportPosts imports blog posts from an uploaded file
+// @Summary Import blog posts
+// @Description Import posts from a file in the request body, in the same formats produced by the export. The import is all or nothing: records that fail validation are reported and skipped, but any other error leaves the database unchanged. With dry_run the import is rolled back after reporting what it would have done.
+// @Tags Blog
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Accept application/zip
+// @Produce json
+// @Param format query string false "File format" Enums(jsonl, csv, markdown) default(jsonl)
+// @Param dry_run query bool false "Report without writing anything"
+// @Param ids query string false "Keep the IDs in the file or assign new ones" Enums(preserve, remap) default(preserve)
+// @Param conflict query string false "What to do when a preserved ID already exists" Enums(skip, overwrite, fail) default(skip)
+// @Success 200 {object} transfer.Report
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
+// @Router /blog-post/import [post]
+func (bc *BlogController) ImportPosts(c *fiber.Ctx) error {
+	f, err := parseFormat(c)
+	if err != nil {
+		return err
+	}
+	opts, err := transfer.ParseOptions(c.QueryBool("dry_run"), c.Query("ids"), c.Query("conflict"))
+	if err != nil {
+		return service.Validation("invalid_query", err.Error(), err)
+	}
+	opts.MaxSize = bc.importMaxSize
+
+	report, err := bc.service.Import(c.UserContext(), bytes.NewReader(c.Body()), f, opts)
+	if err != nil {
+		return err
+	}
+	return c.JSON(report)
+}
+
+func parseFormat(c *fiber.Ctx) (transfer.Format, error) {
+	f, err := transfer.ParseFormat(c.Query("format", string(transfer.JSONL)))
+	if err != nil {
+		return "", service.Validation("invalid_format", err.Error(), err)
+	}
+	return f, nil
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example/mocks"
+	"example/service"
+	"example/transfer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPosts(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockService := new(mocks.BlogService)
+	bc := NewController(mockService)
+	app.Get("/blog-post/export", bc.ExportPosts)
+
+	mockService.On("Export", mock.Anything, mock.Anything, transfer.CSV).
+		Run(func(args mock.Arguments) {
+			_, _ = io.WriteString(args.Get(1).(io.Writer), "id,title\n1,t\n")
+		}).
+		Return(1, nil)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/export?format=csv", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, `attachment; filename="blog-posts.csv"`, resp.Header.Get(fiber.HeaderContentDisposition))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "id,title\n1,t\n", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/export?format=xml", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportPosts(t *testing.T) {
+	tests := []struct {
+		description  string
+		query        string
+		mockOpts     transfer.Options
+		mockReport   *transfer.Report
+		mockErr      error
+		mockCalled   bool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			description:  "success case - defaults",
+			query:        "",
+			mockOpts:     transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictSkip},
+			mockReport:   &transfer.Report{Created: 2},
+			mockCalled:   true,
+			expectedCode: http.StatusOK,
+			expectedBody: `"created":2`,
+		},
+		{
+			description:  "success case - dry run with options",
+			query:        "?format=csv&dry_run=true&ids=remap&conflict=fail",
+			mockOpts:     transfer.Options{DryRun: true, IDs: transfer.RemapIDs, Conflict: transfer.ConflictFail},
+			mockReport:   &transfer.Report{DryRun: true, Created: 1},
+			mockCalled:   true,
+			expectedCode: http.StatusOK,
+			expectedBody: `"dry_run":true`,
+		},
+		{
+			description:  "failure case - unknown conflict policy",
+			query:        "?conflict=merge",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"code":"invalid_query"`,
+		},
+		{
+			description:  "failure case - unknown format",
+			query:        "?format=xml",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"code":"invalid_format"`,
+		},
+		{
+			description:  "failure case - conflict",
+			query:        "?conflict=fail",
+			mockOpts:     transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictFail},
+			mockErr:      service.Conflict("post_exists", "post 1 already exists", errors.New("exists")),
+			mockCalled:   true,
+			expectedCode: http.StatusConflict,
+			expectedBody: `"code":"post_exists"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			mockService := new(mocks.BlogService)
+			bc := NewController(mockService)
+			app.Post("/blog-post/import", bc.ImportPosts)
+
+			if test.mockCalled {
+				mockService.On("Import", mock.Anything, mock.Anything, mock.AnythingOfType("transfer.Format"), test.mockOpts).
+					Return(test.mockReport, test.mockErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/blog-post/import"+test.query, strings.NewReader(`{"title":"t"}`))
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+
+			var body json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Contains(t, string(body), test.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"example/i18n"
+	"example/models"
+	"example/service"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// contentLocales returns the languages the client wants posts in, most
+// preferred first: the one named by the lang parameter, or else those of
+// Accept-Language.
+func contentLocales(c *fiber.Ctx) ([]string, error) {
+	if lang := c.Query("lang"); lang != "" {
+		l, ok := models.CanonicalLocale(lang)
+		if !ok {
+			return nil, service.Validation("invalid_locale", fmt.Sprintf("lang %q is not a language tag such as en or de-AT", lang), nil)
+		}
+		return []string{l}, nil
+	}
+	return i18n.Preferences(c.Get(fiber.HeaderAcceptLanguage)), nil
+}
+
+// localize serves posts in the client's language. Responses vary with
+// Accept-Language, so shared caches must key on it.
+func (bc *BlogController) localize(c *fiber.Ctx, posts []models.BlogPost) error {
+	prefs, err := contentLocales(c)
+	if err != nil {
+		return err
+	}
+	c.Vary(fiber.HeaderAcceptLanguage)
+	return bc.service.Localize(c.UserContext(), posts, prefs)
+}
+
+// List a post's translations
+// GetTranslations lists the translations of a post
+// @Summary List a post's translations
+// @Description Returns the translations of a post, ordered by locale. The post itself is in its own locale.
+// @Tags Translations
+// @Produce json
+// @Param id path int true "Blog Post ID"
+// @Success 200 {array} models.PostTranslation
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/translations [get]
+func (bc *BlogController) GetTranslations(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if _, err := bc.post(c, id); err != nil {
+		return err
+	}
+	translations, err := bc.service.GetTranslations(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(translations)
+}
+
+// Create or replace a translation
+// PutTranslation saves a post's translation in a locale
+// @Summary Create or replace a translation
+// @Description Sets the title, description and body of a post in a locale other than its own. Fails with 423 while another user holds a lock on the post.
+// @Tags Translations
+// @Accept json
+// @Produce json
+// @Param id path int true "Blog Post ID"
+// @Param locale path string true "BCP 47 language tag, such as de or pt-BR"
+// @Param translation body models.TranslationRequest true "Translation"
+// @Success 200 {object} models.PostTranslation "Replaced"
+// @Success 201 {object} models.PostTranslation "Created"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 423 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/translations/{locale} [put]
+func (bc *BlogController) PutTranslation(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var req models.TranslationRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	t, created, err := bc.service.PutTranslation(c.UserContext(), id, c.Params("locale"), req)
+	if err != nil {
+		return err
+	}
+	if created {
+		c.Status(fiber.StatusCreated)
+	}
+	return c.JSON(t)
+}
+
+// Update a translation
+// UpdateTranslation changes some fields of a post's translation
+// @Summary Update a translation
+// @Description Updates the title, description or body of a post's translation. Fails with 423 while another user holds a lock on the post.
+// @Tags Translations
+// @Accept json
+// @Produce json
+// @Param id path int true "Blog Post ID"
+// @Param locale path string true "BCP 47 language tag, such as de or pt-BR"
+// @Param translation body models.UpdateTranslationRequest true "Fields to change"
+// @Success 200 {object} models.PostTranslation
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 423 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/translations/{locale} [patch]
+func (bc *BlogController) UpdateTranslation(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var req models.UpdateTranslationRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	t, err := bc.service.UpdateTranslation(c.UserContext(), id, c.Params("locale"), &req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(t)
+}
+
+// Delete a translation
+// DeleteTranslation deletes a post's translation
+// @Summary Delete a translation
+// @Description Fails with 423 while another user holds a lock on the post.
+// @Tags Translations
+// @Param id path int true "Blog Post ID"
+// @Param locale path string true "BCP 47 language tag, such as de or pt-BR"
+// @Success 204
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 423 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /blog-post/{id}/translations/{locale} [delete]
+func (bc *BlogController) DeleteTranslation(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := bc.service.DeleteTranslation(c.UserContext(), id, c.Params("locale")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Get the sitemap
+// GetSitemap lists the published posts with their versions in every language
+// @Summary Get the sitemap
+// @Description Lists the URL of every published post with its hreflang alternates, one per language it is available in plus x-default, for building XML sitemaps.
+// @Tags Blog
+// @Produce json
+// @Success 200 {array} models.SitemapEntry
+// @Failure 500 {object} models.Problem
+// @Failure 504 {object} models.Problem
+// @Router /sitemap [get]
+func (bc *BlogController) GetSitemap(c *fiber.Ctx) error {
+	all, err := bc.service.GetAll(c.UserContext())
+	if err != nil {
+		return err
+	}
+	posts := make([]models.BlogPost, 0, len(all))
+	for _, p := range all {
+		if p.Status == models.StatusPublished {
+			posts = append(posts, p)
+		}
+	}
+	if err := bc.service.Localize(c.UserContext(), posts, nil); err != nil {
+		return err
+	}
+
+	base := c.BaseURL() + "/api/blog-post/"
+	entries := make([]models.SitemapEntry, len(posts))
+	for i, p := range posts {
+		loc := base + strconv.FormatUint(uint64(p.ID), 10)
+		entries[i] = models.SitemapEntry{
+			Loc:     loc,
+			LastMod: i18n.LastMod(p),
+			Alternates: i18n.Alternates(p, func(locale string) string {
+				if locale == "" {
+					return loc
+				}
+				return loc + "?lang=" + locale
+			}),
+		}
+	}
+	return c.JSON(entries)
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example/i18n"
+	"example/mocks"
+	"example/models"
+	"example/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTranslationApp(se service.Service) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	bc := NewController(se)
+	app.Get("/sitemap", bc.GetSitemap)
+	app.Get("/blog-post/:id", bc.GetPost)
+	app.Put("/blog-post/:id/translations/:locale", bc.PutTranslation)
+	return app
+}
+
+func TestGetPost_language(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "Hello", Status: models.StatusPublished, Locale: "en"}, nil)
+	se.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, posts []models.BlogPost, prefs []string) error {
+		i18n.Localize(&posts[0], []models.PostTranslation{{PostID: 1, Locale: "de", Title: "Hallo"}}, i18n.Chain(prefs))
+		return nil
+	})
+	app := newTranslationApp(se)
+
+	tests := []struct {
+		name, query, accept string
+		code                int
+		language, title     string
+	}{
+		{"Accept-Language", "", "de-CH, en;q=0.5", http.StatusOK, "de", "Hallo"},
+		{"lang overrides Accept-Language", "?lang=EN", "de", http.StatusOK, "en", "Hello"},
+		{"no preference", "", "", http.StatusOK, "en", "Hello"},
+		{"invalid lang", "?lang=12345678901", "", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/blog-post/1"+tt.query, nil)
+			req.Header.Set(fiber.HeaderAcceptLanguage, tt.accept)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			require.Equal(t, tt.code, resp.StatusCode)
+			if tt.code != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.language, resp.Header.Get(fiber.HeaderContentLanguage))
+			assert.Equal(t, fiber.HeaderAcceptLanguage, resp.Header.Get(fiber.HeaderVary))
+			var post models.BlogPost
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&post))
+			assert.Equal(t, tt.title, post.Title)
+			assert.Len(t, post.Translations, 2)
+		})
+	}
+}
+
+func TestPutTranslation(t *testing.T) {
+	se := new(mocks.BlogService)
+	req := models.TranslationRequest{Title: "Hallo", Description: "Gruß", Body: "Text"}
+	se.On("PutTranslation", mock.Anything, uint(1), "de", req).Return(&models.PostTranslation{PostID: 1, Locale: "de"}, true, nil).Once()
+	se.On("PutTranslation", mock.Anything, uint(1), "de", req).Return(&models.PostTranslation{PostID: 1, Locale: "de"}, false, nil).Once()
+	app := newTranslationApp(se)
+
+	for _, code := range []int{http.StatusCreated, http.StatusOK} {
+		r := httptest.NewRequest(http.MethodPut, "/blog-post/1/translations/de",
+			strings.NewReader(`{"title":" Hallo ","description":"Gruß","body":"Text"}`))
+		r.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(r)
+		require.NoError(t, err)
+		assert.Equal(t, code, resp.StatusCode)
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/blog-post/1/translations/de", strings.NewReader(`{"title":"Hallo"}`))
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "body missing")
+	se.AssertNumberOfCalls(t, "PutTranslation", 2)
+}
+
+func TestGetSitemap(t *testing.T) {
+	updated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	se := new(mocks.BlogService)
+	se.On("GetAll", mock.Anything).Return([]models.BlogPost{
+		{ID: 1, Status: models.StatusPublished, Locale: "en", UpdatedAt: updated},
+		{ID: 2, Status: models.StatusDraft, Locale: "en", UpdatedAt: updated},
+	}, nil)
+	se.On("Localize", mock.Anything, mock.MatchedBy(func(posts []models.BlogPost) bool {
+		return len(posts) == 1 && posts[0].ID == 1
+	}), []string(nil)).Return(func(_ context.Context, posts []models.BlogPost, _ []string) error {
+		i18n.Localize(&posts[0], []models.PostTranslation{{PostID: 1, Locale: "fr", UpdatedAt: updated.Add(time.Hour)}}, nil)
+		return nil
+	})
+	app := newTranslationApp(se)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "http://blog.example.com/sitemap", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var entries []models.SitemapEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	require.Len(t, entries, 1, "drafts are left out")
+	assert.Equal(t, models.SitemapEntry{
+		Loc:     "http://blog.example.com/api/blog-post/1",
+		LastMod: updated.Add(time.Hour),
+		Alternates: []models.Alternate{
+			{Hreflang: "en", Href: "http://blog.example.com/api/blog-post/1?lang=en"},
+			{Hreflang: "fr", Href: "http://blog.example.com/api/blog-post/1?lang=fr"},
+			{Hreflang: "x-default", Href: "http://blog.example.com/api/blog-post/1"},
+		},
+	}, entries[0])
+}
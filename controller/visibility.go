@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"example/auth"
+	"example/models"
+	"example/service"
+	"fmt"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// seesDrafts reports whether the request is from a signed-in author, editor
+// or admin. Everyone else only sees published posts.
+func seesDrafts(c *fiber.Ctx) bool {
+	user := auth.User(c.UserContext())
+	return user != nil && slices.Contains(models.Roles, user.Role)
+}
+
+// published returns the published posts among posts.
+func published(posts []models.BlogPost) []models.BlogPost {
+	return slices.DeleteFunc(posts, func(p models.BlogPost) bool {
+		return p.Status != models.StatusPublished
+	})
+}
+
+// post returns the post with the given ID, which is not found by those who
+// may not see it.
+func (bc *BlogController) post(c *fiber.Ctx, id uint) (*models.BlogPost, error) {
+	post, err := bc.service.GetByID(c.UserContext(), id)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != models.StatusPublished && !seesDrafts(c) {
+		return nil, service.NotFound("post_not_found", fmt.Sprintf("post %d not found", id), nil)
+	}
+	return post, nil
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"example/auth"
+	"example/mocks"
+	"example/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftsAreHiddenFromAnonymousReaders(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetAll", mock.Anything).Return(func(context.Context) []models.BlogPost {
+		return []models.BlogPost{
+			{ID: 1, Title: "Out", Status: models.StatusPublished},
+			{ID: 2, Title: "Secret", Status: models.StatusDraft},
+		}
+	}, nil)
+	se.On("GetByID", mock.Anything, uint(2)).Return(&models.BlogPost{ID: 2, Title: "Secret", Status: models.StatusDraft}, nil)
+	se.On("GetTranslations", mock.Anything, uint(2)).Return([]models.PostTranslation{}, nil)
+	se.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	bc := &BlogController{service: se}
+
+	for _, tt := range []struct {
+		name  string
+		user  *models.User
+		posts int
+		code  int
+	}{
+		{"anonymous", nil, 1, fiber.StatusNotFound},
+		{"author", &models.User{ID: 1, Role: models.RoleAuthor}, 2, fiber.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Use(func(c *fiber.Ctx) error {
+				if tt.user != nil {
+					c.SetUserContext(auth.WithUser(c.UserContext(), tt.user))
+				}
+				return c.Next()
+			})
+			app.Get("/blog-post", bc.GetPosts)
+			app.Get("/blog-post/:id", bc.GetPost)
+			app.Get("/blog-post/:id/translations", bc.GetTranslations)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/blog-post", nil))
+			require.NoError(t, err)
+			var posts []models.BlogPost
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&posts))
+			assert.Len(t, posts, tt.posts)
+
+			for _, path := range []string{"/blog-post/2", "/blog-post/2/translations"} {
+				resp, err = app.Test(httptest.NewRequest("GET", path, nil))
+				require.NoError(t, err)
+				assert.Equal(t, tt.code, resp.StatusCode, path)
+			}
+		})
+	}
+}
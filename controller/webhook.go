@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"example/models"
+	"example/repo"
+	"example/service"
+	"example/webhook"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Delivery history page sizes.
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// WebhookController manages webhook subscriptions and their deliveries.
+type WebhookController struct {
+	repo repo.Repository
+}
+
+func NewWebhookController(repo repo.Repository) WebhookController {
+	return WebhookController{repo: repo}
+}
+
+// Create a webhook subscription
+// CreateWebhook subscribes an endpoint to post events
+// @Summary Create a webhook subscription
+// @Description Subscribe a URL to post events, optionally only some types of them. Deliveries are signed with the secret, which is generated unless given and only returned here. Requires an admin API key.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body models.CreateWebhookRequest true "Subscription"
+// @Success 201 {object} models.CreatedWebhook
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks [post]
+func (wc *WebhookController) CreateWebhook(c *fiber.Ctx) error {
+	var req models.CreateWebhookRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	created, err := webhook.Create(c.UserContext(), wc.repo, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// List webhook subscriptions
+// GetWebhooks lists every webhook subscription
+// @Summary List webhook subscriptions
+// @Description Requires an admin API key.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks [get]
+func (wc *WebhookController) GetWebhooks(c *fiber.Ctx) error {
+	subs, err := wc.repo.ListWebhookSubscriptions(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(subs)
+}
+
+// Get a webhook subscription
+// GetWebhook retrieves a webhook subscription by ID
+// @Summary Get a webhook subscription
+// @Description Requires an admin API key.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks/{id} [get]
+func (wc *WebhookController) GetWebhook(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	sub, err := webhook.Get(c.UserContext(), wc.repo, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(sub)
+}
+
+// Update a webhook subscription
+// UpdateWebhook updates a webhook subscription by ID
+// @Summary Update a webhook subscription
+// @Description Change the URL or event types of a subscription, or pause it with active false. Setting active to true re-enables a subscription disabled after repeated failures; its pending deliveries resume. Requires an admin API key.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param webhook body models.UpdateWebhookRequest true "Updated fields"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks/{id} [patch]
+func (wc *WebhookController) UpdateWebhook(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var req models.UpdateWebhookRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	sub, err := webhook.Update(c.UserContext(), wc.repo, id, &req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(sub)
+}
+
+// Delete a webhook subscription
+// DeleteWebhook deletes a webhook subscription by ID
+// @Summary Delete a webhook subscription
+// @Description Delete a subscription along with its delivery history. Requires an admin API key.
+// @Tags Webhooks
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks/{id} [delete]
+func (wc *WebhookController) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := webhook.Delete(c.UserContext(), wc.repo, id); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// List webhook deliveries
+// GetDeliveries lists the deliveries of a webhook subscription
+// @Summary List the deliveries of a webhook subscription
+// @Description Page through a subscription's delivery history, newest first, with the outcome of the latest attempt at each. Pass the smallest ID of a page as before to get the next one. Requires an admin API key.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param before query int false "Only deliveries with a lower ID"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks/{id}/deliveries [get]
+func (wc *WebhookController) GetDeliveries(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	limit := c.QueryInt("limit", defaultDeliveryLimit)
+	if limit <= 0 || limit > maxDeliveryLimit {
+		return service.Validation("invalid_query", "limit must be between 1 and "+strconv.Itoa(maxDeliveryLimit), nil)
+	}
+	before := c.QueryInt("before")
+	if before < 0 {
+		return service.Validation("invalid_query", "before must not be negative", nil)
+	}
+
+	deliveries, err := webhook.Deliveries(c.UserContext(), wc.repo, id, uint(before), limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(deliveries)
+}
+
+// Redeliver a webhook delivery
+// Redeliver sends the event of a past delivery again
+// @Summary Redeliver a webhook event
+// @Description Queue a new delivery of the same event, whether the original succeeded or failed. The new delivery appears in the history alongside the original. Requires an admin API key.
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (wc *WebhookController) Redeliver(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := strconv.Atoi(c.Params("delivery_id"))
+	if err != nil || deliveryID <= 0 {
+		return service.Validation("invalid_id", "Invalid delivery ID parameter", err)
+	}
+
+	d, err := webhook.Redeliver(c.UserContext(), wc.repo, id, uint(deliveryID))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(d)
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example/mocks"
+	"example/models"
+	"example/repo"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newWebhookApp(r *mocks.Repository) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	wc := NewWebhookController(r)
+	app.Post("/webhooks", wc.CreateWebhook)
+	app.Get("/webhooks/:id", wc.GetWebhook)
+	app.Get("/webhooks/:id/deliveries", wc.GetDeliveries)
+	app.Post("/webhooks/:id/deliveries/:delivery_id/redeliver", wc.Redeliver)
+	return app
+}
+
+func TestCreateWebhook(t *testing.T) {
+	tests := []struct {
+		description  string
+		body         string
+		mockCalled   bool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			description:  "success case",
+			body:         `{"url":"https://partner.example/hooks","events":["post.published"]}`,
+			mockCalled:   true,
+			expectedCode: http.StatusCreated,
+			expectedBody: `"secret":"whsec_`,
+		},
+		{
+			description:  "failure case - not an http URL",
+			body:         `{"url":"ftp://partner.example/hooks"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"code":"invalid_url"`,
+		},
+		{
+			description:  "failure case - unknown event",
+			body:         `{"url":"https://partner.example/hooks","events":["post.liked"]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"field":"events[0]","code":"invalid_choice"`,
+		},
+		{
+			description:  "failure case - short secret",
+			body:         `{"url":"https://partner.example/hooks","secret":"short"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"code":"too_short"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			r := new(mocks.Repository)
+			if test.mockCalled {
+				r.On("CreateWebhookSubscription", mock.Anything, mock.Anything).Return(nil)
+				r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+					return fn(r)
+				})
+				r.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(nil)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := newWebhookApp(r).Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), test.expectedBody)
+			r.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetWebhook_hidesSecret(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("GetWebhookSubscription", mock.Anything, uint(1)).Return(&models.WebhookSubscription{ID: 1, URL: "https://partner.example", Secret: "whsec_hidden"}, nil)
+	r.On("GetWebhookSubscription", mock.Anything, uint(2)).Return(nil, gorm.ErrRecordNotFound)
+
+	resp, err := newWebhookApp(r).Test(httptest.NewRequest(http.MethodGet, "/webhooks/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.NotContains(t, string(body), "whsec_hidden")
+
+	resp, err = newWebhookApp(r).Test(httptest.NewRequest(http.MethodGet, "/webhooks/2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetDeliveries(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("GetWebhookSubscription", mock.Anything, uint(1)).Return(&models.WebhookSubscription{ID: 1}, nil)
+	r.On("ListWebhookDeliveries", mock.Anything, uint(1), uint(30), 10).Return([]models.WebhookDelivery{
+		{ID: 29, SubscriptionID: 1, Status: models.DeliveryFailed, ResponseStatus: 500},
+	}, nil)
+
+	resp, err := newWebhookApp(r).Test(httptest.NewRequest(http.MethodGet, "/webhooks/1/deliveries?limit=10&before=30", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []models.WebhookDelivery
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 1)
+	assert.Equal(t, 500, got[0].ResponseStatus)
+
+	resp, err = newWebhookApp(r).Test(httptest.NewRequest(http.MethodGet, "/webhooks/1/deliveries?limit=1000", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRedeliver(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("GetWebhookSubscription", mock.Anything, uint(1)).Return(&models.WebhookSubscription{ID: 1, Active: true}, nil)
+	r.On("GetWebhookDelivery", mock.Anything, uint(1), uint(5)).Return(&models.WebhookDelivery{ID: 5, EventID: 8}, nil)
+	r.On("CreateWebhookDeliveries", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := newWebhookApp(r).Test(httptest.NewRequest(http.MethodPost, "/webhooks/1/deliveries/5/redeliver", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, err = newWebhookApp(r).Test(httptest.NewRequest(http.MethodPost, "/webhooks/1/deliveries/x/redeliver", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
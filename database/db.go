@@ -3,14 +3,16 @@ package database
 import (
 	"example/models"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
 )
 
-func NewDB() *gorm.DB {
+func NewDB(log logger.Interface) *gorm.DB {
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		os.Getenv("DB_HOST"),
@@ -20,13 +22,106 @@ func NewDB() *gorm.DB {
 		os.Getenv("DB_PORT"),
 	)
 
-	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: log})
 	if err != nil {
-		log.Fatal("Failed to connect to database!")
+		slog.Error("Failed to connect to database!", "error", err)
+		os.Exit(1)
 	}
 
-	// Migrate the schema
-	database.AutoMigrate(&models.BlogPost{})
-
 	return database
 }
+
+// tenantModels are the models whose rows belong to a blog.
+var tenantModels = []any{
+	&models.BlogPost{}, &models.Tag{}, &models.Category{}, &models.User{}, &models.APIKey{},
+	&models.OutboxEvent{}, &models.WebhookSubscription{}, &models.WebhookDelivery{},
+	&models.PostLock{}, &models.AuditEntry{}, &models.PostTranslation{},
+}
+
+// replacedIndexes were unique across the deployment before there were
+// several blogs, and are now unique per blog.
+var replacedIndexes = map[any]string{
+	&models.Tag{}:      "idx_tags_slug",
+	&models.Category{}: "idx_categories_slug",
+	&models.User{}:     "idx_users_email",
+}
+
+// Migrate creates or updates the tables of every model. The first run
+// creates the default blog, to which everything written before there were
+// several blogs is given.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Tenant{}); err != nil {
+		return err
+	}
+	def := models.Tenant{Slug: models.DefaultTenant, Title: "Blog"}
+	if err := db.Where(models.Tenant{Slug: models.DefaultTenant}).FirstOrCreate(&def).Error; err != nil {
+		return err
+	}
+	if err := addTenantColumns(db, def.ID); err != nil {
+		return err
+	}
+
+	err := db.AutoMigrate(
+		&models.BlogPost{}, &models.Tag{}, &models.Category{}, &models.Comment{},
+		&models.User{}, &models.APIKey{}, &models.IdempotencyKey{}, &models.OutboxEvent{},
+		&models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.PostLock{}, &models.AuditEntry{},
+		&models.PostTranslation{}, &models.PostRevision{}, &models.ImportState{},
+	)
+	if err != nil {
+		return err
+	}
+	m := db.Migrator()
+	for model, index := range replacedIndexes {
+		if m.HasIndex(model, index) {
+			if err := m.DropIndex(model, index); err != nil {
+				return err
+			}
+		}
+	}
+	return db.Exec(appendOnlyAudit).Error
+}
+
+// addTenantColumns gives the existing rows of tables created before there
+// were several blogs to the blog with the given ID. Adding a column with a
+// default doesn't rewrite rows, so it gets past the append-only audit log.
+func addTenantColumns(db *gorm.DB, id uint) error {
+	m := db.Migrator()
+	for _, model := range tenantModels {
+		if !m.HasTable(model) || m.HasColumn(model, "TenantID") {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		table := clause.Table{Name: stmt.Schema.Table}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE ? ADD COLUMN tenant_id bigint NOT NULL DEFAULT %d", id), table).Error; err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE ? ALTER COLUMN tenant_id DROP DEFAULT", table).Error
+		})
+		if err != nil {
+			return fmt.Errorf("add tenant_id to %s: %w", stmt.Schema.Table, err)
+		}
+	}
+	return nil
+}
+
+// appendOnlyAudit makes the database reject changes to audit entries other
+// than inserts. The hash chain detects tampering by whoever gets past it.
+const appendOnlyAudit = `
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+CREATE TRIGGER audit_entries_append_only BEFORE UPDATE OR DELETE ON audit_entries
+	FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
+
+DROP TRIGGER IF EXISTS audit_entries_no_truncate ON audit_entries;
+CREATE TRIGGER audit_entries_no_truncate BEFORE TRUNCATE ON audit_entries
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_entries_append_only();
+`
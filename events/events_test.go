@@ -0,0 +1,266 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"example/repo"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memOutbox is an outbox kept in memory. Unused Repository methods panic.
+type memOutbox struct {
+	repo.Repository
+	mu     sync.Mutex
+	events []models.OutboxEvent
+}
+
+func (m *memOutbox) add(types ...string) {
+	for _, t := range types {
+		m.events = append(m.events, models.OutboxEvent{ID: uint(len(m.events) + 1), Type: t, Data: json.RawMessage(`{}`)})
+	}
+}
+
+func (m *memOutbox) ClaimOutboxEvents(_ context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.OutboxEvent
+	for i := range m.events {
+		e := &m.events[i]
+		if e.PublishedAt == nil && !e.NextAttemptAt.After(now) && len(out) < limit {
+			out = append(out, *e)
+			e.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return out, nil
+}
+
+func (m *memOutbox) MarkOutboxEventPublished(_ context.Context, id uint, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[id-1].PublishedAt = &at
+	return nil
+}
+
+func (m *memOutbox) RetryOutboxEvent(_ context.Context, id uint, next time.Time, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &m.events[id-1]
+	e.Attempts++
+	e.NextAttemptAt, e.LastError = next, lastError
+	return nil
+}
+
+// fakeSink records what it is sent, failing while fail is set.
+type fakeSink struct {
+	mu   sync.Mutex
+	fail bool
+	got  []uint
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Publish(_ context.Context, e models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	s.got = append(s.got, e.ID)
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	outbox := &memOutbox{}
+	outbox.add(models.EventPostCreated, models.EventPostPublished, models.EventPostDeleted)
+	a, b := &fakeSink{}, &fakeSink{}
+	now := time.Now()
+	r := &Relay{Repo: outbox, Sinks: []Sink{a, b}, BatchSize: 2, now: func() time.Time { return now }}
+
+	n, err := r.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = r.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, _ = r.RunOnce(context.Background())
+	assert.Equal(t, 0, n, "nothing left")
+
+	assert.Equal(t, []uint{1, 2, 3}, a.got)
+	assert.Equal(t, []uint{1, 2, 3}, b.got)
+	for _, e := range outbox.events {
+		assert.NotNil(t, e.PublishedAt)
+	}
+}
+
+func TestRelay_retriesWithBackoff(t *testing.T) {
+	outbox := &memOutbox{}
+	outbox.add(models.EventPostCreated)
+	ok, flaky := &fakeSink{}, &fakeSink{fail: true}
+	now := time.Now()
+	r := &Relay{Repo: outbox, Sinks: []Sink{ok, flaky}, MinBackoff: time.Second, MaxBackoff: 3 * time.Second,
+		now: func() time.Time { return now }}
+
+	for attempt, wait := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		_, err := r.RunOnce(context.Background())
+		require.NoError(t, err)
+		e := outbox.events[0]
+		assert.Equal(t, attempt+1, e.Attempts)
+		assert.Equal(t, now.Add(wait), e.NextAttemptAt, "attempt %d", attempt+1)
+		assert.Equal(t, "fake: unavailable", e.LastError)
+
+		n, _ := r.RunOnce(context.Background())
+		assert.Zero(t, n, "not due yet")
+		now = now.Add(wait)
+	}
+
+	flaky.fail = false
+	_, err := r.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, outbox.events[0].PublishedAt)
+	assert.Equal(t, []uint{1}, flaky.got)
+	assert.Len(t, ok.got, 5, "at least once: the healthy sink got every attempt")
+}
+
+func TestRelay_Run(t *testing.T) {
+	outbox := &memOutbox{}
+	outbox.add(models.EventPostCreated)
+	sink := &fakeSink{}
+	r := &Relay{Repo: outbox, Sinks: []Sink{sink}, Interval: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+	require.Eventually(t, func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return len(sink.got) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewWriterSink(&buf)
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, s.Publish(context.Background(), models.OutboxEvent{
+		ID: 7, Type: models.EventPostCreated, TenantID: 1, PostID: 3, Data: json.RawMessage(`{"id":3}`), OccurredAt: at, Attempts: 2,
+	}))
+	assert.JSONEq(t, `{"id":7,"type":"post.created","post_id":3,"tenant_id":1,"data":{"id":3},"occurred_at":"2026-01-02T03:04:05Z"}`, buf.String())
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusNoContent
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink(srv.URL, nil)
+	e := models.OutboxEvent{ID: 7, Type: models.EventPostDeleted, PostID: 3, Data: json.RawMessage(`{"id":3}`)}
+	require.NoError(t, s.Publish(context.Background(), e))
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "7", got.Header.Get(HeaderEventID))
+	assert.Equal(t, "post.deleted", got.Header.Get(HeaderEventType))
+	assert.Contains(t, string(body), `"data":{"id":3}`)
+
+	status = http.StatusInternalServerError
+	assert.ErrorContains(t, s.Publish(context.Background(), e), "500")
+}
+
+// fakeNATS speaks enough of the NATS protocol for a client to connect and
+// publish messages with headers.
+type fakeNATS struct {
+	ln  net.Listener
+	mu  sync.Mutex
+	got []string // "subject msg-id payload"
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeNATS{ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	_, _ = io.WriteString(conn, `INFO {"server_id":"fake","version":"2.10.0","headers":true,"max_payload":1048576,"proto":1}`+"\r\n")
+	rd := bufio.NewReader(conn)
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			_, _ = io.WriteString(conn, "PONG\r\n")
+		case "HPUB":
+			headerLen, _ := strconv.Atoi(fields[len(fields)-2])
+			total, _ := strconv.Atoi(fields[len(fields)-1])
+			msg := make([]byte, total+2)
+			if _, err := io.ReadFull(rd, msg); err != nil {
+				return
+			}
+			hdr, payload := string(msg[:headerLen]), string(msg[headerLen:total])
+			id := ""
+			for _, h := range strings.Split(hdr, "\r\n") {
+				if v, ok := strings.CutPrefix(h, nats.MsgIdHdr+": "); ok {
+					id = v
+				}
+			}
+			f.mu.Lock()
+			f.got = append(f.got, fields[1]+" "+id+" "+payload)
+			f.mu.Unlock()
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	srv := newFakeNATS(t)
+	conn, err := nats.Connect("nats://"+srv.ln.Addr().String(), nats.Timeout(time.Second))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := NewNATSSink(conn, "blog")
+	require.NoError(t, s.Publish(context.Background(), models.OutboxEvent{ID: 9, Type: models.EventPostUpdated, Data: json.RawMessage(`{}`)}))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	require.Len(t, srv.got, 1)
+	assert.True(t, strings.HasPrefix(srv.got[0], "blog.post.updated 9 {"), srv.got[0])
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"example/poll"
+	"example/repo"
+	"example/tenant"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Relay publishes outbox events to its sinks. Events are delivered at least
+// once: an event is retried, on every sink, until all of them accept it, so
+// a sink may see it again after another one failed. Several relays can run
+// against the same database; each event is leased to one of them at a time.
+type Relay struct {
+	Repo  repo.Repository
+	Sinks []Sink
+
+	// BatchSize is the number of events claimed at a time.
+	BatchSize int
+	// Interval is how long to wait before polling again once the outbox is
+	// empty.
+	Interval time.Duration
+	// Lease is how long claimed events are hidden from other relays. It must
+	// exceed the time taken to publish a batch.
+	Lease time.Duration
+	// MinBackoff and MaxBackoff bound the delay before retrying a failed
+	// event, which doubles with every attempt.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Retention is how long published events are kept. Zero keeps them.
+	Retention time.Duration
+
+	now func() time.Time
+}
+
+// Defaults for a zero-valued Relay field.
+const (
+	DefaultBatchSize  = 100
+	DefaultInterval   = time.Second
+	DefaultLease      = time.Minute
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 10 * time.Minute
+)
+
+// Run relays events until ctx is cancelled. The relay serves every blog.
+func (r *Relay) Run(ctx context.Context) error {
+	ctx = tenant.AllTenants(ctx)
+	r.defaults()
+	p := poll.Poller{
+		Name: "outbox relay", Batch: r.RunOnce, BatchSize: r.BatchSize, Interval: r.Interval,
+		Purge: r.Repo.PurgeOutboxEvents, Retention: r.Retention, Now: r.now,
+	}
+	p.Run(ctx)
+	return nil
+}
+
+// RunOnce claims one batch of due events and publishes them, returning how
+// many were claimed.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	ctx = tenant.AllTenants(ctx)
+	r.defaults()
+	events, err := r.Repo.ClaimOutboxEvents(ctx, r.now(), r.Lease, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, e := range events {
+		if err := r.publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(events), errors.Join(errs...)
+}
+
+// publish sends e to every sink and records the outcome. Only a failure to
+// record it is returned; a failed delivery is scheduled for a retry.
+func (r *Relay) publish(ctx context.Context, e models.OutboxEvent) error {
+	for _, sink := range r.Sinks {
+		if err := sink.Publish(ctx, e); err != nil {
+			next := r.now().Add(poll.Backoff(r.MinBackoff, r.MaxBackoff, e.Attempts+1))
+			slog.WarnContext(ctx, "unable to publish event", "event_id", e.ID, "type", e.Type,
+				"sink", sink.Name(), "attempt", e.Attempts+1, "retry_at", next, "error", err)
+			msg := fmt.Sprintf("%s: %v", sink.Name(), err)
+			return r.Repo.RetryOutboxEvent(context.WithoutCancel(ctx), e.ID, next, msg)
+		}
+	}
+	return r.Repo.MarkOutboxEventPublished(context.WithoutCancel(ctx), e.ID, r.now())
+}
+
+func (r *Relay) defaults() {
+	if r.BatchSize <= 0 {
+		r.BatchSize = DefaultBatchSize
+	}
+	if r.Interval <= 0 {
+		r.Interval = DefaultInterval
+	}
+	if r.Lease <= 0 {
+		r.Lease = DefaultLease
+	}
+	if r.MinBackoff <= 0 {
+		r.MinBackoff = DefaultMinBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = DefaultMaxBackoff
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+}
@@ -0,0 +1,134 @@
+// Package events relays the domain events written to the outbox to the
+// systems that react to them.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"example/models"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Sink is somewhere events are published. Publish must only return nil once
+// the event has been accepted; the relay retries it otherwise.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e models.OutboxEvent) error
+}
+
+// writerSink writes events as JSON lines.
+type writerSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterSink returns a Sink writing one JSON object per line to w, such as
+// os.Stdout.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{enc: json.NewEncoder(w)}
+}
+
+func (s *writerSink) Name() string { return "stdout" }
+
+func (s *writerSink) Publish(_ context.Context, e models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// Webhook event headers.
+const (
+	HeaderEventID   = "X-Event-ID"
+	HeaderEventType = "X-Event-Type"
+)
+
+// webhookSink POSTs events to a URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a Sink POSTing each event as JSON to url. Any 2xx
+// response accepts the event. Receivers should deduplicate on X-Event-ID,
+// since events may be delivered more than once.
+func NewWebhookSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &webhookSink{url: url, client: client}
+}
+
+func (s *webhookSink) Name() string { return "webhook" }
+
+func (s *webhookSink) Publish(ctx context.Context, e models.OutboxEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, strconv.FormatUint(uint64(e.ID), 10))
+	req.Header.Set(HeaderEventType, e.Type)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// natsFlushTimeout bounds the wait for the server when the caller set no
+// deadline.
+const natsFlushTimeout = 10 * time.Second
+
+// natsSink publishes events to a NATS server.
+type natsSink struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSSink returns a Sink publishing each event on the subject
+// "<prefix>.<type>", such as "blog.post.created". The event ID is sent as
+// Nats-Msg-Id, so JetStream streams drop redeliveries.
+func NewNATSSink(conn *nats.Conn, prefix string) Sink {
+	return &natsSink{conn: conn, prefix: prefix}
+}
+
+func (s *natsSink) Name() string { return "nats" }
+
+// Publish waits for the server to acknowledge the connection is flushed, so
+// that a lost connection is noticed and the event retried.
+func (s *natsSink) Publish(ctx context.Context, e models.OutboxEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(s.prefix + "." + e.Type)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatUint(uint64(e.ID), 10))
+	if err := s.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, natsFlushTimeout)
+		defer cancel()
+	}
+	return s.conn.FlushWithContext(ctx)
+}
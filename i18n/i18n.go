@@ -0,0 +1,98 @@
+// Package i18n picks the language posts are served in. A client names the
+// languages it prefers, most preferred first; a post is served in the first
+// of them it has a translation in, trying each language's parents too, such
+// as de for de-AT, and in its own language when none matches.
+package i18n
+
+import (
+	"example/models"
+	"slices"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// wildcard is what the * of Accept-Language parses as.
+var wildcard = language.MustParse("mul")
+
+// Preferences returns the languages listed by an Accept-Language header, in
+// order of preference. Wildcards and invalid entries are dropped.
+func Preferences(acceptLanguage string) []string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil
+	}
+	prefs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag != language.Und && tag != wildcard {
+			prefs = append(prefs, tag.String())
+		}
+	}
+	return prefs
+}
+
+// Chain returns the languages to try, in order, for a client preferring
+// prefs: each of them followed by its parents, without repeats. Invalid
+// language tags are dropped.
+func Chain(prefs []string) []string {
+	var chain []string
+	for _, p := range prefs {
+		tag, err := language.Parse(p)
+		for err == nil && tag != language.Und {
+			if l := tag.String(); !slices.Contains(chain, l) {
+				chain = append(chain, l)
+			}
+			tag = tag.Parent()
+		}
+	}
+	return chain
+}
+
+// Localize replaces the title, description and body of post with those of
+// its translation in the first language of chain it has one in, and lists
+// the languages it is available in. translations are the post's.
+func Localize(post *models.BlogPost, translations []models.PostTranslation, chain []string) {
+	original := post.Locale
+	if original == "" {
+		original = models.DefaultPostLocale
+	}
+	post.Translations = []models.PostLocale{{Locale: original, Original: true, UpdatedAt: post.UpdatedAt}}
+	for _, t := range translations {
+		post.Translations = append(post.Translations, models.PostLocale{Locale: t.Locale, UpdatedAt: t.UpdatedAt})
+	}
+
+	for _, l := range chain {
+		if l == original {
+			return
+		}
+		i := slices.IndexFunc(translations, func(t models.PostTranslation) bool { return t.Locale == l })
+		if i >= 0 {
+			t := translations[i]
+			post.Title, post.Description, post.Body, post.Locale = t.Title, t.Description, t.Body, t.Locale
+			return
+		}
+	}
+}
+
+// Alternates returns the hreflang links of a localised post, with href
+// giving the URL of its version in a language, or its default version for
+// an empty one.
+func Alternates(post models.BlogPost, href func(locale string) string) []models.Alternate {
+	alts := make([]models.Alternate, 0, len(post.Translations)+1)
+	for _, l := range post.Translations {
+		alts = append(alts, models.Alternate{Hreflang: l.Locale, Href: href(l.Locale)})
+	}
+	return append(alts, models.Alternate{Hreflang: "x-default", Href: href("")})
+}
+
+// LastMod returns when a localised post or any of its translations last
+// changed.
+func LastMod(post models.BlogPost) time.Time {
+	last := post.UpdatedAt
+	for _, l := range post.Translations {
+		if l.UpdatedAt.After(last) {
+			last = l.UpdatedAt
+		}
+	}
+	return last
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"example/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferences(t *testing.T) {
+	assert.Equal(t, []string{"fr-CH", "fr", "en"}, Preferences("fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5"))
+	assert.Equal(t, []string{"de", "en"}, Preferences("en;q=0.5, de"), "ordered by weight")
+	assert.Empty(t, Preferences(""))
+	assert.Empty(t, Preferences("!!"))
+}
+
+func TestChain(t *testing.T) {
+	assert.Equal(t, []string{"de-AT", "de", "en"}, Chain([]string{"de-AT", "de", "en"}))
+	assert.Equal(t, []string{"zh-Hant-TW", "zh-Hant", "en"}, Chain([]string{"zh-Hant-TW", "invalid tag", "en"}))
+	assert.Empty(t, Chain(nil))
+}
+
+func TestLocalize(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	translations := []models.PostTranslation{
+		{PostID: 1, Locale: "de", Title: "Hallo", Description: "Gruß", Body: "Text", UpdatedAt: now.Add(time.Hour)},
+		{PostID: 1, Locale: "pt-BR", Title: "Olá", Body: "Texto", UpdatedAt: now},
+	}
+	post := func() models.BlogPost {
+		return models.BlogPost{ID: 1, Title: "Hello", Description: "Greeting", Body: "Text", Locale: "en", UpdatedAt: now}
+	}
+
+	tests := []struct {
+		name  string
+		prefs []string
+		title string
+	}{
+		{"parent of a preference", []string{"de-AT"}, "Hallo"},
+		{"first match wins", []string{"pt-BR", "de"}, "Olá"},
+		{"the original is a match", []string{"en", "de"}, "Hello"},
+		{"no match", []string{"ja"}, "Hello"},
+		{"no preferences", nil, "Hello"},
+		{"no fallback from pt to pt-BR", []string{"pt"}, "Hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := post()
+			Localize(&p, translations, Chain(tt.prefs))
+			assert.Equal(t, tt.title, p.Title)
+			assert.Equal(t, []models.PostLocale{
+				{Locale: "en", Original: true, UpdatedAt: now},
+				{Locale: "de", UpdatedAt: now.Add(time.Hour)},
+				{Locale: "pt-BR", UpdatedAt: now},
+			}, p.Translations)
+		})
+	}
+
+	p := post()
+	Localize(&p, translations, []string{"de"})
+	assert.Equal(t, "Gruß", p.Description)
+	assert.Equal(t, "de", p.Locale)
+	assert.Equal(t, now.Add(time.Hour), LastMod(p))
+	assert.Equal(t, []models.Alternate{
+		{Hreflang: "en", Href: "/p?lang=en"},
+		{Hreflang: "de", Href: "/p?lang=de"},
+		{Hreflang: "pt-BR", Href: "/p?lang=pt-BR"},
+		{Hreflang: "x-default", Href: "/p"},
+	}, Alternates(p, func(l string) string {
+		if l == "" {
+			return "/p"
+		}
+		return "/p?lang=" + l
+	}))
+}
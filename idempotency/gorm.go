@@ -0,0 +1,112 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// purgeInterval is how often expired rows are deleted.
+const purgeInterval = 10 * time.Minute
+
+// gormStore keeps records in the idempotency_keys table, shared by every
+// replica.
+type gormStore struct {
+	db  *gorm.DB
+	now func() time.Time
+
+	mu     sync.Mutex
+	purged time.Time
+}
+
+// NewGormStore returns a Store backed by the database.
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db, now: time.Now}
+}
+
+func (s *gormStore) Claim(ctx context.Context, key, fingerprint string, lock time.Duration) (*Record, error) {
+	now := s.now()
+	s.purge(ctx, now)
+	db := s.db.WithContext(ctx)
+
+	// A row can be released or expire between the statements below, so the
+	// claim is retried a few times before giving up.
+	for range 3 {
+		row := models.IdempotencyKey{Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(lock)}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		err := db.Where(&models.IdempotencyKey{Key: key}).Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if now.Before(existing.ExpiresAt) {
+			return toRecord(existing), nil
+		}
+
+		// Expired: take it over, unless someone else just did.
+		res = db.Model(&models.IdempotencyKey{}).
+			Where("key = ? AND expires_at = ?", key, existing.ExpiresAt).
+			Updates(map[string]any{
+				"fingerprint": fingerprint, "status": 0, "header": nil, "body": nil,
+				"created_at": now, "expires_at": now.Add(lock),
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+	}
+	return nil, ErrContended
+}
+
+func (s *gormStore) Save(ctx context.Context, key string, resp Response, ttl time.Duration) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyKey{Key: key}).
+		Select("status", "header", "body", "expires_at").
+		Updates(models.IdempotencyKey{
+			Status:    resp.Status,
+			Header:    resp.Header,
+			Body:      resp.Body,
+			ExpiresAt: s.now().Add(ttl),
+		}).Error
+}
+
+func (s *gormStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Delete(&models.IdempotencyKey{Key: key}).Error
+}
+
+// purge deletes expired rows every purgeInterval. Failures are left for the
+// next attempt.
+func (s *gormStore) purge(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.purged) < purgeInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.purged = now
+	s.mu.Unlock()
+	s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+}
+
+func toRecord(row models.IdempotencyKey) *Record {
+	rec := &Record{Fingerprint: row.Fingerprint}
+	if row.Status != 0 {
+		rec.Response = &Response{Status: row.Status, Header: row.Header, Body: row.Body}
+	}
+	return rec
+}
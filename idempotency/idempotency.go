@@ -0,0 +1,54 @@
+// Package idempotency stores the responses to requests made with an
+// Idempotency-Key header, so that clients can safely retry them.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Response is a stored response, replayed to retries.
+type Response struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+// Record is what a store holds for a key.
+type Record struct {
+	// Fingerprint identifies the request the key was first used for.
+	Fingerprint string
+	// Response is nil while the first request is still being handled.
+	Response *Response
+}
+
+// Store holds records until they expire.
+type Store interface {
+	// Claim reserves key for a request with the given fingerprint until lock
+	// has passed. If the key is already held, its record is returned instead
+	// and nothing changes.
+	Claim(ctx context.Context, key, fingerprint string, lock time.Duration) (*Record, error)
+	// Save stores the response to the request holding key, for ttl.
+	Save(ctx context.Context, key string, resp Response, ttl time.Duration) error
+	// Release gives up key so that the request can be retried from scratch.
+	Release(ctx context.Context, key string) error
+}
+
+// ErrContended is returned when a key keeps changing hands while being
+// claimed.
+var ErrContended = errors.New("idempotency key is contended")
+
+// Fingerprint identifies a request by its method, URL and body, so that a key
+// reused for a different request can be told apart from a retry.
+func Fingerprint(method, url string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(url))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,182 @@
+package idempotency
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	dbMock "example/database/mocks"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint("POST", "/api/blog-post", []byte(`{"title":"a"}`))
+	assert.Equal(t, a, Fingerprint("POST", "/api/blog-post", []byte(`{"title":"a"}`)))
+	assert.NotEqual(t, a, Fingerprint("POST", "/api/blog-post", []byte(`{"title":"b"}`)))
+	assert.NotEqual(t, a, Fingerprint("PATCH", "/api/blog-post", []byte(`{"title":"a"}`)))
+	assert.NotEqual(t, Fingerprint("POST", "/a", []byte("b")), Fingerprint("POST", "/ab", nil))
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory().(*memory)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	rec, err := s.Claim(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, rec, "claimed")
+
+	rec, err = s.Claim(ctx, "k", "other", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, "fp", rec.Fingerprint)
+	assert.Nil(t, rec.Response, "in flight")
+
+	require.NoError(t, s.Save(ctx, "k", Response{Status: 201, Body: []byte("ok")}, time.Hour))
+	rec, _ = s.Claim(ctx, "k", "fp", time.Minute)
+	require.NotNil(t, rec.Response)
+	assert.Equal(t, 201, rec.Response.Status)
+
+	now = now.Add(time.Hour)
+	rec, _ = s.Claim(ctx, "k", "fp2", time.Minute)
+	assert.Nil(t, rec, "expired records are claimed again")
+
+	require.NoError(t, s.Release(ctx, "k"))
+	rec, _ = s.Claim(ctx, "k", "fp3", time.Minute)
+	assert.Nil(t, rec, "released")
+}
+
+func TestMemory_lockExpires(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory().(*memory)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	_, _ = s.Claim(ctx, "k", "fp", time.Minute)
+	now = now.Add(time.Minute)
+	rec, err := s.Claim(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, rec, "a request that never completed doesn't hold the key forever")
+}
+
+func TestGormStore_Claim(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	insert := regexp.QuoteMeta(`INSERT INTO "idempotency_keys" ("key","fingerprint","status","header","body","created_at","expires_at") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT DO NOTHING`)
+	selectKey := regexp.QuoteMeta(`SELECT * FROM "idempotency_keys" WHERE "idempotency_keys"."key" = $1 LIMIT $2`)
+	columns := []string{"key", "fingerprint", "status", "header", "body", "created_at", "expires_at"}
+
+	tests := []struct {
+		name   string
+		expect func(m sqlmock.Sqlmock)
+		want   *Record
+	}{
+		{
+			name: "new key",
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "completed request",
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectCommit()
+				m.ExpectQuery(selectKey).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("k", "fp", 201, `{"Location":"/posts/1"}`, []byte("{}"), now, now.Add(time.Hour)))
+			},
+			want: &Record{Fingerprint: "fp", Response: &Response{
+				Status: 201, Header: map[string]string{"Location": "/posts/1"}, Body: []byte("{}"),
+			}},
+		},
+		{
+			name: "request in flight",
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectCommit()
+				m.ExpectQuery(selectKey).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("k", "fp", 0, nil, nil, now, now.Add(time.Minute)))
+			},
+			want: &Record{Fingerprint: "fp"},
+		},
+		{
+			name: "expired record is taken over",
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectCommit()
+				m.ExpectQuery(selectKey).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("k", "old", 201, nil, nil, now.Add(-2*time.Hour), now.Add(-time.Hour)))
+				m.ExpectBegin()
+				m.ExpectExec(regexp.QuoteMeta(`UPDATE "idempotency_keys" SET`)).WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbmock := dbMock.NewGormMock(t)
+			s := NewGormStore(db).(*gormStore)
+			s.now = func() time.Time { return now }
+			s.purged = now
+
+			tt.expect(dbmock)
+			rec, err := s.Claim(context.Background(), "k", "fp", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rec)
+			assert.NoError(t, dbmock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGormStore_purgesExpiredRows(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	s := NewGormStore(db)
+
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "idempotency_keys" WHERE expires_at < $1`)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	dbmock.ExpectCommit()
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "idempotency_keys"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+	// Only once per purgeInterval.
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "idempotency_keys"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	for _, key := range []string{"a", "b"} {
+		_, err := s.Claim(context.Background(), key, "fp", time.Minute)
+		require.NoError(t, err)
+	}
+	assert.NoError(t, dbmock.ExpectationsWereMet())
+}
+
+func TestGormStore_SaveAndRelease(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	s := NewGormStore(db)
+
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "idempotency_keys" SET "status"=$1,"header"=$2,"body"=$3,"expires_at"=$4 WHERE "key" = $5`)).
+		WithArgs(201, `{"Content-Type":"application/json"}`, []byte("{}"), sqlmock.AnyArg(), "k").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "idempotency_keys" WHERE "idempotency_keys"."key" = $1`)).
+		WithArgs("k").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	resp := Response{Status: 201, Header: map[string]string{"Content-Type": "application/json"}, Body: []byte("{}")}
+	require.NoError(t, s.Save(context.Background(), "k", resp, time.Hour))
+	require.NoError(t, s.Release(context.Background(), "k"))
+	assert.NoError(t, dbmock.ExpectationsWereMet())
+}
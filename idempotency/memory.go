@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired records are dropped.
+const sweepInterval = time.Minute
+
+type memoryRecord struct {
+	Record
+	expires time.Time
+}
+
+// memory keeps records in process. Retries reaching another replica aren't
+// recognised.
+type memory struct {
+	mu      sync.Mutex
+	records map[string]*memoryRecord
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemory returns a Store local to the process.
+func NewMemory() Store {
+	return &memory{records: map[string]*memoryRecord{}, now: time.Now}
+}
+
+func (m *memory) Claim(_ context.Context, key, fingerprint string, lock time.Duration) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+
+	if r, ok := m.records[key]; ok && now.Before(r.expires) {
+		rec := r.Record
+		return &rec, nil
+	}
+	m.records[key] = &memoryRecord{Record: Record{Fingerprint: fingerprint}, expires: now.Add(lock)}
+	return nil, nil
+}
+
+func (m *memory) Save(_ context.Context, key string, resp Response, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.records[key]
+	if !ok {
+		r = &memoryRecord{}
+		m.records[key] = r
+	}
+	r.Response = &resp
+	r.expires = m.now().Add(ttl)
+	return nil
+}
+
+func (m *memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+func (m *memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+	m.swept = now
+	for key, r := range m.records {
+		if !now.Before(r.expires) {
+			delete(m.records, key)
+		}
+	}
+}
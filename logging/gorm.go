@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger routes GORM's logs through slog. Queries slower than
+// SlowThreshold are logged as warnings; every other query is logged at debug.
+type GormLogger struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+// NewGormLogger returns a GORM logger that writes to l.
+func NewGormLogger(l *slog.Logger, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{Logger: l, SlowThreshold: slowThreshold}
+}
+
+// LogMode is a no-op: the slog handler's level decides what gets written.
+func (g *GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return g
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	g.Logger.InfoContext(ctx, msg, "args", args)
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	g.Logger.WarnContext(ctx, msg, "args", args)
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	g.Logger.ErrorContext(ctx, msg, "args", args)
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		g.Logger.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "elapsed", elapsed, "error", err)
+	case g.SlowThreshold > 0 && elapsed > g.SlowThreshold:
+		sql, rows := fc()
+		g.Logger.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "elapsed", elapsed, "threshold", g.SlowThreshold)
+	case g.Logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		g.Logger.DebugContext(ctx, "query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "warn", "json")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "dropped")
+	logger.WarnContext(ctx, "kept", "post_id", 7)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record), "only the warning should be written, as a single JSON line")
+	assert.Equal(t, "kept", record["msg"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, float64(7), record["post_id"])
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelError, ParseLevel("ERROR"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
+
+func TestGormLogger(t *testing.T) {
+	tests := []struct {
+		description string
+		elapsed     time.Duration
+		err         error
+		expected    string
+	}{
+		{description: "fast query is not logged at info", elapsed: time.Millisecond, expected: ""},
+		{description: "slow query", elapsed: time.Second, expected: "slow query"},
+		{description: "failed query", elapsed: time.Millisecond, err: errors.New("boom"), expected: "query failed"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var buf bytes.Buffer
+			g := NewGormLogger(New(&buf, "info", "json"), 100*time.Millisecond)
+			g.Trace(context.Background(), time.Now().Add(-test.elapsed), func() (string, int64) {
+				return `SELECT * FROM "blog_posts"`, 1
+			}, test.err)
+
+			if test.expected == "" {
+				assert.Empty(t, buf.String())
+				return
+			}
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, test.expected, record["msg"])
+			assert.Equal(t, `SELECT * FROM "blog_posts"`, record["sql"])
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every collector exposed on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	serviceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "service_method_duration_seconds",
+		Help:    "Latency of service.Service calls, by method and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "outcome"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Latency of GORM queries, by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	postsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blog_posts_created_total",
+		Help: "Number of blog posts created.",
+	})
+
+	postsUpdated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blog_posts_updated_total",
+		Help: "Number of blog posts updated.",
+	})
+
+	postsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blog_posts_deleted_total",
+		Help: "Number of blog posts deleted.",
+	})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Number of response cache lookups, by entity and result (hit or miss).",
+	}, []string{"entity", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		serviceDuration,
+		dbQueryDuration,
+		postsCreated,
+		postsUpdated,
+		postsDeleted,
+		cacheRequests,
+	)
+}
+
+// ObserveCache counts a response cache lookup.
+func ObserveCache(entity string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(entity, result).Inc()
+}
+
+// Handler serves the contents of Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ListenAndServe exposes /metrics on its own port so it can be kept off the
+// public listener.
+func ListenAndServe(port string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return http.ListenAndServe(":"+port, mux)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	dbMock "example/database/mocks"
+	"example/mocks"
+	"example/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/blog-post/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/blog-post/:id", "200"))
+	for _, id := range []string{"1", "2", "3"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/blog-post/"+id, nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	after := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/blog-post/:id", "200"))
+	assert.Equal(t, float64(3), after-before, "requests should be labelled by route, not path")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")))
+}
+
+func TestService(t *testing.T) {
+	next := new(mocks.Service)
+	next.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil).Once()
+	next.On("Create", mock.Anything, mock.Anything).Return(uint(0), errors.New("boom")).Once()
+	next.On("Delete", mock.Anything, uint(1)).Return(nil).Once()
+	s := NewService(next)
+
+	created := testutil.ToFloat64(postsCreated)
+	deleted := testutil.ToFloat64(postsDeleted)
+
+	_, err := s.Create(context.Background(), models.CreateBlogRequest{Title: "t", Description: "d", Body: "b"})
+	require.NoError(t, err)
+	_, err = s.Create(context.Background(), models.CreateBlogRequest{Title: "t", Description: "d", Body: "b"})
+	require.Error(t, err)
+	require.NoError(t, s.Delete(context.Background(), 1))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(postsCreated)-created, "failed creates must not be counted")
+	assert.Equal(t, float64(1), testutil.ToFloat64(postsDeleted)-deleted)
+	assert.Equal(t, 3, testutil.CollectAndCount(serviceDuration, "service_method_duration_seconds"))
+	next.AssertExpectations(t)
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	require.NoError(t, db.Use(GormPlugin{DBName: "test"}))
+
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	var posts []models.BlogPost
+	require.NoError(t, db.Find(&posts).Error)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(dbQueryDuration, "db_query_duration_seconds"))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `db_query_duration_seconds_count{operation="query",table="blog_posts"} 1`))
+	assert.True(t, strings.Contains(body, `go_sql_open_connections{db_name="test"}`))
+}
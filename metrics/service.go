@@ -0,0 +1,253 @@
+package metrics
+
+import (
+	"context"
+	"example/models"
+	"example/seed"
+	"example/service"
+	"example/transfer"
+	"example/wxr"
+	"io"
+	"time"
+)
+
+// instrumentedService times every call to the wrapped service.Service and
+// counts successful mutations.
+type instrumentedService struct {
+	next service.Service
+}
+
+// NewService decorates next with per-method timings and business counters.
+func NewService(next service.Service) service.Service {
+	return &instrumentedService{next: next}
+}
+
+func observe(method string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	serviceDuration.WithLabelValues(method, outcome).Observe(time.Since(start).Seconds())
+}
+
+func (s *instrumentedService) Create(ctx context.Context, req models.CreateBlogRequest) (uint, error) {
+	start := time.Now()
+	id, err := s.next.Create(ctx, req)
+	observe("Create", start, err)
+	if err == nil {
+		postsCreated.Inc()
+	}
+	return id, err
+}
+
+func (s *instrumentedService) GetAll(ctx context.Context) ([]models.BlogPost, error) {
+	start := time.Now()
+	posts, err := s.next.GetAll(ctx)
+	observe("GetAll", start, err)
+	return posts, err
+}
+
+func (s *instrumentedService) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	start := time.Now()
+	post, err := s.next.GetByID(ctx, id)
+	observe("GetByID", start, err)
+	return post, err
+}
+
+func (s *instrumentedService) Update(ctx context.Context, id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error) {
+	start := time.Now()
+	post, err := s.next.Update(ctx, id, req)
+	observe("Update", start, err)
+	if err == nil {
+		postsUpdated.Inc()
+	}
+	return post, err
+}
+
+func (s *instrumentedService) Delete(ctx context.Context, id uint) error {
+	start := time.Now()
+	err := s.next.Delete(ctx, id)
+	observe("Delete", start, err)
+	if err == nil {
+		postsDeleted.Inc()
+	}
+	return err
+}
+
+// succeeded counts the outcomes without an error.
+func succeeded(out []models.BulkOutcome) int {
+	n := 0
+	for _, o := range out {
+		if o.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *instrumentedService) CreateBatch(ctx context.Context, reqs []models.CreateBlogRequest, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	start := time.Now()
+	out, err := s.next.CreateBatch(ctx, reqs, mode)
+	observe("CreateBatch", start, err)
+	postsCreated.Add(float64(succeeded(out)))
+	return out, err
+}
+
+func (s *instrumentedService) UpdateBatch(ctx context.Context, items []models.BulkUpdateItem, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	start := time.Now()
+	out, err := s.next.UpdateBatch(ctx, items, mode)
+	observe("UpdateBatch", start, err)
+	postsUpdated.Add(float64(succeeded(out)))
+	return out, err
+}
+
+func (s *instrumentedService) DeleteBatch(ctx context.Context, ids []uint, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	start := time.Now()
+	out, err := s.next.DeleteBatch(ctx, ids, mode)
+	observe("DeleteBatch", start, err)
+	postsDeleted.Add(float64(succeeded(out)))
+	return out, err
+}
+
+func (s *instrumentedService) Export(ctx context.Context, w io.Writer, f transfer.Format) (int, error) {
+	start := time.Now()
+	n, err := s.next.Export(ctx, w, f)
+	observe("Export", start, err)
+	return n, err
+}
+
+func (s *instrumentedService) Import(ctx context.Context, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error) {
+	start := time.Now()
+	report, err := s.next.Import(ctx, rd, f, opts)
+	observe("Import", start, err)
+	if err == nil && !report.DryRun {
+		postsCreated.Add(float64(report.Created))
+		postsUpdated.Add(float64(report.Updated))
+	}
+	return report, err
+}
+
+func (s *instrumentedService) ImportWordPress(ctx context.Context, rd io.Reader, name string, opts wxr.Options) (*wxr.Report, error) {
+	start := time.Now()
+	report, err := s.next.ImportWordPress(ctx, rd, name, opts)
+	observe("ImportWordPress", start, err)
+	if report != nil {
+		postsCreated.Add(float64(report.Imported))
+	}
+	return report, err
+}
+
+func (s *instrumentedService) Seed(ctx context.Context, ds *seed.Dataset) (*seed.Report, error) {
+	start := time.Now()
+	report, err := s.next.Seed(ctx, ds)
+	observe("Seed", start, err)
+	if err == nil {
+		postsCreated.Add(float64(report.Posts))
+	}
+	return report, err
+}
+
+func (s *instrumentedService) GetLock(ctx context.Context, id uint) (*models.PostLock, error) {
+	start := time.Now()
+	lock, err := s.next.GetLock(ctx, id)
+	observe("GetLock", start, err)
+	return lock, err
+}
+
+func (s *instrumentedService) Lock(ctx context.Context, id uint, ttl time.Duration) (*models.PostLock, error) {
+	start := time.Now()
+	lock, err := s.next.Lock(ctx, id, ttl)
+	observe("Lock", start, err)
+	return lock, err
+}
+
+func (s *instrumentedService) Unlock(ctx context.Context, id uint, force bool) error {
+	start := time.Now()
+	err := s.next.Unlock(ctx, id, force)
+	observe("Unlock", start, err)
+	return err
+}
+
+func (s *instrumentedService) GetTranslations(ctx context.Context, id uint) ([]models.PostTranslation, error) {
+	start := time.Now()
+	translations, err := s.next.GetTranslations(ctx, id)
+	observe("GetTranslations", start, err)
+	return translations, err
+}
+
+func (s *instrumentedService) PutTranslation(ctx context.Context, id uint, locale string, req models.TranslationRequest) (*models.PostTranslation, bool, error) {
+	start := time.Now()
+	t, created, err := s.next.PutTranslation(ctx, id, locale, req)
+	observe("PutTranslation", start, err)
+	return t, created, err
+}
+
+func (s *instrumentedService) UpdateTranslation(ctx context.Context, id uint, locale string, req *models.UpdateTranslationRequest) (*models.PostTranslation, error) {
+	start := time.Now()
+	t, err := s.next.UpdateTranslation(ctx, id, locale, req)
+	observe("UpdateTranslation", start, err)
+	return t, err
+}
+
+func (s *instrumentedService) DeleteTranslation(ctx context.Context, id uint, locale string) error {
+	start := time.Now()
+	err := s.next.DeleteTranslation(ctx, id, locale)
+	observe("DeleteTranslation", start, err)
+	return err
+}
+
+func (s *instrumentedService) Localize(ctx context.Context, posts []models.BlogPost, prefs []string) error {
+	start := time.Now()
+	err := s.next.Localize(ctx, posts, prefs)
+	observe("Localize", start, err)
+	return err
+}
+
+func (s *instrumentedService) ListPosts(ctx context.Context, f models.PostFilter) (*models.PostList, error) {
+	start := time.Now()
+	list, err := s.next.ListPosts(ctx, f)
+	observe("ListPosts", start, err)
+	return list, err
+}
+
+func (s *instrumentedService) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	start := time.Now()
+	tag, err := s.next.GetTag(ctx, slug)
+	observe("GetTag", start, err)
+	return tag, err
+}
+
+func (s *instrumentedService) GetRevisions(ctx context.Context, id uint) ([]models.PostRevision, error) {
+	start := time.Now()
+	revs, err := s.next.GetRevisions(ctx, id)
+	observe("GetRevisions", start, err)
+	return revs, err
+}
+
+func (s *instrumentedService) RestoreRevision(ctx context.Context, id, revisionID uint) (*models.BlogPost, error) {
+	start := time.Now()
+	post, err := s.next.RestoreRevision(ctx, id, revisionID)
+	observe("RestoreRevision", start, err)
+	return post, err
+}
+
+func (s *instrumentedService) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	start := time.Now()
+	posts, err := s.next.ListTrash(ctx)
+	observe("ListTrash", start, err)
+	return posts, err
+}
+
+func (s *instrumentedService) RestorePost(ctx context.Context, id uint) (*models.BlogPost, error) {
+	start := time.Now()
+	post, err := s.next.RestorePost(ctx, id)
+	observe("RestorePost", start, err)
+	return post, err
+}
+
+func (s *instrumentedService) PurgePost(ctx context.Context, id uint) error {
+	start := time.Now()
+	err := s.next.PurgePost(ctx, id)
+	observe("PurgePost", start, err)
+	return err
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"example/auth"
+	"example/models"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// VerifyFunc resolves an API key token to the stored key, as
+// auth.VerifyAPIKey does.
+type VerifyFunc func(ctx context.Context, token string) (*models.APIKey, error)
+
+const apiKeyLocal = "apikey"
+
+// Authenticate identifies callers presenting an API key as a bearer token.
+// Requests without one go through anonymously; requests with an invalid one
+// are rejected with 401. Since browsers can't set headers on WebSocket
+// requests, those may pass the key as the access_token parameter instead.
+func Authenticate(verify VerifyFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		switch {
+		case header == "" && websocket.IsWebSocketUpgrade(c) && c.Query("access_token") != "":
+			token = c.Query("access_token")
+		case header == "":
+			return c.Next()
+		case !ok:
+			return fiber.NewError(fiber.StatusUnauthorized, "expected a bearer token")
+		}
+		key, err := verify(c.UserContext(), strings.TrimSpace(token))
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		c.Locals(apiKeyLocal, key)
+		c.SetUserContext(auth.WithUser(c.UserContext(), &key.User))
+		return c.Next()
+	}
+}
+
+// APIKey returns the key the request was authenticated with, or nil for
+// anonymous requests.
+func APIKey(c *fiber.Ctx) *models.APIKey {
+	key, _ := c.Locals(apiKeyLocal).(*models.APIKey)
+	return key
+}
+
+// RequireRole only lets through requests authenticated with an API key whose
+// user has one of the roles. Anonymous requests are rejected with 401, others
+// with 403.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := APIKey(c)
+		if key == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "an API key is required")
+		}
+		if !slices.Contains(roles, key.User.Role) {
+			return fiber.NewError(fiber.StatusForbidden, "the API key's user may not do this")
+		}
+		return c.Next()
+	}
+}
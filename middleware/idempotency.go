@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"example/idempotency"
+	"example/tenant"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// HeaderIdempotencyKey carries the client's key for a POST or PATCH.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed from the store.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength caps keys; clients are expected to send UUIDs.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with a response. Others,
+// such as the request ID and rate limits, describe the retry itself.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation}
+
+// IdempotencyConfig configures the Idempotency middleware.
+type IdempotencyConfig struct {
+	Store idempotency.Store
+	// TTL is how long responses are replayed for.
+	TTL time.Duration
+	// Lock is how long a key stays reserved by a request in flight, in case
+	// it never completes. It should exceed the longest request timeout.
+	Lock time.Duration
+}
+
+// Idempotency makes POST and PATCH requests carrying an Idempotency-Key
+// header safe to retry: the first response is stored and replayed to later
+// requests with the same key and the same method, URL and body. Reusing a
+// key for a different request fails with 422, and a retry arriving while the
+// first request is in flight fails with 409. Server errors aren't stored, so
+// the request can be retried.
+//
+// Keys are scoped to the blog and the authenticated user or, for anonymous
+// callers, the client IP, so that one caller can't replay another's
+// response by guessing their key.
+func Idempotency(cfg IdempotencyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPatch {
+			return c.Next()
+		}
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return fiber.NewError(fiber.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		}
+
+		scope := "ip:" + c.IP()
+		if apiKey := APIKey(c); apiKey != nil {
+			scope = "user:" + strconv.FormatUint(uint64(apiKey.UserID), 10)
+		}
+		if t := tenant.From(c.UserContext()); t != nil {
+			scope = "tenant:" + strconv.FormatUint(uint64(t.ID), 10) + ":" + scope
+		}
+		key = scope + ":" + key
+		ctx := c.UserContext()
+		fingerprint := idempotency.Fingerprint(c.Method(), c.OriginalURL(), c.Body())
+
+		rec, err := cfg.Store.Claim(ctx, key, fingerprint, cfg.Lock)
+		if err != nil {
+			return err
+		}
+		if rec != nil {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				return fiber.NewError(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case rec.Response == nil:
+				return fiber.NewError(fiber.StatusConflict, "a request with this Idempotency-Key is still in progress")
+			}
+			for name, value := range rec.Response.Header {
+				c.Set(name, value)
+			}
+			c.Set(HeaderIdempotentReplayed, "true")
+			return c.Status(rec.Response.Status).Send(rec.Response.Body)
+		}
+
+		// The response is rendered here, errors included, so that it can be
+		// stored as the client sees it.
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				release(ctx, cfg.Store, key)
+				return err
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			release(ctx, cfg.Store, key)
+			return nil
+		}
+		resp := idempotency.Response{
+			Status: status,
+			Header: map[string]string{},
+			Body:   append([]byte(nil), c.Response().Body()...),
+		}
+		for _, name := range replayedHeaders {
+			if value := c.Response().Header.Peek(name); len(value) > 0 {
+				resp.Header[name] = string(value)
+			}
+		}
+		if err := cfg.Store.Save(context.WithoutCancel(ctx), key, resp, cfg.TTL); err != nil {
+			// The client has its response; a retry will be told to wait
+			// until the lock expires.
+			slog.ErrorContext(ctx, "unable to store idempotent response", "error", err)
+		}
+		return nil
+	}
+}
+
+func release(ctx context.Context, store idempotency.Store, key string) {
+	if err := store.Release(context.WithoutCancel(ctx), key); err != nil {
+		slog.ErrorContext(ctx, "unable to release idempotency key", "error", err)
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"example/controller"
+	"example/idempotency"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdempotentApp(handler fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(Authenticate(verifyTokens), Idempotency(IdempotencyConfig{
+		Store: idempotency.NewMemory(),
+		TTL:   time.Hour,
+		Lock:  time.Minute,
+	}))
+	app.Post("/posts", handler)
+	app.Get("/posts", handler)
+	return app
+}
+
+func post(t *testing.T, app *fiber.App, key, token, body string) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestIdempotency_replaysFirstResponse(t *testing.T) {
+	var created atomic.Int32
+	app := newIdempotentApp(func(c *fiber.Ctx) error {
+		id := created.Add(1)
+		c.Location("/posts/" + strconv.Itoa(int(id)))
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"id": id})
+	})
+
+	first := post(t, app, "k1", "", `{"title":"a"}`)
+	require.Equal(t, http.StatusCreated, first.StatusCode)
+	firstBody, _ := io.ReadAll(first.Body)
+
+	retry := post(t, app, "k1", "", `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, retry.StatusCode)
+	retryBody, _ := io.ReadAll(retry.Body)
+	assert.Equal(t, string(firstBody), string(retryBody))
+	assert.Equal(t, "/posts/1", retry.Header.Get(fiber.HeaderLocation))
+	assert.Equal(t, fiber.MIMEApplicationJSON, retry.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, "true", retry.Header.Get(HeaderIdempotentReplayed))
+	assert.Empty(t, first.Header.Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), created.Load(), "handler ran once")
+
+	assert.Equal(t, http.StatusCreated, post(t, app, "k2", "", `{"title":"a"}`).StatusCode)
+	assert.Equal(t, http.StatusCreated, post(t, app, "", "", `{"title":"a"}`).StatusCode)
+	assert.Equal(t, int32(3), created.Load(), "other keys and requests without one aren't replayed")
+}
+
+func TestIdempotency_keyReusedForAnotherRequest(t *testing.T) {
+	app := newIdempotentApp(func(c *fiber.Ctx) error { return c.SendStatus(http.StatusCreated) })
+
+	require.Equal(t, http.StatusCreated, post(t, app, "k1", "", `{"title":"a"}`).StatusCode)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(t, app, "k1", "", `{"title":"b"}`).StatusCode)
+}
+
+func TestIdempotency_keysAreScopedPerUser(t *testing.T) {
+	var calls atomic.Int32
+	app := newIdempotentApp(func(c *fiber.Ctx) error {
+		calls.Add(1)
+		return c.SendStatus(http.StatusCreated)
+	})
+
+	post(t, app, "k1", "alice-key-1", `{}`)
+	post(t, app, "k1", "alice-key-2", `{}`)
+	assert.Equal(t, int32(1), calls.Load(), "same user, other API key")
+	post(t, app, "k1", "bob-key", `{}`)
+	assert.Equal(t, int32(2), calls.Load(), "other user")
+}
+
+func TestIdempotency_anonymousKeysAreScopedPerIP(t *testing.T) {
+	var calls atomic.Int32
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler, ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(Idempotency(IdempotencyConfig{Store: idempotency.NewMemory(), TTL: time.Hour, Lock: time.Minute}))
+	app.Post("/posts", func(c *fiber.Ctx) error {
+		calls.Add(1)
+		return c.SendStatus(http.StatusCreated)
+	})
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.1", "192.0.2.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{}`))
+		req.Header.Set(fiber.HeaderXForwardedFor, ip)
+		req.Header.Set(HeaderIdempotencyKey, "k1")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	assert.Equal(t, int32(2), calls.Load(), "replayed to the same address only")
+}
+
+func TestIdempotency_inFlightDuplicate(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	app := newIdempotentApp(func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendStatus(http.StatusCreated)
+	})
+
+	done := make(chan int)
+	go func() { done <- post(t, app, "k1", "", `{}`).StatusCode }()
+	<-started
+	assert.Equal(t, http.StatusConflict, post(t, app, "k1", "", `{}`).StatusCode)
+	close(release)
+	assert.Equal(t, http.StatusCreated, <-done)
+}
+
+func TestIdempotency_errors(t *testing.T) {
+	var calls atomic.Int32
+	app := newIdempotentApp(func(c *fiber.Ctx) error {
+		if calls.Add(1) == 1 {
+			return fiber.NewError(http.StatusServiceUnavailable, "try again")
+		}
+		return fiber.NewError(http.StatusBadRequest, "invalid")
+	})
+
+	first := post(t, app, "k1", "", `{}`)
+	assert.Equal(t, http.StatusServiceUnavailable, first.StatusCode)
+	assert.Equal(t, controller.ProblemContentType, first.Header.Get(fiber.HeaderContentType))
+
+	// Server errors aren't stored, client errors are.
+	assert.Equal(t, http.StatusBadRequest, post(t, app, "k1", "", `{}`).StatusCode)
+	replay := post(t, app, "k1", "", `{}`)
+	assert.Equal(t, http.StatusBadRequest, replay.StatusCode)
+	assert.Equal(t, controller.ProblemContentType, replay.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_ignoresOtherMethods(t *testing.T) {
+	var calls atomic.Int32
+	app := newIdempotentApp(func(c *fiber.Ctx) error {
+		calls.Add(1)
+		return c.SendStatus(http.StatusOK)
+	})
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set(HeaderIdempotencyKey, "k1")
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_keyTooLong(t *testing.T) {
+	app := newIdempotentApp(func(c *fiber.Ctx) error { return c.SendStatus(http.StatusCreated) })
+	assert.Equal(t, http.StatusBadRequest, post(t, app, strings.Repeat("k", 256), "", `{}`).StatusCode)
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"example/ratelimit"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rate limit response headers, from the IETF RateLimit header fields draft.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// What a rate limit is keyed by. Anonymous requests are always keyed by IP.
+const (
+	RateLimitByIP     = "ip"
+	RateLimitByUser   = "user"
+	RateLimitByAPIKey = "api_key"
+)
+
+// RateLimits holds the budgets applied to each client.
+type RateLimits struct {
+	Store ratelimit.Store
+	// Read applies to GET, HEAD and OPTIONS requests, Write to the others.
+	Read  ratelimit.Limit
+	Write ratelimit.Limit
+	// By is RateLimitByIP, RateLimitByUser or RateLimitByAPIKey.
+	By string
+	// IP applies to every request from an address, whoever makes it. It is
+	// taken by RateLimitIP, before requests are authenticated.
+	IP ratelimit.Limit
+}
+
+// RateLimit rejects requests from clients that have spent their budget with
+// 429, and reports the budget on every response. If the store fails, requests
+// are let through rather than taking the API down with it.
+func RateLimit(rl RateLimits) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		class, limit := "write", rl.Write
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			class, limit = "read", rl.Read
+		}
+		return rl.take(c, class+":"+rl.client(c), limit)
+	}
+}
+
+// RateLimitIP applies rl.IP to every request by client IP. It runs before
+// Authenticate, so that requests with invalid API keys, which are rejected
+// before reaching RateLimit, are limited too.
+func RateLimitIP(rl RateLimits) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return rl.take(c, "any:ip:"+c.IP(), rl.IP)
+	}
+}
+
+// take counts the request in the bucket under key.
+func (rl RateLimits) take(c *fiber.Ctx, key string, limit ratelimit.Limit) error {
+	res, err := rl.Store.Take(c.UserContext(), "ratelimit:"+key, limit)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "rate limit store failed", "error", err)
+		return c.Next()
+	}
+
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Burst))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+	c.Set(HeaderRateLimitReset, seconds(res.Reset))
+	c.Set(HeaderRateLimitPolicy, strconv.Itoa(limit.Burst)+";w="+seconds(limit.Per))
+	if !res.Allowed {
+		c.Set(fiber.HeaderRetryAfter, seconds(res.RetryAfter))
+		return fiber.NewError(fiber.StatusTooManyRequests, "rate limit exceeded, retry in "+seconds(res.RetryAfter)+"s")
+	}
+	return c.Next()
+}
+
+// client identifies who the request is counted against.
+func (rl RateLimits) client(c *fiber.Ctx) string {
+	if key := APIKey(c); key != nil {
+		switch rl.By {
+		case RateLimitByUser:
+			return "user:" + strconv.FormatUint(uint64(key.UserID), 10)
+		case RateLimitByAPIKey:
+			return "key:" + strconv.FormatUint(uint64(key.ID), 10)
+		}
+	}
+	return "ip:" + c.IP()
+}
+
+// seconds rounds d up to whole seconds, as the headers require.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
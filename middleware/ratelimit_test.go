@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example/auth"
+	"example/controller"
+	"example/models"
+	"example/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyTokens accepts "alice-key-1" and "alice-key-2", of an admin, and
+// "bob-key", of an author.
+func verifyTokens(_ context.Context, token string) (*models.APIKey, error) {
+	alice := models.User{ID: 1, Role: models.RoleAdmin}
+	bob := models.User{ID: 2, Role: models.RoleAuthor}
+	keys := map[string]*models.APIKey{
+		"alice-key-1": {ID: 1, UserID: 1, User: alice},
+		"alice-key-2": {ID: 2, UserID: 1, User: alice},
+		"bob-key":     {ID: 3, UserID: 2, User: bob},
+	}
+	if key, ok := keys[token]; ok {
+		return key, nil
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func newRateLimitedApp(by string) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(Authenticate(verifyTokens), RateLimit(RateLimits{
+		Store: ratelimit.NewMemory(),
+		Read:  ratelimit.Limit{Burst: 2, Per: time.Minute},
+		Write: ratelimit.Limit{Burst: 1, Per: time.Minute},
+		By:    by,
+	}))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) }
+	app.Get("/", ok)
+	app.Post("/", ok)
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method, token string) *http.Response {
+	req := httptest.NewRequest(method, "/", nil)
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestRateLimit(t *testing.T) {
+	app := newRateLimitedApp(RateLimitByAPIKey)
+
+	resp := send(t, app, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get(HeaderRateLimitLimit))
+	assert.Equal(t, "1", resp.Header.Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "30", resp.Header.Get(HeaderRateLimitReset))
+	assert.Equal(t, "2;w=60", resp.Header.Get(HeaderRateLimitPolicy))
+
+	assert.Equal(t, http.StatusOK, send(t, app, http.MethodGet, "").StatusCode)
+	resp = send(t, app, http.MethodGet, "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, "0", resp.Header.Get(HeaderRateLimitRemaining))
+	assert.Equal(t, controller.ProblemContentType, resp.Header.Get(fiber.HeaderContentType))
+	var problem models.Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "too_many_requests", problem.Code)
+
+	// Writes have their own budget.
+	assert.Equal(t, http.StatusOK, send(t, app, http.MethodPost, "").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, send(t, app, http.MethodPost, "").StatusCode)
+}
+
+func TestRateLimit_keys(t *testing.T) {
+	tests := []struct {
+		by string
+		// whether alice's second key, and bob's, get their own budget after
+		// alice's first key spent its own
+		secondKey, otherUser bool
+	}{
+		{by: RateLimitByAPIKey, secondKey: true, otherUser: true},
+		{by: RateLimitByUser, secondKey: false, otherUser: true},
+		{by: RateLimitByIP, secondKey: false, otherUser: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			app := newRateLimitedApp(tt.by)
+			require.Equal(t, http.StatusOK, send(t, app, http.MethodPost, "alice-key-1").StatusCode)
+			require.Equal(t, http.StatusTooManyRequests, send(t, app, http.MethodPost, "alice-key-1").StatusCode)
+
+			assert.Equal(t, tt.secondKey, send(t, app, http.MethodPost, "alice-key-2").StatusCode == http.StatusOK)
+			assert.Equal(t, tt.otherUser, send(t, app, http.MethodPost, "bob-key").StatusCode == http.StatusOK)
+		})
+	}
+}
+
+func TestRateLimitIP(t *testing.T) {
+	rl := RateLimits{
+		Store: ratelimit.NewMemory(),
+		Read:  ratelimit.Limit{Burst: 10, Per: time.Minute},
+		Write: ratelimit.Limit{Burst: 10, Per: time.Minute},
+		By:    RateLimitByAPIKey,
+		IP:    ratelimit.Limit{Burst: 2, Per: time.Minute},
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(RateLimitIP(rl), Authenticate(verifyTokens), RateLimit(rl))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	// Invalid keys never reach RateLimit, but still spend the IP's budget.
+	assert.Equal(t, http.StatusUnauthorized, send(t, app, http.MethodGet, "guess-1").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, send(t, app, http.MethodGet, "guess-2").StatusCode)
+	resp := send(t, app, http.MethodGet, "guess-3")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, http.StatusTooManyRequests, send(t, app, http.MethodGet, "alice-key-1").StatusCode)
+}
+
+// brokenStore is a rate limit store that is down.
+type brokenStore struct{}
+
+func (brokenStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("down")
+}
+
+func TestRateLimit_failsOpen(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(RateLimit(RateLimits{Store: brokenStore{}, Read: ratelimit.Limit{Burst: 1, Per: time.Minute}}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, send(t, app, http.MethodGet, "").StatusCode)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(Authenticate(verifyTokens))
+	app.Get("/", func(c *fiber.Ctx) error {
+		if key := APIKey(c); key != nil {
+			return c.JSON(key.ID)
+		}
+		return c.SendString("anonymous")
+	})
+
+	tests := []struct {
+		description string
+		header      string
+		status      int
+		body        string
+	}{
+		{description: "anonymous", status: http.StatusOK, body: "anonymous"},
+		{description: "valid key", header: "Bearer bob-key", status: http.StatusOK, body: "3"},
+		{description: "invalid key", header: "Bearer nope", status: http.StatusUnauthorized},
+		{description: "not a bearer token", header: "Basic Ym9iOnB3", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.body != "" {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.body, string(body))
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(Authenticate(verifyTokens), RequireRole(models.RoleAdmin))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	assert.Equal(t, http.StatusOK, send(t, app, http.MethodGet, "alice-key-1").StatusCode)
+	assert.Equal(t, http.StatusForbidden, send(t, app, http.MethodGet, "bob-key").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, send(t, app, http.MethodGet, "").StatusCode)
+}
+
+func TestAuthenticate_accessToken(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(Authenticate(verifyTokens), RequireRole(models.RoleAdmin))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	get := func(token string, upgrade bool) int {
+		req := httptest.NewRequest(http.MethodGet, "/?access_token="+token, nil)
+		if upgrade {
+			req.Header.Set(fiber.HeaderConnection, "Upgrade")
+			req.Header.Set(fiber.HeaderUpgrade, "websocket")
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, get("alice-key-1", true))
+	assert.Equal(t, http.StatusUnauthorized, get("nope", true))
+	assert.Equal(t, http.StatusUnauthorized, get("alice-key-1", false), "only for WebSocket requests")
+}
+
+func TestAuthenticate_user(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Use(Authenticate(verifyTokens))
+	app.Get("/", func(c *fiber.Ctx) error {
+		if user := auth.User(c.UserContext()); user != nil {
+			return c.SendString(user.Role)
+		}
+		return c.SendString("anonymous")
+	})
+
+	for token, want := range map[string]string{"bob-key": models.RoleAuthor, "": "anonymous"} {
+		body, err := io.ReadAll(send(t, app, http.MethodGet, token).Body)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(body))
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"example/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID is the header used to receive and echo request IDs.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength caps client-supplied IDs so they can't bloat the logs.
+const maxRequestIDLength = 128
+
+// RequestID reuses the caller's X-Request-ID, or generates one, echoes it on
+// the response and stores it on the user context for logging.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(HeaderRequestID)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+		c.Set(HeaderRequestID, id)
+		c.Locals("requestid", id)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
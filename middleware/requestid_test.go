@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example/audit"
+	"example/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Use(AccessLog(logging.New(&buf, "info", "json")))
+	app.Get("/blog-post/:id", func(c *fiber.Ctx) error {
+		return c.SendString(logging.RequestID(c.UserContext()))
+	})
+
+	tests := []struct {
+		description string
+		incoming    string
+	}{
+		{description: "honours incoming ID", incoming: "abc-123"},
+		{description: "generates ID when missing", incoming: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/blog-post/1", nil)
+			if test.incoming != "" {
+				req.Header.Set(HeaderRequestID, test.incoming)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			id := resp.Header.Get(HeaderRequestID)
+			require.NotEmpty(t, id)
+			if test.incoming != "" {
+				assert.Equal(t, test.incoming, id)
+			}
+
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, id, record["request_id"])
+			assert.Equal(t, "/blog-post/:id", record["route"])
+			assert.Equal(t, float64(http.StatusOK), record["status"])
+			assert.Equal(t, float64(len(id)), record["bytes_out"])
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(Source())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(audit.SourceFrom(c.UserContext()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "curl/8.0")
+	req.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.7")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var src audit.Source
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&src))
+	assert.Equal(t, audit.Source{IP: "203.0.113.7", UserAgent: "curl/8.0"}, src)
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"example/audit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Source stores the client's IP and user agent on the user context, for the
+// audit log. Behind a proxy the IP is read from the configured ProxyHeader.
+func Source() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(audit.WithSource(c.UserContext(), audit.Source{
+			IP:        c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+		}))
+		return c.Next()
+	}
+}
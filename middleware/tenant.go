@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"example/tenant"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// ResolveFunc finds the blog a request is for, as tenant.Resolver.Resolve
+// does.
+type ResolveFunc func(ctx context.Context, host, slug string) (*models.Tenant, error)
+
+// Tenant scopes the request to the blog named by a /t/{slug} path prefix,
+// which is then stripped so that routes match as usual, or else to the blog
+// served on the request's host. Requests for no known blog are rejected
+// with 404.
+func Tenant(resolve ResolveFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var slug string
+		if rest, ok := strings.CutPrefix(c.Path(), tenant.PathPrefix); ok {
+			slug, rest, _ = strings.Cut(rest, "/")
+			// The path is overwritten in place.
+			slug = strings.Clone(slug)
+			c.Path("/" + rest)
+		}
+		host := strings.ToLower(c.Hostname())
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		t, err := resolve(c.UserContext(), host, slug)
+		if errors.Is(err, tenant.ErrUnknown) {
+			return fiber.NewError(fiber.StatusNotFound, "there is no blog here")
+		}
+		if err != nil {
+			return err
+		}
+		ctx := tenant.WithTenant(c.UserContext(), t)
+		if slug != "" {
+			ctx = tenant.WithBasePath(ctx, tenant.PathPrefix+slug)
+		}
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// CORS applies base with the origins allowed by the request's blog, or any
+// origin if it allows them all. It must follow Tenant.
+func CORS(base cors.Config) fiber.Handler {
+	var mu sync.Mutex
+	handlers := map[string]fiber.Handler{}
+	return func(c *fiber.Ctx) error {
+		origins := "*"
+		if t := tenant.From(c.UserContext()); t != nil && len(t.CORSOrigins) > 0 {
+			origins = strings.Join(t.CORSOrigins, ",")
+		}
+		mu.Lock()
+		h, ok := handlers[origins]
+		if !ok {
+			cfg := base
+			cfg.AllowOrigins = origins
+			h = cors.New(cfg)
+			handlers[origins] = h
+		}
+		mu.Unlock()
+		return h(c)
+	}
+}
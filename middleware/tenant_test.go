@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example/models"
+	"example/tenant"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenant(t *testing.T) {
+	blogs := map[string]*models.Tenant{
+		"default": {ID: 1, Slug: "default"},
+		"acme":    {ID: 2, Slug: "acme", CORSOrigins: []string{"https://acme.example"}},
+	}
+	resolve := func(_ context.Context, host, slug string) (*models.Tenant, error) {
+		if slug == "" && host == "acme.example" {
+			slug = "acme"
+		}
+		if slug == "" {
+			slug = "default"
+		}
+		if t, ok := blogs[slug]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%w %q", tenant.ErrUnknown, slug)
+	}
+
+	app := fiber.New()
+	app.Use(Tenant(resolve))
+	app.Use(CORS(cors.Config{}))
+	app.Get("/api/blog-post/:id", func(c *fiber.Ctx) error {
+		return c.SendString(fmt.Sprintf("%s %s %q", tenant.From(c.UserContext()).Slug, c.Params("id"), tenant.BasePath(c.UserContext())))
+	})
+
+	tests := []struct {
+		description string
+		target      string
+		host        string
+		origin      string
+		status      int
+		body        string
+		allowOrigin string
+	}{
+		{description: "fallback", target: "/api/blog-post/1", host: "localhost:8080", origin: "https://other.example",
+			status: http.StatusOK, body: `default 1 ""`, allowOrigin: "*"},
+		{description: "host", target: "/api/blog-post/1", host: "ACME.example:8080", origin: "https://acme.example",
+			status: http.StatusOK, body: `acme 1 ""`, allowOrigin: "https://acme.example"},
+		{description: "path prefix", target: "/t/acme/api/blog-post/2", host: "localhost",
+			status: http.StatusOK, body: `acme 2 "/t/acme"`},
+		{description: "origin not allowed", target: "/t/acme/api/blog-post/2", host: "localhost", origin: "https://other.example",
+			status: http.StatusOK, body: `acme 2 "/t/acme"`},
+		{description: "unknown blog", target: "/t/acne/api/blog-post/2", host: "localhost", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			req.Host = test.host
+			if test.origin != "" {
+				req.Header.Set(fiber.HeaderOrigin, test.origin)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, test.status, resp.StatusCode)
+			if test.status != http.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, test.body, string(body))
+			assert.Equal(t, test.allowOrigin, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+		})
+	}
+}
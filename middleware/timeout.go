@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeouts holds the request deadline applied to each route.
+type Timeouts struct {
+	// Default applies to any route without an entry in Routes. Zero disables
+	// the deadline.
+	Default time.Duration
+	// Routes maps "METHOD /path/pattern" (for example
+	// "PATCH /api/blog-post/:id") to its deadline.
+	Routes map[string]time.Duration
+}
+
+// Handler enforces the deadline configured for the route it is registered
+// on, keyed by the route's method and pattern as registered, so that the
+// pattern is only written once. HEAD requests share their GET route's
+// deadline.
+func (t Timeouts) Handler(c *fiber.Ctx) error {
+	route := c.Route()
+	method, path := route.Method, route.Path
+	if method == fiber.MethodHead {
+		method = fiber.MethodGet
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	d, ok := t.Routes[method+" "+path]
+	if !ok {
+		d = t.Default
+	}
+	return withTimeout(c, d)
+}
+
+// Timeout attaches a deadline to the request's user context so that the
+// service and repository calls made by the handler are cancelled once it
+// passes. Requests that run out of time fail with 504, whatever the handler
+// itself returned.
+func Timeout(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return withTimeout(c, d)
+	}
+}
+
+func withTimeout(c *fiber.Ctx, d time.Duration) error {
+	if d <= 0 {
+		return c.Next()
+	}
+	ctx, cancel := context.WithTimeout(c.UserContext(), d)
+	defer cancel()
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		c.Response().ResetBody()
+		return fiber.NewError(fiber.StatusGatewayTimeout, "request timed out")
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example/controller"
+	"example/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	timeouts := Timeouts{
+		Default: time.Second,
+		Routes: map[string]time.Duration{
+			"GET /slow":       20 * time.Millisecond,
+			"GET /group/slow": 20 * time.Millisecond,
+		},
+	}
+	// blocks until the request context is cancelled, like a DB query would
+	wait := func(c *fiber.Ctx) error {
+		select {
+		case <-c.UserContext().Done():
+			return c.UserContext().Err()
+		case <-time.After(200 * time.Millisecond):
+			return c.SendStatus(http.StatusOK)
+		}
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: controller.ErrorHandler})
+	app.Get("/slow", timeouts.Handler, wait)
+	app.Get("/fast", timeouts.Handler, wait)
+	app.Group("/group").Get("/slow/", timeouts.Handler, wait)
+
+	tests := []struct {
+		description  string
+		path         string
+		method       string
+		expectedCode int
+	}{
+		{description: "deadline exceeded", path: "/slow", expectedCode: http.StatusGatewayTimeout},
+		{description: "within default deadline", path: "/fast", expectedCode: http.StatusOK},
+		{description: "route in a group", path: "/group/slow", expectedCode: http.StatusGatewayTimeout},
+		{description: "HEAD shares the GET deadline", path: "/slow", method: http.MethodHead, expectedCode: http.StatusGatewayTimeout},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			resp, err := app.Test(httptest.NewRequest(method, test.path, nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+
+			if test.expectedCode == http.StatusGatewayTimeout && method != http.MethodHead {
+				var body models.Problem
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, "gateway_timeout", body.Code)
+			}
+		})
+	}
+}
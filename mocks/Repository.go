@@ -1,11 +1,16 @@
-// Code generated by mockery v2.52.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
 import (
+	context "context"
 	models "example/models"
 
 	mock "github.com/stretchr/testify/mock"
+
+	repo "example/repo"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -13,27 +18,27 @@ type Repository struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: post
-func (_m *Repository) Create(post *models.BlogPost) (uint, error) {
-	ret := _m.Called(post)
+// AcquirePostLock provides a mock function with given fields: ctx, lock, now
+func (_m *Repository) AcquirePostLock(ctx context.Context, lock *models.PostLock, now time.Time) (bool, error) {
+	ret := _m.Called(ctx, lock, now)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Create")
+		panic("no return value specified for AcquirePostLock")
 	}
 
-	var r0 uint
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*models.BlogPost) (uint, error)); ok {
-		return rf(post)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PostLock, time.Time) (bool, error)); ok {
+		return rf(ctx, lock, now)
 	}
-	if rf, ok := ret.Get(0).(func(*models.BlogPost) uint); ok {
-		r0 = rf(post)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PostLock, time.Time) bool); ok {
+		r0 = rf(ctx, lock, now)
 	} else {
-		r0 = ret.Get(0).(uint)
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(*models.BlogPost) error); ok {
-		r1 = rf(post)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.PostLock, time.Time) error); ok {
+		r1 = rf(ctx, lock, now)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -41,17 +46,47 @@ func (_m *Repository) Create(post *models.BlogPost) (uint, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Repository) Delete(id uint) error {
-	ret := _m.Called(id)
+// ActiveWebhookSubscriptions provides a mock function with given fields: ctx
+func (_m *Repository) ActiveWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Delete")
+		panic("no return value specified for ActiveWebhookSubscriptions")
+	}
+
+	var r0 []models.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.WebhookSubscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.WebhookSubscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AppendAuditEntry provides a mock function with given fields: ctx, entry
+func (_m *Repository) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AppendAuditEntry")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AuditEntry) error); ok {
+		r0 = rf(ctx, entry)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -59,29 +94,47 @@ func (_m *Repository) Delete(id uint) error {
 	return r0
 }
 
-// GetAll provides a mock function with no fields
-func (_m *Repository) GetAll() ([]models.BlogPost, error) {
-	ret := _m.Called()
+// AuditEntriesInBatches provides a mock function with given fields: ctx, f, size, fn
+func (_m *Repository) AuditEntriesInBatches(ctx context.Context, f models.AuditFilter, size int, fn func([]models.AuditEntry) error) error {
+	ret := _m.Called(ctx, f, size, fn)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAll")
+		panic("no return value specified for AuditEntriesInBatches")
 	}
 
-	var r0 []models.BlogPost
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuditFilter, int, func([]models.AuditEntry) error) error); ok {
+		r0 = rf(ctx, f, size, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClaimOutboxEvents provides a mock function with given fields: ctx, now, lease, limit
+func (_m *Repository) ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	ret := _m.Called(ctx, now, lease, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimOutboxEvents")
+	}
+
+	var r0 []models.OutboxEvent
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.BlogPost, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) ([]models.OutboxEvent, error)); ok {
+		return rf(ctx, now, lease, limit)
 	}
-	if rf, ok := ret.Get(0).(func() []models.BlogPost); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) []models.OutboxEvent); ok {
+		r0 = rf(ctx, now, lease, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.BlogPost)
+			r0 = ret.Get(0).([]models.OutboxEvent)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Duration, int) error); ok {
+		r1 = rf(ctx, now, lease, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -89,29 +142,29 @@ func (_m *Repository) GetAll() ([]models.BlogPost, error) {
 	return r0, r1
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *Repository) GetByID(id uint) (*models.BlogPost, error) {
-	ret := _m.Called(id)
+// ClaimWebhookDeliveries provides a mock function with given fields: ctx, now, lease, limit
+func (_m *Repository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	ret := _m.Called(ctx, now, lease, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetByID")
+		panic("no return value specified for ClaimWebhookDeliveries")
 	}
 
-	var r0 *models.BlogPost
+	var r0 []models.WebhookDelivery
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uint) (*models.BlogPost, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) ([]models.WebhookDelivery, error)); ok {
+		return rf(ctx, now, lease, limit)
 	}
-	if rf, ok := ret.Get(0).(func(uint) *models.BlogPost); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) []models.WebhookDelivery); ok {
+		r0 = rf(ctx, now, lease, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.BlogPost)
+			r0 = ret.Get(0).([]models.WebhookDelivery)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uint) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Duration, int) error); ok {
+		r1 = rf(ctx, now, lease, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -119,17 +172,1320 @@ func (_m *Repository) GetByID(id uint) (*models.BlogPost, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, post
-func (_m *Repository) Update(id uint, post *models.BlogPost) error {
-	ret := _m.Called(id, post)
+// Create provides a mock function with given fields: ctx, post
+func (_m *Repository) Create(ctx context.Context, post *models.BlogPost) (uint, error) {
+	ret := _m.Called(ctx, post)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Update")
+		panic("no return value specified for Create")
+	}
+
+	var r0 uint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BlogPost) (uint, error)); ok {
+		return rf(ctx, post)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BlogPost) uint); ok {
+		r0 = rf(ctx, post)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.BlogPost) error); ok {
+		r1 = rf(ctx, post)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, key
+func (_m *Repository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.APIKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateBatch provides a mock function with given fields: ctx, posts
+func (_m *Repository) CreateBatch(ctx context.Context, posts []*models.BlogPost) error {
+	ret := _m.Called(ctx, posts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.BlogPost) error); ok {
+		r0 = rf(ctx, posts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOutboxEvents provides a mock function with given fields: ctx, events
+func (_m *Repository) CreateOutboxEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	ret := _m.Called(ctx, events)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOutboxEvents")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.OutboxEvent) error); ok {
+		r0 = rf(ctx, events)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRevision provides a mock function with given fields: ctx, rev
+func (_m *Repository) CreateRevision(ctx context.Context, rev *models.PostRevision) error {
+	ret := _m.Called(ctx, rev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRevision")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PostRevision) error); ok {
+		r0 = rf(ctx, rev)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateTenant provides a mock function with given fields: ctx, tenant
+func (_m *Repository) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	ret := _m.Called(ctx, tenant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTenant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Tenant) error); ok {
+		r0 = rf(ctx, tenant)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateTranslation provides a mock function with given fields: ctx, t
+func (_m *Repository) CreateTranslation(ctx context.Context, t *models.PostTranslation) error {
+	ret := _m.Called(ctx, t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTranslation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PostTranslation) error); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateUser provides a mock function with given fields: ctx, user
+func (_m *Repository) CreateUser(ctx context.Context, user *models.User) error {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhookDeliveries provides a mock function with given fields: ctx, deliveries
+func (_m *Repository) CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	ret := _m.Called(ctx, deliveries)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookDeliveries")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.WebhookDelivery) error); ok {
+		r0 = rf(ctx, deliveries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhookSubscription provides a mock function with given fields: ctx, sub
+func (_m *Repository) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	ret := _m.Called(ctx, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.WebhookSubscription) error); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Repository) Delete(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeletePostLock provides a mock function with given fields: ctx, postID, userID
+func (_m *Repository) DeletePostLock(ctx context.Context, postID uint, userID uint) (bool, error) {
+	ret := _m.Called(ctx, postID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePostLock")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) (bool, error)); ok {
+		return rf(ctx, postID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) bool); ok {
+		r0 = rf(ctx, postID, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, postID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteTranslation provides a mock function with given fields: ctx, postID, locale
+func (_m *Repository) DeleteTranslation(ctx context.Context, postID uint, locale string) error {
+	ret := _m.Called(ctx, postID, locale)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTranslation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, postID, locale)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWebhookSubscription provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteWebhookSubscription(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhookSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindInBatches provides a mock function with given fields: ctx, size, fn
+func (_m *Repository) FindInBatches(ctx context.Context, size int, fn func([]models.BlogPost) error) error {
+	ret := _m.Called(ctx, size, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindInBatches")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, func([]models.BlogPost) error) error); ok {
+		r0 = rf(ctx, size, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FirstOrCreateCategory provides a mock function with given fields: ctx, category
+func (_m *Repository) FirstOrCreateCategory(ctx context.Context, category *models.Category) error {
+	ret := _m.Called(ctx, category)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FirstOrCreateCategory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Category) error); ok {
+		r0 = rf(ctx, category)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FirstOrCreateTag provides a mock function with given fields: ctx, tag
+func (_m *Repository) FirstOrCreateTag(ctx context.Context, tag *models.Tag) error {
+	ret := _m.Called(ctx, tag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FirstOrCreateTag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Tag) error); ok {
+		r0 = rf(ctx, tag)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAPIKeyByPrefix provides a mock function with given fields: ctx, prefix
+func (_m *Repository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	ret := _m.Called(ctx, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeyByPrefix")
+	}
+
+	var r0 *models.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.APIKey, error)); ok {
+		return rf(ctx, prefix)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.APIKey); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: ctx
+func (_m *Repository) GetAll(ctx context.Context) ([]models.BlogPost, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []models.BlogPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.BlogPost, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.BlogPost); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByID")
+	}
+
+	var r0 *models.BlogPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.BlogPost, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.BlogPost); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetImportState provides a mock function with given fields: ctx, name
+func (_m *Repository) GetImportState(ctx context.Context, name string) (*models.ImportState, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetImportState")
+	}
+
+	var r0 *models.ImportState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.ImportState, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.ImportState); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ImportState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPostLock provides a mock function with given fields: ctx, postID
+func (_m *Repository) GetPostLock(ctx context.Context, postID uint) (*models.PostLock, error) {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPostLock")
+	}
+
+	var r0 *models.PostLock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.PostLock, error)); ok {
+		return rf(ctx, postID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.PostLock); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostLock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPostLockForUpdate provides a mock function with given fields: ctx, postID
+func (_m *Repository) GetPostLockForUpdate(ctx context.Context, postID uint) (*models.PostLock, error) {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPostLockForUpdate")
+	}
+
+	var r0 *models.PostLock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.PostLock, error)); ok {
+		return rf(ctx, postID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.PostLock); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostLock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevision provides a mock function with given fields: ctx, postID, id
+func (_m *Repository) GetRevision(ctx context.Context, postID uint, id uint) (*models.PostRevision, error) {
+	ret := _m.Called(ctx, postID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRevision")
+	}
+
+	var r0 *models.PostRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) (*models.PostRevision, error)); ok {
+		return rf(ctx, postID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) *models.PostRevision); ok {
+		r0 = rf(ctx, postID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, postID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTag provides a mock function with given fields: ctx, slug
+func (_m *Repository) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	ret := _m.Called(ctx, slug)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTag")
+	}
+
+	var r0 *models.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Tag, error)); ok {
+		return rf(ctx, slug)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Tag); ok {
+		r0 = rf(ctx, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, slug)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenantBySlug provides a mock function with given fields: ctx, slug
+func (_m *Repository) GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	ret := _m.Called(ctx, slug)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTenantBySlug")
+	}
+
+	var r0 *models.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Tenant, error)); ok {
+		return rf(ctx, slug)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Tenant); ok {
+		r0 = rf(ctx, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, slug)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTranslation provides a mock function with given fields: ctx, postID, locale
+func (_m *Repository) GetTranslation(ctx context.Context, postID uint, locale string) (*models.PostTranslation, error) {
+	ret := _m.Called(ctx, postID, locale)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTranslation")
+	}
+
+	var r0 *models.PostTranslation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) (*models.PostTranslation, error)); ok {
+		return rf(ctx, postID, locale)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) *models.PostTranslation); ok {
+		r0 = rf(ctx, postID, locale)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostTranslation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(ctx, postID, locale)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *Repository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDelivery provides a mock function with given fields: ctx, subscriptionID, id
+func (_m *Repository) GetWebhookDelivery(ctx context.Context, subscriptionID uint, id uint) (*models.WebhookDelivery, error) {
+	ret := _m.Called(ctx, subscriptionID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDelivery")
+	}
+
+	var r0 *models.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) (*models.WebhookDelivery, error)); ok {
+		return rf(ctx, subscriptionID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) *models.WebhookDelivery); ok {
+		r0 = rf(ctx, subscriptionID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, subscriptionID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookSubscription provides a mock function with given fields: ctx, id
+func (_m *Repository) GetWebhookSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookSubscription")
+	}
+
+	var r0 *models.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.WebhookSubscription, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.WebhookSubscription); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAuditEntries provides a mock function with given fields: ctx, f, before, limit
+func (_m *Repository) ListAuditEntries(ctx context.Context, f models.AuditFilter, before uint, limit int) ([]models.AuditEntry, error) {
+	ret := _m.Called(ctx, f, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEntries")
+	}
+
+	var r0 []models.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuditFilter, uint, int) ([]models.AuditEntry, error)); ok {
+		return rf(ctx, f, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuditFilter, uint, int) []models.AuditEntry); ok {
+		r0 = rf(ctx, f, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.AuditFilter, uint, int) error); ok {
+		r1 = rf(ctx, f, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPosts provides a mock function with given fields: ctx, f
+func (_m *Repository) ListPosts(ctx context.Context, f models.PostFilter) ([]models.BlogPost, int64, error) {
+	ret := _m.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPosts")
+	}
+
+	var r0 []models.BlogPost
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PostFilter) ([]models.BlogPost, int64, error)); ok {
+		return rf(ctx, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PostFilter) []models.BlogPost); ok {
+		r0 = rf(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PostFilter) int64); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, models.PostFilter) error); ok {
+		r2 = rf(ctx, f)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListRevisions provides a mock function with given fields: ctx, postID
+func (_m *Repository) ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRevisions")
+	}
+
+	var r0 []models.PostRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]models.PostRevision, error)); ok {
+		return rf(ctx, postID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []models.PostRevision); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PostRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTenants provides a mock function with given fields: ctx
+func (_m *Repository) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTenants")
+	}
+
+	var r0 []models.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Tenant, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Tenant); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTranslations provides a mock function with given fields: ctx, postIDs
+func (_m *Repository) ListTranslations(ctx context.Context, postIDs []uint) ([]models.PostTranslation, error) {
+	ret := _m.Called(ctx, postIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTranslations")
+	}
+
+	var r0 []models.PostTranslation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) ([]models.PostTranslation, error)); ok {
+		return rf(ctx, postIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) []models.PostTranslation); ok {
+		r0 = rf(ctx, postIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PostTranslation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, postIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTrash provides a mock function with given fields: ctx
+func (_m *Repository) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTrash")
+	}
+
+	var r0 []models.BlogPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.BlogPost, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.BlogPost); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookDeliveries provides a mock function with given fields: ctx, subscriptionID, before, limit
+func (_m *Repository) ListWebhookDeliveries(ctx context.Context, subscriptionID uint, before uint, limit int) ([]models.WebhookDelivery, error) {
+	ret := _m.Called(ctx, subscriptionID, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookDeliveries")
+	}
+
+	var r0 []models.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, int) ([]models.WebhookDelivery, error)); ok {
+		return rf(ctx, subscriptionID, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, int) []models.WebhookDelivery); ok {
+		r0 = rf(ctx, subscriptionID, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, int) error); ok {
+		r1 = rf(ctx, subscriptionID, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookSubscriptions provides a mock function with given fields: ctx
+func (_m *Repository) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookSubscriptions")
+	}
+
+	var r0 []models.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.WebhookSubscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.WebhookSubscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkOutboxEventPublished provides a mock function with given fields: ctx, id, at
+func (_m *Repository) MarkOutboxEventPublished(ctx context.Context, id uint, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkOutboxEventPublished")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OutboxEventsAfter provides a mock function with given fields: ctx, after, types, postIDs, limit
+func (_m *Repository) OutboxEventsAfter(ctx context.Context, after uint, types []string, postIDs []uint, limit int) ([]models.OutboxEvent, error) {
+	ret := _m.Called(ctx, after, types, postIDs, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OutboxEventsAfter")
+	}
+
+	var r0 []models.OutboxEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []string, []uint, int) ([]models.OutboxEvent, error)); ok {
+		return rf(ctx, after, types, postIDs, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []string, []uint, int) []models.OutboxEvent); ok {
+		r0 = rf(ctx, after, types, postIDs, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.OutboxEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, []string, []uint, int) error); ok {
+		r1 = rf(ctx, after, types, postIDs, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostIDTaken provides a mock function with given fields: ctx, id
+func (_m *Repository) PostIDTaken(ctx context.Context, id uint) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostIDTaken")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeOutboxEvents provides a mock function with given fields: ctx, before
+func (_m *Repository) PurgeOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeOutboxEvents")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgePost provides a mock function with given fields: ctx, id
+func (_m *Repository) PurgePost(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgePost")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeWebhookDeliveries provides a mock function with given fields: ctx, before
+func (_m *Repository) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeWebhookDeliveries")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: ctx, post
+func (_m *Repository) Replace(ctx context.Context, post *models.BlogPost) error {
+	ret := _m.Called(ctx, post)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Replace")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BlogPost) error); ok {
+		r0 = rf(ctx, post)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestorePost provides a mock function with given fields: ctx, id
+func (_m *Repository) RestorePost(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestorePost")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetryOutboxEvent provides a mock function with given fields: ctx, id, next, lastError
+func (_m *Repository) RetryOutboxEvent(ctx context.Context, id uint, next time.Time, lastError string) error {
+	ret := _m.Called(ctx, id, next, lastError)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryOutboxEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Time, string) error); ok {
+		r0 = rf(ctx, id, next, lastError)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveImportState provides a mock function with given fields: ctx, st
+func (_m *Repository) SaveImportState(ctx context.Context, st *models.ImportState) error {
+	ret := _m.Called(ctx, st)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveImportState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ImportState) error); ok {
+		r0 = rf(ctx, st)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveWebhookAttempt provides a mock function with given fields: ctx, d, disableAfter
+func (_m *Repository) SaveWebhookAttempt(ctx context.Context, d *models.WebhookDelivery, disableAfter int) (bool, error) {
+	ret := _m.Called(ctx, d, disableAfter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveWebhookAttempt")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.WebhookDelivery, int) (bool, error)); ok {
+		return rf(ctx, d, disableAfter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.WebhookDelivery, int) bool); ok {
+		r0 = rf(ctx, d, disableAfter)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.WebhookDelivery, int) error); ok {
+		r1 = rf(ctx, d, disableAfter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SyncIDSequence provides a mock function with given fields: ctx
+func (_m *Repository) SyncIDSequence(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SyncIDSequence")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Transaction provides a mock function with given fields: ctx, fn
+func (_m *Repository) Transaction(ctx context.Context, fn func(repo.Repository) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Transaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(repo.Repository) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, post
+func (_m *Repository) Update(ctx context.Context, id uint, post *models.BlogPost) error {
+	ret := _m.Called(ctx, id, post)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, *models.BlogPost) error); ok {
+		r0 = rf(ctx, id, post)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTenant provides a mock function with given fields: ctx, tenant
+func (_m *Repository) UpdateTenant(ctx context.Context, tenant *models.Tenant) error {
+	ret := _m.Called(ctx, tenant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTenant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Tenant) error); ok {
+		r0 = rf(ctx, tenant)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTranslation provides a mock function with given fields: ctx, t
+func (_m *Repository) UpdateTranslation(ctx context.Context, t *models.PostTranslation) error {
+	ret := _m.Called(ctx, t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTranslation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PostTranslation) error); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebhookSubscription provides a mock function with given fields: ctx, sub
+func (_m *Repository) UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	ret := _m.Called(ctx, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhookSubscription")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint, *models.BlogPost) error); ok {
-		r0 = rf(id, post)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.WebhookSubscription) error); ok {
+		r0 = rf(ctx, sub)
 	} else {
 		r0 = ret.Error(0)
 	}
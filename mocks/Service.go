@@ -1,11 +1,22 @@
-// Code generated by mockery v2.52.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
 import (
-	models "example/models"
+	context "context"
+	io "io"
 
 	mock "github.com/stretchr/testify/mock"
+
+	models "example/models"
+
+	seed "example/seed"
+
+	time "time"
+
+	transfer "example/transfer"
+
+	wxr "example/wxr"
 )
 
 // Service is an autogenerated mock type for the Service type
@@ -13,9 +24,9 @@ type Service struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: req
-func (_m *Service) Create(req models.CreateBlogRequest) (uint, error) {
-	ret := _m.Called(req)
+// Create provides a mock function with given fields: ctx, req
+func (_m *Service) Create(ctx context.Context, req models.CreateBlogRequest) (uint, error) {
+	ret := _m.Called(ctx, req)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
@@ -23,17 +34,47 @@ func (_m *Service) Create(req models.CreateBlogRequest) (uint, error) {
 
 	var r0 uint
 	var r1 error
-	if rf, ok := ret.Get(0).(func(models.CreateBlogRequest) (uint, error)); ok {
-		return rf(req)
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateBlogRequest) (uint, error)); ok {
+		return rf(ctx, req)
 	}
-	if rf, ok := ret.Get(0).(func(models.CreateBlogRequest) uint); ok {
-		r0 = rf(req)
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateBlogRequest) uint); ok {
+		r0 = rf(ctx, req)
 	} else {
 		r0 = ret.Get(0).(uint)
 	}
 
-	if rf, ok := ret.Get(1).(func(models.CreateBlogRequest) error); ok {
-		r1 = rf(req)
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateBlogRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateBatch provides a mock function with given fields: ctx, reqs, mode
+func (_m *Service) CreateBatch(ctx context.Context, reqs []models.CreateBlogRequest, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	ret := _m.Called(ctx, reqs, mode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBatch")
+	}
+
+	var r0 []models.BulkOutcome
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.CreateBlogRequest, models.BulkMode) ([]models.BulkOutcome, error)); ok {
+		return rf(ctx, reqs, mode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []models.CreateBlogRequest, models.BulkMode) []models.BulkOutcome); ok {
+		r0 = rf(ctx, reqs, mode)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BulkOutcome)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []models.CreateBlogRequest, models.BulkMode) error); ok {
+		r1 = rf(ctx, reqs, mode)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -41,17 +82,65 @@ func (_m *Service) Create(req models.CreateBlogRequest) (uint, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Service) Delete(id uint) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Service) Delete(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBatch provides a mock function with given fields: ctx, ids, mode
+func (_m *Service) DeleteBatch(ctx context.Context, ids []uint, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	ret := _m.Called(ctx, ids, mode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBatch")
+	}
+
+	var r0 []models.BulkOutcome
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint, models.BulkMode) ([]models.BulkOutcome, error)); ok {
+		return rf(ctx, ids, mode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []uint, models.BulkMode) []models.BulkOutcome); ok {
+		r0 = rf(ctx, ids, mode)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BulkOutcome)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []uint, models.BulkMode) error); ok {
+		r1 = rf(ctx, ids, mode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteTranslation provides a mock function with given fields: ctx, id, locale
+func (_m *Service) DeleteTranslation(ctx context.Context, id uint, locale string) error {
+	ret := _m.Called(ctx, id, locale)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTranslation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, id, locale)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -59,9 +148,37 @@ func (_m *Service) Delete(id uint) error {
 	return r0
 }
 
-// GetAll provides a mock function with no fields
-func (_m *Service) GetAll() ([]models.BlogPost, error) {
-	ret := _m.Called()
+// Export provides a mock function with given fields: ctx, w, f
+func (_m *Service) Export(ctx context.Context, w io.Writer, f transfer.Format) (int, error) {
+	ret := _m.Called(ctx, w, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Export")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, io.Writer, transfer.Format) (int, error)); ok {
+		return rf(ctx, w, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, io.Writer, transfer.Format) int); ok {
+		r0 = rf(ctx, w, f)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, io.Writer, transfer.Format) error); ok {
+		r1 = rf(ctx, w, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: ctx
+func (_m *Service) GetAll(ctx context.Context) ([]models.BlogPost, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
@@ -69,19 +186,19 @@ func (_m *Service) GetAll() ([]models.BlogPost, error) {
 
 	var r0 []models.BlogPost
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.BlogPost, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.BlogPost, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []models.BlogPost); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []models.BlogPost); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.BlogPost)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -89,9 +206,9 @@ func (_m *Service) GetAll() ([]models.BlogPost, error) {
 	return r0, r1
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *Service) GetByID(id uint) (*models.BlogPost, error) {
-	ret := _m.Called(id)
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *Service) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByID")
@@ -99,19 +216,199 @@ func (_m *Service) GetByID(id uint) (*models.BlogPost, error) {
 
 	var r0 *models.BlogPost
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uint) (*models.BlogPost, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.BlogPost, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(uint) *models.BlogPost); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.BlogPost); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.BlogPost)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uint) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLock provides a mock function with given fields: ctx, id
+func (_m *Service) GetLock(ctx context.Context, id uint) (*models.PostLock, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLock")
+	}
+
+	var r0 *models.PostLock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.PostLock, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.PostLock); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostLock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevisions provides a mock function with given fields: ctx, id
+func (_m *Service) GetRevisions(ctx context.Context, id uint) ([]models.PostRevision, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRevisions")
+	}
+
+	var r0 []models.PostRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]models.PostRevision, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []models.PostRevision); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PostRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTag provides a mock function with given fields: ctx, slug
+func (_m *Service) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	ret := _m.Called(ctx, slug)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTag")
+	}
+
+	var r0 *models.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Tag, error)); ok {
+		return rf(ctx, slug)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Tag); ok {
+		r0 = rf(ctx, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, slug)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTranslations provides a mock function with given fields: ctx, id
+func (_m *Service) GetTranslations(ctx context.Context, id uint) ([]models.PostTranslation, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTranslations")
+	}
+
+	var r0 []models.PostTranslation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) ([]models.PostTranslation, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []models.PostTranslation); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PostTranslation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, rd, f, opts
+func (_m *Service) Import(ctx context.Context, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error) {
+	ret := _m.Called(ctx, rd, f, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Import")
+	}
+
+	var r0 *transfer.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, transfer.Format, transfer.Options) (*transfer.Report, error)); ok {
+		return rf(ctx, rd, f, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, transfer.Format, transfer.Options) *transfer.Report); ok {
+		r0 = rf(ctx, rd, f, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*transfer.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, io.Reader, transfer.Format, transfer.Options) error); ok {
+		r1 = rf(ctx, rd, f, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportWordPress provides a mock function with given fields: ctx, rd, name, opts
+func (_m *Service) ImportWordPress(ctx context.Context, rd io.Reader, name string, opts wxr.Options) (*wxr.Report, error) {
+	ret := _m.Called(ctx, rd, name, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportWordPress")
+	}
+
+	var r0 *wxr.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, string, wxr.Options) (*wxr.Report, error)); ok {
+		return rf(ctx, rd, name, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, string, wxr.Options) *wxr.Report); ok {
+		r0 = rf(ctx, rd, name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wxr.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, io.Reader, string, wxr.Options) error); ok {
+		r1 = rf(ctx, rd, name, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -119,9 +416,280 @@ func (_m *Service) GetByID(id uint) (*models.BlogPost, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, post
-func (_m *Service) Update(id uint, post *models.UpdateBlogRequest) (*models.BlogPost, error) {
-	ret := _m.Called(id, post)
+// ListPosts provides a mock function with given fields: ctx, f
+func (_m *Service) ListPosts(ctx context.Context, f models.PostFilter) (*models.PostList, error) {
+	ret := _m.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPosts")
+	}
+
+	var r0 *models.PostList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PostFilter) (*models.PostList, error)); ok {
+		return rf(ctx, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PostFilter) *models.PostList); ok {
+		r0 = rf(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PostFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTrash provides a mock function with given fields: ctx
+func (_m *Service) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTrash")
+	}
+
+	var r0 []models.BlogPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.BlogPost, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.BlogPost); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Localize provides a mock function with given fields: ctx, posts, prefs
+func (_m *Service) Localize(ctx context.Context, posts []models.BlogPost, prefs []string) error {
+	ret := _m.Called(ctx, posts, prefs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Localize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.BlogPost, []string) error); ok {
+		r0 = rf(ctx, posts, prefs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Lock provides a mock function with given fields: ctx, id, ttl
+func (_m *Service) Lock(ctx context.Context, id uint, ttl time.Duration) (*models.PostLock, error) {
+	ret := _m.Called(ctx, id, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Lock")
+	}
+
+	var r0 *models.PostLock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Duration) (*models.PostLock, error)); ok {
+		return rf(ctx, id, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Duration) *models.PostLock); ok {
+		r0 = rf(ctx, id, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostLock)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, time.Duration) error); ok {
+		r1 = rf(ctx, id, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgePost provides a mock function with given fields: ctx, id
+func (_m *Service) PurgePost(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgePost")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PutTranslation provides a mock function with given fields: ctx, id, locale, req
+func (_m *Service) PutTranslation(ctx context.Context, id uint, locale string, req models.TranslationRequest) (*models.PostTranslation, bool, error) {
+	ret := _m.Called(ctx, id, locale, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutTranslation")
+	}
+
+	var r0 *models.PostTranslation
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, models.TranslationRequest) (*models.PostTranslation, bool, error)); ok {
+		return rf(ctx, id, locale, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, models.TranslationRequest) *models.PostTranslation); ok {
+		r0 = rf(ctx, id, locale, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostTranslation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, models.TranslationRequest) bool); ok {
+		r1 = rf(ctx, id, locale, req)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uint, string, models.TranslationRequest) error); ok {
+		r2 = rf(ctx, id, locale, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RestorePost provides a mock function with given fields: ctx, id
+func (_m *Service) RestorePost(ctx context.Context, id uint) (*models.BlogPost, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestorePost")
+	}
+
+	var r0 *models.BlogPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) (*models.BlogPost, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *models.BlogPost); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreRevision provides a mock function with given fields: ctx, id, revisionID
+func (_m *Service) RestoreRevision(ctx context.Context, id uint, revisionID uint) (*models.BlogPost, error) {
+	ret := _m.Called(ctx, id, revisionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreRevision")
+	}
+
+	var r0 *models.BlogPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) (*models.BlogPost, error)); ok {
+		return rf(ctx, id, revisionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) *models.BlogPost); ok {
+		r0 = rf(ctx, id, revisionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BlogPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, id, revisionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Seed provides a mock function with given fields: ctx, ds
+func (_m *Service) Seed(ctx context.Context, ds *seed.Dataset) (*seed.Report, error) {
+	ret := _m.Called(ctx, ds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Seed")
+	}
+
+	var r0 *seed.Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *seed.Dataset) (*seed.Report, error)); ok {
+		return rf(ctx, ds)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *seed.Dataset) *seed.Report); ok {
+		r0 = rf(ctx, ds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*seed.Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *seed.Dataset) error); ok {
+		r1 = rf(ctx, ds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unlock provides a mock function with given fields: ctx, id, force
+func (_m *Service) Unlock(ctx context.Context, id uint, force bool) error {
+	ret := _m.Called(ctx, id, force)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unlock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, bool) error); ok {
+		r0 = rf(ctx, id, force)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, post
+func (_m *Service) Update(ctx context.Context, id uint, post *models.UpdateBlogRequest) (*models.BlogPost, error) {
+	ret := _m.Called(ctx, id, post)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
@@ -129,19 +697,79 @@ func (_m *Service) Update(id uint, post *models.UpdateBlogRequest) (*models.Blog
 
 	var r0 *models.BlogPost
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uint, *models.UpdateBlogRequest) (*models.BlogPost, error)); ok {
-		return rf(id, post)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, *models.UpdateBlogRequest) (*models.BlogPost, error)); ok {
+		return rf(ctx, id, post)
 	}
-	if rf, ok := ret.Get(0).(func(uint, *models.UpdateBlogRequest) *models.BlogPost); ok {
-		r0 = rf(id, post)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, *models.UpdateBlogRequest) *models.BlogPost); ok {
+		r0 = rf(ctx, id, post)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.BlogPost)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uint, *models.UpdateBlogRequest) error); ok {
-		r1 = rf(id, post)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, *models.UpdateBlogRequest) error); ok {
+		r1 = rf(ctx, id, post)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateBatch provides a mock function with given fields: ctx, items, mode
+func (_m *Service) UpdateBatch(ctx context.Context, items []models.BulkUpdateItem, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	ret := _m.Called(ctx, items, mode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBatch")
+	}
+
+	var r0 []models.BulkOutcome
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.BulkUpdateItem, models.BulkMode) ([]models.BulkOutcome, error)); ok {
+		return rf(ctx, items, mode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []models.BulkUpdateItem, models.BulkMode) []models.BulkOutcome); ok {
+		r0 = rf(ctx, items, mode)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BulkOutcome)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []models.BulkUpdateItem, models.BulkMode) error); ok {
+		r1 = rf(ctx, items, mode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTranslation provides a mock function with given fields: ctx, id, locale, req
+func (_m *Service) UpdateTranslation(ctx context.Context, id uint, locale string, req *models.UpdateTranslationRequest) (*models.PostTranslation, error) {
+	ret := _m.Called(ctx, id, locale, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTranslation")
+	}
+
+	var r0 *models.PostTranslation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, *models.UpdateTranslationRequest) (*models.PostTranslation, error)); ok {
+		return rf(ctx, id, locale, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, *models.UpdateTranslationRequest) *models.PostTranslation); ok {
+		r0 = rf(ctx, id, locale, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PostTranslation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, *models.UpdateTranslationRequest) error); ok {
+		r1 = rf(ctx, id, locale, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audited actions.
+const (
+	AuditPostCreated        = "post.created"
+	AuditPostUpdated        = "post.updated"
+	AuditPostDeleted        = "post.deleted"
+	AuditPostRestored       = "post.restored"
+	AuditPostPurged         = "post.purged"
+	AuditPostsImported      = "posts.imported"
+	AuditPostForceUnlocked  = "post.force_unlocked"
+	AuditTranslationCreated = "post.translation_created"
+	AuditTranslationUpdated = "post.translation_updated"
+	AuditTranslationDeleted = "post.translation_deleted"
+	AuditUserCreated        = "user.created"
+	AuditAPIKeyIssued       = "api_key.issued"
+	AuditWebhookCreated     = "webhook.created"
+	AuditWebhookUpdated     = "webhook.updated"
+	AuditWebhookDeleted     = "webhook.deleted"
+	AuditTenantCreated      = "tenant.created"
+	AuditTenantUpdated      = "tenant.updated"
+	AuditSignedIn           = "user.signed_in"
+	AuditSignInFailed       = "user.sign_in_failed"
+)
+
+// Change is the value of a field before and after an action. Before is nil
+// for created entities and After for deleted ones.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Changes maps field names to how they changed.
+type Changes map[string]Change
+
+// AuditEntry records an action, who took it and from where. The entries of a
+// blog form a hash chain: each one's Hash covers its contents and the
+// previous entry's hash, so that altering or removing an entry, or moving it
+// to another blog, breaks the chain from there on.
+type AuditEntry struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID uint   `gorm:"not null;index" json:"-"`
+	Action   string `gorm:"index" json:"action"`
+	ActorID  *uint  `gorm:"index" json:"actor_id"`
+	// Actor is the actor's email at the time, kept should the user go.
+	Actor     string  `json:"actor,omitempty"`
+	IP        string  `json:"ip,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	RequestID string  `gorm:"index" json:"request_id,omitempty"`
+	Entity    string  `gorm:"index:idx_audit_entries_entity" json:"entity"`
+	EntityID  uint    `gorm:"index:idx_audit_entries_entity" json:"entity_id"`
+	Changes   Changes `gorm:"type:jsonb;serializer:json" json:"changes,omitempty"`
+	// Details holds whatever else is worth knowing about the action.
+	Details   json.RawMessage `gorm:"type:jsonb" json:"details,omitempty"`
+	CreatedAt time.Time       `gorm:"index" json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `gorm:"uniqueIndex" json:"hash"`
+}
+
+// Seal stamps the entry with the time and chains it to the entry before it,
+// whose hash is prev. The time is rounded to what the database stores.
+func (e *AuditEntry) Seal(prev string, at time.Time) {
+	e.CreatedAt = at.UTC().Truncate(time.Microsecond)
+	e.PrevHash = prev
+	e.Hash = e.ComputeHash()
+}
+
+// ComputeHash returns the hex SHA-256 of the entry's contents, PrevHash
+// included. JSON values are hashed in a canonical form, so that the hash
+// survives the database reformatting them.
+func (e *AuditEntry) ComputeHash() string {
+	var actorID uint
+	if e.ActorID != nil {
+		actorID = *e.ActorID
+	}
+	data, _ := json.Marshal(struct {
+		PrevHash  string `json:"prev_hash"`
+		Action    string `json:"action"`
+		ActorID   uint   `json:"actor_id"`
+		Actor     string `json:"actor"`
+		IP        string `json:"ip"`
+		UserAgent string `json:"user_agent"`
+		RequestID string `json:"request_id"`
+		Entity    string `json:"entity"`
+		EntityID  uint   `json:"entity_id"`
+		Changes   any    `json:"changes"`
+		Details   any    `json:"details"`
+		CreatedAt string `json:"created_at"`
+	}{
+		e.PrevHash, e.Action, actorID, e.Actor, e.IP, e.UserAgent, e.RequestID, e.Entity, e.EntityID,
+		canonical(e.Changes), canonical(e.Details), e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonical returns v as generic JSON values, which marshal with sorted
+// keys and without insignificant whitespace.
+func canonical(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if json.Unmarshal(data, &out) != nil {
+		return nil
+	}
+	return out
+}
+
+// AuditFilter narrows down a search of the audit log. Zero fields match
+// everything.
+type AuditFilter struct {
+	Action    string
+	ActorID   uint
+	Entity    string
+	EntityID  uint
+	RequestID string
+	From, To  time.Time
+}
@@ -0,0 +1,66 @@
+package models
+
+// BulkMode selects how a bulk request treats failing items.
+type BulkMode string
+
+const (
+	// BulkAtomic applies every item or none of them.
+	BulkAtomic BulkMode = "atomic"
+	// BulkBestEffort applies every item that can be applied.
+	BulkBestEffort BulkMode = "best_effort"
+)
+
+type BulkCreateRequest struct {
+	Mode  BulkMode            `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Items []CreateBlogRequest `json:"items" validate:"required,min=1"`
+}
+
+func (r *BulkCreateRequest) Normalize() {
+	for i := range r.Items {
+		r.Items[i].Normalize()
+	}
+}
+
+type BulkUpdateItem struct {
+	ID uint `json:"id" validate:"required"`
+	UpdateBlogRequest
+}
+
+type BulkUpdateRequest struct {
+	Mode  BulkMode         `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Items []BulkUpdateItem `json:"items" validate:"required,min=1"`
+}
+
+func (r *BulkUpdateRequest) Normalize() {
+	for i := range r.Items {
+		r.Items[i].Normalize()
+	}
+}
+
+type BulkDeleteRequest struct {
+	Mode BulkMode `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	IDs  []uint   `json:"ids" validate:"required,min=1,dive,required"`
+}
+
+func (r *BulkDeleteRequest) Normalize() {}
+
+// BulkOutcome is the service-level result of one item of a bulk operation.
+type BulkOutcome struct {
+	ID  uint
+	Err error
+}
+
+// BulkResult reports what happened to one item, in request order.
+type BulkResult struct {
+	Index  int      `json:"index"`
+	ID     uint     `json:"id,omitempty"`
+	Status int      `json:"status"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode      BulkMode     `json:"mode"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey holds the response to a request sent with an
+// Idempotency-Key header, so that retries of it can be answered the same way.
+type IdempotencyKey struct {
+	Key         string `gorm:"primaryKey"`
+	Fingerprint string
+	// Status is zero while the first request is still being handled.
+	Status    int
+	Header    map[string]string `gorm:"serializer:json;type:jsonb"`
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index"`
+}
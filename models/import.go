@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ImportState is the progress of a resumable import, such as that of a
+// WordPress export, under a name chosen by whoever runs it. It is saved in
+// the same transaction as the posts it covers, so that resuming neither
+// repeats nor misses any.
+type ImportState struct {
+	ID        uint            `gorm:"primaryKey" json:"-"`
+	TenantID  uint            `gorm:"not null;uniqueIndex:idx_import_states_tenant_name" json:"-"`
+	Name      string          `gorm:"not null;uniqueIndex:idx_import_states_tenant_name" json:"name"`
+	State     json.RawMessage `gorm:"type:jsonb;not null" json:"state"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
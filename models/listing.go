@@ -0,0 +1,28 @@
+package models
+
+// DefaultPageSize and MaxPageSize bound the number of posts listed at once.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// PostFilter narrows down a listing of posts. Zero fields match everything.
+type PostFilter struct {
+	Status string
+	// Tag is the slug of a tag the posts must have.
+	Tag string
+	// Query matches posts with it in their title, description or body,
+	// ignoring case.
+	Query string
+	IDs   []uint
+	// Offset and Limit select a page of the matching posts; a zero Limit
+	// is DefaultPageSize.
+	Offset int
+	Limit  int
+}
+
+// PostList is a page of posts with the number of posts on every page.
+type PostList struct {
+	Posts []BlogPost `json:"posts"`
+	Total int64      `json:"total"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// PostLock is an editor's check-out of a post. While it is held, only its
+// holder may update the post. Locks expire unless renewed; an expired lock
+// is as good as none and is overwritten by the next one acquired.
+type PostLock struct {
+	PostID     uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	Post       *BlogPost `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	TenantID   uint      `gorm:"not null;index" json:"-"`
+	UserID     uint      `gorm:"index" json:"user_id"`
+	User       *User     `json:"user,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Held reports whether the lock is still in force at the given time.
+func (l *PostLock) Held(now time.Time) bool {
+	return l != nil && now.Before(l.ExpiresAt)
+}
+
+// Holder names the user holding the lock.
+func (l *PostLock) Holder() string {
+	switch {
+	case l.User == nil:
+		return "another user"
+	case l.User.Name != "":
+		return l.User.Name
+	default:
+		return l.User.Email
+	}
+}
@@ -2,13 +2,67 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Post statuses. Drafts are only visible to their authors.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
 )
 
 type BlogPost struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	TenantID    uint   `gorm:"not null;index" json:"-"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Body        string `json:"body"`
+	// Locale is the language of the title, description and body: the
+	// post's own, or that of the translation it is served in.
+	Locale string `gorm:"not null;default:en" json:"locale"`
+	Status string `gorm:"not null;default:published" json:"status"`
+	// PublishedAt is when the post was first published.
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// DeletedAt is when the post was moved to the trash. Trashed posts are
+	// left out of every query unless asked for, and can be restored until
+	// purged.
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	Tags       []Tag          `gorm:"many2many:post_tags" json:"tags,omitempty"`
+	Categories []Category     `gorm:"many2many:post_categories" json:"categories,omitempty"`
+	Comments   []Comment      `json:"comments,omitempty"`
+	// Translations lists the languages the post is available in, when it
+	// is served localised.
+	Translations []PostLocale `gorm:"-" json:"translations,omitempty"`
+}
+
+// Tag is a free-form label shared between the posts of a blog.
+type Tag struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID uint   `gorm:"not null;uniqueIndex:idx_tags_tenant_slug" json:"-"`
+	Name     string `json:"name"`
+	Slug     string `gorm:"uniqueIndex:idx_tags_tenant_slug" json:"slug"`
+}
+
+// Category groups posts by topic. Categories may be nested.
+type Category struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID uint   `gorm:"not null;uniqueIndex:idx_categories_tenant_slug" json:"-"`
+	Name     string `json:"name"`
+	Slug     string `gorm:"uniqueIndex:idx_categories_tenant_slug" json:"slug"`
+	ParentID *uint  `json:"parent_id,omitempty"`
+}
+
+// Comment is a reader's comment on a post.
+type Comment struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
+	BlogPostID  uint      `gorm:"index" json:"post_id"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"-"`
+	AuthorURL   string    `json:"author_url,omitempty"`
 	Body        string    `json:"body"`
+	Approved    bool      `json:"approved"`
 	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
 }
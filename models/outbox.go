@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types written to the outbox.
+const (
+	EventPostCreated   = "post.created"
+	EventPostUpdated   = "post.updated"
+	EventPostPublished = "post.published"
+	EventPostDeleted   = "post.deleted"
+)
+
+// OutboxEvent is a domain event waiting to be published. It is written in the
+// same transaction as the change it describes; its JSON form is what sinks
+// receive.
+type OutboxEvent struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	TenantID   uint            `gorm:"not null;index" json:"tenant_id,omitempty"`
+	Type       string          `json:"type"`
+	PostID     uint            `gorm:"index" json:"post_id"`
+	Data       json.RawMessage `gorm:"type:jsonb" json:"data"`
+	OccurredAt time.Time       `json:"occurred_at"`
+
+	Attempts int `json:"-"`
+	// NextAttemptAt is when the event may next be picked up, either for the
+	// first time or after a failed or abandoned attempt.
+	NextAttemptAt time.Time  `gorm:"index" json:"-"`
+	PublishedAt   *time.Time `gorm:"index" json:"-"`
+	LastError     string     `json:"-"`
+}
@@ -1,17 +1,59 @@
 package models
 
-import "github.com/go-playground/validator/v10"
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
 
 type CreateBlogRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description" validate:"required"`
-	Body        string `json:"body" validate:"required"`
+	Title       string `json:"title" validate:"required,notblank,max=200"`
+	Description string `json:"description" validate:"required,notblank,max=500"`
+	Body        string `json:"body" validate:"required,notblank,max=100000"`
+	// Status defaults to published.
+	Status string `json:"status" validate:"omitempty,oneof=draft published"`
+	// Locale is the language the post is written in, en by default.
+	Locale string `json:"locale" validate:"omitempty,locale"`
+}
+
+// Normalize trims surrounding whitespace and converts the text to Unicode
+// NFC so that visually identical input is stored identically.
+func (r *CreateBlogRequest) Normalize() {
+	r.Title = normalize(r.Title)
+	r.Description = normalize(r.Description)
+	r.Body = normalizeBody(r.Body)
+	if l, ok := CanonicalLocale(r.Locale); ok {
+		r.Locale = l
+	}
 }
 
 type UpdateBlogRequest struct {
-	Title       *string `json:"title" validate:"omitempty"`       // Optional
-	Description *string `json:"description" validate:"omitempty"` // Optional
-	Body        *string `json:"body" validate:"omitempty"`        // Optional
+	Title       *string `json:"title" validate:"omitnil,notblank,max=200"`       // Optional
+	Description *string `json:"description" validate:"omitnil,notblank,max=500"` // Optional
+	Body        *string `json:"body" validate:"omitnil,notblank,max=100000"`     // Optional
+	Status      *string `json:"status" validate:"omitnil,oneof=draft published"` // Optional
+}
+
+// Normalize applies the same clean-up as CreateBlogRequest.Normalize to the
+// fields that are present.
+func (r *UpdateBlogRequest) Normalize() {
+	if r.Title != nil {
+		*r.Title = normalize(*r.Title)
+	}
+	if r.Description != nil {
+		*r.Description = normalize(*r.Description)
+	}
+	if r.Body != nil {
+		*r.Body = normalizeBody(*r.Body)
+	}
+}
+
+// normalize trims and collapses runs of whitespace in single-line fields.
+func normalize(s string) string {
+	return norm.NFC.String(strings.Join(strings.Fields(s), " "))
 }
 
-var Validate = validator.New()
+// normalizeBody only trims, preserving the body's line breaks and indentation.
+func normalizeBody(s string) string {
+	return norm.NFC.String(strings.TrimSpace(s))
+}
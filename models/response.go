@@ -1,5 +1,15 @@
 package models
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// Problem is an RFC 9457 problem details object, served as
+// application/problem+json for every error response.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists per-field problems for validation failures.
+	Errors []FieldError `json:"errors,omitempty"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PostRevision is a post's title, description, body and status as saved by
+// a create or an update. A post's revisions are its history, newest last.
+type PostRevision struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	TenantID uint `gorm:"not null;index" json:"-"`
+	PostID   uint `gorm:"not null;index" json:"post_id"`
+	// Post is only declared for revisions to be deleted with their post.
+	Post        *BlogPost `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Body        string    `json:"body"`
+	Status      string    `json:"status"`
+	// AuthorID is the user who saved the revision, if any; Author is their
+	// email at the time, kept should the user go.
+	AuthorID  *uint     `json:"author_id,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
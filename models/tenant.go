@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DefaultTenant is the slug of the blog created by migrations, which owns
+// everything written before there were several.
+const DefaultTenant = "default"
+
+// DefaultFeedItems is the number of posts in a blog's feeds unless it sets
+// its own.
+const DefaultFeedItems = 20
+
+// Tenant is one of the blogs hosted by the deployment. Each has its own
+// posts, users, API keys, webhooks and audit log, and never sees another's.
+type Tenant struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// Slug names the blog in paths prefixed with /t/{slug}.
+	Slug string `gorm:"not null;uniqueIndex" json:"slug"`
+	// Host is the domain name the blog is served on, if it has its own.
+	Host        *string `gorm:"uniqueIndex" json:"host,omitempty"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	// FeedItems is the number of latest posts in the blog's feeds; zero uses
+	// DefaultFeedItems. FeedFullText puts whole posts in feeds rather than
+	// their descriptions.
+	FeedItems    int  `json:"feed_items"`
+	FeedFullText bool `json:"feed_full_text"`
+	// CORSOrigins are the origins browsers may call the API from; empty
+	// allows any.
+	CORSOrigins []string  `gorm:"type:jsonb;serializer:json" json:"cors_origins"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// FeedSize returns the number of posts in the blog's feeds.
+func (t *Tenant) FeedSize() int {
+	if t.FeedItems <= 0 {
+		return DefaultFeedItems
+	}
+	return t.FeedItems
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultPostLocale is the language of posts created without one.
+const DefaultPostLocale = "en"
+
+// PostTranslation is a post's title, description and body in another
+// language than the post's own.
+type PostTranslation struct {
+	ID       uint `gorm:"primaryKey" json:"-"`
+	TenantID uint `gorm:"not null;index" json:"-"`
+	PostID   uint `gorm:"not null;uniqueIndex:idx_post_translations_post_locale" json:"post_id"`
+	// Post is only declared for translations to be deleted with their post.
+	Post        *BlogPost `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Locale      string    `gorm:"not null;uniqueIndex:idx_post_translations_post_locale" json:"locale"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PostLocale is a language a post is available in.
+type PostLocale struct {
+	Locale string `json:"locale"`
+	// Original is set for the post's own language.
+	Original  bool      `json:"original,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TranslationRequest creates or replaces a translation.
+type TranslationRequest struct {
+	Title       string `json:"title" validate:"required,notblank,max=200"`
+	Description string `json:"description" validate:"required,notblank,max=500"`
+	Body        string `json:"body" validate:"required,notblank,max=100000"`
+}
+
+// Normalize applies the same clean-up as CreateBlogRequest.Normalize.
+func (r *TranslationRequest) Normalize() {
+	r.Title = normalize(r.Title)
+	r.Description = normalize(r.Description)
+	r.Body = normalizeBody(r.Body)
+}
+
+// UpdateTranslationRequest changes some fields of a translation.
+type UpdateTranslationRequest struct {
+	Title       *string `json:"title" validate:"omitnil,notblank,max=200"`
+	Description *string `json:"description" validate:"omitnil,notblank,max=500"`
+	Body        *string `json:"body" validate:"omitnil,notblank,max=100000"`
+}
+
+// Normalize applies the same clean-up as UpdateBlogRequest.Normalize.
+func (r *UpdateTranslationRequest) Normalize() {
+	if r.Title != nil {
+		*r.Title = normalize(*r.Title)
+	}
+	if r.Description != nil {
+		*r.Description = normalize(*r.Description)
+	}
+	if r.Body != nil {
+		*r.Body = normalizeBody(*r.Body)
+	}
+}
+
+// Alternate is a version of a page in another language, as listed by
+// hreflang links in sitemaps. Hreflang is x-default for the version served
+// when none of the others matches.
+type Alternate struct {
+	Hreflang string `json:"hreflang"`
+	Href     string `json:"href"`
+}
+
+// SitemapEntry is a published post with its versions in every language.
+type SitemapEntry struct {
+	Loc string `json:"loc"`
+	// LastMod is when the post or any of its translations last changed.
+	LastMod    time.Time   `json:"lastmod"`
+	Alternates []Alternate `json:"alternates"`
+}
+
+// CanonicalLocale returns the BCP 47 language tag s in its canonical form,
+// such as de-AT for DE-at, and whether s is one.
+func CanonicalLocale(s string) (string, bool) {
+	tag, err := language.Parse(s)
+	if err != nil || tag == language.Und {
+		return "", false
+	}
+	return tag.String(), true
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Roles a user can have.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleAuthor = "author"
+)
+
+// Roles lists every role.
+var Roles = []string{RoleAdmin, RoleEditor, RoleAuthor}
+
+// User is someone who can administer or write for a blog.
+type User struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TenantID     uint      `gorm:"not null;uniqueIndex:idx_users_tenant_email" json:"-"`
+	Email        string    `gorm:"uniqueIndex:idx_users_tenant_email" json:"email"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// APIKey authenticates programmatic access on behalf of a user. Only a hash
+// of the key is stored; Prefix identifies it without revealing it.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   uint       `gorm:"not null;index" json:"-"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	User       User       `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `gorm:"uniqueIndex" json:"prefix"`
+	Hash       string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/ja"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+)
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// DefaultLocale is used for validation messages when the client's
+// Accept-Language matches none of Locales.
+const DefaultLocale = "en"
+
+// Locales lists the languages validation messages are available in.
+var Locales = []string{"en", "fr", "ja"}
+
+var Validate = validator.New(validator.WithRequiredStructEnabled())
+
+var translations = ut.New(en.New(), en.New(), fr.New(), ja.New())
+
+// customMessages translates the custom rules.
+var customMessages = map[string]map[string]string{
+	"notblank": {
+		"en": "{0} must not be blank",
+		"fr": "{0} ne doit pas être vide",
+		"ja": "{0}は空白のみにできません",
+	},
+	"locale": {
+		"en": "{0} must be a language tag such as en or de-AT",
+		"fr": "{0} doit être une étiquette de langue comme en ou de-AT",
+		"ja": "{0}はenやde-ATのような言語タグでなければなりません",
+	},
+}
+
+// codes maps validator tags to the stable codes returned to clients.
+var codes = map[string]string{
+	"required": "required",
+	"notblank": "blank",
+	"max":      "too_long",
+	"min":      "too_short",
+	"oneof":    "invalid_choice",
+	"http_url": "invalid_url",
+	"unique":   "duplicate",
+	"locale":   "invalid_locale",
+}
+
+func init() {
+	// Report fields by their JSON names rather than their Go names.
+	Validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := Validate.RegisterValidation("notblank", notBlank); err != nil {
+		panic(err)
+	}
+	if err := Validate.RegisterValidation("locale", validLocale); err != nil {
+		panic(err)
+	}
+
+	register := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"fr": fr_translations.RegisterDefaultTranslations,
+		"ja": ja_translations.RegisterDefaultTranslations,
+	}
+	for locale, fn := range register {
+		trans, _ := translations.GetTranslator(locale)
+		if err := fn(Validate, trans); err != nil {
+			panic(err)
+		}
+		for tag, messages := range customMessages {
+			msg := messages[locale]
+			err := Validate.RegisterTranslation(tag, trans,
+				func(ut ut.Translator) error { return ut.Add(tag, msg, true) },
+				func(ut ut.Translator, fe validator.FieldError) string {
+					t, _ := ut.T(tag, fe.Field())
+					return t
+				})
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// notBlank rejects strings made up only of whitespace.
+func notBlank(fl validator.FieldLevel) bool {
+	return strings.TrimFunc(fl.Field().String(), unicode.IsSpace) != ""
+}
+
+// validLocale accepts BCP 47 language tags.
+func validLocale(fl validator.FieldLevel) bool {
+	_, ok := CanonicalLocale(fl.Field().String())
+	return ok
+}
+
+// FieldErrors converts an error returned by Validate into per-field errors
+// with messages in the given locale. It returns nil for any other error.
+func FieldErrors(err error, locale string) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	trans, _ := translations.GetTranslator(locale)
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		code, ok := codes[fe.Tag()]
+		if !ok {
+			code = fe.Tag()
+		}
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Code:    code,
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookSubscription is a partner endpoint notified of post events. The
+// secret signs every delivery and is only shown when the subscription is
+// created.
+type WebhookSubscription struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID uint   `gorm:"not null;index" json:"-"`
+	URL      string `json:"url"`
+	Secret   string `json:"-"`
+	// Events lists the event types delivered; empty means all of them.
+	Events []string `gorm:"serializer:json;type:jsonb" json:"events"`
+	Active bool     `gorm:"not null;default:true" json:"active"`
+	// ConsecutiveFailures counts failed attempts since the last success. The
+	// subscription is disabled once it reaches the configured limit.
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Matches reports whether events of the given type are delivered to the
+// subscription.
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, eventType)
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a subscription. The
+// deliveries of a subscription form its delivery history.
+type WebhookDelivery struct {
+	ID             uint                 `gorm:"primaryKey" json:"id"`
+	TenantID       uint                 `gorm:"not null;index" json:"-"`
+	SubscriptionID uint                 `gorm:"index" json:"subscription_id"`
+	Subscription   *WebhookSubscription `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	EventID        uint                 `gorm:"index" json:"event_id"`
+	EventType      string               `json:"event_type"`
+	// Payload is the request body: the outbox event as JSON.
+	Payload json.RawMessage `gorm:"type:jsonb" json:"payload" swaggertype:"object"`
+	Status  string          `gorm:"index" json:"status"`
+
+	Attempts int `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is next tried.
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required,http_url,max=2000"`
+	// Events defaults to every event type.
+	Events []string `json:"events" validate:"omitempty,unique,dive,oneof=post.created post.updated post.published post.deleted"`
+	// Secret is generated when omitted.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=200"`
+}
+
+// Normalize trims the URL and secret.
+func (r *CreateWebhookRequest) Normalize() {
+	r.URL = strings.TrimSpace(r.URL)
+	r.Secret = strings.TrimSpace(r.Secret)
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url" validate:"omitnil,http_url,max=2000"`                                                          // Optional
+	Events *[]string `json:"events" validate:"omitnil,unique,dive,oneof=post.created post.updated post.published post.deleted"` // Optional
+	// Active re-enables a disabled subscription, or pauses one.
+	Active *bool `json:"active"` // Optional
+}
+
+// Normalize trims the URL.
+func (r *UpdateWebhookRequest) Normalize() {
+	if r.URL != nil {
+		*r.URL = strings.TrimSpace(*r.URL)
+	}
+}
+
+// CreatedWebhook is the response to creating a subscription, the only one
+// that includes its secret.
+type CreatedWebhook struct {
+	WebhookSubscription
+	Secret string `json:"secret"`
+}
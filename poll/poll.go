@@ -0,0 +1,77 @@
+// Package poll runs the background workers that drain a table of due work,
+// such as the outbox relay and the webhook dispatcher.
+package poll
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PurgeInterval is how often finished items past their retention are
+// deleted.
+const PurgeInterval = time.Hour
+
+// Poller processes batches of due items until its context is cancelled.
+type Poller struct {
+	// Name identifies the worker in logs.
+	Name string
+	// Batch claims and processes one batch of due items, returning how many
+	// it claimed.
+	Batch func(ctx context.Context) (int, error)
+	// BatchSize is the size of a full batch, after which Batch is called
+	// again at once.
+	BatchSize int
+	// Interval is how long to wait before polling again once a batch comes
+	// back short.
+	Interval time.Duration
+	// Purge deletes the finished items older than before, every
+	// PurgeInterval, if Retention is set.
+	Purge     func(ctx context.Context, before time.Time) (int64, error)
+	Retention time.Duration
+	// Now is the clock, time.Now if nil.
+	Now func() time.Time
+}
+
+// Run polls until ctx is cancelled.
+func (p *Poller) Run(ctx context.Context) {
+	now := p.Now
+	if now == nil {
+		now = time.Now
+	}
+	var purged time.Time
+	for {
+		if p.Purge != nil && p.Retention > 0 && now().Sub(purged) >= PurgeInterval {
+			purged = now()
+			if n, err := p.Purge(ctx, purged.Add(-p.Retention)); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "unable to purge", "worker", p.Name, "error", err)
+			} else if n > 0 {
+				slog.InfoContext(ctx, "purged", "worker", p.Name, "count", n)
+			}
+		}
+
+		n, err := p.Batch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "unable to process batch", "worker", p.Name, "error", err)
+		}
+		// Keep draining while full batches come back.
+		if err == nil && n == p.BatchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.Interval):
+		}
+	}
+}
+
+// Backoff is the delay after the given failed attempt, counting from 1:
+// first, doubled with every further attempt, up to limit.
+func Backoff(first, limit time.Duration, attempt int) time.Duration {
+	d := first
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
@@ -0,0 +1,51 @@
+package poll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range []time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 9: 5 * time.Second} {
+		if want != 0 {
+			assert.Equal(t, want, Backoff(time.Second, 5*time.Second, attempt), "attempt %d", attempt)
+		}
+	}
+}
+
+func TestPoller_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var batches, purges int
+	sizes := []int{2, 2, 1}
+	p := &Poller{
+		Name: "test", BatchSize: 2, Interval: time.Hour, Retention: time.Hour,
+		Batch: func(context.Context) (int, error) {
+			n := sizes[batches]
+			if batches++; batches == len(sizes) {
+				cancel()
+			}
+			return n, nil
+		},
+		Purge: func(context.Context, time.Time) (int64, error) {
+			purges++
+			return 0, nil
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return once cancelled")
+	}
+	// Full batches are followed by another at once; the purge is hourly.
+	assert.Equal(t, 3, batches)
+	assert.Equal(t, 1, purges)
+}
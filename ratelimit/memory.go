@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full buckets are dropped from a memory store.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	// full is when the bucket will have refilled completely; after that it
+	// is indistinguishable from a missing one.
+	full time.Time
+}
+
+// memory keeps buckets in process. Each replica counts separately.
+type memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemory returns a Store local to the process.
+func NewMemory() Store {
+	return &memory{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (m *memory) Take(_ context.Context, key string, l Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), at: now}
+		m.buckets[key] = b
+	}
+	b.tokens = refill(l, b.tokens, now.Sub(b.at))
+	b.at = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	r := result(l, b.tokens, allowed)
+	b.full = now.Add(r.Reset)
+	return r, nil
+}
+
+func (m *memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+	m.swept = now
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
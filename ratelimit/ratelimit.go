@@ -0,0 +1,85 @@
+// Package ratelimit implements token-bucket rate limiting over a store that
+// can be local to the process or shared by every replica.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests may be made at once, and the
+// bucket refills at Burst tokens per Per.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// ParseLimit parses a limit written as "burst/period", such as "120/1m".
+func ParseLimit(s string) (Limit, error) {
+	n, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period such as 120/1m", s)
+	}
+	burst, err := strconv.Atoi(n)
+	if err != nil || burst < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: request count must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return Limit{Burst: burst, Per: d}, nil
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Burst) + "/" + l.Per.String()
+}
+
+// perToken is how long the bucket takes to regain one token.
+func (l Limit) perToken() time.Duration {
+	return l.Per / time.Duration(l.Burst)
+}
+
+// Result is the state of a bucket after a request has been counted.
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a request would be allowed, zero when
+	// this one was.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Take removes a token from the bucket under key, if it has one.
+	Take(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago.
+func refill(l Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return tokens
+	}
+	return math.Min(float64(l.Burst), tokens+float64(elapsed)/float64(l.perToken()))
+}
+
+// result describes a bucket left with tokens after a request.
+func result(l Limit, tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     l,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(l.Burst) - tokens) * float64(l.perToken())),
+	}
+	if !allowed {
+		r.RetryAfter = time.Duration((1 - tokens) * float64(l.perToken()))
+	}
+	return r
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "120/1m", want: Limit{Burst: 120, Per: time.Minute}},
+		{in: " 5/1s ", want: Limit{Burst: 5, Per: time.Second}},
+		{in: "120", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "ten/1m", wantErr: true},
+		{in: "10/forever", wantErr: true},
+		{in: "10/-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// testStore runs the behaviour every Store must share. advance moves the
+// store's clock forward.
+func testStore(t *testing.T, s Store, advance func(time.Duration)) {
+	ctx := context.Background()
+	l := Limit{Burst: 3, Per: 3 * time.Second}
+
+	for want := 2; want >= 0; want-- {
+		r, err := s.Take(ctx, "a", l)
+		require.NoError(t, err)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, want, r.Remaining)
+		assert.Zero(t, r.RetryAfter)
+	}
+
+	r, err := s.Take(ctx, "a", l)
+	require.NoError(t, err)
+	assert.False(t, r.Allowed, "burst spent")
+	assert.Equal(t, 0, r.Remaining)
+	assert.InDelta(t, time.Second, r.RetryAfter, float64(10*time.Millisecond))
+	assert.InDelta(t, 3*time.Second, r.Reset, float64(10*time.Millisecond))
+
+	r, err = s.Take(ctx, "b", l)
+	require.NoError(t, err)
+	assert.True(t, r.Allowed, "buckets are per key")
+
+	advance(time.Second)
+	r, err = s.Take(ctx, "a", l)
+	require.NoError(t, err)
+	assert.True(t, r.Allowed, "one token refilled")
+	assert.Equal(t, 0, r.Remaining)
+
+	advance(time.Hour)
+	r, err = s.Take(ctx, "a", l)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Remaining, "refills up to the burst only")
+}
+
+func TestMemory(t *testing.T) {
+	s := NewMemory().(*memory)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	testStore(t, s, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestMemory_sweepsFullBuckets(t *testing.T) {
+	s := NewMemory().(*memory)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	l := Limit{Burst: 10, Per: time.Second}
+
+	_, _ = s.Take(context.Background(), "a", l)
+	now = now.Add(2 * sweepInterval)
+	_, _ = s.Take(context.Background(), "b", l)
+	assert.NotContains(t, s.buckets, "a")
+	assert.Contains(t, s.buckets, "b")
+}
+
+func TestRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s := NewRedis(client).(*redisStore)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	testStore(t, s, func(d time.Duration) {
+		now = now.Add(d)
+		mr.FastForward(d)
+	})
+}
+
+func TestRedis_bucketsExpire(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err := NewRedis(client).Take(context.Background(), "a", Limit{Burst: 10, Per: 10 * time.Second})
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("a"))
+	mr.FastForward(3 * time.Second)
+	assert.False(t, mr.Exists("a"), "gone once it would be full")
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript is the token bucket of Take, run atomically by the server. The
+// bucket is a hash of its tokens and the time they were counted, expiring
+// once it would be full again. Tokens are returned as a string since Redis
+// truncates Lua numbers to integers.
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local per_token = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or burst
+local at = tonumber(state[2]) or now
+if now > at then
+  tokens = math.min(burst, tokens + (now - at) / per_token)
+end
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * per_token) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// redisStore shares buckets between every replica using the server.
+type redisStore struct {
+	client redis.Scripter
+	now    func() time.Time
+}
+
+// NewRedis returns a Store backed by a Redis server, or anything speaking
+// its protocol and running Lua scripts.
+func NewRedis(client redis.Scripter) Store {
+	return &redisStore{client: client, now: time.Now}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, l Limit) (Result, error) {
+	perToken := float64(l.perToken()) / float64(time.Millisecond)
+	now := s.now().UnixMilli()
+	vals, err := takeScript.Run(ctx, s.client, []string{key}, l.Burst, perToken, now).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	tokens, err := strconv.ParseFloat(vals[1].(string), 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(l, tokens, vals[0].(int64) == 1), nil
+}
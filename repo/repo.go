@@ -1,22 +1,171 @@
 package repo
 
 import (
+	"context"
 	"example/models"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // BlogService defines methods for blog operations.
 //
 //go:generate mockery --name=Repository --outpkg mocks
 type Repository interface {
-	Create(post *models.BlogPost) (uint, error)
-	GetAll() ([]models.BlogPost, error)
-	GetByID(id uint) (*models.BlogPost, error)
-	Update(id uint, post *models.BlogPost) error
-	Delete(id uint) error
+	Create(ctx context.Context, post *models.BlogPost) (uint, error)
+	GetAll(ctx context.Context) ([]models.BlogPost, error)
+	GetByID(ctx context.Context, id uint) (*models.BlogPost, error)
+	Update(ctx context.Context, id uint, post *models.BlogPost) error
+	// Delete moves a post to the trash. Returns gorm.ErrRecordNotFound if no
+	// post has the ID.
+	Delete(ctx context.Context, id uint) error
+	CreateBatch(ctx context.Context, posts []*models.BlogPost) error
+	// FindInBatches calls fn with successive pages of posts in ID order so
+	// that every post can be visited without loading them all at once.
+	FindInBatches(ctx context.Context, size int, fn func([]models.BlogPost) error) error
+	// Replace overwrites every column of the post with the same ID,
+	// timestamps included.
+	Replace(ctx context.Context, post *models.BlogPost) error
+	// SyncIDSequence moves the ID sequence past the largest ID in use, which
+	// is needed after inserting posts with explicit IDs.
+	SyncIDSequence(ctx context.Context) error
+	// PostIDTaken reports whether any post has the ID, including one in the
+	// trash or of another blog, as IDs are shared by every blog.
+	PostIDTaken(ctx context.Context, id uint) (bool, error)
+	// FirstOrCreateTag loads the tag with the same slug, creating it if there
+	// is none.
+	FirstOrCreateTag(ctx context.Context, tag *models.Tag) error
+	// FirstOrCreateCategory loads the category with the same slug, creating
+	// it if there is none.
+	FirstOrCreateCategory(ctx context.Context, category *models.Category) error
+	// GetImportState returns gorm.ErrRecordNotFound if no import has been
+	// saved under the name.
+	GetImportState(ctx context.Context, name string) (*models.ImportState, error)
+	// SaveImportState creates or replaces the state saved under st.Name.
+	SaveImportState(ctx context.Context, st *models.ImportState) error
+	CreateUser(ctx context.Context, user *models.User) error
+	// GetUserByEmail returns gorm.ErrRecordNotFound if no user has the email.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	// GetAPIKeyByPrefix loads an API key along with its user.
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	CreateOutboxEvents(ctx context.Context, events []*models.OutboxEvent) error
+	// ClaimOutboxEvents returns up to limit unpublished events that are due,
+	// oldest first, and hides them from other callers for lease. Events held
+	// by a concurrent caller are skipped rather than waited for.
+	ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id uint, at time.Time) error
+	// RetryOutboxEvent records a failed attempt and when to try again.
+	RetryOutboxEvent(ctx context.Context, id uint, next time.Time, lastError string) error
+	// OutboxEventsAfter returns up to limit events with an ID above after, in
+	// ID order. Non-empty types and postIDs only return events matching them.
+	OutboxEventsAfter(ctx context.Context, after uint, types []string, postIDs []uint, limit int) ([]models.OutboxEvent, error)
+	// PurgeOutboxEvents deletes events published before the given time.
+	PurgeOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+	CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	// GetWebhookSubscription returns gorm.ErrRecordNotFound if no
+	// subscription has the ID.
+	GetWebhookSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	// DeleteWebhookSubscription deletes a subscription along with its
+	// deliveries. Returns gorm.ErrRecordNotFound if no subscription has the ID.
+	DeleteWebhookSubscription(ctx context.Context, id uint) error
+	// ActiveWebhookSubscriptions lists the subscriptions new events are
+	// delivered to.
+	ActiveWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	// ClaimWebhookDeliveries returns up to limit pending deliveries to active
+	// subscriptions that are due, oldest first and with their subscription,
+	// and hides them from other callers for lease.
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	// SaveWebhookAttempt stores the outcome of an attempt at a delivery and
+	// counts it towards its subscription's consecutive failures. A
+	// subscription reaching disableAfter failures is disabled, which is
+	// reported; zero never disables it.
+	SaveWebhookAttempt(ctx context.Context, d *models.WebhookDelivery, disableAfter int) (disabled bool, err error)
+	// ListWebhookDeliveries returns up to limit deliveries of a subscription,
+	// newest first. A non-zero before only returns deliveries with a lower ID.
+	ListWebhookDeliveries(ctx context.Context, subscriptionID, before uint, limit int) ([]models.WebhookDelivery, error)
+	// GetWebhookDelivery returns gorm.ErrRecordNotFound if the subscription
+	// has no delivery with the ID.
+	GetWebhookDelivery(ctx context.Context, subscriptionID, id uint) (*models.WebhookDelivery, error)
+	// PurgeWebhookDeliveries deletes finished deliveries created before the
+	// given time.
+	PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+	// GetPostLock loads a post's lock along with its holder, expired or not.
+	// Returns gorm.ErrRecordNotFound if the post was never locked.
+	GetPostLock(ctx context.Context, postID uint) (*models.PostLock, error)
+	// GetPostLockForUpdate is GetPostLock locking the row until the
+	// transaction ends, so that the lock can't be taken over before a write
+	// checked against it commits.
+	GetPostLockForUpdate(ctx context.Context, postID uint) (*models.PostLock, error)
+	// AcquirePostLock stores the lock unless another user holds one on the
+	// post that hasn't expired by now, which is reported. Renewing one's own
+	// lock keeps its acquisition time.
+	AcquirePostLock(ctx context.Context, lock *models.PostLock, now time.Time) (acquired bool, err error)
+	// DeletePostLock deletes a post's lock if userID holds it.
+	DeletePostLock(ctx context.Context, postID, userID uint) (deleted bool, err error)
+	// AppendAuditEntry seals the entry, chaining it to the last one, and
+	// stores it. Appends are serialised until the surrounding transaction,
+	// if any, ends.
+	AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	// ListAuditEntries returns up to limit entries matching the filter,
+	// newest first. A non-zero before only returns entries with a lower ID.
+	ListAuditEntries(ctx context.Context, f models.AuditFilter, before uint, limit int) ([]models.AuditEntry, error)
+	// AuditEntriesInBatches calls fn with successive pages of the entries
+	// matching the filter, oldest first.
+	AuditEntriesInBatches(ctx context.Context, f models.AuditFilter, size int, fn func([]models.AuditEntry) error) error
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	// UpdateTenant saves every setting of a blog.
+	UpdateTenant(ctx context.Context, tenant *models.Tenant) error
+	// ListTenants returns every blog, in ID order.
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+	// GetTenantBySlug returns gorm.ErrRecordNotFound if no blog has the slug.
+	GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	// ListTranslations returns the translations of the given posts, ordered
+	// by post and locale.
+	ListTranslations(ctx context.Context, postIDs []uint) ([]models.PostTranslation, error)
+	// GetTranslation returns gorm.ErrRecordNotFound if the post has no
+	// translation in the locale.
+	GetTranslation(ctx context.Context, postID uint, locale string) (*models.PostTranslation, error)
+	CreateTranslation(ctx context.Context, t *models.PostTranslation) error
+	// UpdateTranslation saves every field of a translation.
+	UpdateTranslation(ctx context.Context, t *models.PostTranslation) error
+	// ListPosts returns the posts matching f on the page it selects, newest
+	// first, with their tags, and the number of posts matching f.
+	ListPosts(ctx context.Context, f models.PostFilter) ([]models.BlogPost, int64, error)
+	// GetTag returns gorm.ErrRecordNotFound if there is no tag with the
+	// slug.
+	GetTag(ctx context.Context, slug string) (*models.Tag, error)
+	// DeleteTranslation returns gorm.ErrRecordNotFound if the post has no
+	// translation in the locale.
+	DeleteTranslation(ctx context.Context, postID uint, locale string) error
+	// ListTrash returns the posts in the trash, most recently trashed first.
+	ListTrash(ctx context.Context) ([]models.BlogPost, error)
+	// RestorePost takes a post out of the trash. Returns
+	// gorm.ErrRecordNotFound if the trash holds no post with the ID.
+	RestorePost(ctx context.Context, id uint) error
+	// PurgePost deletes a post in the trash for good, along with its
+	// translations, revisions and lock. Returns gorm.ErrRecordNotFound if
+	// the trash holds no post with the ID.
+	PurgePost(ctx context.Context, id uint) error
+	CreateRevision(ctx context.Context, rev *models.PostRevision) error
+	// ListRevisions returns a post's revisions, newest first.
+	ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error)
+	// GetRevision returns gorm.ErrRecordNotFound if the post has no revision
+	// with the ID.
+	GetRevision(ctx context.Context, postID, id uint) (*models.PostRevision, error)
+	// Transaction runs fn with a Repository bound to a single database
+	// transaction, committing if fn returns nil and rolling back otherwise.
+	Transaction(ctx context.Context, fn func(Repository) error) error
 }
 
+// createBatchSize is the number of rows sent per INSERT by CreateBatch.
+const createBatchSize = 100
+
 // BlogServiceImpl implements BlogService
 type repo struct {
 	db *gorm.DB
@@ -27,8 +176,8 @@ func NewRepo(db *gorm.DB) *repo {
 }
 
 // Create a new blog post
-func (r *repo) Create(post *models.BlogPost) (uint, error) {
-	err := r.db.Create(post).Error
+func (r *repo) Create(ctx context.Context, post *models.BlogPost) (uint, error) {
+	err := r.db.WithContext(ctx).Create(post).Error
 	if err != nil {
 		return 0, err
 	}
@@ -36,25 +185,631 @@ func (r *repo) Create(post *models.BlogPost) (uint, error) {
 }
 
 // Get all blog posts
-func (r *repo) GetAll() ([]models.BlogPost, error) {
+func (r *repo) GetAll(ctx context.Context) ([]models.BlogPost, error) {
 	var posts []models.BlogPost
-	err := r.db.Find(&posts).Error
+	err := r.db.WithContext(ctx).Find(&posts).Error
 	return posts, err
 }
 
 // Get a single blog post by ID
-func (r *repo) GetByID(id uint) (*models.BlogPost, error) {
+func (r *repo) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
 	var post models.BlogPost
-	err := r.db.First(&post, id).Error
+	err := r.db.WithContext(ctx).First(&post, id).Error
 	return &post, err
 }
 
 // Update a blog post
-func (r *repo) Update(id uint, post *models.BlogPost) error {
-	return r.db.Model(&models.BlogPost{}).Where("id = ?", id).Updates(post).Error
+func (r *repo) Update(ctx context.Context, id uint, post *models.BlogPost) error {
+	return r.db.WithContext(ctx).Model(&models.BlogPost{}).Where("id = ?", id).Updates(post).Error
+}
+
+// Move a blog post to the trash. Returns gorm.ErrRecordNotFound if no post
+// has the ID.
+func (r *repo) Delete(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Delete(&models.BlogPost{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Create many blog posts with multi-row inserts. IDs are set on the posts.
+func (r *repo) CreateBatch(ctx context.Context, posts []*models.BlogPost) error {
+	return r.db.WithContext(ctx).CreateInBatches(posts, createBatchSize).Error
+}
+
+// Run fn inside a transaction
+func (r *repo) Transaction(ctx context.Context, fn func(Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repo{db: tx})
+	})
+}
+
+// Visit every blog post, size posts at a time
+func (r *repo) FindInBatches(ctx context.Context, size int, fn func([]models.BlogPost) error) error {
+	var posts []models.BlogPost
+	return r.db.WithContext(ctx).Order("id").FindInBatches(&posts, size, func(tx *gorm.DB, batch int) error {
+		return fn(posts)
+	}).Error
+}
+
+// Replace a blog post, keeping the timestamps given; those left zero keep
+// their stored values
+func (r *repo) Replace(ctx context.Context, post *models.BlogPost) error {
+	var omit []string
+	if post.CreatedAt.IsZero() {
+		omit = append(omit, "created_at")
+	}
+	if post.UpdatedAt.IsZero() {
+		omit = append(omit, "updated_at")
+	}
+	res := r.db.WithContext(ctx).Model(&models.BlogPost{ID: post.ID}).Select("*").Omit(omit...).UpdateColumns(post)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Sync the blog_posts ID sequence with the table
+func (r *repo) SyncIDSequence(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Exec(`SELECT setval(pg_get_serial_sequence('blog_posts', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM blog_posts`).
+		Error
+}
+
+// Check whether a post of any blog has the ID, trashed or not
+func (r *repo) PostIDTaken(ctx context.Context, id uint) (bool, error) {
+	var taken bool
+	err := r.db.WithContext(ctx).Raw(`SELECT EXISTS (SELECT 1 FROM blog_posts WHERE id = ?)`, id).Scan(&taken).Error
+	return taken, err
+}
+
+// Find or create a tag by slug
+func (r *repo) FirstOrCreateTag(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Where(models.Tag{Slug: tag.Slug}).FirstOrCreate(tag).Error
+}
+
+// Find or create a category by slug
+func (r *repo) FirstOrCreateCategory(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Where(models.Category{Slug: category.Slug}).FirstOrCreate(category).Error
+}
+
+// Get the saved state of an import
+func (r *repo) GetImportState(ctx context.Context, name string) (*models.ImportState, error) {
+	var st models.ImportState
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&st).Error
+	return &st, err
+}
+
+// Save the state of an import, replacing any under the same name
+func (r *repo) SaveImportState(ctx context.Context, st *models.ImportState) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "updated_at"}),
+	}).Create(st).Error
+}
+
+// Create a user
+func (r *repo) CreateUser(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+// Get a user by email address
+func (r *repo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	return &user, err
+}
+
+// Create an API key
+func (r *repo) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Omit("User").Create(key).Error
+}
+
+// Get an API key, and its user, by prefix
+func (r *repo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Preload("User").Where("prefix = ?", prefix).First(&key).Error
+	return &key, err
+}
+
+// Create outbox events
+func (r *repo) CreateOutboxEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(events, createBatchSize).Error
+}
+
+// Claim due outbox events for lease
+func (r *repo) ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Order("id").Limit(limit).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		ids := make([]uint, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return events, err
+}
+
+// Mark an outbox event as published
+func (r *repo) MarkOutboxEventPublished(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{ID: id}).Update("published_at", at).Error
+}
+
+// Record a failed attempt at publishing an outbox event
+func (r *repo) RetryOutboxEvent(ctx context.Context, id uint, next time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{ID: id}).Updates(map[string]any{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": next,
+		"last_error":      lastError,
+	}).Error
+}
+
+// List the outbox events after an ID
+func (r *repo) OutboxEventsAfter(ctx context.Context, after uint, types []string, postIDs []uint, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	q := r.db.WithContext(ctx).Where("id > ?", after)
+	if len(types) > 0 {
+		q = q.Where("type IN ?", types)
+	}
+	if len(postIDs) > 0 {
+		q = q.Where("post_id IN ?", postIDs)
+	}
+	err := q.Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// Delete outbox events published before a given time
+func (r *repo) PurgeOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("published_at < ?", before).Delete(&models.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
+
+// Create a webhook subscription
+func (r *repo) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+// List every webhook subscription
+func (r *repo) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+// Get a webhook subscription by ID
+func (r *repo) GetWebhookSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := r.db.WithContext(ctx).First(&sub, id).Error
+	return &sub, err
+}
+
+// Update every column of a webhook subscription
+func (r *repo) UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Select("*").Omit("created_at").Updates(sub).Error
+}
+
+// Delete a webhook subscription and its deliveries
+func (r *repo) DeleteWebhookSubscription(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&models.WebhookSubscription{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// List the active webhook subscriptions
+func (r *repo) ActiveWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("active").Order("id").Find(&subs).Error
+	return subs, err
+}
+
+// Create webhook deliveries
+func (r *repo) CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Subscription").CreateInBatches(deliveries, createBatchSize).Error
+}
+
+// Claim due webhook deliveries for lease
+func (r *repo) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Where("subscription_id IN (?)", tx.Model(&models.WebhookSubscription{}).Select("id").Where("active")).
+			Order("id").Limit(limit).Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]uint, len(deliveries))
+		subIDs := make([]uint, 0, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+			subIDs = append(subIDs, d.SubscriptionID)
+		}
+		if err := tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error; err != nil {
+			return err
+		}
+
+		var subs []models.WebhookSubscription
+		if err := tx.Where("id IN ?", subIDs).Find(&subs).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]*models.WebhookSubscription, len(subs))
+		for i := range subs {
+			byID[subs[i].ID] = &subs[i]
+		}
+		for i := range deliveries {
+			deliveries[i].Subscription = byID[deliveries[i].SubscriptionID]
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+// Save the outcome of a webhook delivery attempt
+func (r *repo) SaveWebhookAttempt(ctx context.Context, d *models.WebhookDelivery, disableAfter int) (bool, error) {
+	var disabled bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{ID: d.ID}).
+			Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error", "delivered_at").
+			Updates(d).Error
+		if err != nil {
+			return err
+		}
+
+		subs := tx.Model(&models.WebhookSubscription{})
+		if d.Status == models.DeliverySucceeded {
+			return subs.Where("id = ? AND consecutive_failures <> 0", d.SubscriptionID).
+				Update("consecutive_failures", 0).Error
+		}
+		err = subs.Where("id = ?", d.SubscriptionID).
+			Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error
+		if err != nil || disableAfter <= 0 {
+			return err
+		}
+		res := tx.Model(&models.WebhookSubscription{}).
+			Where("id = ? AND active AND consecutive_failures >= ?", d.SubscriptionID, disableAfter).
+			Updates(map[string]any{"active": false, "disabled_at": d.LastAttemptAt})
+		disabled = res.RowsAffected > 0
+		return res.Error
+	})
+	return disabled, err
+}
+
+// List the deliveries of a webhook subscription, newest first
+func (r *repo) ListWebhookDeliveries(ctx context.Context, subscriptionID, before uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	q := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if before > 0 {
+		q = q.Where("id < ?", before)
+	}
+	err := q.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Get a delivery of a webhook subscription
+func (r *repo) GetWebhookDelivery(ctx context.Context, subscriptionID, id uint) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).First(&d, id).Error
+	return &d, err
+}
+
+// Delete finished webhook deliveries created before a given time
+func (r *repo) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("status <> ? AND created_at < ?", models.DeliveryPending, before).Delete(&models.WebhookDelivery{})
+	return res.RowsAffected, res.Error
+}
+
+// Get a post's lock with its holder
+func (r *repo) GetPostLock(ctx context.Context, postID uint) (*models.PostLock, error) {
+	var lock models.PostLock
+	err := r.db.WithContext(ctx).Preload("User").First(&lock, "post_id = ?", postID).Error
+	return &lock, err
+}
+
+func (r *repo) GetPostLockForUpdate(ctx context.Context, postID uint) (*models.PostLock, error) {
+	var lock models.PostLock
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("User").First(&lock, "post_id = ?", postID).Error
+	return &lock, err
+}
+
+// Take or renew a post's lock in a single statement, so that concurrent
+// callers can't both get it
+func (r *repo) AcquirePostLock(ctx context.Context, lock *models.PostLock, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "post_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"user_id": gorm.Expr("excluded.user_id"),
+			"acquired_at": gorm.Expr("CASE WHEN post_locks.user_id = excluded.user_id AND post_locks.expires_at > ? "+
+				"THEN post_locks.acquired_at ELSE excluded.acquired_at END", now),
+			"expires_at": gorm.Expr("excluded.expires_at"),
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			gorm.Expr("post_locks.user_id = excluded.user_id OR post_locks.expires_at <= ?", now),
+		}},
+	}).Create(lock)
+	return res.RowsAffected > 0, res.Error
+}
+
+// Delete a post's lock held by a user
+func (r *repo) DeletePostLock(ctx context.Context, postID, userID uint) (bool, error) {
+	res := r.db.WithContext(ctx).Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.PostLock{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// auditLockKey identifies, with a blog's ID, the advisory lock serialising
+// appends to that blog's audit log, so that no two entries are chained to
+// the same one.
+const auditLockKey = 0x61756474
+
+// Append an entry to the audit log's hash chain
+func (r *repo) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A dry run has the entry stamped with its blog without writing it,
+		// so that only that blog's chain is locked.
+		if err := tx.Session(&gorm.Session{DryRun: true}).Create(entry).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", auditLockKey, entry.TenantID).Error; err != nil {
+			return err
+		}
+		var last []string
+		err := tx.Model(&models.AuditEntry{}).Order("id DESC").Limit(1).Pluck("hash", &last).Error
+		if err != nil {
+			return err
+		}
+		prev := ""
+		if len(last) > 0 {
+			prev = last[0]
+		}
+		entry.Seal(prev, time.Now())
+		return tx.Create(entry).Error
+	})
+}
+
+// List audit entries, newest first
+func (r *repo) ListAuditEntries(ctx context.Context, f models.AuditFilter, before uint, limit int) ([]models.AuditEntry, error) {
+	q := r.db.WithContext(ctx).Scopes(auditFilter(f))
+	if before > 0 {
+		q = q.Where("id < ?", before)
+	}
+	var entries []models.AuditEntry
+	err := q.Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// Visit audit entries, oldest first, size at a time
+func (r *repo) AuditEntriesInBatches(ctx context.Context, f models.AuditFilter, size int, fn func([]models.AuditEntry) error) error {
+	var entries []models.AuditEntry
+	return r.db.WithContext(ctx).Scopes(auditFilter(f)).Order("id").FindInBatches(&entries, size, func(*gorm.DB, int) error {
+		return fn(entries)
+	}).Error
+}
+
+func auditFilter(f models.AuditFilter) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		if f.Action != "" {
+			q = q.Where("action = ?", f.Action)
+		}
+		if f.ActorID != 0 {
+			q = q.Where("actor_id = ?", f.ActorID)
+		}
+		if f.Entity != "" {
+			q = q.Where("entity = ?", f.Entity)
+		}
+		if f.EntityID != 0 {
+			q = q.Where("entity_id = ?", f.EntityID)
+		}
+		if f.RequestID != "" {
+			q = q.Where("request_id = ?", f.RequestID)
+		}
+		if !f.From.IsZero() {
+			q = q.Where("created_at >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			q = q.Where("created_at < ?", f.To)
+		}
+		return q
+	}
+}
+
+// Create a blog
+func (r *repo) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	return r.db.WithContext(ctx).Create(tenant).Error
+}
+
+// Update every setting of a blog
+func (r *repo) UpdateTenant(ctx context.Context, tenant *models.Tenant) error {
+	return r.db.WithContext(ctx).Select("*").Omit("created_at").Updates(tenant).Error
+}
+
+// List every blog
+func (r *repo) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := r.db.WithContext(ctx).Order("id").Find(&tenants).Error
+	return tenants, err
+}
+
+// Get a blog by slug
+func (r *repo) GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&tenant).Error
+	return &tenant, err
+}
+
+// List the translations of posts
+func (r *repo) ListTranslations(ctx context.Context, postIDs []uint) ([]models.PostTranslation, error) {
+	var translations []models.PostTranslation
+	err := r.db.WithContext(ctx).Where("post_id IN ?", postIDs).Order("post_id, locale").Find(&translations).Error
+	return translations, err
+}
+
+// Get a post's translation in a locale
+func (r *repo) GetTranslation(ctx context.Context, postID uint, locale string) (*models.PostTranslation, error) {
+	var t models.PostTranslation
+	err := r.db.WithContext(ctx).Where("post_id = ? AND locale = ?", postID, locale).First(&t).Error
+	return &t, err
+}
+
+// Create a translation
+func (r *repo) CreateTranslation(ctx context.Context, t *models.PostTranslation) error {
+	return r.db.WithContext(ctx).Omit("Post").Create(t).Error
+}
+
+// Update every field of a translation
+func (r *repo) UpdateTranslation(ctx context.Context, t *models.PostTranslation) error {
+	return r.db.WithContext(ctx).Select("*").Omit("created_at", "Post").Updates(t).Error
+}
+
+// Delete a post's translation in a locale
+func (r *repo) DeleteTranslation(ctx context.Context, postID uint, locale string) error {
+	res := r.db.WithContext(ctx).Where("post_id = ? AND locale = ?", postID, locale).Delete(&models.PostTranslation{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// List a page of posts, newest first
+func (r *repo) ListPosts(ctx context.Context, f models.PostFilter) ([]models.BlogPost, int64, error) {
+	q := r.db.WithContext(ctx).Model(&models.BlogPost{}).Scopes(postFilter(f))
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var posts []models.BlogPost
+	err := q.Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("slug") }).
+		Order("published_at DESC NULLS LAST").Order("id DESC").
+		Offset(f.Offset).Limit(f.Limit).Find(&posts).Error
+	return posts, total, err
+}
+
+func postFilter(f models.PostFilter) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		if f.Status != "" {
+			q = q.Where("status = ?", f.Status)
+		}
+		if f.Tag != "" {
+			// The tag is the post's blog's, whose slug is unique there.
+			q = q.Where(`id IN (SELECT post_tags.blog_post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id
+				WHERE tags.slug = ? AND tags.tenant_id = blog_posts.tenant_id)`, f.Tag)
+		}
+		if f.Query != "" {
+			like := "%" + escapeLike(f.Query) + "%"
+			q = q.Where("title ILIKE ? OR description ILIKE ? OR body ILIKE ?", like, like, like)
+		}
+		if len(f.IDs) > 0 {
+			q = q.Where("id IN ?", f.IDs)
+		}
+		return q
+	}
+}
+
+// escapeLike escapes the wildcards of LIKE patterns in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Get a tag by slug
+func (r *repo) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&tag).Error
+	return &tag, err
+}
+
+// List the posts in the trash, most recently trashed first
+func (r *repo) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	var posts []models.BlogPost
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Order("id DESC").Find(&posts).Error
+	return posts, err
+}
+
+// Take a post out of the trash
+func (r *repo) RestorePost(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Unscoped().Model(&models.BlogPost{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete a post in the trash for good. Its translations, revisions and lock
+// go with it through their foreign keys; its tags, categories and comments,
+// whose tables have no cascade, are deleted first.
+func (r *repo) PurgePost(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post models.BlogPost
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("deleted_at IS NOT NULL").First(&post, id).Error
+		if err != nil {
+			return err
+		}
+		for _, q := range []string{
+			"DELETE FROM post_tags WHERE blog_post_id = ?",
+			"DELETE FROM post_categories WHERE blog_post_id = ?",
+		} {
+			if err := tx.Exec(q, id).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("blog_post_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&post).Error
+	})
+}
+
+// Create a revision
+func (r *repo) CreateRevision(ctx context.Context, rev *models.PostRevision) error {
+	return r.db.WithContext(ctx).Omit("Post").Create(rev).Error
+}
+
+// List a post's revisions, newest first
+func (r *repo) ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	var revs []models.PostRevision
+	err := r.db.WithContext(ctx).Where("post_id = ?", postID).Order("id DESC").Find(&revs).Error
+	return revs, err
 }
 
-// Delete a blog post
-func (r *repo) Delete(id uint) error {
-	return r.db.Delete(&models.BlogPost{}, id).Error
+// Get a revision of a post
+func (r *repo) GetRevision(ctx context.Context, postID, id uint) (*models.PostRevision, error) {
+	var rev models.PostRevision
+	err := r.db.WithContext(ctx).Where("post_id = ?", postID).First(&rev, id).Error
+	return &rev, err
 }
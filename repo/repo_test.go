@@ -1,7 +1,9 @@
 package repo_test
 
 import (
+	"context"
 	"database/sql/driver"
+	"errors"
 	dbMock "example/database/mocks"
 	"example/models"
 	"example/repo"
@@ -59,7 +61,7 @@ func Test_repo_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := repo.NewRepo(tt.fields.db)
-			if _, err := r.Create(tt.args.post); (err != nil) != tt.wantErr {
+			if _, err := r.Create(context.Background(), tt.args.post); (err != nil) != tt.wantErr {
 				t.Errorf("repo.Create() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -96,7 +98,7 @@ func Test_repo_GetAll(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := repo.NewRepo(tt.fields.db)
-			got, err := r.GetAll()
+			got, err := r.GetAll(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("repo.GetAll() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -129,7 +131,7 @@ func Test_repo_GetByID(t *testing.T) {
 				db: func() *gorm.DB {
 					db, dbmock := dbMock.NewGormMock(t)
 					selectRows := sqlmock.NewRows([]string{"id"}).AddRow("12345").AddRow("123456")
-					dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."id" = $1 ORDER BY "blog_posts"."id" LIMIT $2`)).
+					dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."id" = $1 AND "blog_posts"."deleted_at" IS NULL ORDER BY "blog_posts"."id" LIMIT $2`)).
 						WillReturnRows(selectRows)
 					return db
 				}(),
@@ -140,7 +142,7 @@ func Test_repo_GetByID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := repo.NewRepo(tt.fields.db)
-			got, err := r.GetByID(tt.args.id)
+			got, err := r.GetByID(context.Background(), tt.args.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("repo.GetByID() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -189,7 +191,7 @@ func Test_repo_Update(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := repo.NewRepo(tt.fields.db)
-			if err := r.Update(tt.args.id, tt.args.post); (err != nil) != tt.wantErr {
+			if err := r.Update(context.Background(), tt.args.id, tt.args.post); (err != nil) != tt.wantErr {
 				t.Errorf("repo.Update() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -227,9 +229,532 @@ func Test_repo_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := repo.NewRepo(tt.fields.db)
 
-			if err := r.Delete(tt.args.id); (err != nil) != tt.wantErr {
+			if err := r.Delete(context.Background(), tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("repo.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_repo_CreateBatch(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "blog_posts" ("tenant_id","title","description","body","locale","status","published_at","created_at","updated_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10),($11,$12,$13,$14,$15,$16,$17,$18,$19,$20) RETURNING "id"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11).AddRow(12))
+	dbmock.ExpectCommit()
+
+	posts := []*models.BlogPost{{Title: "one"}, {Title: "two"}}
+	r := repo.NewRepo(db)
+	if err := r.CreateBatch(context.Background(), posts); err != nil {
+		t.Fatalf("repo.CreateBatch() error = %v", err)
+	}
+	if posts[0].ID != 11 || posts[1].ID != 12 {
+		t.Errorf("repo.CreateBatch() IDs = %d, %d, want 11, 12", posts[0].ID, posts[1].ID)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_Transaction(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "blog_posts" SET "deleted_at"=$1 WHERE "blog_posts"."id" = $2 AND "blog_posts"."deleted_at" IS NULL`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbmock.ExpectRollback()
+
+	r := repo.NewRepo(db)
+	err := r.Transaction(context.Background(), func(tx repo.Repository) error {
+		return tx.Delete(context.Background(), 1)
+	})
+	if err != gorm.ErrRecordNotFound {
+		t.Errorf("repo.Transaction() error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_FindInBatches(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	cols := []string{"id", "title", "description", "body", "created_at", "updated_at"}
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."deleted_at" IS NULL ORDER BY id,"blog_posts"."id" LIMIT $1`)).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(1, "a", "", "", nil, nil).AddRow(2, "b", "", "", nil, nil))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."id" > $1 AND "blog_posts"."deleted_at" IS NULL ORDER BY id,"blog_posts"."id" LIMIT $2`)).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(3, "c", "", "", nil, nil))
+
+	var titles []string
+	r := repo.NewRepo(db)
+	err := r.FindInBatches(context.Background(), 2, func(posts []models.BlogPost) error {
+		for _, p := range posts {
+			titles = append(titles, p.Title)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("repo.FindInBatches() error = %v", err)
+	}
+	if !reflect.DeepEqual(titles, []string{"a", "b", "c"}) {
+		t.Errorf("repo.FindInBatches() visited %v, want [a b c]", titles)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_Replace(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "blog_posts" SET`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	err := r.Replace(context.Background(), &models.BlogPost{ID: 9, Title: "t"})
+	if err != gorm.ErrRecordNotFound {
+		t.Errorf("repo.Replace() error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_Replace_keepsMissingTimestamps(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "blog_posts" SET "id"=$1,"tenant_id"=$2,"title"=$3,"description"=$4,"body"=$5,"locale"=$6,"status"=$7,"published_at"=$8,"updated_at"=$9,"deleted_at"=$10 WHERE`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	post := &models.BlogPost{ID: 9, Title: "t", UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if err := r.Replace(context.Background(), post); err != nil {
+		t.Fatalf("repo.Replace() error = %v", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_SaveImportState(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "import_states" ("tenant_id","name","state","updated_at") VALUES ($1,$2,$3,$4) ` +
+		`ON CONFLICT ("tenant_id","name") DO UPDATE SET "state"="excluded"."state","updated_at"="excluded"."updated_at" RETURNING "id"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	err := r.SaveImportState(context.Background(), &models.ImportState{Name: "export.xml", State: []byte(`{"posts":{}}`)})
+	if err != nil {
+		t.Fatalf("repo.SaveImportState() error = %v", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_FirstOrCreateTag(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tags" WHERE "tags"."slug" = $1 ORDER BY "tags"."id" LIMIT $2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}))
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "tags" ("tenant_id","name","slug") VALUES ($1,$2,$3) RETURNING "id"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	dbmock.ExpectCommit()
+
+	tag := models.Tag{Name: "Go", Slug: "go"}
+	r := repo.NewRepo(db)
+	if err := r.FirstOrCreateTag(context.Background(), &tag); err != nil {
+		t.Fatalf("repo.FirstOrCreateTag() error = %v", err)
+	}
+	if tag.ID != 4 {
+		t.Errorf("repo.FirstOrCreateTag() ID = %d, want 4", tag.ID)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_GetAPIKeyByPrefix(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_keys" WHERE prefix = $1 ORDER BY "api_keys"."id" LIMIT $2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "prefix", "hash"}).AddRow(1, 7, "deploy", "abc123", "h"))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(7, "ops@example.com"))
+
+	r := repo.NewRepo(db)
+	key, err := r.GetAPIKeyByPrefix(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("repo.GetAPIKeyByPrefix() error = %v", err)
+	}
+	if key.User.Email != "ops@example.com" {
+		t.Errorf("repo.GetAPIKeyByPrefix() user = %q, want ops@example.com", key.User.Email)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_ClaimOutboxEvents(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_events" WHERE published_at IS NULL AND next_attempt_at <= $1 ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`)).
+		WithArgs(now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "post_id", "data"}).
+			AddRow(3, models.EventPostCreated, 1, []byte(`{}`)).
+			AddRow(4, models.EventPostPublished, 1, []byte(`{}`)))
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "next_attempt_at"=$1 WHERE id IN ($2,$3)`)).
+		WithArgs(now.Add(time.Minute), 3, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	events, err := r.ClaimOutboxEvents(context.Background(), now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("repo.ClaimOutboxEvents() error = %v", err)
+	}
+	if len(events) != 2 || events[0].ID != 3 || events[1].Type != models.EventPostPublished {
+		t.Errorf("repo.ClaimOutboxEvents() = %+v", events)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_RetryOutboxEvent(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	next := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=attempts + 1,"last_error"=$1,"next_attempt_at"=$2 WHERE "id" = $3`)).
+		WithArgs("webhook: timeout", next, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	if err := r.RetryOutboxEvent(context.Background(), 5, next, "webhook: timeout"); err != nil {
+		t.Fatalf("repo.RetryOutboxEvent() error = %v", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_ClaimWebhookDeliveries(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE (status = $1 AND next_attempt_at <= $2) AND subscription_id IN (SELECT "id" FROM "webhook_subscriptions" WHERE active) ORDER BY id LIMIT $3 FOR UPDATE SKIP LOCKED`)).
+		WithArgs(models.DeliveryPending, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "event_type"}).
+			AddRow(7, 2, models.EventPostCreated))
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "next_attempt_at"=$1 WHERE id IN ($2)`)).
+		WithArgs(now.Add(time.Minute), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_subscriptions" WHERE id IN ($1)`)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret"}).AddRow(2, "https://partner.example", "s3cret"))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	deliveries, err := r.ClaimWebhookDeliveries(context.Background(), now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("repo.ClaimWebhookDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Subscription == nil || deliveries[0].Subscription.Secret != "s3cret" {
+		t.Errorf("repo.ClaimWebhookDeliveries() = %+v", deliveries)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_SaveWebhookAttempt(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := at.Add(time.Minute)
+	d := &models.WebhookDelivery{
+		ID: 7, SubscriptionID: 2, Status: models.DeliveryPending, Attempts: 3, NextAttemptAt: next,
+		LastAttemptAt: &at, ResponseStatus: 500, LastError: "endpoint answered 500 Internal Server Error",
+	}
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "status"=$1,"attempts"=$2,"next_attempt_at"=$3,"last_attempt_at"=$4,"response_status"=$5,"last_error"=$6,"delivered_at"=$7 WHERE "id" = $8`)).
+		WithArgs(models.DeliveryPending, 3, next, at, 500, d.LastError, nil, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_subscriptions" SET "consecutive_failures"=consecutive_failures + 1,"updated_at"=$1 WHERE id = $2`)).
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_subscriptions" SET "active"=$1,"disabled_at"=$2,"updated_at"=$3 WHERE id = $4 AND active AND consecutive_failures >= $5`)).
+		WithArgs(false, at, sqlmock.AnyArg(), 2, 20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	disabled, err := r.SaveWebhookAttempt(context.Background(), d, 20)
+	if err != nil {
+		t.Fatalf("repo.SaveWebhookAttempt() error = %v", err)
+	}
+	if !disabled {
+		t.Error("repo.SaveWebhookAttempt() did not report the subscription disabled")
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_OutboxEventsAfter(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_events" WHERE id > $1 AND type IN ($2,$3) AND post_id IN ($4) ORDER BY id LIMIT $5`)).
+		WithArgs(10, models.EventPostPublished, models.EventPostDeleted, 7, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "post_id"}).AddRow(12, models.EventPostDeleted, 7))
+
+	r := repo.NewRepo(db)
+	events, err := r.OutboxEventsAfter(context.Background(), 10, []string{models.EventPostPublished, models.EventPostDeleted}, []uint{7}, 100)
+	if err != nil {
+		t.Fatalf("repo.OutboxEventsAfter() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != 12 {
+		t.Errorf("repo.OutboxEventsAfter() = %+v", events)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_AcquirePostLock(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := now.Add(5 * time.Minute)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "post_locks" ("post_id","tenant_id","user_id","acquired_at","expires_at") VALUES ($1,$2,$3,$4,$5) `+
+		`ON CONFLICT ("post_id") DO UPDATE SET "acquired_at"=CASE WHEN post_locks.user_id = excluded.user_id AND post_locks.expires_at > $6 `+
+		`THEN post_locks.acquired_at ELSE excluded.acquired_at END,"expires_at"=excluded.expires_at,"user_id"=excluded.user_id `+
+		`WHERE post_locks.user_id = excluded.user_id OR post_locks.expires_at <= $7`)).
+		WithArgs(3, 1, 2, now, expires, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	acquired, err := r.AcquirePostLock(context.Background(), &models.PostLock{PostID: 3, TenantID: 1, UserID: 2, AcquiredAt: now, ExpiresAt: expires}, now)
+	if err != nil {
+		t.Fatalf("repo.AcquirePostLock() error = %v", err)
+	}
+	if acquired {
+		t.Error("repo.AcquirePostLock() acquired a lock held by someone else")
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_AppendAuditEntry(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1, $2)`)).
+		WithArgs(0x61756474, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT "hash" FROM "audit_entries" ORDER BY id DESC LIMIT $1`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("abc"))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_entries" ("tenant_id","action","actor_id","actor","ip","user_agent","request_id","entity","entity_id","changes","details","created_at","prev_hash","hash") `+
+		`VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,(NULL),$11,$12,$13) RETURNING "id"`)).
+		WithArgs(1, models.AuditPostDeleted, nil, "", "", "", "", "post", 3, `{"title":{"before":"t","after":null}}`,
+			sqlmock.AnyArg(), "abc", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	entry := &models.AuditEntry{TenantID: 1, Action: models.AuditPostDeleted, Entity: "post", EntityID: 3,
+		Changes: models.Changes{"title": {Before: "t"}}}
+	if err := r.AppendAuditEntry(context.Background(), entry); err != nil {
+		t.Fatalf("repo.AppendAuditEntry() error = %v", err)
+	}
+	if entry.PrevHash != "abc" || entry.Hash != entry.ComputeHash() {
+		t.Errorf("repo.AppendAuditEntry() entry not chained: %+v", entry)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_ListAuditEntries(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_entries" WHERE id < $1 AND action = $2 AND entity_id = $3 AND created_at >= $4 ORDER BY id DESC LIMIT $5`)).
+		WithArgs(100, models.AuditPostUpdated, 3, from, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action"}).AddRow(99, models.AuditPostUpdated))
+
+	r := repo.NewRepo(db)
+	entries, err := r.ListAuditEntries(context.Background(),
+		models.AuditFilter{Action: models.AuditPostUpdated, EntityID: 3, From: from}, 100, 20)
+	if err != nil {
+		t.Fatalf("repo.ListAuditEntries() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != 99 {
+		t.Errorf("repo.ListAuditEntries() = %+v", entries)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_UpdateTenant(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "tenants" SET "slug"=$1,"host"=$2,"title"=$3,"description"=$4,"feed_items"=$5,"feed_full_text"=$6,"cors_origins"=$7,"updated_at"=$8 WHERE "id" = $9`)).
+		WithArgs("acme", nil, "Acme", "", 0, false, `["https://acme.example"]`, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	r := repo.NewRepo(db)
+	err := r.UpdateTenant(context.Background(), &models.Tenant{ID: 2, Slug: "acme", Title: "Acme", CORSOrigins: []string{"https://acme.example"}})
+	if err != nil {
+		t.Fatalf("repo.UpdateTenant() error = %v", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_DeleteTranslation(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	for _, affected := range []int64{1, 0} {
+		dbmock.ExpectBegin()
+		dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "post_translations" WHERE post_id = $1 AND locale = $2`)).
+			WithArgs(7, "de").
+			WillReturnResult(sqlmock.NewResult(0, affected))
+		dbmock.ExpectCommit()
+	}
+
+	r := repo.NewRepo(db)
+	if err := r.DeleteTranslation(context.Background(), 7, "de"); err != nil {
+		t.Fatalf("repo.DeleteTranslation() error = %v", err)
+	}
+	if err := r.DeleteTranslation(context.Background(), 7, "de"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("repo.DeleteTranslation() error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_ListPosts(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "blog_posts" WHERE status = $1 AND (id IN (SELECT post_tags.blog_post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE tags.slug = $2 AND tags.tenant_id = blog_posts.tenant_id)) AND (title ILIKE $3 OR description ILIKE $4 OR body ILIKE $5) AND "blog_posts"."deleted_at" IS NULL`)).
+		WithArgs("published", "go", `%50\% off%`, `%50\% off%`, `%50\% off%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE status = $1 AND (id IN (SELECT post_tags.blog_post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE tags.slug = $2 AND tags.tenant_id = blog_posts.tenant_id)) AND (title ILIKE $3 OR description ILIKE $4 OR body ILIKE $5) AND "blog_posts"."deleted_at" IS NULL ORDER BY published_at DESC NULLS LAST,id DESC LIMIT $6 OFFSET $7`)).
+		WithArgs("published", "go", `%50\% off%`, `%50\% off%`, `%50\% off%`, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(7, "seven"))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "post_tags" WHERE "post_tags"."blog_post_id" = $1`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"blog_post_id", "tag_id"}).AddRow(7, 4))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tags" WHERE "tags"."id" = $1 ORDER BY slug`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}).AddRow(4, "Go", "go"))
+
+	r := repo.NewRepo(db)
+	posts, total, err := r.ListPosts(context.Background(), models.PostFilter{
+		Status: models.StatusPublished, Tag: "go", Query: "50% off", Offset: 2, Limit: 2,
+	})
+	if err != nil {
+		t.Fatalf("repo.ListPosts() error = %v", err)
+	}
+	if total != 3 || len(posts) != 1 || len(posts[0].Tags) != 1 || posts[0].Tags[0].Slug != "go" {
+		t.Errorf("repo.ListPosts() = %+v, %d", posts, total)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_RestoreAndPurgePost(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "blog_posts" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "blog_posts" WHERE deleted_at IS NOT NULL AND "blog_posts"."id" = $1`)).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	dbmock.ExpectRollback()
+
+	r := repo.NewRepo(db)
+	if err := r.RestorePost(context.Background(), 7); err != nil {
+		t.Fatalf("repo.RestorePost() error = %v", err)
+	}
+	if err := r.PurgePost(context.Background(), 7); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("repo.PurgePost() error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// The ID of a post in the trash is still taken.
+func Test_repo_PostIDTaken(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM blog_posts WHERE id = $1)`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	taken, err := repo.NewRepo(db).PostIDTaken(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("repo.PostIDTaken() error = %v", err)
+	}
+	if !taken {
+		t.Error("repo.PostIDTaken() = false, want true")
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// A tagged, categorised and commented post is purged along with its rows in
+// the tables without a cascade.
+func Test_repo_PurgePost(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectBegin()
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "blog_posts" WHERE deleted_at IS NOT NULL AND "blog_posts"."id" = $1 ORDER BY "blog_posts"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM post_tags WHERE blog_post_id = $1`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM post_categories WHERE blog_post_id = $1`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "comments" WHERE blog_post_id = $1`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "blog_posts" WHERE "blog_posts"."id" = $1`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbmock.ExpectCommit()
+
+	if err := repo.NewRepo(db).PurgePost(context.Background(), 3); err != nil {
+		t.Fatalf("repo.PurgePost() error = %v", err)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_repo_ListTrash(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC,id DESC`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(3, "gone"))
+
+	posts, err := repo.NewRepo(db).ListTrash(context.Background())
+	if err != nil {
+		t.Fatalf("repo.ListTrash() error = %v", err)
+	}
+	if len(posts) != 1 || posts[0].Title != "gone" {
+		t.Errorf("repo.ListTrash() = %v", posts)
+	}
+	if err := dbmock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
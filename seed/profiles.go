@@ -0,0 +1,67 @@
+package seed
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile describes the shape of a generated dataset.
+type Profile struct {
+	Name string `json:"name"`
+	// Posts and Users are the number of posts and users to generate.
+	Posts int `json:"posts"`
+	Users int `json:"users"`
+	// Tags is the size of the tag vocabulary; each post gets up to
+	// MaxTagsPerPost of them.
+	Tags           int `json:"tags"`
+	MaxTagsPerPost int `json:"max_tags_per_post"`
+	// MaxComments is the largest number of comments on a post.
+	MaxComments int `json:"max_comments"`
+	// Paragraphs is the range of paragraphs in a post body.
+	MinParagraphs int `json:"min_paragraphs"`
+	MaxParagraphs int `json:"max_paragraphs"`
+	// Unicode fills the text with hard-to-handle Unicode instead of words.
+	Unicode bool `json:"unicode"`
+}
+
+// Profiles are the built-in dataset shapes, by name.
+var Profiles = map[string]Profile{
+	"small": {
+		Name: "small", Posts: 20, Users: 3, Tags: 8, MaxTagsPerPost: 3,
+		MaxComments: 3, MinParagraphs: 2, MaxParagraphs: 5,
+	},
+	"large": {
+		Name: "large", Posts: 5000, Users: 50, Tags: 200, MaxTagsPerPost: 6,
+		MaxComments: 20, MinParagraphs: 3, MaxParagraphs: 30,
+	},
+	"pathological-unicode": {
+		Name: "pathological-unicode", Posts: 50, Users: 5, Tags: 20, MaxTagsPerPost: 4,
+		MaxComments: 5, MinParagraphs: 1, MaxParagraphs: 8, Unicode: true,
+	},
+}
+
+// DefaultProfile is used when no profile is named.
+const DefaultProfile = "small"
+
+// ProfileNames lists the built-in profiles in alphabetical order.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupProfile returns the built-in profile with the given name.
+func LookupProfile(name string) (Profile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+	p, ok := Profiles[name]
+	if !ok {
+		return p, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(ProfileNames(), ", "))
+	}
+	return p, nil
+}
@@ -0,0 +1,215 @@
+// Package seed generates reproducible fake data for local environments and
+// load tests. The same profile and seed always produce the same dataset.
+package seed
+
+import (
+	"example/models"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Password is the password of every generated user.
+const Password = "seed-password"
+
+// epoch is the creation date of the oldest generated post.
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Limits of the API, which generated posts stay within.
+const (
+	maxTitle       = 200
+	maxDescription = 500
+	maxBody        = 100000
+)
+
+// Dataset is the generated data, ready to be loaded.
+type Dataset struct {
+	Profile Profile           `json:"profile"`
+	Seed    uint64            `json:"seed"`
+	Users   []models.User     `json:"users"`
+	Tags    []models.Tag      `json:"tags"`
+	Posts   []models.BlogPost `json:"posts"`
+}
+
+// Generate builds the dataset of a profile. It doesn't touch the database.
+func Generate(p Profile, seed uint64) *Dataset {
+	g := &generator{r: rand.New(rand.NewPCG(seed, 0x5eed)), unicode: p.Unicode}
+	ds := &Dataset{Profile: p, Seed: seed}
+
+	for i := range p.Users {
+		first, last := g.pick(firstNames), g.pick(lastNames)
+		ds.Users = append(ds.Users, models.User{
+			Email: fmt.Sprintf("%s.%s.%d@example.test", strings.ToLower(first), strings.ToLower(last), i+1),
+			Name:  first + " " + last,
+			Role:  models.Roles[g.r.IntN(len(models.Roles))],
+		})
+	}
+
+	seen := map[string]bool{}
+	for len(ds.Tags) < p.Tags {
+		name := g.tagName()
+		slug := slugify(name)
+		if seen[slug] {
+			slug = fmt.Sprintf("%s-%d", slug, len(ds.Tags)+1)
+		}
+		seen[slug] = true
+		ds.Tags = append(ds.Tags, models.Tag{Name: name, Slug: slug})
+	}
+
+	// Posts are spread over a year, oldest first.
+	step := 365 * 24 * time.Hour / time.Duration(max(p.Posts, 1))
+	for i := range p.Posts {
+		created := epoch.Add(time.Duration(i)*step + time.Duration(g.r.Int64N(int64(step))))
+		post := g.post(p, i)
+		post.CreatedAt = created
+		post.UpdatedAt = created
+		post.PublishedAt = &created
+		if g.r.IntN(3) == 0 {
+			post.UpdatedAt = created.Add(time.Duration(g.r.Int64N(int64(30 * 24 * time.Hour))))
+		}
+
+		for _, t := range g.r.Perm(len(ds.Tags))[:g.between(0, min(p.MaxTagsPerPost, len(ds.Tags)))] {
+			post.Tags = append(post.Tags, ds.Tags[t])
+		}
+		for range g.between(0, p.MaxComments) {
+			post.Comments = append(post.Comments, models.Comment{
+				Author:    g.pick(firstNames) + " " + g.pick(lastNames),
+				Body:      g.sentence(4, 30),
+				Approved:  g.r.IntN(5) != 0,
+				CreatedAt: post.CreatedAt.Add(time.Duration(g.r.Int64N(int64(60 * 24 * time.Hour)))),
+			})
+		}
+		ds.Posts = append(ds.Posts, post)
+	}
+	return ds
+}
+
+type generator struct {
+	r       *rand.Rand
+	unicode bool
+}
+
+func (g *generator) pick(list []string) string {
+	return list[g.r.IntN(len(list))]
+}
+
+// between returns a number in [lo, hi].
+func (g *generator) between(lo, hi int) int {
+	if hi <= lo {
+		return lo
+	}
+	return lo + g.r.IntN(hi-lo+1)
+}
+
+// words returns n words, or pathological fragments in the Unicode profile.
+func (g *generator) words(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		if g.unicode {
+			out[i] = g.pick(unicodeFragments)
+		} else {
+			out[i] = g.pick(words)
+		}
+	}
+	return out
+}
+
+func (g *generator) sentence(lo, hi int) string {
+	w := g.words(g.between(lo, hi))
+	w[0] = capitalize(w[0])
+	return strings.Join(w, " ") + "."
+}
+
+func (g *generator) tagName() string {
+	if g.unicode {
+		return g.pick(unicodeFragments)
+	}
+	return g.pick(words)
+}
+
+// post generates the text of a post. The API normalises text before storing
+// it, so generated posts are normalised the same way.
+func (g *generator) post(p Profile, i int) models.BlogPost {
+	title := capitalize(strings.Join(g.words(g.between(2, 9)), " "))
+	description := g.sentence(8, 30)
+	if p.Unicode && i == 0 {
+		// One post sits exactly at every length limit.
+		title, description = g.fill(maxTitle), g.fill(maxDescription)
+	}
+
+	var body strings.Builder
+	for n := range g.between(p.MinParagraphs, p.MaxParagraphs) {
+		if n > 0 {
+			body.WriteString("\n\n")
+		}
+		switch g.r.IntN(8) {
+		case 0:
+			body.WriteString("## " + capitalize(strings.Join(g.words(g.between(2, 6)), " ")))
+		case 1:
+			for j := range g.between(2, 5) {
+				if j > 0 {
+					body.WriteString("\n")
+				}
+				body.WriteString("- " + g.sentence(3, 10))
+			}
+		case 2:
+			body.WriteString("```go\nfunc " + g.pick(words) + "() error {\n\treturn nil\n}\n```")
+		default:
+			for j := range g.between(2, 6) {
+				if j > 0 {
+					body.WriteString(" ")
+				}
+				body.WriteString(g.sentence(5, 20))
+			}
+		}
+	}
+
+	req := models.CreateBlogRequest{
+		Title:       truncate(title, maxTitle),
+		Description: truncate(description, maxDescription),
+		Body:        truncate(body.String(), maxBody),
+	}
+	req.Normalize()
+	return models.BlogPost{Title: req.Title, Description: req.Description, Body: req.Body, Status: models.StatusPublished}
+}
+
+// fill returns exactly n runes of pathological text, once normalised.
+func (g *generator) fill(n int) string {
+	var b strings.Builder
+	for utf8.RuneCountInString(b.String()) < n {
+		b.WriteString(g.pick(unicodeAtoms))
+	}
+	return string([]rune(b.String())[:n])
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return strings.ToUpper(string(r)) + s[size:]
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
+
+func slugify(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// Report summarises a load.
+type Report struct {
+	Profile  string `json:"profile"`
+	Seed     uint64 `json:"seed"`
+	Users    int    `json:"users"`
+	Tags     int    `json:"tags"`
+	Posts    int    `json:"posts"`
+	Comments int    `json:"comments"`
+}
@@ -0,0 +1,71 @@
+package seed
+
+import (
+	"example/models"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	p := Profiles["small"]
+	a, b := Generate(p, 42), Generate(p, 42)
+	assert.Equal(t, a, b)
+
+	c := Generate(p, 43)
+	assert.NotEqual(t, a.Posts[0].Title, c.Posts[0].Title)
+}
+
+func TestGenerateProfiles(t *testing.T) {
+	for _, name := range ProfileNames() {
+		t.Run(name, func(t *testing.T) {
+			p := Profiles[name]
+			ds := Generate(p, 1)
+			require.Len(t, ds.Posts, p.Posts)
+			assert.Len(t, ds.Users, p.Users)
+			assert.Len(t, ds.Tags, p.Tags)
+
+			slugs := map[string]bool{}
+			for _, tag := range ds.Tags {
+				assert.False(t, slugs[tag.Slug], "duplicate tag %q", tag.Slug)
+				slugs[tag.Slug] = true
+			}
+			for i, post := range ds.Posts {
+				req := models.CreateBlogRequest{Title: post.Title, Description: post.Description, Body: post.Body}
+				require.NoError(t, models.Validate.Struct(req), "post %d", i)
+				assert.LessOrEqual(t, len(post.Tags), p.MaxTagsPerPost)
+				assert.LessOrEqual(t, len(post.Comments), p.MaxComments)
+				assert.False(t, post.UpdatedAt.Before(post.CreatedAt))
+				if i > 0 {
+					assert.True(t, post.CreatedAt.After(ds.Posts[i-1].CreatedAt))
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePathologicalUnicode(t *testing.T) {
+	ds := Generate(Profiles["pathological-unicode"], 7)
+	first := ds.Posts[0]
+	assert.Equal(t, maxTitle, utf8.RuneCountInString(first.Title))
+	assert.Equal(t, maxDescription, utf8.RuneCountInString(first.Description))
+
+	nonASCII := 0
+	for _, post := range ds.Posts {
+		if utf8.RuneCountInString(post.Title) != len(post.Title) {
+			nonASCII++
+		}
+	}
+	assert.Greater(t, nonASCII, len(ds.Posts)*3/4)
+}
+
+func TestLookupProfile(t *testing.T) {
+	p, err := LookupProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, p.Name)
+
+	_, err = LookupProfile("huge")
+	assert.Error(t, err)
+}
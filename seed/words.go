@@ -0,0 +1,57 @@
+package seed
+
+// Changing these lists changes every generated dataset; append only if
+// existing seeds must keep producing the same data.
+
+var firstNames = []string{
+	"Ada", "Alan", "Barbara", "Dennis", "Edsger", "Frances", "Grace", "Guido",
+	"Hedy", "Ivan", "Joan", "John", "Ken", "Linus", "Margaret", "Niklaus",
+	"Radia", "Rob", "Shafi", "Sophie", "Tim", "Whitfield", "Yukihiro", "Zhores",
+}
+
+var lastNames = []string{
+	"Allen", "Backus", "Cerf", "Dijkstra", "Engelbart", "Floyd", "Goldwasser",
+	"Hamilton", "Hopper", "Kernighan", "Knuth", "Lamport", "Liskov", "Lovelace",
+	"McCarthy", "Perlman", "Pike", "Ritchie", "Sutherland", "Thompson", "Turing",
+	"Wilson", "Wirth", "Matsumoto",
+}
+
+var words = []string{
+	"api", "architecture", "async", "benchmark", "blog", "buffer", "cache",
+	"channel", "cloud", "cluster", "compiler", "concurrency", "container",
+	"context", "database", "debugging", "deploy", "design", "distributed",
+	"docker", "error", "event", "fiber", "framework", "function", "garbage",
+	"golang", "goroutine", "graph", "handler", "http", "index", "interface",
+	"json", "kernel", "latency", "library", "linux", "logging", "memory",
+	"metrics", "microservice", "migration", "module", "mutex", "network",
+	"observability", "open", "optimisation", "package", "parser", "pattern",
+	"performance", "pipeline", "pointer", "postgres", "profiling", "protocol",
+	"query", "queue", "refactoring", "release", "reliability", "request",
+	"schema", "security", "server", "service", "slice", "socket", "sql",
+	"stream", "struct", "testing", "thread", "throughput", "timeout", "tracing",
+	"transaction", "type", "unicode", "update", "validation", "version", "web",
+	"workflow", "a", "about", "across", "after", "and", "because", "before",
+	"better", "every", "faster", "for", "from", "how", "in", "into", "is",
+	"it", "more", "new", "of", "on", "our", "simple", "the", "to", "today",
+	"under", "we", "what", "when", "why", "with", "without", "you",
+}
+
+// unicodeFragments are words that tend to break text handling: scripts
+// written right to left, combining marks, emoji sequences, invisible and
+// directional characters, and decomposed forms that normalisation changes.
+var unicodeFragments = []string{
+	"مرحبا", "שלום", "日本語", "中文字符", "한국어", "ภาษาไทย", "हिन्दी", "Ελληνικά",
+	"Кириллица", "Z\u0337\u0322a\u0338\u0321l\u0335\u0328g\u0336\u0322o\u0334\u0322",
+	"e\u0301te\u0301", "A\u030angstro\u0308m",
+	"\U0001F469\u200d\U0001F469\u200d\U0001F467\u200d\U0001F466", "\U0001F3F3\ufe0f\u200d\U0001F308",
+	"\U0001F44D\U0001F3FD", "\U0001F1EF\U0001F1F5",
+	"zero\u200bwidth", "soft\u00adhyphen", "\u202ereversed\u202c", "\u2066isolate\u2069",
+	"\ufeffbom", "no\u00a0break", "ﬁﬂ", "𝔘𝔫𝔦𝔠𝔬𝔡𝔢", "Ǆǅǆ", "ß", "İı", "ﷺ", "𒀀", "ඞ",
+	"'quoted'", "\"double\"", "<script>", "&amp;", "back\\slash", "%s%n", "null\u2400",
+}
+
+// unicodeAtoms are single characters, some of them several bytes or code
+// points long, used to fill fields to their exact length limit.
+var unicodeAtoms = []string{
+	"é", "ß", "日", "ش", "ש", "𝔘", "😀", "ñ", "ø", "ก", "अ", "Ж", "Ω",
+}
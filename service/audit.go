@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"example/audit"
+	"example/auth"
+	"example/models"
+)
+
+// audit records an action on a post in the audit log, with the fields it
+// changed. before is nil for created posts and after for deleted ones.
+func (s *service) audit(ctx context.Context, action string, id uint, before, after *models.BlogPost) error {
+	return audit.Record(ctx, s.repo, auth.User(ctx), &models.AuditEntry{
+		Action: action, Entity: "post", EntityID: id, Changes: audit.Diff(postFields(before), postFields(after)),
+	})
+}
+
+// postFields returns the audited fields of a post.
+func postFields(p *models.BlogPost) map[string]any {
+	if p == nil {
+		return nil
+	}
+	fields := map[string]any{
+		"title":        p.Title,
+		"description":  p.Description,
+		"body":         p.Body,
+		"status":       p.Status,
+		"published_at": nil,
+	}
+	if p.PublishedAt != nil {
+		fields["published_at"] = p.PublishedAt.UTC()
+	}
+	return fields
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"example/audit"
+	"example/auth"
+	"example/logging"
+	"example/mocks"
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// audited returns the entries appended to the audit log through r.
+func audited(r *mocks.Repository) []*models.AuditEntry {
+	var entries []*models.AuditEntry
+	for _, call := range r.Calls {
+		if call.Method == "AppendAuditEntry" {
+			entries = append(entries, call.Arguments.Get(1).(*models.AuditEntry))
+		}
+	}
+	return entries
+}
+
+func TestAudit_Update(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "old", Body: "same"}, nil)
+	r.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil)
+
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 5, Email: "bob@example.com"})
+	ctx = audit.WithSource(logging.WithRequestID(ctx, "req-1"), audit.Source{IP: "203.0.113.7", UserAgent: "curl/8.0"})
+	title := "new"
+	_, err := NewService(r).Update(ctx, 1, &models.UpdateBlogRequest{Title: &title})
+	require.NoError(t, err)
+
+	entries := audited(r)
+	require.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, models.AuditPostUpdated, e.Action)
+	assert.Equal(t, "post", e.Entity)
+	assert.Equal(t, uint(1), e.EntityID)
+	assert.Equal(t, models.Changes{"title": {Before: "old", After: "new"}}, e.Changes, "only what changed")
+	assert.Equal(t, uint(5), *e.ActorID)
+	assert.Equal(t, "bob@example.com", e.Actor)
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, "curl/8.0", e.UserAgent)
+	assert.Equal(t, "req-1", e.RequestID)
+}
+
+func TestAudit_Create(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(7), nil)
+
+	_, err := NewService(r).Create(context.Background(), models.CreateBlogRequest{Title: "t", Body: "b"})
+	require.NoError(t, err)
+
+	entries := audited(r)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditPostCreated, entries[0].Action)
+	assert.Equal(t, uint(7), entries[0].EntityID)
+	assert.Nil(t, entries[0].ActorID, "anonymous")
+	assert.Equal(t, models.Change{After: "t"}, entries[0].Changes["title"])
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"example/models"
+	"log/slog"
+)
+
+// RolledBack marks the items of a failed atomic batch that were not applied
+// because of another item's failure.
+func RolledBack() *Error {
+	return Conflict("rolled_back", "not applied because another item in the batch failed", nil)
+}
+
+// CreateBatch creates many posts with batched inserts. In atomic mode a
+// failure creates nothing and is returned; in best-effort mode failing rows
+// are isolated and reported in their outcome.
+func (s *service) CreateBatch(ctx context.Context, reqs []models.CreateBlogRequest, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	posts := make([]*models.BlogPost, len(reqs))
+	for i, req := range reqs {
+		posts[i] = newPost(req)
+	}
+	out := make([]models.BulkOutcome, len(reqs))
+
+	err := s.write(ctx, func(tx *service) error {
+		return tx.createPosts(ctx, posts)
+	})
+	if err == nil {
+		for i, post := range posts {
+			out[i].ID = post.ID
+		}
+		slog.InfoContext(ctx, "posts created in bulk", "count", len(posts))
+		return out, nil
+	}
+	if mode == models.BulkAtomic {
+		slog.ErrorContext(ctx, "unable to create posts in bulk", "count", len(posts), "error", err)
+		for i := range out {
+			out[i].Err = err
+		}
+		return out, err
+	}
+
+	// The batch was rolled back as a whole; retry row by row to find out
+	// which posts were at fault.
+	for i, req := range reqs {
+		out[i].ID, out[i].Err = s.Create(ctx, req)
+	}
+	return out, nil
+}
+
+// createPosts inserts posts in batches and audits, revises and announces
+// each, on a service bound to a transaction by write.
+func (s *service) createPosts(ctx context.Context, posts []*models.BlogPost) error {
+	if err := s.repo.CreateBatch(ctx, posts); err != nil {
+		return err
+	}
+	var events []*models.OutboxEvent
+	for _, post := range posts {
+		data, err := payload(post)
+		if err != nil {
+			return err
+		}
+		events = append(events, newEvents(post.ID, data, createdEvents(post)...)...)
+		if err := s.audit(ctx, models.AuditPostCreated, post.ID, nil, post); err != nil {
+			return err
+		}
+		if err := s.revise(ctx, post); err != nil {
+			return err
+		}
+	}
+	return s.emit(ctx, events)
+}
+
+// UpdateBatch applies partial updates to many posts.
+func (s *service) UpdateBatch(ctx context.Context, items []models.BulkUpdateItem, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	return s.batch(ctx, len(items), mode, func(s *service, i int) (uint, error) {
+		_, err := s.Update(ctx, items[i].ID, &items[i].UpdateBlogRequest)
+		return items[i].ID, err
+	})
+}
+
+// DeleteBatch deletes many posts.
+func (s *service) DeleteBatch(ctx context.Context, ids []uint, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	return s.batch(ctx, len(ids), mode, func(s *service, i int) (uint, error) {
+		return ids[i], s.Delete(ctx, ids[i])
+	})
+}
+
+// batch runs op for each of n items. In atomic mode all items share one
+// transaction which is rolled back on the first failure; the remaining items
+// are reported as rolled back and the failure is returned.
+func (s *service) batch(ctx context.Context, n int, mode models.BulkMode, op func(s *service, i int) (uint, error)) ([]models.BulkOutcome, error) {
+	out := make([]models.BulkOutcome, n)
+	if mode != models.BulkAtomic {
+		for i := range out {
+			out[i].ID, out[i].Err = op(s, i)
+		}
+		return out, nil
+	}
+
+	failed := -1
+	err := s.write(ctx, func(txs *service) error {
+		for i := range out {
+			out[i].ID, out[i].Err = op(txs, i)
+			if out[i].Err != nil {
+				failed = i
+				return out[i].Err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return out, nil
+	}
+	for i := range out {
+		if i != failed {
+			out[i].Err = RolledBack()
+		}
+	}
+	if failed < 0 {
+		// The commit itself failed, so no item is to blame.
+		for i := range out {
+			out[i].Err = err
+		}
+	}
+	return out, err
+}
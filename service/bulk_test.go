@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"example/mocks"
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_service_CreateBatch(t *testing.T) {
+	reqs := []models.CreateBlogRequest{
+		{Title: "one", Description: "d", Body: "b"},
+		{Title: "two", Description: "d", Body: "b", Locale: "de"},
+	}
+	tests := []struct {
+		name     string
+		mode     models.BulkMode
+		repo     func() *mocks.Repository
+		wantIDs  []uint
+		wantErrs []bool
+		wantErr  bool
+	}{
+		{
+			name: "batched insert",
+			mode: models.BulkAtomic,
+			repo: func() *mocks.Repository {
+				r := new(mocks.Repository)
+				transactional(r)
+				r.On("CreateBatch", mock.Anything, mock.Anything).Return(func(_ context.Context, posts []*models.BlogPost) error {
+					for i, p := range posts {
+						p.ID = uint(i + 1)
+					}
+					return nil
+				})
+				return r
+			},
+			wantIDs:  []uint{1, 2},
+			wantErrs: []bool{false, false},
+		},
+		{
+			name: "atomic failure creates nothing",
+			mode: models.BulkAtomic,
+			repo: func() *mocks.Repository {
+				r := new(mocks.Repository)
+				transactional(r)
+				r.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("duplicate key"))
+				return r
+			},
+			wantIDs:  []uint{0, 0},
+			wantErrs: []bool{true, true},
+			wantErr:  true,
+		},
+		{
+			name: "best effort isolates the failing row",
+			mode: models.BulkBestEffort,
+			repo: func() *mocks.Repository {
+				r := new(mocks.Repository)
+				transactional(r)
+				r.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("duplicate key"))
+				r.On("Create", mock.Anything, mock.MatchedBy(func(p *models.BlogPost) bool { return p.Title == "one" })).Return(uint(0), errors.New("duplicate key"))
+				r.On("Create", mock.Anything, mock.MatchedBy(func(p *models.BlogPost) bool { return p.Title == "two" && p.Locale == "de" })).Return(uint(9), nil)
+				return r
+			},
+			wantIDs:  []uint{0, 9},
+			wantErrs: []bool{true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{repo: tt.repo()}
+			out, err := s.CreateBatch(context.Background(), reqs, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("service.CreateBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, o := range out {
+				if o.ID != tt.wantIDs[i] || (o.Err != nil) != tt.wantErrs[i] {
+					t.Errorf("service.CreateBatch() outcome %d = %+v, want ID %d, err %v", i, o, tt.wantIDs[i], tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_service_DeleteBatch(t *testing.T) {
+	newRepo := func() *mocks.Repository {
+		r := new(mocks.Repository)
+		r.On("Delete", mock.Anything, uint(1)).Return(nil)
+		r.On("Delete", mock.Anything, uint(2)).Return(errors.New("boom"))
+		r.On("Delete", mock.Anything, uint(3)).Return(nil)
+		r.On("GetByID", mock.Anything, mock.Anything).Return(&models.BlogPost{}, nil)
+		transactional(r)
+		return r
+	}
+
+	t.Run("atomic stops at the first failure", func(t *testing.T) {
+		r := newRepo()
+		s := &service{repo: r}
+		out, err := s.DeleteBatch(context.Background(), []uint{1, 2, 3}, models.BulkAtomic)
+		if err == nil {
+			t.Fatal("service.DeleteBatch() expected an error")
+		}
+		if !errors.Is(out[0].Err, ErrConflict) || !errors.Is(out[2].Err, ErrConflict) {
+			t.Errorf("service.DeleteBatch() other items should be rolled back, got %+v", out)
+		}
+		if errors.Is(out[1].Err, ErrConflict) {
+			t.Errorf("service.DeleteBatch() failing item should keep its own error, got %v", out[1].Err)
+		}
+		r.AssertNotCalled(t, "Delete", mock.Anything, uint(3))
+	})
+
+	t.Run("best effort continues", func(t *testing.T) {
+		r := newRepo()
+		s := &service{repo: r}
+		out, err := s.DeleteBatch(context.Background(), []uint{1, 2, 3}, models.BulkBestEffort)
+		if err != nil {
+			t.Fatalf("service.DeleteBatch() error = %v", err)
+		}
+		if out[0].Err != nil || out[1].Err == nil || out[2].Err != nil {
+			t.Errorf("service.DeleteBatch() = %+v", out)
+		}
+		r.AssertNumberOfCalls(t, "Transaction", 3) // one per item
+	})
+}
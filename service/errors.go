@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"example/models"
+)
+
+// Sentinel kinds for domain errors. Use errors.Is to test an error's kind.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+	ErrLocked     = errors.New("locked")
+)
+
+// Error is a domain error carrying a stable, machine-readable code and a
+// message that is safe to show to API clients.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+	// Fields lists the individual problems behind a validation error.
+	Fields []models.FieldError
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// NotFound reports that the requested entity does not exist.
+func NotFound(code, message string, err error) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message, Err: err}
+}
+
+// Conflict reports that the request clashes with the current state.
+func Conflict(code, message string, err error) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message, Err: err}
+}
+
+// Validation reports that the input is malformed or breaks a rule.
+func Validation(code, message string, err error) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message, Err: err}
+}
+
+// InvalidFields reports a validation failure broken down by field.
+func InvalidFields(fields []models.FieldError, err error) *Error {
+	return &Error{Kind: ErrValidation, Code: "invalid_fields", Message: "One or more fields are invalid", Err: err, Fields: fields}
+}
+
+// Forbidden reports that the caller may not perform the operation.
+func Forbidden(code, message string, err error) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message, Err: err}
+}
+
+// Locked reports that the entity is checked out by someone else.
+func Locked(code, message string, err error) *Error {
+	return &Error{Kind: ErrLocked, Code: code, Message: message, Err: err}
+}
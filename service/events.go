@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"example/models"
+	"example/repo"
+	"time"
+)
+
+// write runs fn in a transaction, so that the outbox events it records are
+// committed together with the change they describe, and then passes the
+// events to the notifier. A service already bound to a transaction runs fn
+// in it.
+func (s *service) write(ctx context.Context, fn func(tx *service) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+	var emitted []models.OutboxEvent
+	err := s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		emitted = emitted[:0]
+		return fn(&service{repo: tx, inTx: true, emitted: &emitted})
+	})
+	if err == nil && s.notifier != nil && len(emitted) > 0 {
+		s.notifier.Notify(ctx, emitted)
+	}
+	return err
+}
+
+// record writes events of the given types about post to the outbox. Events
+// reach anonymous stream clients, so those about a draft carry only its ID
+// and status.
+func (s *service) record(ctx context.Context, post *models.BlogPost, types ...string) error {
+	data, err := payload(post)
+	if err != nil {
+		return err
+	}
+	return s.emit(ctx, newEvents(post.ID, data, types...))
+}
+
+// payload returns the data of events about post, as record describes.
+func payload(post *models.BlogPost) ([]byte, error) {
+	if post.Status != models.StatusPublished {
+		return json.Marshal(map[string]any{"id": post.ID, "status": post.Status})
+	}
+	return json.Marshal(post)
+}
+
+// recordDeleted writes a post.deleted event, which only carries the ID.
+func (s *service) recordDeleted(ctx context.Context, id uint) error {
+	data, err := json.Marshal(map[string]uint{"id": id})
+	if err != nil {
+		return err
+	}
+	return s.emit(ctx, newEvents(id, data, models.EventPostDeleted))
+}
+
+// emit writes events to the outbox and queues their delivery to the webhook
+// subscriptions interested in them.
+func (s *service) emit(ctx context.Context, events []*models.OutboxEvent) error {
+	if err := s.repo.CreateOutboxEvents(ctx, events); err != nil {
+		return err
+	}
+	if s.emitted != nil {
+		for _, e := range events {
+			*s.emitted = append(*s.emitted, *e)
+		}
+	}
+	subs, err := s.repo.ActiveWebhookSubscriptions(ctx)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+	var deliveries []*models.WebhookDelivery
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			if sub.Matches(e.Type) {
+				deliveries = append(deliveries, &models.WebhookDelivery{
+					SubscriptionID: sub.ID,
+					EventID:        e.ID,
+					EventType:      e.Type,
+					Payload:        payload,
+					Status:         models.DeliveryPending,
+					NextAttemptAt:  e.OccurredAt,
+				})
+			}
+		}
+	}
+	return s.repo.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+func newEvents(postID uint, data []byte, types ...string) []*models.OutboxEvent {
+	now := time.Now()
+	events := make([]*models.OutboxEvent, len(types))
+	for i, t := range types {
+		events[i] = &models.OutboxEvent{Type: t, PostID: postID, Data: data, OccurredAt: now, NextAttemptAt: now}
+	}
+	return events
+}
+
+// createdEvents are the events of a new post.
+func createdEvents(post *models.BlogPost) []string {
+	if post.Status == models.StatusPublished {
+		return []string{models.EventPostCreated, models.EventPostPublished}
+	}
+	return []string{models.EventPostCreated}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/mocks"
+	"example/models"
+	"example/repo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// transactional makes r run transactions in place and accept outbox events,
+// recording their types in order, audit entries and revisions. There are no
+// webhook subscriptions, and no post is locked unless GetPostLockForUpdate was mocked
+// beforehand.
+func transactional(r *mocks.Repository) *[]string {
+	var types []string
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		return fn(r)
+	}).Maybe()
+	r.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(func(_ context.Context, events []*models.OutboxEvent) error {
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		return nil
+	}).Maybe()
+	r.On("ActiveWebhookSubscriptions", mock.Anything).Return(nil, nil).Maybe()
+	r.On("GetPostLockForUpdate", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound).Maybe()
+	r.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(nil).Maybe()
+	r.On("CreateRevision", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return &types
+}
+
+func TestEvents_Create(t *testing.T) {
+	tests := []struct {
+		status string
+		want   []string
+	}{
+		{status: "", want: []string{models.EventPostCreated, models.EventPostPublished}},
+		{status: models.StatusPublished, want: []string{models.EventPostCreated, models.EventPostPublished}},
+		{status: models.StatusDraft, want: []string{models.EventPostCreated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			r := new(mocks.Repository)
+			types := transactional(r)
+			r.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil)
+
+			_, err := NewService(r).Create(context.Background(), models.CreateBlogRequest{Title: "t", Status: tt.status})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *types)
+		})
+	}
+}
+
+func TestEvents_draftsCarryNoContent(t *testing.T) {
+	r := new(mocks.Repository)
+	var data []json.RawMessage
+	r.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(func(_ context.Context, events []*models.OutboxEvent) error {
+		for _, e := range events {
+			data = append(data, e.Data)
+		}
+		return nil
+	})
+	transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil)
+
+	for _, status := range []string{models.StatusDraft, models.StatusPublished} {
+		_, err := NewService(r).Create(context.Background(), models.CreateBlogRequest{Title: "t", Body: "secret", Status: status})
+		require.NoError(t, err)
+	}
+	require.Len(t, data, 3)
+	assert.JSONEq(t, `{"id":1,"status":"draft"}`, string(data[0]))
+	assert.Contains(t, string(data[1]), "secret")
+}
+
+func TestEvents_bulkDraftsCarryNoContent(t *testing.T) {
+	r := new(mocks.Repository)
+	var data []json.RawMessage
+	r.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(func(_ context.Context, events []*models.OutboxEvent) error {
+		for _, e := range events {
+			data = append(data, e.Data)
+		}
+		return nil
+	})
+	transactional(r)
+	r.On("CreateBatch", mock.Anything, mock.Anything).Return(func(_ context.Context, posts []*models.BlogPost) error {
+		for i, p := range posts {
+			p.ID = uint(i + 1)
+		}
+		return nil
+	})
+
+	_, err := NewService(r).CreateBatch(context.Background(), []models.CreateBlogRequest{
+		{Title: "t", Body: "secret", Status: models.StatusDraft},
+		{Title: "t", Body: "public", Status: models.StatusPublished},
+	}, models.BulkAtomic)
+	require.NoError(t, err)
+	require.Len(t, data, 3)
+	assert.JSONEq(t, `{"id":1,"status":"draft"}`, string(data[0]))
+	for _, d := range data {
+		assert.NotContains(t, string(d), "secret")
+	}
+	assert.Contains(t, string(data[1]), "public")
+}
+
+func TestEvents_Update(t *testing.T) {
+	published, draft := models.StatusPublished, models.StatusDraft
+	tests := []struct {
+		name   string
+		from   string
+		status *string
+		want   []string
+	}{
+		{name: "edit", from: models.StatusPublished, want: []string{models.EventPostUpdated}},
+		{name: "publish a draft", from: models.StatusDraft, status: &published, want: []string{models.EventPostUpdated, models.EventPostPublished}},
+		{name: "already published", from: models.StatusPublished, status: &published, want: []string{models.EventPostUpdated}},
+		{name: "unpublish", from: models.StatusPublished, status: &draft, want: []string{models.EventPostUpdated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := new(mocks.Repository)
+			types := transactional(r)
+			r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Status: tt.from}, nil)
+			r.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil)
+
+			post, err := NewService(r).Update(context.Background(), 1, &models.UpdateBlogRequest{Status: tt.status})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *types)
+			if tt.from == models.StatusDraft {
+				assert.NotNil(t, post.PublishedAt, "set on first publication")
+			}
+		})
+	}
+}
+
+func TestEvents_Delete(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		return fn(r)
+	})
+	r.On("GetByID", mock.Anything, uint(4)).Return(&models.BlogPost{ID: 4, Title: "t"}, nil)
+	r.On("GetPostLockForUpdate", mock.Anything, uint(4)).Return(nil, gorm.ErrRecordNotFound)
+	r.On("Delete", mock.Anything, uint(4)).Return(nil)
+	r.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+		return e.Action == models.AuditPostDeleted && e.EntityID == 4 && e.Changes["title"].Before == "t"
+	})).Return(nil)
+	r.On("CreateOutboxEvents", mock.Anything, mock.MatchedBy(func(events []*models.OutboxEvent) bool {
+		var data map[string]uint
+		return len(events) == 1 && events[0].Type == models.EventPostDeleted && events[0].PostID == 4 &&
+			json.Unmarshal(events[0].Data, &data) == nil && data["id"] == 4
+	})).Return(nil)
+	r.On("ActiveWebhookSubscriptions", mock.Anything).Return(nil, nil)
+
+	require.NoError(t, NewService(r).Delete(context.Background(), 4))
+	r.AssertExpectations(t)
+}
+
+func TestEvents_outboxFailureFailsTheWrite(t *testing.T) {
+	r := new(mocks.Repository)
+	rolledBack := false
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		err := fn(r)
+		rolledBack = err != nil
+		return err
+	})
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil)
+	r.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(nil)
+	r.On("CreateRevision", mock.Anything, mock.Anything).Return(nil)
+	r.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(errors.New("outbox unavailable"))
+
+	_, err := NewService(r).Create(context.Background(), models.CreateBlogRequest{Title: "t"})
+	assert.Error(t, err)
+	assert.True(t, rolledBack)
+}
+
+func TestEvents_batchSharesOneTransaction(t *testing.T) {
+	r := new(mocks.Repository)
+	types := transactional(r)
+	r.On("GetByID", mock.Anything, mock.Anything).Return(&models.BlogPost{}, nil)
+	r.On("Delete", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := NewService(r).DeleteBatch(context.Background(), []uint{1, 2, 3}, models.BulkAtomic)
+	require.NoError(t, err)
+	r.AssertNumberOfCalls(t, "Transaction", 1)
+	assert.Equal(t, []string{models.EventPostDeleted, models.EventPostDeleted, models.EventPostDeleted}, *types)
+}
+
+func TestEvents_webhookDeliveries(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		return fn(r)
+	})
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(7), nil)
+	r.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(nil)
+	r.On("CreateRevision", mock.Anything, mock.Anything).Return(nil)
+	r.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(func(_ context.Context, events []*models.OutboxEvent) error {
+		for i, e := range events {
+			e.ID = uint(100 + i)
+		}
+		return nil
+	})
+	r.On("ActiveWebhookSubscriptions", mock.Anything).Return([]models.WebhookSubscription{
+		{ID: 1},
+		{ID: 2, Events: []string{models.EventPostPublished}},
+		{ID: 3, Events: []string{models.EventPostDeleted}},
+	}, nil)
+	var deliveries []*models.WebhookDelivery
+	r.On("CreateWebhookDeliveries", mock.Anything, mock.Anything).Return(func(_ context.Context, d []*models.WebhookDelivery) error {
+		deliveries = d
+		return nil
+	})
+
+	_, err := NewService(r).Create(context.Background(), models.CreateBlogRequest{Title: "t"})
+	require.NoError(t, err)
+
+	type delivery struct {
+		sub, event uint
+		typ        string
+	}
+	var got []delivery
+	for _, d := range deliveries {
+		got = append(got, delivery{d.SubscriptionID, d.EventID, d.EventType})
+		assert.Equal(t, models.DeliveryPending, d.Status)
+		var payload struct {
+			ID     uint `json:"id"`
+			PostID uint `json:"post_id"`
+		}
+		require.NoError(t, json.Unmarshal(d.Payload, &payload))
+		assert.Equal(t, d.EventID, payload.ID)
+		assert.Equal(t, uint(7), payload.PostID)
+	}
+	assert.Equal(t, []delivery{
+		{1, 100, models.EventPostCreated},
+		{1, 101, models.EventPostPublished},
+		{2, 101, models.EventPostPublished},
+	}, got)
+}
+
+// notifier records the events it is told about.
+type notifier struct{ events []models.OutboxEvent }
+
+func (n *notifier) Notify(_ context.Context, events []models.OutboxEvent) {
+	n.events = append(n.events, events...)
+}
+
+func TestEvents_notifiedAfterCommit(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil)
+	r.On("GetByID", mock.Anything, uint(2)).Return(&models.BlogPost{ID: 2}, nil)
+	r.On("GetByID", mock.Anything, uint(3)).Return(nil, gorm.ErrRecordNotFound)
+	r.On("Delete", mock.Anything, uint(2)).Return(nil)
+	n := &notifier{}
+	s := NewService(r, WithNotifier(n))
+
+	_, err := s.Create(context.Background(), models.CreateBlogRequest{Title: "t"})
+	require.NoError(t, err)
+	assert.Len(t, n.events, 2)
+
+	_, err = s.DeleteBatch(context.Background(), []uint{2, 3}, models.BulkAtomic)
+	assert.Error(t, err)
+	assert.Len(t, n.events, 2, "nothing from a rolled back batch")
+
+	_, err = s.DeleteBatch(context.Background(), []uint{2}, models.BulkAtomic)
+	require.NoError(t, err)
+	require.Len(t, n.events, 3)
+	assert.Equal(t, models.EventPostDeleted, n.events[2].Type)
+}
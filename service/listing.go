@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ListPosts returns a page of the posts matching f, newest first, with their
+// tags. Limits beyond models.MaxPageSize are capped.
+func (s *service) ListPosts(ctx context.Context, f models.PostFilter) (*models.PostList, error) {
+	switch {
+	case f.Limit <= 0:
+		f.Limit = models.DefaultPageSize
+	case f.Limit > models.MaxPageSize:
+		f.Limit = models.MaxPageSize
+	}
+	f.Offset = max(f.Offset, 0)
+	posts, total, err := s.repo.ListPosts(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list posts: %w", err)
+	}
+	return &models.PostList{Posts: posts, Total: total}, nil
+}
+
+// GetTag returns the tag with the given slug.
+func (s *service) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	tag, err := s.repo.GetTag(ctx, slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, NotFound("tag_not_found", fmt.Sprintf("there is no tag %q", slug), err)
+	}
+	return tag, err
+}
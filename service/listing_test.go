@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"example/mocks"
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestService_ListPosts_limits(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("ListPosts", mock.Anything, models.PostFilter{Limit: models.DefaultPageSize}).Return(nil, int64(0), nil)
+	r.On("ListPosts", mock.Anything, models.PostFilter{Limit: models.MaxPageSize}).Return([]models.BlogPost{{ID: 1}}, int64(1), nil)
+	s := NewService(r)
+
+	_, err := s.ListPosts(context.Background(), models.PostFilter{Offset: -5})
+	require.NoError(t, err)
+	list, err := s.ListPosts(context.Background(), models.PostFilter{Limit: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, &models.PostList{Posts: []models.BlogPost{{ID: 1}}, Total: 1}, list)
+}
+
+func TestService_GetTag_notFound(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("GetTag", mock.Anything, "go").Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := NewService(r).GetTag(context.Background(), "go")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/audit"
+	"example/auth"
+	"example/models"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetLock returns the lock held on a post.
+func (s *service) GetLock(ctx context.Context, id uint) (*models.PostLock, error) {
+	lock, err := s.repo.GetPostLock(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && !lock.Held(time.Now()) {
+		return nil, NotFound("post_not_locked", fmt.Sprintf("post %d is not locked", id), err)
+	}
+	return lock, err
+}
+
+// Lock checks a post out to the caller for ttl, or extends the caller's
+// lock by ttl from now.
+func (s *service) Lock(ctx context.Context, id uint, ttl time.Duration) (*models.PostLock, error) {
+	user := auth.User(ctx)
+	if user == nil {
+		return nil, Forbidden("authentication_required", "only authenticated users can lock posts", nil)
+	}
+	var lock *models.PostLock
+	err := s.write(ctx, func(tx *service) error {
+		if _, err := tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		now := time.Now()
+		acquired, err := tx.repo.AcquirePostLock(ctx, &models.PostLock{
+			PostID: id, UserID: user.ID, AcquiredAt: now, ExpiresAt: now.Add(ttl),
+		}, now)
+		if err != nil {
+			return err
+		}
+		if lock, err = tx.repo.GetPostLock(ctx, id); err != nil {
+			return err
+		}
+		if !acquired {
+			return lockedBy(lock)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrLocked) {
+		return nil, err
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to lock post", "post_id", id, "error", err)
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Unlock releases the caller's lock on a post. Releasing a lock that isn't
+// held does nothing. Admins may force the release of anyone's lock, which is
+// recorded in the audit log.
+func (s *service) Unlock(ctx context.Context, id uint, force bool) error {
+	user := auth.User(ctx)
+	if user == nil {
+		return Forbidden("authentication_required", "only authenticated users can unlock posts", nil)
+	}
+	err := s.write(ctx, func(tx *service) error {
+		if _, err := tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		lock, err := tx.repo.GetPostLock(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if lock.UserID == user.ID || !lock.Held(time.Now()) {
+			_, err := tx.repo.DeletePostLock(ctx, id, lock.UserID)
+			return err
+		}
+		if !force {
+			return lockedBy(lock)
+		}
+		if user.Role != models.RoleAdmin {
+			return Forbidden("admin_required", "only admins can force a lock's release", nil)
+		}
+
+		deleted, err := tx.repo.DeletePostLock(ctx, id, lock.UserID)
+		if err != nil || !deleted {
+			return err
+		}
+		details, err := json.Marshal(map[string]any{
+			"holder_id": lock.UserID, "acquired_at": lock.AcquiredAt, "expires_at": lock.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		slog.WarnContext(ctx, "post lock released by force", "post_id", id, "holder_id", lock.UserID, "user_id", user.ID)
+		return audit.Record(ctx, tx.repo, user, &models.AuditEntry{
+			Action: models.AuditPostForceUnlocked, Entity: "post", EntityID: id, Details: details,
+		})
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrLocked) && !errors.Is(err, ErrForbidden) {
+		slog.ErrorContext(ctx, "unable to unlock post", "post_id", id, "error", err)
+	}
+	return err
+}
+
+// checkLock fails if someone other than the caller holds a lock on the post.
+// The lock is read for update, so that it stays as checked until the write
+// commits.
+func (s *service) checkLock(ctx context.Context, id uint) error {
+	lock, err := s.repo.GetPostLockForUpdate(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil || !lock.Held(time.Now()) {
+		return err
+	}
+	if user := auth.User(ctx); user != nil && user.ID == lock.UserID {
+		return nil
+	}
+	return lockedBy(lock)
+}
+
+func lockedBy(lock *models.PostLock) *Error {
+	return Locked("post_locked", fmt.Sprintf("post %d is locked by %s until %s",
+		lock.PostID, lock.Holder(), lock.ExpiresAt.UTC().Format(time.RFC3339)), nil)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"example/auth"
+	"example/mocks"
+	"example/models"
+	"example/transfer"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var (
+	alice = &models.User{ID: 1, Name: "Alice", Role: models.RoleAuthor}
+	bob   = &models.User{ID: 2, Name: "Bob", Role: models.RoleEditor}
+	admin = &models.User{ID: 3, Name: "Root", Role: models.RoleAdmin}
+)
+
+// lockedRepo is a repository in which post 1 exists and is locked by alice.
+func lockedRepo() *mocks.Repository {
+	r := new(mocks.Repository)
+	lock := &models.PostLock{PostID: 1, UserID: alice.ID, User: alice, ExpiresAt: time.Now().Add(time.Minute)}
+	r.On("GetPostLock", mock.Anything, uint(1)).Return(lock, nil)
+	r.On("GetPostLockForUpdate", mock.Anything, uint(1)).Return(lock, nil)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1}, nil)
+	return r
+}
+
+func TestService_Update_locked(t *testing.T) {
+	title := "new"
+	r := lockedRepo()
+	r.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil)
+	s := NewService(r)
+
+	_, err := s.Update(auth.WithUser(context.Background(), bob), 1, &models.UpdateBlogRequest{Title: &title})
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.ErrorContains(t, err, "locked by Alice")
+	_, err = s.Update(context.Background(), 1, &models.UpdateBlogRequest{Title: &title})
+	assert.ErrorIs(t, err, ErrLocked, "anonymous callers don't hold the lock either")
+
+	_, err = s.Update(auth.WithUser(context.Background(), alice), 1, &models.UpdateBlogRequest{Title: &title})
+	assert.NoError(t, err)
+	r.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestService_Update_expiredLock(t *testing.T) {
+	title := "new"
+	r := new(mocks.Repository)
+	r.On("GetPostLockForUpdate", mock.Anything, uint(1)).Return(&models.PostLock{PostID: 1, UserID: alice.ID, ExpiresAt: time.Now().Add(-time.Second)}, nil)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1}, nil)
+	r.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil)
+
+	_, err := NewService(r).Update(auth.WithUser(context.Background(), bob), 1, &models.UpdateBlogRequest{Title: &title})
+	assert.NoError(t, err)
+}
+
+func TestService_Lock(t *testing.T) {
+	r := new(mocks.Repository)
+	held := &models.PostLock{PostID: 1, UserID: bob.ID, User: bob, ExpiresAt: time.Now().Add(5 * time.Minute)}
+	r.On("GetPostLock", mock.Anything, uint(1)).Return(held, nil)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1}, nil)
+	r.On("AcquirePostLock", mock.Anything, mock.MatchedBy(func(l *models.PostLock) bool {
+		return l.PostID == 1 && l.UserID == bob.ID && l.ExpiresAt.Sub(l.AcquiredAt) == 5*time.Minute
+	}), mock.Anything).Return(true, nil).Once()
+	r.On("AcquirePostLock", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	s := NewService(r)
+
+	lock, err := s.Lock(auth.WithUser(context.Background(), bob), 1, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, held, lock)
+
+	_, err = s.Lock(auth.WithUser(context.Background(), alice), 1, 5*time.Minute)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.ErrorContains(t, err, "locked by Bob")
+
+	_, err = s.Lock(context.Background(), 1, 5*time.Minute)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestService_Lock_missingPost(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := NewService(r).Lock(auth.WithUser(context.Background(), bob), 9, time.Minute)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_Unlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *models.User
+		force   bool
+		wantErr error
+		audited bool
+	}{
+		{name: "holder", user: alice},
+		{name: "someone else", user: bob, wantErr: ErrLocked},
+		{name: "someone else forcing", user: bob, force: true, wantErr: ErrForbidden},
+		{name: "admin", user: admin, wantErr: ErrLocked},
+		{name: "admin forcing", user: admin, force: true, audited: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := lockedRepo()
+			r.On("DeletePostLock", mock.Anything, uint(1), alice.ID).Return(true, nil)
+			err := NewService(r).Unlock(auth.WithUser(context.Background(), tt.user), 1, tt.force)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				r.AssertNotCalled(t, "DeletePostLock", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			r.AssertCalled(t, "DeletePostLock", mock.Anything, uint(1), alice.ID)
+			entries := audited(r)
+			if !tt.audited {
+				assert.Empty(t, entries)
+				return
+			}
+			require.Len(t, entries, 1)
+			e := entries[0]
+			assert.Equal(t, models.AuditPostForceUnlocked, e.Action)
+			assert.Equal(t, admin.ID, *e.ActorID)
+			assert.Equal(t, "post", e.Entity)
+			assert.Equal(t, uint(1), e.EntityID)
+			assert.Contains(t, string(e.Details), `"holder_id":1`)
+		})
+	}
+}
+
+func TestService_GetLock(t *testing.T) {
+	s := NewService(lockedRepo())
+	lock, err := s.GetLock(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, lock.UserID)
+
+	r := new(mocks.Repository)
+	r.On("GetPostLock", mock.Anything, uint(2)).Return(nil, gorm.ErrRecordNotFound)
+	r.On("GetPostLock", mock.Anything, uint(3)).Return(&models.PostLock{PostID: 3, ExpiresAt: time.Now().Add(-time.Second)}, nil)
+	_, err = NewService(r).GetLock(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = NewService(r).GetLock(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrNotFound, "expired")
+}
+
+func TestService_Delete_locked(t *testing.T) {
+	r := lockedRepo()
+	r.On("Delete", mock.Anything, uint(1)).Return(nil)
+	s := NewService(r)
+
+	err := s.Delete(auth.WithUser(context.Background(), bob), 1)
+	assert.ErrorIs(t, err, ErrLocked)
+	_, err = s.DeleteBatch(auth.WithUser(context.Background(), bob), []uint{1}, models.BulkAtomic)
+	assert.ErrorIs(t, err, ErrLocked)
+	r.AssertNotCalled(t, "Delete", mock.Anything, uint(1))
+
+	require.NoError(t, s.Delete(auth.WithUser(context.Background(), alice), 1))
+}
+
+func TestService_Import_locked(t *testing.T) {
+	r := lockedRepo()
+	r.On("Replace", mock.Anything, mock.Anything).Return(nil)
+	opts := transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictOverwrite}
+
+	_, err := NewService(r).Import(auth.WithUser(context.Background(), bob), strings.NewReader(`{"id":1,"title":"t","description":"d","body":"b"}`), transfer.JSONL, opts)
+	assert.ErrorIs(t, err, ErrLocked)
+	r.AssertNotCalled(t, "Replace", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"example/auth"
+	"example/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// GetRevisions returns a post's revisions, newest first.
+func (s *service) GetRevisions(ctx context.Context, id uint) ([]models.PostRevision, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRevisions(ctx, id)
+}
+
+// RestoreRevision sets a post's title, description and body back to those
+// of one of its revisions, which is an update like any other: it is audited
+// and makes a new revision. The post's status is left as it is.
+func (s *service) RestoreRevision(ctx context.Context, id, revisionID uint) (*models.BlogPost, error) {
+	rev, err := s.repo.GetRevision(ctx, id, revisionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, NotFound("revision_not_found", fmt.Sprintf("post %d has no revision %d", id, revisionID), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch revision: %w", err)
+	}
+	return s.Update(ctx, id, &models.UpdateBlogRequest{Title: &rev.Title, Description: &rev.Description, Body: &rev.Body})
+}
+
+// revise records a post as just saved in its history.
+func (s *service) revise(ctx context.Context, post *models.BlogPost) error {
+	rev := &models.PostRevision{PostID: post.ID, Title: post.Title, Description: post.Description,
+		Body: post.Body, Status: post.Status}
+	if user := auth.User(ctx); user != nil {
+		rev.AuthorID, rev.Author = &user.ID, user.Email
+	}
+	return s.repo.CreateRevision(ctx, rev)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"example/auth"
+	"example/mocks"
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// revisions returns the revisions created through r, in order.
+func revisions(r *mocks.Repository) []*models.PostRevision {
+	var revs []*models.PostRevision
+	for _, call := range r.Calls {
+		if call.Method == "CreateRevision" {
+			revs = append(revs, call.Arguments.Get(1).(*models.PostRevision))
+		}
+	}
+	return revs
+}
+
+func TestService_revisions(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "First", Body: "Body", Status: models.StatusDraft}, nil)
+	r.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil)
+	s := NewService(r)
+	ctx := auth.WithUser(context.Background(), &models.User{ID: 4, Email: "ed@example.com"})
+
+	_, err := s.Create(ctx, models.CreateBlogRequest{Title: "First", Description: "D", Body: "Body", Status: models.StatusDraft})
+	require.NoError(t, err)
+	title := "Second"
+	_, err = s.Update(ctx, 1, &models.UpdateBlogRequest{Title: &title})
+	require.NoError(t, err)
+
+	revs := revisions(r)
+	require.Len(t, revs, 2)
+	assert.Equal(t, "First", revs[0].Title)
+	assert.Equal(t, models.StatusDraft, revs[0].Status)
+	assert.Equal(t, "Second", revs[1].Title)
+	assert.Equal(t, uint(1), revs[1].PostID)
+	assert.Equal(t, "ed@example.com", revs[1].Author)
+	require.NotNil(t, revs[1].AuthorID)
+	assert.Equal(t, uint(4), *revs[1].AuthorID)
+}
+
+func TestService_RestoreRevision(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetRevision", mock.Anything, uint(1), uint(3)).Return(&models.PostRevision{ID: 3, PostID: 1, Title: "Old", Description: "Was", Body: "Then", Status: models.StatusDraft}, nil)
+	r.On("GetRevision", mock.Anything, uint(1), uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "New", Body: "Now", Status: models.StatusPublished}, nil)
+	r.On("Update", mock.Anything, uint(1), mock.Anything).Return(nil)
+	s := NewService(r)
+
+	post, err := s.RestoreRevision(context.Background(), 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "Old", post.Title)
+	assert.Equal(t, "Then", post.Body)
+	assert.Equal(t, models.StatusPublished, post.Status, "the status is left as it is")
+	require.Len(t, revisions(r), 1, "restoring makes a revision")
+	assert.Equal(t, models.AuditPostUpdated, audited(r)[0].Action)
+
+	_, err = s.RestoreRevision(context.Background(), 1, 9)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"example/audit"
+	"example/auth"
+	"example/models"
+	"example/seed"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// Seed loads a generated dataset in a single transaction. Users and tags
+// that already exist are reused, so loading the same dataset twice adds its
+// posts again but nothing else. Posts are created like any other, so they
+// are audited, revised and announced.
+func (s *service) Seed(ctx context.Context, ds *seed.Dataset) (*seed.Report, error) {
+	report := &seed.Report{Profile: ds.Profile.Name, Seed: ds.Seed}
+	hash, err := auth.HashPassword(seed.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.write(ctx, func(tx *service) error {
+		for _, u := range ds.Users {
+			_, err := tx.repo.GetUserByEmail(ctx, u.Email)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			u.PasswordHash = hash
+			if err := tx.repo.CreateUser(ctx, &u); err != nil {
+				return fmt.Errorf("user %s: %w", u.Email, err)
+			}
+			err = audit.Record(ctx, tx.repo, auth.User(ctx), &models.AuditEntry{
+				Action: models.AuditUserCreated, Entity: "user", EntityID: u.ID,
+				Changes: audit.Diff(nil, map[string]any{"email": u.Email, "name": u.Name, "role": u.Role}),
+			})
+			if err != nil {
+				return err
+			}
+			report.Users++
+		}
+
+		tags := map[string]models.Tag{}
+		for _, t := range ds.Tags {
+			if err := tx.repo.FirstOrCreateTag(ctx, &t); err != nil {
+				return fmt.Errorf("tag %s: %w", t.Slug, err)
+			}
+			tags[t.Slug] = t
+			report.Tags++
+		}
+
+		posts := make([]*models.BlogPost, len(ds.Posts))
+		for i, p := range ds.Posts {
+			post := p
+			post.Tags = make([]models.Tag, len(p.Tags))
+			for j, t := range p.Tags {
+				post.Tags[j] = tags[t.Slug]
+			}
+			post.Comments = append([]models.Comment(nil), p.Comments...)
+			posts[i] = &post
+			report.Comments += len(post.Comments)
+		}
+		if err := tx.createPosts(ctx, posts); err != nil {
+			return err
+		}
+		report.Posts = len(posts)
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to seed the database", "profile", ds.Profile.Name, "seed", ds.Seed, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "database seeded", "profile", ds.Profile.Name, "seed", ds.Seed,
+		"users", report.Users, "tags", report.Tags, "posts", report.Posts)
+	return report, nil
+}
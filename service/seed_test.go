@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"example/mocks"
+	"example/models"
+	"example/seed"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestService_Seed(t *testing.T) {
+	ctx := context.Background()
+	ds := seed.Generate(seed.Profile{Name: "tiny", Posts: 3, Users: 2, Tags: 2, MaxTagsPerPost: 2, MaxComments: 2, MinParagraphs: 1, MaxParagraphs: 1}, 9)
+
+	r := new(mocks.Repository)
+	types := transactional(r)
+	r.On("GetUserByEmail", ctx, ds.Users[0].Email).Return(&ds.Users[0], nil)
+	r.On("GetUserByEmail", ctx, ds.Users[1].Email).Return(nil, gorm.ErrRecordNotFound)
+	r.On("CreateUser", ctx, mock.MatchedBy(func(u *models.User) bool {
+		return u.Email == ds.Users[1].Email && u.PasswordHash != ""
+	})).Return(nil)
+	r.On("FirstOrCreateTag", ctx, mock.AnythingOfType("*models.Tag")).
+		Run(func(args mock.Arguments) {
+			tag := args.Get(1).(*models.Tag)
+			tag.ID = uint(len(tag.Slug))
+		}).
+		Return(nil)
+	var created []*models.BlogPost
+	r.On("CreateBatch", ctx, mock.AnythingOfType("[]*models.BlogPost")).
+		Run(func(args mock.Arguments) {
+			created = args.Get(1).([]*models.BlogPost)
+			for i, p := range created {
+				p.ID = uint(i + 1)
+			}
+		}).
+		Return(nil)
+
+	report, err := NewService(r).Seed(ctx, ds)
+	require.NoError(t, err)
+	r.AssertExpectations(t)
+
+	comments := 0
+	for _, p := range ds.Posts {
+		comments += len(p.Comments)
+	}
+	assert.Equal(t, &seed.Report{Profile: "tiny", Seed: 9, Users: 1, Tags: 2, Posts: 3, Comments: comments}, report)
+	require.Len(t, created, 3)
+	for _, p := range created {
+		for _, tag := range p.Tags {
+			assert.NotZero(t, tag.ID, "tags are linked to the stored ones")
+		}
+	}
+	// The user and every post are audited, and the posts announced.
+	assert.Len(t, audited(r), 4)
+	assert.Equal(t, 3, count(*types, models.EventPostCreated))
+	// The dataset itself is left untouched.
+	assert.Equal(t, seed.Generate(ds.Profile, 9), ds)
+}
+
+func count(types []string, typ string) int {
+	n := 0
+	for _, t := range types {
+		if t == typ {
+			n++
+		}
+	}
+	return n
+}
@@ -1,44 +1,120 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"example/models"
 	"example/repo"
+	"example/seed"
+	"example/transfer"
+	"example/wxr"
 	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // BlogService defines methods for blog operations.
 //
 //go:generate mockery --name=Service --outpkg mocks
 type Service interface {
-	Create(req models.CreateBlogRequest) (uint, error)
-	GetAll() ([]models.BlogPost, error)
-	GetByID(id uint) (*models.BlogPost, error)
-	Update(id uint, post *models.UpdateBlogRequest) (*models.BlogPost, error)
-	Delete(id uint) error
+	Create(ctx context.Context, req models.CreateBlogRequest) (uint, error)
+	GetAll(ctx context.Context) ([]models.BlogPost, error)
+	GetByID(ctx context.Context, id uint) (*models.BlogPost, error)
+	Update(ctx context.Context, id uint, post *models.UpdateBlogRequest) (*models.BlogPost, error)
+	Delete(ctx context.Context, id uint) error
+	CreateBatch(ctx context.Context, reqs []models.CreateBlogRequest, mode models.BulkMode) ([]models.BulkOutcome, error)
+	UpdateBatch(ctx context.Context, items []models.BulkUpdateItem, mode models.BulkMode) ([]models.BulkOutcome, error)
+	DeleteBatch(ctx context.Context, ids []uint, mode models.BulkMode) ([]models.BulkOutcome, error)
+	Export(ctx context.Context, w io.Writer, f transfer.Format) (int, error)
+	Import(ctx context.Context, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error)
+	// ImportWordPress imports a WordPress export, resuming the import saved
+	// under name if there is one.
+	ImportWordPress(ctx context.Context, rd io.Reader, name string, opts wxr.Options) (*wxr.Report, error)
+	GetLock(ctx context.Context, id uint) (*models.PostLock, error)
+	Lock(ctx context.Context, id uint, ttl time.Duration) (*models.PostLock, error)
+	Unlock(ctx context.Context, id uint, force bool) error
+	GetTranslations(ctx context.Context, id uint) ([]models.PostTranslation, error)
+	PutTranslation(ctx context.Context, id uint, locale string, req models.TranslationRequest) (*models.PostTranslation, bool, error)
+	UpdateTranslation(ctx context.Context, id uint, locale string, req *models.UpdateTranslationRequest) (*models.PostTranslation, error)
+	DeleteTranslation(ctx context.Context, id uint, locale string) error
+	Localize(ctx context.Context, posts []models.BlogPost, prefs []string) error
+	ListPosts(ctx context.Context, f models.PostFilter) (*models.PostList, error)
+	GetTag(ctx context.Context, slug string) (*models.Tag, error)
+	GetRevisions(ctx context.Context, id uint) ([]models.PostRevision, error)
+	RestoreRevision(ctx context.Context, id, revisionID uint) (*models.BlogPost, error)
+	ListTrash(ctx context.Context) ([]models.BlogPost, error)
+	RestorePost(ctx context.Context, id uint) (*models.BlogPost, error)
+	PurgePost(ctx context.Context, id uint) error
+	// Seed loads a generated dataset.
+	Seed(ctx context.Context, ds *seed.Dataset) (*seed.Report, error)
+}
+
+// Notifier is told about the events of every committed write, for instance
+// to push them to live subscribers.
+type Notifier interface {
+	Notify(ctx context.Context, events []models.OutboxEvent)
 }
 
 // BlogServiceImpl implements BlogService
 type service struct {
-	repo repo.Repository
+	repo     repo.Repository
+	notifier Notifier
+	// inTx is set on services bound to a transaction by write, which
+	// collects the events they emit in emitted.
+	inTx    bool
+	emitted *[]models.OutboxEvent
+}
+
+// Option configures the service.
+type Option func(*service)
+
+// WithNotifier passes the events of committed writes to n.
+func WithNotifier(n Notifier) Option {
+	return func(s *service) {
+		s.notifier = n
+	}
 }
 
-func NewService(repo repo.Repository) *service {
-	return &service{repo: repo}
+func NewService(repo repo.Repository, opts ...Option) *service {
+	s := &service{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Create a new blog post
-func (s *service) Create(req models.CreateBlogRequest) (uint, error) {
-	id, err := s.repo.Create(&models.BlogPost{
-		Title:       req.Title,
-		Description: req.Description,
-		Body:        req.Body,
+func (s *service) Create(ctx context.Context, req models.CreateBlogRequest) (uint, error) {
+	post := newPost(req)
+	var id uint
+	err := s.write(ctx, func(tx *service) error {
+		var err error
+		if id, err = tx.repo.Create(ctx, post); err != nil {
+			return err
+		}
+		post.ID = id
+		if err := tx.audit(ctx, models.AuditPostCreated, id, nil, post); err != nil {
+			return err
+		}
+		if err := tx.revise(ctx, post); err != nil {
+			return err
+		}
+		return tx.record(ctx, post, createdEvents(post)...)
 	})
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to create post", "error", err)
+		return id, err
+	}
+	slog.InfoContext(ctx, "post created", "post_id", id)
 	return id, err
 }
 
 // Get all blog posts
-func (s *service) GetAll() ([]models.BlogPost, error) {
-	posts, err := s.repo.GetAll()
+func (s *service) GetAll(ctx context.Context) ([]models.BlogPost, error) {
+	posts, err := s.repo.GetAll(ctx)
 	if err != nil {
 		return []models.BlogPost{}, fmt.Errorf("unable to fetch posts: %w", err)
 	}
@@ -46,36 +122,125 @@ func (s *service) GetAll() ([]models.BlogPost, error) {
 }
 
 // Get a single blog post by ID
-func (s *service) GetByID(id uint) (*models.BlogPost, error) {
-	post, err := s.repo.GetByID(id)
+func (s *service) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	post, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, postNotFound(id, err)
+	}
 	return post, err
 }
 
 // Update a blog post
-func (s *service) Update(id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error) {
+func (s *service) Update(ctx context.Context, id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error) {
+	var post *models.BlogPost
+	err := s.write(ctx, func(tx *service) error {
+		var err error
+		post, err = tx.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch post : %w", err)
+		}
+		if err := tx.checkLock(ctx, id); err != nil {
+			return err
+		}
+		before := *post
 
-	post, err := s.GetByID(id)
+		// Update only provided fields
+		if req.Title != nil {
+			post.Title = *req.Title
+		}
+		if req.Description != nil {
+			post.Description = *req.Description
+		}
+		if req.Body != nil {
+			post.Body = *req.Body
+		}
+		types := []string{models.EventPostUpdated}
+		if req.Status != nil {
+			if *req.Status == models.StatusPublished && post.Status != models.StatusPublished {
+				types = append(types, models.EventPostPublished)
+				if post.PublishedAt == nil {
+					now := time.Now()
+					post.PublishedAt = &now
+				}
+			}
+			post.Status = *req.Status
+		}
+		if err := tx.repo.Update(ctx, id, post); err != nil {
+			return err
+		}
+		if err := tx.audit(ctx, models.AuditPostUpdated, id, &before, post); err != nil {
+			return err
+		}
+		if err := tx.revise(ctx, post); err != nil {
+			return err
+		}
+		return tx.record(ctx, post, types...)
+	})
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrLocked) {
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch post : %w", err)
+		slog.ErrorContext(ctx, "unable to update post", "post_id", id, "error", err)
+		return post, err
 	}
+	slog.InfoContext(ctx, "post updated", "post_id", id)
+	return post, err
+
+}
 
-	// Update only provided fields
-	if req.Title != nil {
-		post.Title = *req.Title
+// Delete moves a blog post to the trash
+func (s *service) Delete(ctx context.Context, id uint) error {
+	err := s.write(ctx, func(tx *service) error {
+		post, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.checkLock(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.audit(ctx, models.AuditPostDeleted, id, post, nil); err != nil {
+			return err
+		}
+		return tx.recordDeleted(ctx, id)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return postNotFound(id, err)
 	}
-	if req.Description != nil {
-		post.Description = *req.Description
+	if errors.Is(err, ErrNotFound) {
+		return err
 	}
-	if req.Body != nil {
-		post.Body = *req.Body
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to delete post", "post_id", id, "error", err)
+		return err
 	}
-	err = s.repo.Update(id, post)
-	return post, err
-
+	slog.InfoContext(ctx, "post deleted", "post_id", id)
+	return nil
 }
 
-// Delete a blog post
-func (s *service) Delete(id uint) error {
-	return s.repo.Delete(id)
+func postNotFound(id uint, err error) *Error {
+	return NotFound("post_not_found", fmt.Sprintf("post %d not found", id), err)
+}
 
+func newPost(req models.CreateBlogRequest) *models.BlogPost {
+	post := &models.BlogPost{
+		Title:       req.Title,
+		Description: req.Description,
+		Body:        req.Body,
+		Status:      req.Status,
+		Locale:      req.Locale,
+	}
+	if post.Locale == "" {
+		post.Locale = models.DefaultPostLocale
+	}
+	if post.Status == "" {
+		post.Status = models.StatusPublished
+	}
+	if post.Status == models.StatusPublished {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+	return post
 }
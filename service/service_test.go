@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"example/mocks"
 	"example/models"
 	"example/repo"
@@ -10,6 +12,7 @@ import (
 	"time"
 
 	"github.com/c2fo/testify/mock"
+	"gorm.io/gorm"
 )
 
 func Test_service_Create(t *testing.T) {
@@ -30,7 +33,8 @@ func Test_service_Create(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("Create", mock.Anything).Return(uint(1), nil)
+					transactional(repo)
+					repo.On("Create", mock.Anything, mock.Anything).Return(uint(1), nil)
 					return repo
 				}(),
 			},
@@ -45,7 +49,7 @@ func Test_service_Create(t *testing.T) {
 			s := &service{
 				repo: tt.fields.repo,
 			}
-			if _, err := s.Create(tt.args.req); (err != nil) != tt.wantErr {
+			if _, err := s.Create(context.Background(), tt.args.req); (err != nil) != tt.wantErr {
 				t.Errorf("service.Create() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -68,7 +72,7 @@ func Test_service_GetAll(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("GetAll").Return([]models.BlogPost{{ID: 1, Title: "title", Description: "description", Body: "body", CreatedAt: ti, UpdatedAt: ti}}, nil)
+					repo.On("GetAll", mock.Anything).Return([]models.BlogPost{{ID: 1, Title: "title", Description: "description", Body: "body", CreatedAt: ti, UpdatedAt: ti}}, nil)
 					return repo
 				}(),
 			},
@@ -80,7 +84,7 @@ func Test_service_GetAll(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("GetAll").Return([]models.BlogPost{}, fmt.Errorf("unable to fetch post"))
+					repo.On("GetAll", mock.Anything).Return([]models.BlogPost{}, fmt.Errorf("unable to fetch post"))
 					return repo
 				}(),
 			},
@@ -93,7 +97,7 @@ func Test_service_GetAll(t *testing.T) {
 			s := &service{
 				repo: tt.fields.repo,
 			}
-			got, err := s.GetAll()
+			got, err := s.GetAll(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("service.GetAll() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -124,7 +128,7 @@ func Test_service_GetByID(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("GetByID", mock.Anything).Return(&models.BlogPost{ID: 1, Title: "title", Description: "description", Body: "body"}, nil)
+					repo.On("GetByID", mock.Anything, mock.Anything).Return(&models.BlogPost{ID: 1, Title: "title", Description: "description", Body: "body"}, nil)
 					return repo
 				}(),
 			},
@@ -137,7 +141,8 @@ func Test_service_GetByID(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("GetByID", mock.Anything).Return(nil, fmt.Errorf("unable to fetch post"))
+					transactional(repo)
+					repo.On("GetByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("unable to fetch post"))
 					return repo
 				}(),
 			},
@@ -152,7 +157,7 @@ func Test_service_GetByID(t *testing.T) {
 			s := &service{
 				repo: tt.fields.repo,
 			}
-			got, err := s.GetByID(tt.args.id)
+			got, err := s.GetByID(context.Background(), tt.args.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("service.GetByID() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -185,7 +190,8 @@ func Test_service_Update(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("GetByID", mock.Anything).Return(nil, fmt.Errorf("unable to fetch post"))
+					transactional(repo)
+					repo.On("GetByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("unable to fetch post"))
 					return repo
 				}(),
 			},
@@ -197,8 +203,9 @@ func Test_service_Update(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("GetByID", mock.Anything).Return(&models.BlogPost{ID: 1, Title: "title", Description: "description", Body: "body"}, nil)
-					repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+					transactional(repo)
+					repo.On("GetByID", mock.Anything, mock.Anything).Return(&models.BlogPost{ID: 1, Title: "title", Description: "description", Body: "body"}, nil)
+					repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 					return repo
 				}(),
@@ -213,7 +220,7 @@ func Test_service_Update(t *testing.T) {
 			s := &service{
 				repo: tt.fields.repo,
 			}
-			got, err := s.Update(tt.args.id, tt.args.req)
+			got, err := s.Update(context.Background(), tt.args.id, tt.args.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("service.Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -243,7 +250,9 @@ func Test_service_Delete(t *testing.T) {
 			fields: fields{
 				repo: func() repo.Repository {
 					repo := new(mocks.Repository)
-					repo.On("Delete", mock.Anything).Return(nil)
+					transactional(repo)
+					repo.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1}, nil)
+					repo.On("Delete", mock.Anything, mock.Anything).Return(nil)
 
 					return repo
 				}(),
@@ -259,9 +268,28 @@ func Test_service_Delete(t *testing.T) {
 			s := &service{
 				repo: tt.fields.repo,
 			}
-			if err := s.Delete(tt.args.id); (err != nil) != tt.wantErr {
+			if err := s.Delete(context.Background(), tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("service.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_service_NotFound(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetByID", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	r.On("Delete", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+	s := &service{repo: r}
+
+	ss := "mockString"
+	_, getErr := s.GetByID(context.Background(), 1)
+	_, updateErr := s.Update(context.Background(), 1, &models.UpdateBlogRequest{Title: &ss})
+	deleteErr := s.Delete(context.Background(), 1)
+
+	for name, err := range map[string]error{"GetByID": getErr, "Update": updateErr, "Delete": deleteErr} {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("service.%s() error = %v, want ErrNotFound", name, err)
+		}
+	}
+}
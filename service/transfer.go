@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/audit"
+	"example/auth"
+	"example/models"
+	"example/transfer"
+	"io"
+	"log/slog"
+)
+
+// Export writes every post to w in the given format.
+func (s *service) Export(ctx context.Context, w io.Writer, f transfer.Format) (int, error) {
+	n, err := transfer.Export(ctx, s.repo, w, f)
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to export posts", "format", f, "exported", n, "error", err)
+		return n, err
+	}
+	slog.InfoContext(ctx, "posts exported", "format", f, "count", n)
+	return n, nil
+}
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// Import reads posts in the given format from rd. Nothing is written unless
+// the whole file is imported, and then each post is audited, revised and
+// announced like any other, and the import itself is audited.
+func (s *service) Import(ctx context.Context, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error) {
+	var report *transfer.Report
+	err := s.write(ctx, func(tx *service) error {
+		var err error
+		if report, err = transfer.Import(ctx, importStore{tx}, rd, f, opts); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		details, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx.repo, auth.User(ctx), &models.AuditEntry{
+			Action: models.AuditPostsImported, Entity: "post", Details: details,
+		})
+	})
+	switch {
+	case errors.Is(err, errDryRun):
+	case errors.Is(err, transfer.ErrInvalidFile):
+		return nil, Validation("invalid_import_file", err.Error(), err)
+	case errors.Is(err, transfer.ErrConflict):
+		return nil, Conflict("post_exists", err.Error(), err)
+	case err != nil:
+		slog.ErrorContext(ctx, "unable to import posts", "format", f, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "posts imported", "format", f, "dry_run", report.DryRun,
+		"created", report.Created, "updated", report.Updated, "skipped", report.Skipped, "failed", report.Failed)
+	return report, nil
+}
+
+// importStore writes the posts of an import with a service bound to its
+// transaction.
+type importStore struct {
+	tx *service
+}
+
+func (st importStore) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	return st.tx.repo.GetByID(ctx, id)
+}
+
+func (st importStore) IDTaken(ctx context.Context, id uint) (bool, error) {
+	return st.tx.repo.PostIDTaken(ctx, id)
+}
+
+func (st importStore) CreatePost(ctx context.Context, post *models.BlogPost) error {
+	return st.tx.importPost(ctx, post)
+}
+
+func (st importStore) ReplacePost(ctx context.Context, before, post *models.BlogPost) error {
+	return st.tx.replacePost(ctx, before, post)
+}
+
+func (st importStore) SyncIDSequence(ctx context.Context) error {
+	return st.tx.repo.SyncIDSequence(ctx)
+}
+
+// importPost creates an imported post, under its own ID if it has one, and
+// audits, revises and announces it like any other.
+func (s *service) importPost(ctx context.Context, post *models.BlogPost) error {
+	id, err := s.repo.Create(ctx, post)
+	if err != nil {
+		return err
+	}
+	post.ID = id
+	if err := s.audit(ctx, models.AuditPostCreated, id, nil, post); err != nil {
+		return err
+	}
+	if err := s.revise(ctx, post); err != nil {
+		return err
+	}
+	return s.record(ctx, post, createdEvents(post)...)
+}
+
+// replacePost overwrites a post with imported values, timestamps included,
+// unless another user holds its lock. before is the post as stored.
+func (s *service) replacePost(ctx context.Context, before, post *models.BlogPost) error {
+	if err := s.checkLock(ctx, post.ID); err != nil {
+		return err
+	}
+	if err := s.repo.Replace(ctx, post); err != nil {
+		return err
+	}
+	if err := s.audit(ctx, models.AuditPostUpdated, post.ID, before, post); err != nil {
+		return err
+	}
+	if err := s.revise(ctx, post); err != nil {
+		return err
+	}
+	types := []string{models.EventPostUpdated}
+	if post.Status == models.StatusPublished && before.Status != models.StatusPublished {
+		types = append(types, models.EventPostPublished)
+	}
+	return s.record(ctx, post, types...)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"example/mocks"
+	"example/models"
+	"example/transfer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const importFile = `{"title":"New","description":"d","body":"b"}
+{"id":2,"title":"Replaced","description":"d","body":"b"}
+`
+
+func TestService_Import(t *testing.T) {
+	r := new(mocks.Repository)
+	types := transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(5), nil)
+	r.On("GetByID", mock.Anything, uint(2)).Return(&models.BlogPost{ID: 2, Title: "Old", Status: models.StatusPublished}, nil)
+	r.On("Replace", mock.Anything, mock.Anything).Return(nil)
+	n := &notifier{}
+
+	opts := transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictOverwrite}
+	report, err := NewService(r, WithNotifier(n)).Import(context.Background(), strings.NewReader(importFile), transfer.JSONL, opts)
+	require.NoError(t, err)
+	assert.Equal(t, &transfer.Report{Created: 1, Updated: 1}, report)
+
+	// Each post is audited and announced in the import's transaction.
+	want := []string{models.EventPostCreated, models.EventPostPublished, models.EventPostUpdated}
+	assert.Equal(t, want, *types)
+	assert.Len(t, n.events, 3)
+	var actions []string
+	for _, e := range audited(r) {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{models.AuditPostCreated, models.AuditPostUpdated, models.AuditPostsImported}, actions)
+}
+
+func TestService_Import_dryRun(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(5), nil)
+	r.On("GetByID", mock.Anything, uint(2)).Return(nil, gorm.ErrRecordNotFound)
+	r.On("PostIDTaken", mock.Anything, uint(2)).Return(false, nil)
+	r.On("SyncIDSequence", mock.Anything).Return(nil)
+	n := &notifier{}
+
+	opts := transfer.Options{DryRun: true, IDs: transfer.PreserveIDs}
+	report, err := NewService(r, WithNotifier(n)).Import(context.Background(), strings.NewReader(importFile), transfer.JSONL, opts)
+	require.NoError(t, err)
+	assert.Equal(t, &transfer.Report{DryRun: true, Created: 2}, report)
+	assert.Empty(t, n.events)
+	for _, e := range audited(r) {
+		assert.NotEqual(t, models.AuditPostsImported, e.Action)
+	}
+}
+
+// A post in the trash keeps its ID, which an import reports as a conflict
+// rather than failing to insert it again.
+func TestService_Import_trashedID(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("Create", mock.Anything, mock.Anything).Return(uint(5), nil)
+	r.On("GetByID", mock.Anything, uint(2)).Return(nil, gorm.ErrRecordNotFound)
+	r.On("PostIDTaken", mock.Anything, uint(2)).Return(true, nil)
+
+	opts := transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictFail}
+	_, err := NewService(r).Import(context.Background(), strings.NewReader(importFile), transfer.JSONL, opts)
+	assert.ErrorIs(t, err, ErrConflict)
+	r.AssertNotCalled(t, "SyncIDSequence", mock.Anything)
+}
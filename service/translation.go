@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/audit"
+	"example/auth"
+	"example/i18n"
+	"example/models"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// GetTranslations returns a post's translations, ordered by locale.
+func (s *service) GetTranslations(ctx context.Context, id uint) ([]models.PostTranslation, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTranslations(ctx, []uint{id})
+}
+
+// PutTranslation creates a post's translation in a locale, or replaces it,
+// and reports which.
+func (s *service) PutTranslation(ctx context.Context, id uint, locale string, req models.TranslationRequest) (*models.PostTranslation, bool, error) {
+	var (
+		t       *models.PostTranslation
+		created bool
+	)
+	err := s.write(ctx, func(tx *service) error {
+		post, err := tx.translatable(ctx, id, &locale)
+		if err != nil {
+			return err
+		}
+		t, err = tx.repo.GetTranslation(ctx, id, locale)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			created = true
+			t = &models.PostTranslation{PostID: post.ID, Locale: locale,
+				Title: req.Title, Description: req.Description, Body: req.Body}
+			if err := tx.repo.CreateTranslation(ctx, t); err != nil {
+				return err
+			}
+			return tx.auditTranslation(ctx, models.AuditTranslationCreated, t, nil, t)
+		case err != nil:
+			return err
+		}
+		before := *t
+		t.Title, t.Description, t.Body = req.Title, req.Description, req.Body
+		if err := tx.repo.UpdateTranslation(ctx, t); err != nil {
+			return err
+		}
+		return tx.auditTranslation(ctx, models.AuditTranslationUpdated, t, &before, t)
+	})
+	if err != nil {
+		return nil, false, translationError(ctx, "unable to save translation", id, locale, err)
+	}
+	slog.InfoContext(ctx, "translation saved", "post_id", id, "locale", locale, "created", created)
+	return t, created, nil
+}
+
+// UpdateTranslation changes the given fields of a post's translation.
+func (s *service) UpdateTranslation(ctx context.Context, id uint, locale string, req *models.UpdateTranslationRequest) (*models.PostTranslation, error) {
+	var t *models.PostTranslation
+	err := s.write(ctx, func(tx *service) error {
+		if _, err := tx.translatable(ctx, id, &locale); err != nil {
+			return err
+		}
+		var err error
+		if t, err = tx.getTranslation(ctx, id, locale); err != nil {
+			return err
+		}
+		before := *t
+		if req.Title != nil {
+			t.Title = *req.Title
+		}
+		if req.Description != nil {
+			t.Description = *req.Description
+		}
+		if req.Body != nil {
+			t.Body = *req.Body
+		}
+		if err := tx.repo.UpdateTranslation(ctx, t); err != nil {
+			return err
+		}
+		return tx.auditTranslation(ctx, models.AuditTranslationUpdated, t, &before, t)
+	})
+	if err != nil {
+		return nil, translationError(ctx, "unable to update translation", id, locale, err)
+	}
+	slog.InfoContext(ctx, "translation updated", "post_id", id, "locale", locale)
+	return t, nil
+}
+
+// DeleteTranslation deletes a post's translation in a locale.
+func (s *service) DeleteTranslation(ctx context.Context, id uint, locale string) error {
+	err := s.write(ctx, func(tx *service) error {
+		if _, err := tx.translatable(ctx, id, &locale); err != nil {
+			return err
+		}
+		t, err := tx.getTranslation(ctx, id, locale)
+		if err != nil {
+			return err
+		}
+		if err := tx.repo.DeleteTranslation(ctx, id, locale); err != nil {
+			return err
+		}
+		return tx.auditTranslation(ctx, models.AuditTranslationDeleted, t, t, nil)
+	})
+	if err != nil {
+		return translationError(ctx, "unable to delete translation", id, locale, err)
+	}
+	slog.InfoContext(ctx, "translation deleted", "post_id", id, "locale", locale)
+	return nil
+}
+
+// Localize serves each post in the first language of the client's
+// preferences, most preferred first, that it has a translation in, and
+// lists the languages it is available in.
+func (s *service) Localize(ctx context.Context, posts []models.BlogPost, prefs []string) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	translations, err := s.repo.ListTranslations(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("unable to fetch translations: %w", err)
+	}
+	byPost := map[uint][]models.PostTranslation{}
+	for _, t := range translations {
+		byPost[t.PostID] = append(byPost[t.PostID], t)
+	}
+	chain := i18n.Chain(prefs)
+	for i := range posts {
+		i18n.Localize(&posts[i], byPost[posts[i].ID], chain)
+	}
+	return nil
+}
+
+// translatable returns the post to translate, once its lock is checked, and
+// canonicalises the locale, which must differ from the post's own.
+func (s *service) translatable(ctx context.Context, id uint, locale *string) (*models.BlogPost, error) {
+	l, ok := models.CanonicalLocale(*locale)
+	if !ok {
+		return nil, Validation("invalid_locale", fmt.Sprintf("%q is not a language tag such as en or de-AT", *locale), nil)
+	}
+	*locale = l
+	post, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if post.Locale == l {
+		return nil, Validation("original_locale", fmt.Sprintf("post %d is written in %s; update the post itself", id, l), nil)
+	}
+	return post, s.checkLock(ctx, id)
+}
+
+func (s *service) getTranslation(ctx context.Context, id uint, locale string) (*models.PostTranslation, error) {
+	t, err := s.repo.GetTranslation(ctx, id, locale)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, NotFound("translation_not_found", fmt.Sprintf("post %d has no %s translation", id, locale), err)
+	}
+	return t, err
+}
+
+// auditTranslation records an action on a translation in the audit log,
+// against its post.
+func (s *service) auditTranslation(ctx context.Context, action string, t, before, after *models.PostTranslation) error {
+	details, err := json.Marshal(map[string]string{"locale": t.Locale})
+	if err != nil {
+		return err
+	}
+	return audit.Record(ctx, s.repo, auth.User(ctx), &models.AuditEntry{
+		Action: action, Entity: "post", EntityID: t.PostID, Details: details,
+		Changes: audit.Diff(translationFields(before), translationFields(after)),
+	})
+}
+
+// translationFields returns the audited fields of a translation.
+func translationFields(t *models.PostTranslation) map[string]any {
+	if t == nil {
+		return nil
+	}
+	return map[string]any{"title": t.Title, "description": t.Description, "body": t.Body}
+}
+
+// translationError logs unexpected errors.
+func translationError(ctx context.Context, msg string, id uint, locale string, err error) error {
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrLocked) && !errors.Is(err, ErrValidation) {
+		slog.ErrorContext(ctx, msg, "post_id", id, "locale", locale, "error", err)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"example/auth"
+	"example/mocks"
+	"example/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestService_PutTranslation(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Locale: "en"}, nil)
+	r.On("GetTranslation", mock.Anything, uint(1), "de-AT").Return(nil, gorm.ErrRecordNotFound).Once()
+	r.On("CreateTranslation", mock.Anything, mock.Anything).Return(nil)
+	r.On("GetTranslation", mock.Anything, uint(1), "de-AT").Return(&models.PostTranslation{PostID: 1, Locale: "de-AT", Title: "Hallo", Body: "Text"}, nil)
+	r.On("UpdateTranslation", mock.Anything, mock.Anything).Return(nil)
+	s := NewService(r)
+
+	tr, created, err := s.PutTranslation(context.Background(), 1, "DE-at", models.TranslationRequest{Title: "Hallo", Body: "Text"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "de-AT", tr.Locale, "canonicalised")
+
+	tr, created, err = s.PutTranslation(context.Background(), 1, "de-AT", models.TranslationRequest{Title: "Servus", Body: "Text"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "Servus", tr.Title)
+
+	entries := audited(r)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditTranslationCreated, entries[0].Action)
+	assert.Equal(t, models.AuditTranslationUpdated, entries[1].Action)
+	assert.Equal(t, models.Changes{"title": {Before: "Hallo", After: "Servus"}}, entries[1].Changes)
+	assert.JSONEq(t, `{"locale":"de-AT"}`, string(entries[1].Details))
+}
+
+func TestService_PutTranslation_invalid(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Locale: "en"}, nil)
+	s := NewService(r)
+
+	_, _, err := s.PutTranslation(context.Background(), 1, "not a language", models.TranslationRequest{})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, _, err = s.PutTranslation(context.Background(), 1, "EN", models.TranslationRequest{})
+	assert.ErrorIs(t, err, ErrValidation, "the post's own language")
+	r.AssertNotCalled(t, "CreateTranslation", mock.Anything, mock.Anything)
+}
+
+func TestService_UpdateTranslation(t *testing.T) {
+	title := "Bonjour"
+	r := new(mocks.Repository)
+	r.On("GetPostLockForUpdate", mock.Anything, uint(1)).Return(&models.PostLock{
+		PostID: 1, UserID: alice.ID, User: alice, ExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Locale: "en"}, nil)
+	r.On("GetTranslation", mock.Anything, uint(1), "fr").Return(&models.PostTranslation{PostID: 1, Locale: "fr", Title: "Salut", Body: "Texte"}, nil)
+	r.On("GetTranslation", mock.Anything, uint(1), "de").Return(nil, gorm.ErrRecordNotFound)
+	r.On("UpdateTranslation", mock.Anything, mock.Anything).Return(nil)
+	s := NewService(r)
+
+	_, err := s.UpdateTranslation(auth.WithUser(context.Background(), bob), 1, "fr", &models.UpdateTranslationRequest{Title: &title})
+	assert.ErrorIs(t, err, ErrLocked)
+
+	ctx := auth.WithUser(context.Background(), alice)
+	tr, err := s.UpdateTranslation(ctx, 1, "fr", &models.UpdateTranslationRequest{Title: &title})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", tr.Title)
+	assert.Equal(t, "Texte", tr.Body)
+
+	_, err = s.UpdateTranslation(ctx, 1, "de", &models.UpdateTranslationRequest{Title: &title})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_DeleteTranslation(t *testing.T) {
+	r := new(mocks.Repository)
+	transactional(r)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Locale: "en"}, nil)
+	r.On("GetTranslation", mock.Anything, uint(1), "fr").Return(&models.PostTranslation{PostID: 1, Locale: "fr", Title: "Salut"}, nil)
+	r.On("DeleteTranslation", mock.Anything, uint(1), "fr").Return(nil)
+
+	require.NoError(t, NewService(r).DeleteTranslation(context.Background(), 1, "fr"))
+	entries := audited(r)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditTranslationDeleted, entries[0].Action)
+	assert.Equal(t, "Salut", entries[0].Changes["title"].Before)
+}
+
+func TestService_Localize(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("ListTranslations", mock.Anything, []uint{1, 2}).Return([]models.PostTranslation{
+		{PostID: 1, Locale: "de", Title: "Hallo"},
+		{PostID: 2, Locale: "fr", Title: "Salut"},
+	}, nil)
+	posts := []models.BlogPost{{ID: 1, Title: "Hello", Locale: "en"}, {ID: 2, Title: "Hi", Locale: "en"}}
+
+	require.NoError(t, NewService(r).Localize(context.Background(), posts, []string{"de-CH", "fr"}))
+	assert.Equal(t, "Hallo", posts[0].Title, "de-CH falls back to de")
+	assert.Equal(t, "de", posts[0].Locale)
+	assert.Equal(t, "Salut", posts[1].Title, "then to the next preference")
+	assert.Len(t, posts[1].Translations, 2)
+}
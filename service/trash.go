@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// ListTrash returns the posts in the trash, most recently trashed first.
+func (s *service) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	posts, err := s.repo.ListTrash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch trash: %w", err)
+	}
+	return posts, nil
+}
+
+// RestorePost takes a post out of the trash. Since its deletion was
+// announced, it is announced again as created.
+func (s *service) RestorePost(ctx context.Context, id uint) (*models.BlogPost, error) {
+	var post *models.BlogPost
+	err := s.write(ctx, func(tx *service) error {
+		if err := tx.repo.RestorePost(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		if post, err = tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.audit(ctx, models.AuditPostRestored, id, nil, post); err != nil {
+			return err
+		}
+		return tx.record(ctx, post, createdEvents(post)...)
+	})
+	if err != nil {
+		return nil, trashError(ctx, "unable to restore post", id, err)
+	}
+	slog.InfoContext(ctx, "post restored", "post_id", id)
+	return post, nil
+}
+
+// PurgePost deletes a post in the trash for good.
+func (s *service) PurgePost(ctx context.Context, id uint) error {
+	err := s.write(ctx, func(tx *service) error {
+		if err := tx.repo.PurgePost(ctx, id); err != nil {
+			return err
+		}
+		return tx.audit(ctx, models.AuditPostPurged, id, nil, nil)
+	})
+	if err != nil {
+		return trashError(ctx, "unable to purge post", id, err)
+	}
+	slog.InfoContext(ctx, "post purged", "post_id", id)
+	return nil
+}
+
+// trashError reports posts missing from the trash as such, and logs
+// unexpected errors.
+func trashError(ctx context.Context, msg string, id uint, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound("post_not_in_trash", fmt.Sprintf("post %d is not in the trash", id), err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		slog.ErrorContext(ctx, msg, "post_id", id, "error", err)
+	}
+	return err
+}
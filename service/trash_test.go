@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"example/mocks"
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestService_RestorePost(t *testing.T) {
+	r := new(mocks.Repository)
+	types := transactional(r)
+	r.On("RestorePost", mock.Anything, uint(1)).Return(nil)
+	r.On("RestorePost", mock.Anything, uint(2)).Return(gorm.ErrRecordNotFound)
+	r.On("GetByID", mock.Anything, uint(1)).Return(&models.BlogPost{ID: 1, Title: "Back", Status: models.StatusPublished}, nil)
+	s := NewService(r)
+
+	post, err := s.RestorePost(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Back", post.Title)
+	assert.Equal(t, []string{models.EventPostCreated, models.EventPostPublished}, *types)
+	entries := audited(r)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditPostRestored, entries[0].Action)
+
+	_, err = s.RestorePost(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_PurgePost(t *testing.T) {
+	r := new(mocks.Repository)
+	types := transactional(r)
+	r.On("PurgePost", mock.Anything, uint(1)).Return(nil)
+	r.On("PurgePost", mock.Anything, uint(2)).Return(gorm.ErrRecordNotFound)
+	s := NewService(r)
+
+	require.NoError(t, s.PurgePost(context.Background(), 1))
+	assert.Empty(t, *types, "the deletion was announced when trashed")
+	entries := audited(r)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditPostPurged, entries[0].Action)
+
+	err := s.PurgePost(context.Background(), 2)
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, "post_not_in_trash", e.Code)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"example/wxr"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// ImportWordPress imports a WordPress export, resuming the import saved
+// under name if there is one. Posts are created like any other, so they
+// are audited, revised and announced.
+func (s *service) ImportWordPress(ctx context.Context, rd io.Reader, name string, opts wxr.Options) (*wxr.Report, error) {
+	st := wxr.NewState()
+	saved, err := s.repo.GetImportState(ctx, name)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(saved.State, st); err != nil {
+			return nil, fmt.Errorf("invalid state of import %q: %w", name, err)
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	report, err := wxr.Import(ctx, wordPressStore{s: s, name: name}, rd, st, opts)
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to import WordPress export", "import", name, "error", err)
+		return report, err
+	}
+	slog.InfoContext(ctx, "WordPress export imported", "import", name, "imported", report.Imported,
+		"resumed", report.Resumed, "skipped", report.Skipped, "failed", report.Failed)
+	return report, nil
+}
+
+// wordPressStore writes a WordPress import through the service, saving the
+// state of the import in the same transaction as each post.
+type wordPressStore struct {
+	s    *service
+	name string
+}
+
+func (w wordPressStore) FirstOrCreateTag(ctx context.Context, tag *models.Tag) error {
+	return w.s.repo.FirstOrCreateTag(ctx, tag)
+}
+
+func (w wordPressStore) FirstOrCreateCategory(ctx context.Context, category *models.Category) error {
+	return w.s.repo.FirstOrCreateCategory(ctx, category)
+}
+
+func (w wordPressStore) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	return w.s.GetByID(ctx, id)
+}
+
+func (w wordPressStore) CreatePost(ctx context.Context, post *models.BlogPost, imported func() *wxr.State) error {
+	return w.s.write(ctx, func(tx *service) error {
+		if err := tx.importPost(ctx, post); err != nil {
+			return err
+		}
+		return tx.saveImportState(ctx, w.name, imported())
+	})
+}
+
+func (w wordPressStore) UpdateBody(ctx context.Context, id uint, body string, st *wxr.State) error {
+	return w.s.write(ctx, func(tx *service) error {
+		post, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		before := *post
+		post.Body = body
+		if err := tx.replacePost(ctx, &before, post); err != nil {
+			return err
+		}
+		return tx.saveImportState(ctx, w.name, st)
+	})
+}
+
+func (w wordPressStore) SaveState(ctx context.Context, st *wxr.State) error {
+	return w.s.saveImportState(ctx, w.name, st)
+}
+
+// saveImportState saves the state of the import named name.
+func (s *service) saveImportState(ctx context.Context, name string, st any) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.repo.SaveImportState(ctx, &models.ImportState{Name: name, State: data})
+}
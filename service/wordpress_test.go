@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"example/mocks"
+	"example/models"
+	"example/repo"
+	"example/wxr"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const wordPressExport = `<rss xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/"><channel>
+<item>
+	<title>Hello</title>
+	<content:encoded><![CDATA[<p>Hello world</p>]]></content:encoded>
+	<wp:post_id>11</wp:post_id>
+	<wp:post_date_gmt>2019-05-01 09:30:00</wp:post_date_gmt>
+	<wp:status>publish</wp:status>
+	<wp:post_type>post</wp:post_type>
+</item>
+</channel></rss>`
+
+func TestService_ImportWordPress(t *testing.T) {
+	tx := new(mocks.Repository)
+	types := transactional(tx)
+	tx.On("Create", mock.Anything, mock.Anything).Return(uint(7), nil)
+	tx.On("SaveImportState", mock.Anything, mock.Anything).Return(nil)
+
+	r := new(mocks.Repository)
+	r.On("GetImportState", mock.Anything, "export.xml").Return(nil, gorm.ErrRecordNotFound)
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		return fn(tx)
+	})
+	r.On("GetByID", mock.Anything, uint(7)).Return(&models.BlogPost{ID: 7, Body: "<p>Hello world</p>"}, nil)
+	r.On("SaveImportState", mock.Anything, mock.Anything).Return(nil)
+
+	report, err := NewService(r).ImportWordPress(context.Background(), strings.NewReader(wordPressExport), "export.xml", wxr.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+
+	// The post is created, audited and announced in the same transaction as
+	// the state recording it.
+	assert.Equal(t, []string{models.EventPostCreated, models.EventPostPublished}, *types)
+	require.Len(t, audited(tx), 1)
+	assert.Equal(t, models.AuditPostCreated, audited(tx)[0].Action)
+	st := tx.Calls[len(tx.Calls)-1].Arguments.Get(1).(*models.ImportState)
+	assert.Equal(t, "export.xml", st.Name)
+	var saved wxr.State
+	require.NoError(t, json.Unmarshal(st.State, &saved))
+	assert.Equal(t, map[int64]uint{11: 7}, saved.Posts)
+	r.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,328 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"example/i18n"
+	"example/tenant"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the file in a build's directory recording what it holds,
+// so that the next build only renders the posts changed since.
+const ManifestFile = ".site-manifest.json"
+
+// manifest records a build: what its pages were rendered with, when each
+// post it rendered last changed and the languages it was in along with the
+// files of its pages, and all the files it wrote.
+type manifest struct {
+	Theme    string    `json:"theme"`
+	Origin   string    `json:"origin"`
+	PageSize int       `json:"page_size"`
+	Blog     time.Time `json:"blog_updated_at"`
+	// Posts are keyed by ID.
+	Posts map[uint]builtPost `json:"posts"`
+	Files []string           `json:"files"`
+}
+
+type builtPost struct {
+	LastMod time.Time `json:"lastmod"`
+	// Locales are recorded because removing a translation leaves the
+	// post's last modification time as it was.
+	Locales []string `json:"locales"`
+	Files   []string `json:"files"`
+}
+
+// BuildReport summarises a build.
+type BuildReport struct {
+	// Full is whether every post was rendered, because asked for or because
+	// something all pages depend on changed since the last build.
+	Full bool `json:"full"`
+	// Rendered posts changed since the last build; the pages of Skipped
+	// ones were left as they were.
+	Rendered int `json:"rendered"`
+	Skipped  int `json:"skipped"`
+	// Written files are new or changed, Unchanged ones were already up to
+	// date, and Removed ones were left over from pages that no longer exist.
+	Written   int `json:"written"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+}
+
+// Build renders the published posts of the blog in ctx, its listings, tag
+// pages, feed, sitemap and 404 page, along with the theme's static files,
+// into dir as files to be served by a static web server from baseURL.
+// Pages are written as index.html files in directories named like their
+// paths, such as posts/1/index.html.
+//
+// Builds are incremental: a post's pages are only rendered if the post or
+// one of its translations changed, or a translation was added or removed,
+// since the last build, as recorded in ManifestFile, unless full is set or
+// the theme, baseURL, page size or blog changed. Files are only written if
+// their contents changed, and those of pages no longer published are
+// removed. The output depends on nothing but the posts, so that building
+// twice gives the same files.
+func (s *Site) Build(ctx context.Context, dir, baseURL string, full bool) (*BuildReport, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	v := view{ctx: ctx, blog: tenant.From(ctx), origin: u.Scheme + "://" + u.Host, base: strings.TrimSuffix(u.Path, "/")}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	prev, err := readManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	next := &manifest{Theme: s.theme.Digest(), Origin: v.origin + v.base, PageSize: s.pageSize, Posts: map[uint]builtPost{}}
+	if v.blog != nil {
+		next.Blog = v.blog.UpdatedAt.UTC()
+	}
+	report := &BuildReport{Full: full || prev.Theme != next.Theme || prev.Origin != next.Origin ||
+		prev.PageSize != next.PageSize || !prev.Blog.Equal(next.Blog)}
+	b := &builder{dir: dir, report: report, files: map[string]bool{}}
+
+	posts, err := s.published(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		last := i18n.LastMod(post).UTC()
+		var locales []string
+		for _, l := range post.Translations {
+			locales = append(locales, l.Locale)
+		}
+		if old, ok := prev.Posts[post.ID]; ok && !report.Full && old.LastMod.Equal(last) &&
+			slices.Equal(old.Locales, locales) && b.exist(old.Files) {
+			report.Skipped++
+			for _, name := range old.Files {
+				b.files[name] = true
+			}
+			next.Posts[post.ID] = old
+			continue
+		}
+		report.Rendered++
+		built := builtPost{LastMod: last, Locales: locales}
+		for _, l := range post.Translations {
+			locale := l.Locale
+			if l.Original {
+				locale = ""
+			}
+			p, err := s.postPage(v, post.ID, locale)
+			if err != nil {
+				return nil, fmt.Errorf("post %d: %w", post.ID, err)
+			}
+			name := pageFile(postPath(post.ID, locale))
+			if err := s.writePage(b, name, p); err != nil {
+				return nil, err
+			}
+			built.Files = append(built.Files, name)
+		}
+		next.Posts[post.ID] = built
+	}
+
+	if err := s.buildListing(b, "", func(n int) (*Page, error) { return s.indexPage(v, n) }); err != nil {
+		return nil, err
+	}
+	var slugs []string
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			if !slices.Contains(slugs, tag.Slug) {
+				slugs = append(slugs, tag.Slug)
+			}
+		}
+	}
+	slices.Sort(slugs)
+	for _, slug := range slugs {
+		if err := s.buildListing(b, tagPath(slug), func(n int) (*Page, error) { return s.tagPage(v, slug, n) }); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.writePage(b, "404.html", s.notFoundPage(v)); err != nil {
+		return nil, err
+	}
+	feed, err := s.feed(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.write("feed.xml", feed); err != nil {
+		return nil, err
+	}
+	sitemap, err := s.sitemapOf(v, posts)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.write("sitemap.xml", sitemap); err != nil {
+		return nil, err
+	}
+	err = fs.WalkDir(s.theme.Static(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(s.theme.Static(), name)
+		if err != nil {
+			return err
+		}
+		return b.write(path.Join("static", name), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range prev.Files {
+		if !b.files[name] {
+			if err := b.remove(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name := range b.files {
+		next.Files = append(next.Files, name)
+	}
+	slices.Sort(next.Files)
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, ManifestFile), append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// buildListing writes every page of a listing, page returning its nth one.
+// prefix is the path of its first page.
+func (s *Site) buildListing(b *builder, prefix string, page func(n int) (*Page, error)) error {
+	for n := 1; ; n++ {
+		p, err := page(n)
+		if err != nil {
+			return fmt.Errorf("%s: %w", listingPath(prefix, n), err)
+		}
+		if err := s.writePage(b, pageFile(listingPath(prefix, n)), p); err != nil {
+			return err
+		}
+		if p.Pagination == nil || n >= p.Pagination.Pages {
+			return nil
+		}
+	}
+}
+
+func (s *Site) writePage(b *builder, name string, p *Page) error {
+	html, err := s.theme.Render(p.template, p)
+	if err != nil {
+		return fmt.Errorf("unable to render %s: %w", name, err)
+	}
+	return b.write(name, html)
+}
+
+// pageFile returns the file a page's path is served from by static web
+// servers: the index.html of the directory the path names.
+func pageFile(urlPath string) string {
+	return path.Join(strings.TrimPrefix(urlPath, "/"), "index.html")
+}
+
+// builder writes the files of a build, keeping track of them.
+type builder struct {
+	dir    string
+	report *BuildReport
+	// files are the slash-separated names of the files of the build,
+	// relative to dir.
+	files map[string]bool
+}
+
+// write writes a file of the build unless it already holds data.
+func (b *builder) write(name string, data []byte) error {
+	b.files[name] = true
+	file := filepath.Join(b.dir, filepath.FromSlash(name))
+	if old, err := os.ReadFile(file); err == nil && bytes.Equal(old, data) {
+		b.report.Unchanged++
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	if err := writeFile(file, data); err != nil {
+		return err
+	}
+	b.report.Written++
+	return nil
+}
+
+// exist reports whether all the named files are in the build's directory.
+func (b *builder) exist(names []string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(b.dir, filepath.FromSlash(name))); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// remove deletes a file of a previous build, and the directories it leaves
+// empty.
+func (b *builder) remove(name string) error {
+	file := filepath.Join(b.dir, filepath.FromSlash(name))
+	if err := os.Remove(file); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	b.report.Removed++
+	for d := path.Dir(name); d != "."; d = path.Dir(d) {
+		// Removing a directory fails if it isn't empty.
+		if os.Remove(filepath.Join(b.dir, filepath.FromSlash(d))) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func readManifest(file string) (*manifest, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", file, err)
+	}
+	return m, nil
+}
+
+// writeFile replaces a file atomically, so that a web server serving the
+// directory never reads it half written.
+func writeFile(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,166 @@
+package site
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"example/i18n"
+	"example/mocks"
+	"example/models"
+	"example/service"
+	"example/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// serving makes se list the posts returned by posts, as the service does.
+func serving(se *mocks.BlogService, posts func() []models.BlogPost) {
+	se.On("ListPosts", mock.Anything, mock.Anything).Return(func(_ context.Context, f models.PostFilter) *models.PostList {
+		var matching []models.BlogPost
+		for _, p := range posts() {
+			if (len(f.IDs) == 0 || slices.Contains(f.IDs, p.ID)) &&
+				(f.Tag == "" || slices.ContainsFunc(p.Tags, func(t models.Tag) bool { return t.Slug == f.Tag })) {
+				matching = append(matching, p)
+			}
+		}
+		list := &models.PostList{Total: int64(len(matching))}
+		list.Posts = matching[min(f.Offset, len(matching)):min(f.Offset+f.Limit, len(matching))]
+		return list
+	}, nil)
+	se.On("GetTag", mock.Anything, mock.Anything).Return(func(_ context.Context, slug string) (*models.Tag, error) {
+		for _, p := range posts() {
+			for _, t := range p.Tags {
+				if t.Slug == slug {
+					return &t, nil
+				}
+			}
+		}
+		return nil, service.NotFound("tag_not_found", "no", nil)
+	})
+	localizing(se)
+}
+
+// files returns the contents of the files under dir by name.
+func files(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	m := map[string]string{}
+	require.NoError(t, filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		rel, _ := filepath.Rel(dir, name)
+		m[filepath.ToSlash(rel)] = string(data)
+		return err
+	}))
+	return m
+}
+
+func TestSite_Build(t *testing.T) {
+	goTag := []models.Tag{{Name: "Go", Slug: "go"}}
+	posts := []models.BlogPost{
+		{ID: 3, Title: "Third", Locale: "en", PublishedAt: &published, UpdatedAt: published},
+		{ID: 2, Title: "Second", Locale: "en", PublishedAt: &published, UpdatedAt: published, Tags: goTag},
+		{ID: 1, Title: "Hello world", Locale: "en", PublishedAt: &published, UpdatedAt: published, Tags: goTag},
+	}
+	se := new(mocks.BlogService)
+	serving(se, func() []models.BlogPost { return slices.Clone(posts) })
+	s, err := New(se, Config{PageSize: 2})
+	require.NoError(t, err)
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: 1, Slug: "acme", Title: "Acme", UpdatedAt: published})
+	dir := t.TempDir()
+
+	report, err := s.Build(ctx, dir, "https://cdn.example/blog/", false)
+	require.NoError(t, err)
+	built := files(t, dir)
+	assert.Equal(t, &BuildReport{Full: true, Rendered: 3, Written: len(built) - 1}, report)
+	for _, name := range []string{
+		"index.html", "page/2/index.html", "posts/1/index.html", "posts/1/de/index.html", "posts/2/index.html",
+		"posts/3/index.html", "tags/go/index.html", "404.html", "feed.xml", "sitemap.xml", "static/style.css", ManifestFile,
+	} {
+		assert.Contains(t, built, name)
+	}
+	assert.Len(t, built, 12)
+	assert.Contains(t, built["index.html"], `<a rel="next" href="/blog/page/2">`)
+	assert.Contains(t, built["posts/1/de/index.html"], `<link rel="canonical" href="https://cdn.example/blog/posts/1/de">`)
+	assert.Contains(t, built["posts/1/index.html"], `<h1>Hello world</h1>`)
+	assert.Contains(t, built["sitemap.xml"], "<loc>https://cdn.example/blog/tags/go</loc>")
+
+	report, err = s.Build(ctx, dir, "https://cdn.example/blog/", false)
+	require.NoError(t, err)
+	// The pages of skipped posts aren't counted.
+	assert.Equal(t, &BuildReport{Skipped: 3, Unchanged: len(built) - 1 - 4}, report)
+	assert.Equal(t, built, files(t, dir), "builds are deterministic")
+
+	posts[1].Title, posts[1].UpdatedAt = "Second, edited", published.Add(time.Hour)
+	posts = posts[1:]
+	report, err = s.Build(ctx, dir, "https://cdn.example/blog/", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Rendered)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 2, report.Removed, "the third post's page and the index's second page")
+	rebuilt := files(t, dir)
+	assert.Contains(t, rebuilt["posts/2/index.html"], "Second, edited")
+	assert.Contains(t, rebuilt["index.html"], "Second, edited")
+	assert.Equal(t, built["posts/1/index.html"], rebuilt["posts/1/index.html"])
+	assert.NotContains(t, rebuilt, "posts/3/index.html")
+	assert.NoDirExists(t, filepath.Join(dir, "posts", "3"))
+	assert.NoDirExists(t, filepath.Join(dir, "page"))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "posts", "1", "de", "index.html")))
+	report, err = s.Build(ctx, dir, "https://cdn.example/blog/", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Rendered, "posts with missing files are rendered again")
+	assert.Equal(t, rebuilt, files(t, dir))
+
+	report, err = s.Build(ctx, dir, "https://cdn.example/blog/", true)
+	require.NoError(t, err)
+	assert.Equal(t, &BuildReport{Full: true, Rendered: 2, Unchanged: len(rebuilt) - 1}, report)
+
+	report, err = s.Build(ctx, dir, "https://blog.example", false)
+	require.NoError(t, err)
+	assert.True(t, report.Full, "changing the URL changes every page")
+	assert.Contains(t, files(t, dir)["posts/1/index.html"], `href="https://blog.example/posts/1"`)
+
+	_, err = s.Build(ctx, dir, "blog.example", false)
+	assert.Error(t, err)
+}
+
+func TestSite_Build_removedTranslation(t *testing.T) {
+	posts := []models.BlogPost{{ID: 1, Title: "Hello world", Locale: "en", PublishedAt: &published, UpdatedAt: published}}
+	translations := []models.PostTranslation{{PostID: 1, Locale: "de", Title: "Hallo Welt", UpdatedAt: published}}
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, mock.Anything).Return(func(context.Context, models.PostFilter) *models.PostList {
+		return &models.PostList{Posts: slices.Clone(posts), Total: int64(len(posts))}
+	}, nil)
+	se.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, posts []models.BlogPost, prefs []string) error {
+		for i := range posts {
+			i18n.Localize(&posts[i], translations, i18n.Chain(prefs))
+		}
+		return nil
+	})
+	s, err := New(se, Config{})
+	require.NoError(t, err)
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: 1, Slug: "acme", UpdatedAt: published})
+	dir := t.TempDir()
+
+	_, err = s.Build(ctx, dir, "https://blog.example", false)
+	require.NoError(t, err)
+	assert.Contains(t, files(t, dir)["posts/1/index.html"], `hreflang="de"`)
+
+	// Deleting the translation leaves the post's last modification as it was.
+	translations = nil
+	report, err := s.Build(ctx, dir, "https://blog.example", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Rendered)
+	built := files(t, dir)
+	assert.NotContains(t, built, "posts/1/de/index.html")
+	assert.NotContains(t, built["posts/1/index.html"], `hreflang="de"`)
+}
@@ -0,0 +1,255 @@
+package site
+
+import (
+	"encoding/xml"
+	"example/i18n"
+	"example/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+// atomFeed is an Atom feed (RFC 4287) of the latest posts.
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomPerson  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel      string `xml:"rel,attr,omitempty"`
+	Href     string `xml:"href,attr"`
+	Hreflang string `xml:"hreflang,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+// feed renders the Atom feed of the blog's latest posts: their whole bodies
+// if the blog puts them in feeds, or else their descriptions. Its time of
+// update is that of the latest change to them, so that the feed only
+// changes along with its posts.
+func (s *Site) feed(v view) ([]byte, error) {
+	size, fullText := models.DefaultFeedItems, false
+	if v.blog != nil {
+		size, fullText = v.blog.FeedSize(), v.blog.FeedFullText
+	}
+	list, err := s.service.ListPosts(v.ctx, models.PostFilter{Status: models.StatusPublished, Limit: min(size, models.MaxPageSize)})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.Localize(v.ctx, list.Posts, v.prefs); err != nil {
+		return nil, err
+	}
+
+	home := v.origin + v.base + "/"
+	f := atomFeed{ID: home, Title: siteName(v.blog), Author: atomPerson{Name: siteName(v.blog)},
+		Links: []atomLink{{Rel: "self", Href: v.origin + v.base + "/feed.xml"}, {Rel: "alternate", Href: home}}}
+	if v.blog != nil {
+		f.Subtitle = v.blog.Description
+	}
+	var updated time.Time
+	for _, post := range list.Posts {
+		url := v.origin + v.base + postPath(post.ID, localePath(post))
+		e := atomEntry{ID: url, Title: post.Title, Link: atomLink{Rel: "alternate", Href: url, Hreflang: post.Locale},
+			Updated: rfc3339(i18n.LastMod(post))}
+		if post.PublishedAt != nil {
+			e.Published = rfc3339(*post.PublishedAt)
+		}
+		for _, tag := range post.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: tag.Slug, Label: tag.Name})
+		}
+		if fullText {
+			html, err := s.markdown.Render(post.Body)
+			if err != nil {
+				return nil, err
+			}
+			e.Content = &atomText{Type: "html", Lang: post.Locale, Text: string(html)}
+		} else if post.Description != "" {
+			e.Summary = &atomText{Lang: post.Locale, Text: post.Description}
+		}
+		updated = maxTime(updated, i18n.LastMod(post))
+		f.Entries = append(f.Entries, e)
+	}
+	if updated.IsZero() && v.blog != nil {
+		updated = v.blog.UpdatedAt
+	}
+	f.Updated = rfc3339(updated)
+	return marshalXML(f)
+}
+
+// sitemapURLSet is a sitemap (https://www.sitemaps.org) listing pages with
+// their versions in other languages.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string        `xml:"loc"`
+	LastMod string        `xml:"lastmod,omitempty"`
+	Links   []sitemapLink `xml:"xhtml:link"`
+}
+
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// sitemap renders the sitemap of the blog: its index, every published post
+// in each of its languages, and the pages of its tags.
+func (s *Site) sitemap(v view) ([]byte, error) {
+	posts, err := s.published(v)
+	if err != nil {
+		return nil, err
+	}
+	return s.sitemapOf(v, posts)
+}
+
+// sitemapOf renders the sitemap of the blog given its published posts,
+// localised.
+func (s *Site) sitemapOf(v view, posts []models.BlogPost) ([]byte, error) {
+	set := sitemapURLSet{XHTML: "http://www.w3.org/1999/xhtml"}
+	var latest time.Time
+	tags := map[string]time.Time{}
+	var entries []sitemapURL
+	for _, post := range posts {
+		last := i18n.LastMod(post)
+		latest = maxTime(latest, last)
+		for _, tag := range post.Tags {
+			tags[tag.Slug] = maxTime(tags[tag.Slug], last)
+		}
+
+		href := postHref(v, post)
+		var links []sitemapLink
+		for _, alt := range i18n.Alternates(post, href) {
+			links = append(links, sitemapLink{Rel: "alternate", Hreflang: alt.Hreflang, Href: alt.Href})
+		}
+		for _, l := range post.Translations {
+			entries = append(entries, sitemapURL{Loc: href(l.Locale), LastMod: rfc3339(last), Links: links})
+		}
+	}
+
+	set.URLs = append(set.URLs, sitemapURL{Loc: v.origin + v.base + "/", LastMod: rfc3339OrEmpty(latest)})
+	set.URLs = append(set.URLs, entries...)
+	slugs := make([]string, 0, len(tags))
+	for slug := range tags {
+		slugs = append(slugs, slug)
+	}
+	slices.Sort(slugs)
+	for _, slug := range slugs {
+		set.URLs = append(set.URLs, sitemapURL{Loc: v.origin + v.base + tagPath(slug), LastMod: rfc3339(tags[slug])})
+	}
+	return marshalXML(set)
+}
+
+// published returns all of the blog's published posts, latest first,
+// localised for v.
+func (s *Site) published(v view) ([]models.BlogPost, error) {
+	var posts []models.BlogPost
+	for {
+		list, err := s.service.ListPosts(v.ctx, models.PostFilter{Status: models.StatusPublished,
+			Offset: len(posts), Limit: models.MaxPageSize})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.service.Localize(v.ctx, list.Posts, v.prefs); err != nil {
+			return nil, err
+		}
+		posts = append(posts, list.Posts...)
+		if len(list.Posts) < models.MaxPageSize || int64(len(posts)) >= list.Total {
+			return posts, nil
+		}
+	}
+}
+
+// postHref returns the function giving the URL of post in a language, for
+// i18n.Alternates.
+func postHref(v view, post models.BlogPost) func(string) string {
+	original := originalLocale(post)
+	return func(l string) string {
+		if l == "" || l == original {
+			return v.origin + v.base + postPath(post.ID, "")
+		}
+		return v.origin + v.base + postPath(post.ID, l)
+	}
+}
+
+// localePath returns the locale in the path of a localised post's page:
+// empty for its original.
+func localePath(post models.BlogPost) string {
+	if post.Locale == originalLocale(post) {
+		return ""
+	}
+	return post.Locale
+}
+
+// originalLocale returns the language a localised post was written in.
+func originalLocale(post models.BlogPost) string {
+	for _, l := range post.Translations {
+		if l.Original {
+			return l.Locale
+		}
+	}
+	return post.Locale
+}
+
+func marshalXML(v any) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return []byte(b.String()), nil
+}
+
+func rfc3339(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func rfc3339OrEmpty(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return rfc3339(t)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,35 @@
+package site
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// Markdown renders GitHub-flavoured Markdown to HTML.
+type Markdown struct {
+	md goldmark.Markdown
+}
+
+// NewMarkdown returns a renderer. Raw HTML in posts, such as that of posts
+// imported from WordPress, is left out unless rawHTML is set, since it could
+// run scripts in readers' browsers; only set it if every author is trusted.
+func NewMarkdown(rawHTML bool) *Markdown {
+	opts := []goldmark.Option{goldmark.WithExtensions(extension.GFM)}
+	if rawHTML {
+		opts = append(opts, goldmark.WithRendererOptions(html.WithUnsafe()))
+	}
+	return &Markdown{md: goldmark.New(opts...)}
+}
+
+// Render returns the HTML of src.
+func (m *Markdown) Render(src string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := m.md.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,458 @@
+// Package site serves a blog as HTML pages rendered on the server: the
+// latest posts, a page per post, translation and tag, a feed, a sitemap and
+// a 404 page. Only published posts are shown, in the reader's language where
+// translated. The same pages can be built into a directory of static files.
+package site
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"example/cache"
+	"example/i18n"
+	"example/models"
+	"example/service"
+	"example/tenant"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultPageSize is the number of posts per page of a listing.
+const DefaultPageSize = 10
+
+// Config configures a Site.
+type Config struct {
+	// ThemeDir holds templates and static files overriding the embedded
+	// theme's; empty uses the embedded theme as is.
+	ThemeDir string
+	// PageSize is the number of posts per page; zero is DefaultPageSize.
+	PageSize int
+	// RawHTML renders the HTML in posts' bodies rather than leaving it out.
+	RawHTML bool
+	// Cache keeps rendered pages for CacheTTL; nil renders every request.
+	Cache    cache.Store
+	CacheTTL time.Duration
+}
+
+// Site renders pages from the posts of a service.Service.
+type Site struct {
+	service  service.Service
+	theme    *Theme
+	markdown *Markdown
+	pageSize int
+	cache    cache.Store
+	cacheTTL time.Duration
+}
+
+// New returns a Site rendering the posts of svc, failing if the theme
+// doesn't parse.
+func New(svc service.Service, cfg Config) (*Site, error) {
+	md := NewMarkdown(cfg.RawHTML)
+	theme, err := LoadTheme(cfg.ThemeDir, Funcs(md))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = DefaultPageSize
+	}
+	return &Site{service: svc, theme: theme, markdown: md, pageSize: min(cfg.PageSize, models.MaxPageSize),
+		cache: cfg.Cache, cacheTTL: cfg.CacheTTL}, nil
+}
+
+// Funcs returns the functions templates may call.
+func Funcs(md *Markdown) template.FuncMap {
+	return template.FuncMap{
+		"markdown": md.Render,
+		"date":     func(t time.Time) string { return t.Format("January 2, 2006") },
+		"rfc3339":  rfc3339,
+		// ogLocale writes a language tag as OpenGraph does, such as pt_BR.
+		"ogLocale": func(l string) string { return strings.ReplaceAll(l, "-", "_") },
+	}
+}
+
+// Register adds the site's pages to r, each behind the before handlers,
+// such as a request timeout.
+func (s *Site) Register(r fiber.Router, before ...fiber.Handler) {
+	get := func(path string, h fiber.Handler) {
+		r.Get(path, append(slices.Clip(before), h)...)
+	}
+	get("/", s.cached(s.Index))
+	get("/page/:page", s.cached(s.Index))
+	get("/posts/:id", s.cached(s.Post))
+	get("/posts/:id/:locale", s.cached(s.Post))
+	get("/tags/:slug", s.cached(s.Tag))
+	get("/tags/:slug/page/:page", s.cached(s.Tag))
+	get("/feed.xml", s.cached(s.Feed))
+	get("/sitemap.xml", s.cached(s.Sitemap))
+	get("/static/*", s.Static)
+}
+
+// Page is what page templates are executed with.
+type Page struct {
+	Blog     *models.Tenant
+	SiteName string
+	// Title and Description are the page's own; Title is empty on the
+	// first page of the index.
+	Title       string
+	Description string
+	// URL is the page's canonical URL. Base starts the paths of links to
+	// the blog's pages, and is empty unless the blog is served under a
+	// path prefix.
+	URL        string
+	Base       string
+	Locale     string
+	Alternates []models.Alternate
+	Post       *models.BlogPost
+	Tag        *models.Tag
+	Posts      []models.BlogPost
+	Pagination *Pagination
+
+	template string
+}
+
+// Pagination links a page of a listing to its neighbours.
+type Pagination struct {
+	Page, Pages int
+	Prev, Next  string
+}
+
+// view is what rendering a page depends on besides the posts: the blog,
+// where it is served, and the languages the reader prefers.
+type view struct {
+	ctx  context.Context
+	blog *models.Tenant
+	// origin is the scheme and host absolute URLs start with, and base the
+	// path prefix of the blog, if any.
+	origin, base string
+	prefs        []string
+}
+
+// errNotFound is returned for pages that don't exist.
+var errNotFound = errors.New("page not found")
+
+// Index lists the latest posts, a page at a time.
+func (s *Site) Index(c *fiber.Ctx) error {
+	n, ok := pageNumber(c)
+	if !ok {
+		return s.NotFound(c)
+	}
+	p, err := s.indexPage(s.view(c), n)
+	return s.send(c, p, err)
+}
+
+// Post shows a post, in the language given by its path if any, or else the
+// one the reader prefers.
+func (s *Site) Post(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 0)
+	if err != nil || id == 0 {
+		return s.NotFound(c)
+	}
+	p, err := s.postPage(s.view(c), uint(id), c.Params("locale"))
+	if err == nil {
+		c.Set(fiber.HeaderContentLanguage, p.Locale)
+	}
+	return s.send(c, p, err)
+}
+
+// Tag lists the posts with a tag, a page at a time.
+func (s *Site) Tag(c *fiber.Ctx) error {
+	n, ok := pageNumber(c)
+	if !ok {
+		return s.NotFound(c)
+	}
+	p, err := s.tagPage(s.view(c), c.Params("slug"), n)
+	return s.send(c, p, err)
+}
+
+// Feed serves the Atom feed of the latest posts.
+func (s *Site) Feed(c *fiber.Ctx) error {
+	data, err := s.feed(s.view(c))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "application/atom+xml; charset=utf-8")
+	return c.Send(data)
+}
+
+// Sitemap serves the XML sitemap of the blog.
+func (s *Site) Sitemap(c *fiber.Ctx) error {
+	data, err := s.sitemap(s.view(c))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Send(data)
+}
+
+// NotFound renders the 404 page.
+func (s *Site) NotFound(c *fiber.Ctx) error {
+	html, err := s.theme.Render("404.html", s.notFoundPage(s.view(c)))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(fiber.StatusNotFound).Send(html)
+}
+
+// Static serves the theme's static files.
+func (s *Site) Static(c *fiber.Ctx) error {
+	name := c.Params("*")
+	if !fs.ValidPath(name) {
+		return s.NotFound(c)
+	}
+	data, err := fs.ReadFile(s.theme.Static(), name)
+	if err != nil {
+		return s.NotFound(c)
+	}
+	if typ := mime.TypeByExtension(path.Ext(name)); typ != "" {
+		c.Set(fiber.HeaderContentType, typ)
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Send(data)
+}
+
+// send renders p, or the 404 page if there is no such page.
+func (s *Site) send(c *fiber.Ctx, p *Page, err error) error {
+	if errors.Is(err, errNotFound) {
+		return s.NotFound(c)
+	}
+	if err != nil {
+		return err
+	}
+	html, err := s.theme.Render(p.template, p)
+	if err != nil {
+		return fmt.Errorf("unable to render %s: %w", p.template, err)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Vary(fiber.HeaderAcceptLanguage)
+	return c.Send(html)
+}
+
+func (s *Site) view(c *fiber.Ctx) view {
+	ctx := c.UserContext()
+	return view{ctx: ctx, blog: tenant.From(ctx), origin: c.BaseURL(), base: tenant.BasePath(ctx), prefs: preferences(c)}
+}
+
+// pageNumber returns the number of the page of a listing asked for, which
+// is 1 unless given by its path.
+func pageNumber(c *fiber.Ctx) (int, bool) {
+	if c.Params("page") == "" {
+		return 1, true
+	}
+	n, err := strconv.Atoi(c.Params("page"))
+	return n, err == nil && n >= 1
+}
+
+func (s *Site) indexPage(v view, n int) (*Page, error) {
+	p := s.page(v, "index.html", "/")
+	return p, s.listing(v, p, models.PostFilter{}, "", n)
+}
+
+func (s *Site) tagPage(v view, slug string, n int) (*Page, error) {
+	tag, err := s.service.GetTag(v.ctx, slug)
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := s.page(v, "tag.html", tagPath(tag.Slug))
+	p.Title, p.Tag = tag.Name, tag
+	p.Description = fmt.Sprintf("Posts tagged %s", tag.Name)
+	return p, s.listing(v, p, models.PostFilter{Tag: tag.Slug}, tagPath(tag.Slug), n)
+}
+
+// postPage renders a post in locale, which must be its own or one it is
+// translated into, or if empty in the language the reader prefers.
+func (s *Site) postPage(v view, id uint, locale string) (*Page, error) {
+	prefs := v.prefs
+	if locale != "" {
+		l, ok := models.CanonicalLocale(locale)
+		if !ok {
+			return nil, errNotFound
+		}
+		prefs = []string{l}
+	}
+	list, err := s.service.ListPosts(v.ctx, models.PostFilter{IDs: []uint{id}, Status: models.StatusPublished, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Posts) == 0 {
+		return nil, errNotFound
+	}
+	post := &list.Posts[0]
+	if err := s.service.Localize(v.ctx, list.Posts, prefs); err != nil {
+		return nil, err
+	}
+	// Pages in languages the post isn't in, or naming its own, would only
+	// duplicate another page.
+	if locale != "" && (post.Locale != prefs[0] || localePath(*post) == "") {
+		return nil, errNotFound
+	}
+
+	href := postHref(v, *post)
+	p := s.page(v, "post.html", postPath(id, ""))
+	p.Title, p.Description, p.Post = post.Title, post.Description, post
+	p.Locale, p.URL, p.Alternates = post.Locale, href(post.Locale), i18n.Alternates(*post, href)
+	return p, nil
+}
+
+func (s *Site) notFoundPage(v view) *Page {
+	p := s.page(v, "404.html", "/404.html")
+	p.Title = "Page not found"
+	return p
+}
+
+// listing sets the nth page of the published posts matching f on p. prefix
+// is the path of the listing's pages, which are numbered from 2 under
+// prefix/page/.
+func (s *Site) listing(v view, p *Page, f models.PostFilter, prefix string, n int) error {
+	f.Status, f.Offset, f.Limit = models.StatusPublished, (n-1)*s.pageSize, s.pageSize
+	list, err := s.service.ListPosts(v.ctx, f)
+	if err != nil {
+		return err
+	}
+	pages := max(1, int((list.Total+int64(s.pageSize)-1)/int64(s.pageSize)))
+	if n > pages {
+		return errNotFound
+	}
+	if err := s.service.Localize(v.ctx, list.Posts, v.prefs); err != nil {
+		return err
+	}
+
+	link := func(n int) string { return v.base + listingPath(prefix, n) }
+	p.Posts, p.URL = list.Posts, v.origin+link(n)
+	switch {
+	case n > 1 && p.Title == "":
+		p.Title = "Page " + strconv.Itoa(n)
+	case n > 1:
+		p.Title += " · Page " + strconv.Itoa(n)
+	}
+	if pages > 1 {
+		p.Pagination = &Pagination{Page: n, Pages: pages}
+		if n > 1 {
+			p.Pagination.Prev = link(n - 1)
+		}
+		if n < pages {
+			p.Pagination.Next = link(n + 1)
+		}
+	}
+	return nil
+}
+
+// page returns a page with the parts common to all, at the given path.
+func (s *Site) page(v view, tmpl, path string) *Page {
+	p := &Page{Blog: v.blog, SiteName: siteName(v.blog), Base: v.base, Locale: models.DefaultPostLocale,
+		URL: v.origin + v.base + path, template: tmpl}
+	if v.blog != nil {
+		p.Description = v.blog.Description
+	}
+	return p
+}
+
+func siteName(blog *models.Tenant) string {
+	if blog == nil || blog.Title == "" {
+		return "Blog"
+	}
+	return blog.Title
+}
+
+// The paths of the pages, relative to the blog's base.
+func postPath(id uint, locale string) string {
+	p := "/posts/" + strconv.FormatUint(uint64(id), 10)
+	if locale != "" {
+		p += "/" + locale
+	}
+	return p
+}
+
+func tagPath(slug string) string {
+	return "/tags/" + slug
+}
+
+func listingPath(prefix string, n int) string {
+	if n == 1 {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	return prefix + "/page/" + strconv.Itoa(n)
+}
+
+// cached serves pages rendered by h from the cache for as long as the
+// cache's TTL, so that a page read often is rendered once in a while. Only
+// successful responses are cached.
+func (s *Site) cached(h fiber.Handler) fiber.Handler {
+	if s.cache == nil || s.cacheTTL <= 0 {
+		return h
+	}
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		key := cacheKey(c)
+		if data, ok, err := s.cache.Get(ctx, key); err != nil {
+			slog.WarnContext(ctx, "page cache read failed", "error", err)
+		} else if ok {
+			// The headers are stored before the body.
+			if header, body, ok := bytes.Cut(data, []byte("\n\n")); ok {
+				for _, line := range strings.Split(string(header), "\n") {
+					k, val, _ := strings.Cut(line, ": ")
+					c.Set(k, val)
+				}
+				return c.Send(body)
+			}
+		}
+		if err := h(c); err != nil {
+			return err
+		}
+		resp := c.Response()
+		if resp.StatusCode() != fiber.StatusOK {
+			return nil
+		}
+		var data bytes.Buffer
+		for _, k := range []string{fiber.HeaderContentType, fiber.HeaderContentLanguage, fiber.HeaderVary} {
+			if val := resp.Header.Peek(k); len(val) > 0 {
+				fmt.Fprintf(&data, "%s: %s\n", k, val)
+			}
+		}
+		data.WriteByte('\n')
+		data.Write(resp.Body())
+		if err := s.cache.Set(ctx, key, data.Bytes(), s.cacheTTL); err != nil {
+			slog.WarnContext(ctx, "page cache write failed", "error", err)
+		}
+		return nil
+	}
+}
+
+// cacheKey identifies a page: its blog, its URL without the query and the
+// languages it may be served in. The only parameter pages depend on, lang,
+// counts through the languages; keying on the others would let any query
+// string bypass the cache and fill it with copies of a page.
+func cacheKey(c *fiber.Ctx) string {
+	ctx := c.UserContext()
+	var id uint
+	if t := tenant.From(ctx); t != nil {
+		id = t.ID
+	}
+	return fmt.Sprintf("site:tenant:%d:%s%s%s:%s", id, c.BaseURL(), tenant.BasePath(ctx), c.Path(),
+		strings.Join(i18n.Chain(preferences(c)), ","))
+}
+
+// preferences returns the languages the reader prefers: the one named by
+// the lang parameter, or else those of Accept-Language.
+func preferences(c *fiber.Ctx) []string {
+	if l, ok := models.CanonicalLocale(c.Query("lang")); ok {
+		return []string{l}
+	}
+	return i18n.Preferences(c.Get(fiber.HeaderAcceptLanguage))
+}
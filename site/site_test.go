@@ -0,0 +1,320 @@
+package site
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"example/cache"
+	"example/i18n"
+	"example/mocks"
+	"example/models"
+	"example/service"
+	"example/tenant"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var published = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newApp(t *testing.T, se service.Service, cfg Config) *fiber.App {
+	t.Helper()
+	s, err := New(se, cfg)
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := tenant.WithTenant(c.UserContext(), &models.Tenant{ID: 1, Slug: "acme", Title: "Acme <Blog>", Description: "News from Acme"})
+		c.SetUserContext(tenant.WithBasePath(ctx, "/t/acme"))
+		return c.Next()
+	})
+	s.Register(app)
+	app.Use(s.NotFound)
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, target string, header ...string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "http://blog.example"+target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+// localizing makes se localise posts as the service does, with a German
+// translation of post 1.
+func localizing(se *mocks.BlogService) {
+	se.On("Localize", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, posts []models.BlogPost, prefs []string) error {
+		for i := range posts {
+			var translations []models.PostTranslation
+			if posts[i].ID == 1 {
+				translations = []models.PostTranslation{{PostID: 1, Locale: "de", Title: "Hallo Welt", Body: "Hallo"}}
+			}
+			i18n.Localize(&posts[i], translations, i18n.Chain(prefs))
+		}
+		return nil
+	})
+}
+
+func TestSite_Index(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, models.PostFilter{Status: models.StatusPublished, Limit: 2}).Return(&models.PostList{
+		Posts: []models.BlogPost{
+			{ID: 1, Title: "Hello world", Description: "First", Locale: "en", PublishedAt: &published, Tags: []models.Tag{{Name: "Go", Slug: "go"}}},
+			{ID: 2, Title: "Second", Locale: "en", PublishedAt: &published},
+		},
+		Total: 3,
+	}, nil)
+	se.On("ListPosts", mock.Anything, models.PostFilter{Status: models.StatusPublished, Offset: 2, Limit: 2}).Return(&models.PostList{
+		Posts: []models.BlogPost{{ID: 3, Title: "Third", Locale: "en"}}, Total: 3,
+	}, nil)
+	localizing(se)
+	app := newApp(t, se, Config{PageSize: 2})
+
+	resp, body := get(t, app, "/")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMETextHTMLCharsetUTF8, resp.Header.Get(fiber.HeaderContentType))
+	assert.Contains(t, body, `<title>Acme &lt;Blog&gt;</title>`)
+	assert.Contains(t, body, `<meta property="og:type" content="website">`)
+	assert.Contains(t, body, `<link rel="canonical" href="http://blog.example/t/acme/">`)
+	assert.Contains(t, body, `<a href="/t/acme/posts/1">Hello world</a>`)
+	assert.Contains(t, body, `<a href="/t/acme/tags/go">Go</a>`)
+	assert.Contains(t, body, `<time datetime="2026-03-01T12:00:00Z">March 1, 2026</time>`)
+	assert.Contains(t, body, `<a rel="next" href="/t/acme/page/2">`)
+	assert.NotContains(t, body, `rel="prev"`)
+
+	resp, body = get(t, app, "/page/2")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `<title>Page 2 · Acme &lt;Blog&gt;</title>`)
+	assert.Contains(t, body, `<a rel="prev" href="/t/acme/">`)
+	assert.Contains(t, body, `<link rel="canonical" href="http://blog.example/t/acme/page/2">`)
+	assert.Contains(t, body, "Page 2 of 2")
+
+	for _, target := range []string{"/page/0", "/page/two"} {
+		resp, _ = get(t, app, target)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, target)
+	}
+}
+
+func TestSite_Index_pastTheEnd(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, mock.Anything).Return(&models.PostList{Total: 1}, nil)
+	app := newApp(t, se, Config{})
+
+	resp, body := get(t, app, "/page/2")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, body, "Page not found")
+}
+
+func TestSite_Post(t *testing.T) {
+	se := new(mocks.BlogService)
+	// Localising changes posts, so each request gets its own.
+	se.On("ListPosts", mock.Anything, models.PostFilter{IDs: []uint{1}, Status: models.StatusPublished, Limit: 1}).Return(func(context.Context, models.PostFilter) *models.PostList {
+		return &models.PostList{
+			Posts: []models.BlogPost{{ID: 1, Title: "Hello world", Description: "A <greeting>", Locale: "en",
+				Body: "Some **bold** text <script>alert(1)</script>", PublishedAt: &published, Tags: []models.Tag{{Name: "Go", Slug: "go"}}}},
+			Total: 1,
+		}
+	}, nil)
+	se.On("ListPosts", mock.Anything, mock.Anything).Return(&models.PostList{}, nil)
+	localizing(se)
+	app := newApp(t, se, Config{})
+
+	resp, body := get(t, app, "/posts/1")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `<title>Hello world · Acme &lt;Blog&gt;</title>`)
+	assert.Contains(t, body, `<meta name="description" content="A &lt;greeting&gt;">`)
+	assert.Contains(t, body, `<meta property="og:type" content="article">`)
+	assert.Contains(t, body, `<meta property="og:title" content="Hello world">`)
+	assert.Contains(t, body, `<meta property="og:url" content="http://blog.example/t/acme/posts/1">`)
+	assert.Contains(t, body, `<meta property="article:published_time" content="2026-03-01T12:00:00Z">`)
+	assert.Contains(t, body, `<meta property="article:tag" content="Go">`)
+	assert.Contains(t, body, `<link rel="alternate" hreflang="de" href="http://blog.example/t/acme/posts/1/de">`)
+	assert.Contains(t, body, `<link rel="alternate" hreflang="x-default" href="http://blog.example/t/acme/posts/1">`)
+	assert.Contains(t, body, "Some <strong>bold</strong> text")
+	assert.NotContains(t, body, "<script>", "raw HTML is left out by default")
+	assert.Equal(t, "en", resp.Header.Get(fiber.HeaderContentLanguage))
+
+	resp, body = get(t, app, "/posts/1", fiber.HeaderAcceptLanguage, "de-CH")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `<html lang="de">`)
+	assert.Contains(t, body, `<h1>Hallo Welt</h1>`)
+	assert.Contains(t, body, `<meta property="og:locale" content="de">`)
+	assert.Equal(t, "de", resp.Header.Get(fiber.HeaderContentLanguage))
+	assert.Contains(t, body, `<link rel="canonical" href="http://blog.example/t/acme/posts/1/de">`)
+
+	resp, body = get(t, app, "/posts/1/DE")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `<h1>Hallo Welt</h1>`)
+	assert.Contains(t, body, `<link rel="canonical" href="http://blog.example/t/acme/posts/1/de">`)
+
+	for _, target := range []string{"/posts/2", "/posts/abc", "/posts/1/fr", "/posts/1/en", "/posts/1/!", "/nowhere"} {
+		resp, _ = get(t, app, target)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, target)
+	}
+}
+
+func TestSite_Tag(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("GetTag", mock.Anything, "go").Return(&models.Tag{Name: "Go", Slug: "go"}, nil)
+	se.On("GetTag", mock.Anything, "rust").Return(nil, service.NotFound("tag_not_found", "no", nil))
+	se.On("ListPosts", mock.Anything, models.PostFilter{Tag: "go", Status: models.StatusPublished, Limit: DefaultPageSize}).Return(&models.PostList{
+		Posts: []models.BlogPost{{ID: 2, Title: "Gophers", Locale: "en"}}, Total: 1,
+	}, nil)
+	localizing(se)
+	app := newApp(t, se, Config{})
+
+	resp, body := get(t, app, "/tags/go")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Posts tagged “Go”")
+	assert.Contains(t, body, `<a href="/t/acme/posts/2">Gophers</a>`)
+	assert.Contains(t, body, `<link rel="canonical" href="http://blog.example/t/acme/tags/go">`)
+
+	resp, _ = get(t, app, "/tags/rust")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestSite_cache(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, mock.Anything).Return(&models.PostList{
+		Posts: []models.BlogPost{{ID: 1, Title: "Hello world", Locale: "en"}}, Total: 1,
+	}, nil)
+	localizing(se)
+	app := newApp(t, se, Config{Cache: cache.NewLRU(10), CacheTTL: time.Minute})
+
+	_, first := get(t, app, "/")
+	_, second := get(t, app, "/")
+	assert.Equal(t, first, second)
+	se.AssertNumberOfCalls(t, "ListPosts", 1)
+
+	for _, target := range []string{"/?utm_source=feed", "/?lang=xx", "/?page=2&ref=x"} {
+		_, body := get(t, app, target)
+		assert.Equal(t, first, body, target)
+	}
+	se.AssertNumberOfCalls(t, "ListPosts", 1)
+
+	_, body := get(t, app, "/", fiber.HeaderAcceptLanguage, "de")
+	assert.Contains(t, body, "Hallo Welt", "languages are cached apart")
+	se.AssertNumberOfCalls(t, "ListPosts", 2)
+
+	_, body = get(t, app, "/?lang=de")
+	_, again := get(t, app, "/?utm_source=feed&lang=de")
+	assert.Contains(t, body, "Hallo Welt")
+	assert.Equal(t, body, again)
+	se.AssertNumberOfCalls(t, "ListPosts", 2)
+}
+
+func TestSite_theme(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "404.html"), []byte(`{{define "content"}}<p>Lost in {{.SiteName}}</p>{{end}}`), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "static"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "static", "extra.css"), []byte("p {}"), 0o644))
+	app := newApp(t, new(mocks.BlogService), Config{ThemeDir: dir})
+
+	resp, body := get(t, app, "/nowhere")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, body, "<p>Lost in Acme &lt;Blog&gt;</p>")
+	assert.Contains(t, body, `<link rel="stylesheet" href="/t/acme/static/style.css">`, "the rest is embedded")
+
+	resp, body = get(t, app, "/static/extra.css")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "p {}", body)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), "text/css")
+	resp, _ = get(t, app, "/static/style.css")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err := New(new(mocks.BlogService), Config{ThemeDir: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "post.html"), []byte(`{{define "content"}}{{.Nope}}{{end`), 0o644))
+	_, err = New(new(mocks.BlogService), Config{ThemeDir: dir})
+	assert.Error(t, err, "templates that don't parse")
+}
+
+func TestMarkdown_rawHTML(t *testing.T) {
+	html, err := NewMarkdown(true).Render("<aside>note</aside>")
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<aside>note</aside>")
+}
+
+func TestSite_Feed(t *testing.T) {
+	se := new(mocks.BlogService)
+	updated := published.Add(time.Hour)
+	se.On("ListPosts", mock.Anything, models.PostFilter{Status: models.StatusPublished, Limit: models.DefaultFeedItems}).Return(func(context.Context, models.PostFilter) *models.PostList {
+		return &models.PostList{Posts: []models.BlogPost{
+			{ID: 1, Title: "Hello world", Description: "First & foremost", Body: "Some **bold** text", Locale: "en",
+				PublishedAt: &published, UpdatedAt: updated, Tags: []models.Tag{{Name: "Go", Slug: "go"}}},
+		}, Total: 1}
+	}, nil)
+	localizing(se)
+	app := newApp(t, se, Config{})
+
+	resp, body := get(t, app, "/feed.xml")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", resp.Header.Get(fiber.HeaderContentType))
+	assert.Contains(t, body, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, body, `<title>Acme &lt;Blog&gt;</title>`)
+	assert.Contains(t, body, `<link rel="self" href="http://blog.example/t/acme/feed.xml"></link>`)
+	assert.Contains(t, body, `<updated>2026-03-01T13:00:00Z</updated>`)
+	assert.Contains(t, body, `<id>http://blog.example/t/acme/posts/1</id>`)
+	assert.Contains(t, body, `<published>2026-03-01T12:00:00Z</published>`)
+	assert.Contains(t, body, `<category term="go" label="Go"></category>`)
+	assert.Contains(t, body, `>First &amp; foremost</summary>`)
+	assert.NotContains(t, body, "<content")
+
+	_, body = get(t, app, "/feed.xml", fiber.HeaderAcceptLanguage, "de")
+	assert.Contains(t, body, `<title>Hallo Welt</title>`)
+	assert.Contains(t, body, `<id>http://blog.example/t/acme/posts/1/de</id>`)
+}
+
+func TestSite_Feed_fullText(t *testing.T) {
+	s, err := New(nil, Config{})
+	require.NoError(t, err)
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, models.PostFilter{Status: models.StatusPublished, Limit: 2}).Return(&models.PostList{
+		Posts: []models.BlogPost{{ID: 1, Title: "Hello world", Body: "Some **bold** text", Locale: "en"}}, Total: 1,
+	}, nil)
+	localizing(se)
+	s.service = se
+
+	blog := &models.Tenant{Title: "Acme", FeedItems: 2, FeedFullText: true, UpdatedAt: published}
+	data, err := s.feed(view{ctx: context.Background(), blog: blog, origin: "https://acme.example"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<content type="html" xml:lang="en">&lt;p&gt;Some &lt;strong&gt;bold&lt;/strong&gt; text&lt;/p&gt;`)
+	assert.NotContains(t, string(data), "<summary")
+}
+
+func TestSite_Sitemap(t *testing.T) {
+	se := new(mocks.BlogService)
+	se.On("ListPosts", mock.Anything, models.PostFilter{Status: models.StatusPublished, Limit: models.MaxPageSize}).Return(func(context.Context, models.PostFilter) *models.PostList {
+		return &models.PostList{Posts: []models.BlogPost{
+			{ID: 1, Title: "Hello world", Locale: "en", UpdatedAt: published, Tags: []models.Tag{{Name: "Go", Slug: "go"}}},
+			{ID: 2, Title: "Second", Locale: "en", UpdatedAt: published.Add(time.Hour)},
+		}, Total: 2}
+	}, nil)
+	localizing(se)
+	app := newApp(t, se, Config{})
+
+	resp, body := get(t, app, "/sitemap.xml", fiber.HeaderAcceptLanguage, "de")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEApplicationXMLCharsetUTF8, resp.Header.Get(fiber.HeaderContentType))
+	assert.Contains(t, body, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">`)
+	assert.Contains(t, body, "<loc>http://blog.example/t/acme/</loc>\n    <lastmod>2026-03-01T13:00:00Z</lastmod>")
+	assert.Contains(t, body, "<loc>http://blog.example/t/acme/posts/1</loc>")
+	assert.Contains(t, body, "<loc>http://blog.example/t/acme/posts/1/de</loc>")
+	assert.Contains(t, body, `<xhtml:link rel="alternate" hreflang="de" href="http://blog.example/t/acme/posts/1/de"></xhtml:link>`)
+	assert.Contains(t, body, `<xhtml:link rel="alternate" hreflang="x-default" href="http://blog.example/t/acme/posts/1"></xhtml:link>`)
+	assert.Contains(t, body, "<loc>http://blog.example/t/acme/tags/go</loc>\n    <lastmod>2026-03-01T12:00:00Z</lastmod>")
+	assert.NotContains(t, body, "/posts/2/")
+}
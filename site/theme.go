@@ -0,0 +1,152 @@
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+//go:embed theme
+var embedded embed.FS
+
+// The templates of a theme. Each page is parsed along with the shared
+// templates: layout.html, which renders the page's "content" template, and
+// partials.html, holding templates the pages have in common.
+var (
+	shared = []string{"layout.html", "partials.html"}
+	pages  = []string{"index.html", "post.html", "tag.html", "404.html"}
+)
+
+// Theme is a set of parsed page templates and the static files, such as
+// stylesheets, that they link to under /static.
+type Theme struct {
+	pages  map[string]*template.Template
+	static fs.FS
+	digest string
+}
+
+// LoadTheme parses the embedded theme. Files in dir, if not empty, take the
+// place of the embedded files with the same names, so that a theme only
+// holds what it changes; static files go in its static directory.
+func LoadTheme(dir string, funcs template.FuncMap) (*Theme, error) {
+	base, err := fs.Sub(embedded, "theme")
+	if err != nil {
+		return nil, err
+	}
+	files := base
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("theme directory: %w", err)
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("theme directory: %s is not a directory", dir)
+		}
+		files = overlay{top: os.DirFS(dir), base: base}
+	}
+
+	t := &Theme{pages: map[string]*template.Template{}}
+	if t.static, err = fs.Sub(files, "static"); err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		tmpl := template.New("").Funcs(funcs)
+		for _, name := range append(shared, page) {
+			src, err := fs.ReadFile(files, name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tmpl.New(name).Parse(string(src)); err != nil {
+				return nil, err
+			}
+		}
+		t.pages[page] = tmpl
+	}
+	if t.digest, err = digest(files); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// digest hashes the names and contents of the files of a theme, in order.
+func digest(files fs.FS) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %d\n", name, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Render executes the layout for a page.
+func (t *Theme) Render(page string, data any) ([]byte, error) {
+	tmpl, ok := t.pages[page]
+	if !ok {
+		return nil, fmt.Errorf("no page template %q", page)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Static returns the theme's static files.
+func (t *Theme) Static() fs.FS {
+	return t.static
+}
+
+// Digest identifies the theme's files, changing whenever any of them does.
+func (t *Theme) Digest() string {
+	return t.digest
+}
+
+// overlay opens files from top, or from base if top doesn't have them.
+type overlay struct {
+	top, base fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return f, err
+}
+
+// ReadDir lists the files of a directory in top and base.
+func (o overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	top, err := fs.ReadDir(o.top, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	base, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && (err != nil || !errors.Is(baseErr, fs.ErrNotExist)) {
+		return nil, baseErr
+	}
+	entries := top
+	for _, e := range base {
+		if !slices.ContainsFunc(top, func(t fs.DirEntry) bool { return t.Name() == e.Name() }) {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
@@ -0,0 +1,127 @@
+// Package stream pushes post events to live subscribers, such as the
+// clients of the server-sent events endpoint.
+package stream
+
+import (
+	"context"
+	"example/models"
+	"slices"
+	"sync"
+)
+
+// DefaultBuffer is the number of events a subscriber may fall behind by
+// before it is dropped.
+const DefaultBuffer = 256
+
+// Filter selects the events a subscriber receives. Empty fields match
+// everything.
+type Filter struct {
+	// TenantID is the blog whose events are received.
+	TenantID uint
+	Types    []string
+	PostIDs  []uint
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e models.OutboxEvent) bool {
+	return (f.TenantID == 0 || f.TenantID == e.TenantID) &&
+		(len(f.Types) == 0 || slices.Contains(f.Types, e.Type)) &&
+		(len(f.PostIDs) == 0 || slices.Contains(f.PostIDs, e.PostID))
+}
+
+// Broker fans the events of committed writes out to subscribers and keeps
+// them in a log from which reconnecting subscribers catch up. It implements
+// service.Notifier.
+type Broker struct {
+	log    Log
+	buffer int
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// NewBroker returns a Broker keeping events in log.
+func NewBroker(log Log) *Broker {
+	return &Broker{log: log, buffer: DefaultBuffer, subs: map[*Subscription]struct{}{}}
+}
+
+// Notify appends events to the log and sends them to every subscriber whose
+// filter they match. A subscriber that has fallen too far behind is dropped
+// rather than allowed to hold up the others; it can resume from the log.
+func (b *Broker) Notify(ctx context.Context, events []models.OutboxEvent) {
+	b.log.Append(ctx, events...)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		for _, e := range events {
+			if !sub.filter.Match(e) {
+				continue
+			}
+			select {
+			case sub.ch <- e:
+			default:
+				b.drop(sub)
+			}
+			if sub.closed {
+				break
+			}
+		}
+	}
+}
+
+// Subscribe starts receiving the events matching f. The subscription must be
+// closed once done with.
+func (b *Broker) Subscribe(f Filter) *Subscription {
+	sub := &Subscription{b: b, filter: f, ch: make(chan models.OutboxEvent, b.buffer)}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Since returns up to limit logged events matching f with an ID above
+// after, in ID order.
+func (b *Broker) Since(ctx context.Context, after uint, f Filter, limit int) ([]models.OutboxEvent, error) {
+	return b.log.Since(ctx, after, f, limit)
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// drop ends a subscription. b.mu must be held.
+func (b *Broker) drop(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subs, sub)
+	close(sub.ch)
+}
+
+// Subscription receives the events matching its filter.
+type Subscription struct {
+	b      *Broker
+	filter Filter
+	ch     chan models.OutboxEvent
+	// closed is guarded by b.mu.
+	closed bool
+}
+
+// Events delivers the subscription's events. It is closed when the
+// subscription is, including when the broker drops a subscriber that fell
+// behind.
+func (s *Subscription) Events() <-chan models.OutboxEvent {
+	return s.ch
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.drop(s)
+}
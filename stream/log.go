@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"cmp"
+	"context"
+	"example/models"
+	"example/repo"
+	"slices"
+	"sync"
+)
+
+// Log keeps past events for subscribers resuming after a disconnection.
+type Log interface {
+	// Append records events as they are committed.
+	Append(ctx context.Context, events ...models.OutboxEvent)
+	// Since returns up to limit events matching f with an ID above after, in
+	// ID order.
+	Since(ctx context.Context, after uint, f Filter, limit int) ([]models.OutboxEvent, error)
+}
+
+// memoryLog is a ring buffer of the latest events.
+type memoryLog struct {
+	mu     sync.Mutex
+	events []models.OutboxEvent
+	next   int
+	full   bool
+}
+
+// NewMemoryLog returns a Log holding the last size events committed by this
+// process.
+func NewMemoryLog(size int) Log {
+	return &memoryLog{events: make([]models.OutboxEvent, max(size, 1))}
+}
+
+func (l *memoryLog) Append(_ context.Context, events ...models.OutboxEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range events {
+		l.events[l.next] = e
+		l.next = (l.next + 1) % len(l.events)
+		l.full = l.full || l.next == 0
+	}
+}
+
+func (l *memoryLog) Since(_ context.Context, after uint, f Filter, limit int) ([]models.OutboxEvent, error) {
+	l.mu.Lock()
+	held := l.events[:l.next]
+	if l.full {
+		held = l.events
+	}
+	var out []models.OutboxEvent
+	for _, e := range held {
+		if e.ID > after && f.Match(e) {
+			out = append(out, e)
+		}
+	}
+	l.mu.Unlock()
+
+	// Transactions may commit out of ID order.
+	slices.SortFunc(out, func(a, b models.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// dbLog reads past events from the outbox table.
+type dbLog struct {
+	repo repo.Repository
+}
+
+// NewDBLog returns a Log reading from the outbox, where events are already
+// stored, so that subscribers can resume from events committed by any
+// process for as long as the outbox retains them.
+func NewDBLog(r repo.Repository) Log {
+	return dbLog{repo: r}
+}
+
+func (dbLog) Append(context.Context, ...models.OutboxEvent) {}
+
+func (l dbLog) Since(ctx context.Context, after uint, f Filter, limit int) ([]models.OutboxEvent, error) {
+	return l.repo.OutboxEventsAfter(ctx, after, f.Types, f.PostIDs, limit)
+}
@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"context"
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(id, postID uint, typ string) models.OutboxEvent {
+	return models.OutboxEvent{ID: id, PostID: postID, Type: typ}
+}
+
+func ids(events []models.OutboxEvent) []uint {
+	out := []uint{}
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+// drain returns the events waiting in sub.
+func drain(sub *Subscription) []models.OutboxEvent {
+	var out []models.OutboxEvent
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return out
+			}
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func TestBroker_filters(t *testing.T) {
+	b := NewBroker(NewMemoryLog(10))
+	all := b.Subscribe(Filter{})
+	defer all.Close()
+	published := b.Subscribe(Filter{Types: []string{models.EventPostPublished}})
+	defer published.Close()
+	post2 := b.Subscribe(Filter{PostIDs: []uint{2}})
+	defer post2.Close()
+	otherBlog := b.Subscribe(Filter{TenantID: 2})
+	defer otherBlog.Close()
+
+	other := event(4, 3, models.EventPostCreated)
+	other.TenantID = 2
+	b.Notify(context.Background(), []models.OutboxEvent{
+		event(1, 1, models.EventPostCreated),
+		event(2, 1, models.EventPostPublished),
+		event(3, 2, models.EventPostUpdated),
+		other,
+	})
+
+	assert.Equal(t, []uint{1, 2, 3, 4}, ids(drain(all)))
+	assert.Equal(t, []uint{2}, ids(drain(published)))
+	assert.Equal(t, []uint{3}, ids(drain(post2)))
+	assert.Equal(t, []uint{4}, ids(drain(otherBlog)))
+}
+
+func TestBroker_dropsSlowSubscribers(t *testing.T) {
+	b := NewBroker(NewMemoryLog(10))
+	b.buffer = 2
+	slow := b.Subscribe(Filter{})
+	fast := b.Subscribe(Filter{})
+	defer fast.Close()
+
+	b.Notify(context.Background(), []models.OutboxEvent{event(1, 1, models.EventPostCreated), event(2, 1, models.EventPostUpdated)})
+	drain(fast)
+	b.Notify(context.Background(), []models.OutboxEvent{event(3, 1, models.EventPostUpdated)})
+
+	assert.Equal(t, []uint{1, 2}, ids(drain(slow)), "what it had buffered, then closed")
+	_, open := <-slow.Events()
+	assert.False(t, open)
+	assert.Equal(t, []uint{3}, ids(drain(fast)))
+	assert.Equal(t, 1, b.Subscribers())
+	slow.Close() // closing a dropped subscription is harmless
+}
+
+func TestMemoryLog(t *testing.T) {
+	l := NewMemoryLog(3)
+	ctx := context.Background()
+	// Transactions may commit out of ID order.
+	l.Append(ctx, event(2, 1, models.EventPostCreated), event(1, 1, models.EventPostCreated))
+	l.Append(ctx, event(3, 2, models.EventPostCreated), event(5, 2, models.EventPostDeleted), event(4, 1, models.EventPostUpdated))
+
+	got, err := l.Since(ctx, 0, Filter{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{3, 4, 5}, ids(got), "the oldest fell out")
+
+	got, _ = l.Since(ctx, 3, Filter{}, 10)
+	assert.Equal(t, []uint{4, 5}, ids(got))
+	got, _ = l.Since(ctx, 0, Filter{}, 2)
+	assert.Equal(t, []uint{3, 4}, ids(got))
+	got, _ = l.Since(ctx, 0, Filter{PostIDs: []uint{2}, Types: []string{models.EventPostDeleted}}, 10)
+	assert.Equal(t, []uint{5}, ids(got))
+}
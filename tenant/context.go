@@ -0,0 +1,51 @@
+package tenant
+
+import (
+	"context"
+	"example/models"
+)
+
+type tenantKey struct{}
+
+type allTenantsKey struct{}
+
+type basePathKey struct{}
+
+// WithTenant returns a copy of ctx scoped to the blog t. Database queries
+// made with it only see and write t's data.
+func WithTenant(ctx context.Context, t *models.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// From returns the blog ctx is scoped to, or nil.
+func From(ctx context.Context) *models.Tenant {
+	t, _ := ctx.Value(tenantKey{}).(*models.Tenant)
+	return t
+}
+
+// AllTenants returns a copy of ctx whose database queries span every blog.
+// It is for the background jobs serving all of them, such as the outbox
+// relay; anything acting for a request must use WithTenant.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// spansAll reports whether ctx was returned by AllTenants and not scoped to
+// a blog since.
+func spansAll(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all && From(ctx) == nil
+}
+
+// WithBasePath returns a copy of ctx recording that the blog is served under
+// path, such as /t/acme, rather than at the root of its host.
+func WithBasePath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, basePathKey{}, path)
+}
+
+// BasePath returns the path the blog of a request is served under, which
+// links to its pages must start with; empty at the root of its host.
+func BasePath(ctx context.Context) string {
+	path, _ := ctx.Value(basePathKey{}).(string)
+	return path
+}
@@ -0,0 +1,126 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrNoTenant is returned for queries on a blog's data made with a
+	// context scoped to no blog.
+	ErrNoTenant = errors.New("no tenant in context")
+	// ErrCrossTenant is returned for writes of a record belonging to another
+	// blog than the context's.
+	ErrCrossTenant = errors.New("record belongs to another tenant")
+)
+
+// field is the name of the field holding the blog a model belongs to.
+const field = "TenantID"
+
+// GormPlugin confines every query on a model with a TenantID field to the
+// blog of the statement's context: reads, updates and deletes only match its
+// rows, and inserts are stamped with it. A query without a blog in its
+// context fails with ErrNoTenant, unless the context came from AllTenants.
+// Raw SQL is left alone.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tenant"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:create", stamp); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", scope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", func(db *gorm.DB) {
+		stamp(db)
+		scope(db)
+	}); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:delete", scope); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("tenant:row", scope)
+}
+
+// tenantField returns the TenantID field of the statement's model, or nil if
+// its rows don't belong to a blog.
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(field)
+}
+
+// scope restricts the statement to the rows of the context's blog.
+func scope(db *gorm.DB) {
+	f := tenantField(db)
+	if f == nil || db.Error != nil {
+		return
+	}
+	ctx := db.Statement.Context
+	t := From(ctx)
+	if t == nil {
+		if !spansAll(ctx) {
+			_ = db.AddError(fmt.Errorf("%w: %s", ErrNoTenant, db.Statement.Schema.Table))
+		}
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: t.ID},
+	}})
+}
+
+// stamp sets the context's blog on the records written, refusing records of
+// another blog.
+func stamp(db *gorm.DB) {
+	f := tenantField(db)
+	if f == nil || db.Error != nil {
+		return
+	}
+	ctx := db.Statement.Context
+	t := From(ctx)
+	var id uint
+	if t != nil {
+		id = t.ID
+	} else if !spansAll(ctx) {
+		_ = db.AddError(fmt.Errorf("%w: %s", ErrNoTenant, db.Statement.Schema.Table))
+		return
+	}
+
+	set := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct || rv.Type() != db.Statement.Schema.ModelType {
+			return
+		}
+		v, zero := f.ValueOf(ctx, rv)
+		switch {
+		case zero && id == 0:
+			_ = db.AddError(fmt.Errorf("%w: %s", ErrNoTenant, db.Statement.Schema.Table))
+		case zero:
+			_ = f.Set(ctx, rv, id)
+		case id != 0 && v != id:
+			_ = db.AddError(fmt.Errorf("%w: %s", ErrCrossTenant, db.Statement.Schema.Table))
+		}
+	}
+	// Updates write Dest, which may differ from the model, such as a map.
+	rv := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			set(rv.Index(i))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
@@ -0,0 +1,94 @@
+package tenant
+
+import (
+	"context"
+	"example/models"
+	"example/repo"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultRefresh is how long a Resolver trusts its list of blogs before
+// reloading it.
+const DefaultRefresh = 30 * time.Second
+
+// Resolver finds the blog a request is for. Blogs are few and rarely
+// change, so it keeps all of them in memory and reloads them periodically;
+// a blog provisioned elsewhere is served within the refresh interval.
+type Resolver struct {
+	repo repo.Repository
+	// fallback is the slug of the blog serving requests that name none.
+	fallback string
+	refresh  time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	bySlug   map[string]*models.Tenant
+	byHost   map[string]*models.Tenant
+}
+
+// NewResolver returns a Resolver loading blogs from r every refresh. Requests
+// naming no known blog are served by the blog called fallback, unless it is
+// empty.
+func NewResolver(r repo.Repository, fallback string, refresh time.Duration) *Resolver {
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+	return &Resolver{repo: r, fallback: fallback, refresh: refresh, now: time.Now}
+}
+
+// Resolve returns the blog named by slug, if not empty, or else served on
+// host, or else the fallback. It returns ErrUnknown if there is none. The
+// blog returned is shared between requests and must not be modified.
+func (r *Resolver) Resolve(ctx context.Context, host, slug string) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(ctx); err != nil {
+		return nil, err
+	}
+	if slug != "" {
+		if t, ok := r.bySlug[slug]; ok {
+			return t, nil
+		}
+		// A mistyped slug must not fall back to another blog.
+		return nil, fmt.Errorf("%w %q", ErrUnknown, slug)
+	}
+	if t, ok := r.byHost[host]; ok {
+		return t, nil
+	}
+	if t, ok := r.bySlug[r.fallback]; ok && r.fallback != "" {
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w on %s", ErrUnknown, host)
+}
+
+// load reloads the blogs once the refresh interval is over. Should that
+// fail, the blogs loaded before are kept. r.mu must be held.
+func (r *Resolver) load(ctx context.Context) error {
+	if r.bySlug != nil && r.now().Sub(r.loadedAt) < r.refresh {
+		return nil
+	}
+	tenants, err := r.repo.ListTenants(ctx)
+	if err != nil {
+		if r.bySlug != nil {
+			slog.WarnContext(ctx, "unable to reload tenants", "error", err)
+			r.loadedAt = r.now()
+			return nil
+		}
+		return fmt.Errorf("load tenants: %w", err)
+	}
+	r.bySlug = make(map[string]*models.Tenant, len(tenants))
+	r.byHost = make(map[string]*models.Tenant, len(tenants))
+	for i := range tenants {
+		t := &tenants[i]
+		r.bySlug[t.Slug] = t
+		if t.Host != nil {
+			r.byHost[*t.Host] = t
+		}
+	}
+	r.loadedAt = r.now()
+	return nil
+}
@@ -0,0 +1,131 @@
+// Package tenant hosts several independent blogs in one deployment. Each
+// request is served for the blog its host name or /t/{slug} path prefix
+// names, and the database only lets it see that blog's data.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"example/audit"
+	"example/auth"
+	"example/models"
+	"example/repo"
+	"example/service"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PathPrefix starts the paths naming a blog by its slug, as in
+// /t/acme/api/blog-post.
+const PathPrefix = "/t/"
+
+// ErrUnknown is returned when no blog matches a request.
+var ErrUnknown = errors.New("unknown blog")
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Create provisions a blog.
+func Create(ctx context.Context, r repo.Repository, t *models.Tenant) error {
+	if err := check(ctx, r, t); err != nil {
+		return err
+	}
+	return r.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.CreateTenant(ctx, t); err != nil {
+			return err
+		}
+		return record(ctx, tx, models.AuditTenantCreated, nil, t)
+	})
+}
+
+// Get returns the blog with the given slug.
+func Get(ctx context.Context, r repo.Repository, slug string) (*models.Tenant, error) {
+	t, err := r.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrUnknown, slug, err)
+	}
+	return t, nil
+}
+
+// Update saves changes to a blog's settings.
+func Update(ctx context.Context, r repo.Repository, t *models.Tenant) error {
+	if err := check(ctx, r, t); err != nil {
+		return err
+	}
+	return r.Transaction(ctx, func(tx repo.Repository) error {
+		before, err := tx.GetTenantBySlug(ctx, t.Slug)
+		if err != nil {
+			return err
+		}
+		if err := tx.UpdateTenant(ctx, t); err != nil {
+			return err
+		}
+		return record(ctx, tx, models.AuditTenantUpdated, before, t)
+	})
+}
+
+// record audits a change to a blog's settings in the blog's own log.
+func record(ctx context.Context, r repo.Repository, action string, before, after *models.Tenant) error {
+	fields := func(t *models.Tenant) map[string]any {
+		if t == nil {
+			return nil
+		}
+		var host any
+		if t.Host != nil {
+			host = *t.Host
+		}
+		return map[string]any{"slug": t.Slug, "host": host, "title": t.Title, "description": t.Description,
+			"feed_items": t.FeedItems, "feed_full_text": t.FeedFullText, "cors_origins": t.CORSOrigins}
+	}
+	return audit.Record(WithTenant(ctx, after), r, auth.User(ctx), &models.AuditEntry{
+		Action: action, Entity: "tenant", EntityID: after.ID, Changes: audit.Diff(fields(before), fields(after)),
+	})
+}
+
+// check validates t and makes sure no other blog has its slug or host.
+func check(ctx context.Context, r repo.Repository, t *models.Tenant) error {
+	if err := validate(t); err != nil {
+		return err
+	}
+	others, err := r.ListTenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, o := range others {
+		switch {
+		case o.ID == t.ID:
+		case o.Slug == t.Slug:
+			return service.Conflict("tenant_exists", fmt.Sprintf("a blog called %q already exists", t.Slug), nil)
+		case t.Host != nil && o.Host != nil && *o.Host == *t.Host:
+			return service.Conflict("host_taken", fmt.Sprintf("%s already serves the blog %q", *t.Host, o.Slug), nil)
+		}
+	}
+	return nil
+}
+
+// validate normalises the blog's host and origins and checks its settings.
+func validate(t *models.Tenant) error {
+	if !slugPattern.MatchString(t.Slug) {
+		return service.Validation("invalid_slug", "the slug must be lowercase letters, digits and dashes", nil)
+	}
+	if t.Host != nil {
+		host := strings.ToLower(strings.TrimSpace(*t.Host))
+		if host == "" || strings.ContainsAny(host, "/:@ ") {
+			return service.Validation("invalid_host", fmt.Sprintf("%q is not a host name", *t.Host), nil)
+		}
+		t.Host = &host
+	}
+	if t.FeedItems < 0 {
+		return service.Validation("invalid_feed_items", "the number of feed items must not be negative", nil)
+	}
+	for i, origin := range t.CORSOrigins {
+		u, err := url.Parse(strings.TrimSpace(origin))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") ||
+			u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return service.Validation("invalid_origin", fmt.Sprintf("%q is not an origin such as https://example.com", origin), err)
+		}
+		t.CORSOrigins[i] = strings.ToLower(u.Scheme + "://" + u.Host)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	dbMock "example/database/mocks"
+	"example/mocks"
+	"example/models"
+	"example/repo"
+	"example/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestGormPlugin(t *testing.T) {
+	db, dbmock := dbMock.NewGormMock(t)
+	require.NoError(t, db.Use(GormPlugin{}))
+	r := repo.NewRepo(db)
+	acme := WithTenant(context.Background(), &models.Tenant{ID: 3, Slug: "acme"})
+
+	t.Run("reads are scoped", func(t *testing.T) {
+		dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."id" = $1 AND "blog_posts"."tenant_id" = $2`)).
+			WithArgs(7, 3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(7, 3))
+		post, err := r.GetByID(acme, 7)
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), post.TenantID)
+	})
+
+	t.Run("another blog's post is not found", func(t *testing.T) {
+		dbmock.ExpectQuery(regexp.QuoteMeta(`"blog_posts"."tenant_id" = $2`)).
+			WithArgs(7, 3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		_, err := r.GetByID(acme, 7)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("deletes are scoped", func(t *testing.T) {
+		dbmock.ExpectBegin()
+		dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "blog_posts" SET "deleted_at"=$1 WHERE "blog_posts"."id" = $2 AND "blog_posts"."tenant_id" = $3`)).
+			WithArgs(sqlmock.AnyArg(), 7, 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbmock.ExpectCommit()
+		assert.ErrorIs(t, r.Delete(acme, 7), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("inserts are stamped", func(t *testing.T) {
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "api_keys"`)).
+			WithArgs(3, 2, "ci", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		dbmock.ExpectCommit()
+		key := &models.APIKey{Name: "ci", UserID: 2}
+		require.NoError(t, db.WithContext(acme).Omit("User").Create(key).Error)
+		assert.Equal(t, uint(3), key.TenantID)
+	})
+
+	t.Run("another blog's record is refused", func(t *testing.T) {
+		dbmock.ExpectBegin()
+		dbmock.ExpectRollback()
+		err := db.WithContext(acme).Omit("User").Create(&models.APIKey{TenantID: 4, Name: "ci"}).Error
+		assert.ErrorIs(t, err, ErrCrossTenant)
+	})
+
+	t.Run("no blog is refused", func(t *testing.T) {
+		_, err := r.GetByID(context.Background(), 7)
+		assert.ErrorIs(t, err, ErrNoTenant)
+		dbmock.ExpectBegin()
+		dbmock.ExpectRollback()
+		err = db.Omit("User").Create(&models.APIKey{Name: "ci"}).Error
+		assert.ErrorIs(t, err, ErrNoTenant)
+	})
+
+	t.Run("audit entries lock their blog's chain", func(t *testing.T) {
+		dbmock.ExpectBegin()
+		dbmock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1, $2)`)).
+			WithArgs(0x61756474, 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT "hash" FROM "audit_entries" WHERE "audit_entries"."tenant_id" = $1`)).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_entries"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		dbmock.ExpectCommit()
+		require.NoError(t, r.AppendAuditEntry(acme, &models.AuditEntry{Action: models.AuditPostDeleted, Entity: "post", EntityID: 7}))
+	})
+
+	t.Run("background jobs span every blog", func(t *testing.T) {
+		all := AllTenants(context.Background())
+		dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."id" = $1 AND "blog_posts"."deleted_at" IS NULL ORDER BY`)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(7, 4))
+		_, err := r.GetByID(all, 7)
+		require.NoError(t, err)
+
+		// They still can't write records belonging to no blog.
+		dbmock.ExpectBegin()
+		dbmock.ExpectRollback()
+		err = db.WithContext(all).Omit("User").Create(&models.APIKey{Name: "ci"}).Error
+		assert.ErrorIs(t, err, ErrNoTenant)
+	})
+
+	t.Run("imports see the IDs of every blog", func(t *testing.T) {
+		dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM blog_posts WHERE id = $1)`)).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		taken, err := r.PostIDTaken(acme, 7)
+		require.NoError(t, err)
+		assert.True(t, taken)
+	})
+
+	t.Run("blogs themselves are not scoped", func(t *testing.T) {
+		dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tenants" ORDER BY id`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(1, "default"))
+		tenants, err := r.ListTenants(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, tenants, 1)
+	})
+
+	require.NoError(t, dbmock.ExpectationsWereMet())
+}
+
+func TestResolver(t *testing.T) {
+	host := "acme.example"
+	tenants := []models.Tenant{
+		{ID: 1, Slug: models.DefaultTenant},
+		{ID: 2, Slug: "acme", Host: &host},
+	}
+	r := new(mocks.Repository)
+	r.On("ListTenants", mock.Anything).Return(tenants, nil).Once()
+	res := NewResolver(r, models.DefaultTenant, time.Minute)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	res.now = func() time.Time { return now }
+
+	tests := []struct {
+		description string
+		host, slug  string
+		want        string
+	}{
+		{description: "slug", host: "localhost", slug: "acme", want: "acme"},
+		{description: "host", host: "acme.example", want: "acme"},
+		{description: "fallback", host: "localhost", want: models.DefaultTenant},
+		{description: "unknown slug", host: "acme.example", slug: "acne"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got, err := res.Resolve(context.Background(), test.host, test.slug)
+			if test.want == "" {
+				assert.ErrorIs(t, err, ErrUnknown)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got.Slug)
+		})
+	}
+
+	t.Run("reloads after the refresh interval", func(t *testing.T) {
+		r.On("ListTenants", mock.Anything).Return(nil, errors.New("connection refused")).Once()
+		now = now.Add(time.Minute)
+		got, err := res.Resolve(context.Background(), "acme.example", "")
+		require.NoError(t, err, "the blogs loaded before are kept")
+		assert.Equal(t, "acme", got.Slug)
+
+		r.On("ListTenants", mock.Anything).Return(tenants[1:], nil).Once()
+		now = now.Add(time.Minute)
+		_, err = res.Resolve(context.Background(), "localhost", "")
+		assert.ErrorIs(t, err, ErrUnknown, "the fallback is gone")
+	})
+
+	t.Run("no fallback", func(t *testing.T) {
+		r := new(mocks.Repository)
+		r.On("ListTenants", mock.Anything).Return(tenants, nil)
+		_, err := NewResolver(r, "", 0).Resolve(context.Background(), "localhost", "")
+		assert.ErrorIs(t, err, ErrUnknown)
+	})
+
+	r.AssertExpectations(t)
+}
+
+func TestCreate(t *testing.T) {
+	host := "acme.example"
+	existing := []models.Tenant{{ID: 1, Slug: models.DefaultTenant}, {ID: 2, Slug: "acme", Host: &host}}
+
+	tests := []struct {
+		description string
+		tenant      models.Tenant
+		code        string
+	}{
+		{description: "valid", tenant: models.Tenant{Slug: "zeta", Host: ptr(" Zeta.Example "),
+			CORSOrigins: []string{"HTTPS://Zeta.Example/"}}},
+		{description: "invalid slug", tenant: models.Tenant{Slug: "Zeta!"}, code: "invalid_slug"},
+		{description: "invalid host", tenant: models.Tenant{Slug: "zeta", Host: ptr("zeta.example:8080")}, code: "invalid_host"},
+		{description: "invalid origin", tenant: models.Tenant{Slug: "zeta", CORSOrigins: []string{"zeta.example"}}, code: "invalid_origin"},
+		{description: "negative feed items", tenant: models.Tenant{Slug: "zeta", FeedItems: -1}, code: "invalid_feed_items"},
+		{description: "slug taken", tenant: models.Tenant{Slug: "acme"}, code: "tenant_exists"},
+		{description: "host taken", tenant: models.Tenant{Slug: "zeta", Host: ptr("ACME.example")}, code: "host_taken"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			r := new(mocks.Repository)
+			entries := audited(r)
+			r.On("ListTenants", mock.Anything).Return(existing, nil).Maybe()
+			r.On("CreateTenant", mock.Anything, mock.Anything).Return(func(_ context.Context, t *models.Tenant) error {
+				t.ID = 3
+				return nil
+			}).Maybe()
+
+			err := Create(context.Background(), r, &test.tenant)
+			if test.code != "" {
+				var serr *service.Error
+				require.ErrorAs(t, err, &serr)
+				assert.Equal(t, test.code, serr.Code)
+				r.AssertNotCalled(t, "CreateTenant", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "zeta.example", *test.tenant.Host)
+			assert.Equal(t, []string{"https://zeta.example"}, test.tenant.CORSOrigins)
+			r.AssertCalled(t, "CreateTenant", mock.Anything, &test.tenant)
+			require.Len(t, *entries, 1)
+			e := (*entries)[0]
+			assert.Equal(t, models.AuditTenantCreated, e.Action)
+			assert.Equal(t, uint(3), e.EntityID)
+			assert.Equal(t, uint(3), e.TenantID)
+			assert.Equal(t, "zeta.example", e.Changes["host"].After)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	stored := models.Tenant{ID: 2, Slug: "acme", Title: "Acme"}
+	r := new(mocks.Repository)
+	entries := audited(r)
+	r.On("ListTenants", mock.Anything).Return([]models.Tenant{stored}, nil)
+	r.On("GetTenantBySlug", mock.Anything, "acme").Return(func(context.Context, string) (*models.Tenant, error) {
+		t := stored
+		return &t, nil
+	})
+	r.On("UpdateTenant", mock.Anything, mock.Anything).Return(nil)
+
+	updated := stored
+	updated.Title = "Acme Corp"
+	require.NoError(t, Update(context.Background(), r, &updated))
+
+	r.AssertCalled(t, "UpdateTenant", mock.Anything, &updated)
+	require.Len(t, *entries, 1)
+	e := (*entries)[0]
+	assert.Equal(t, models.AuditTenantUpdated, e.Action)
+	assert.Equal(t, models.Changes{"title": {Before: "Acme", After: "Acme Corp"}}, e.Changes)
+	assert.Equal(t, uint(2), e.TenantID, "the entry goes to the blog's own log")
+}
+
+// audited runs transactions on r itself and returns the entries appended to
+// the audit log, stamped with the blog of their context as the database
+// would.
+func audited(r *mocks.Repository) *[]*models.AuditEntry {
+	var entries []*models.AuditEntry
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		return fn(r)
+	}).Maybe()
+	r.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(func(ctx context.Context, e *models.AuditEntry) error {
+		if t := From(ctx); t != nil {
+			e.TenantID = t.ID
+		}
+		entries = append(entries, e)
+		return nil
+	}).Maybe()
+	return &entries
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,276 @@
+package tracing
+
+import (
+	"context"
+	"example/models"
+	"example/seed"
+	"example/service"
+	"example/transfer"
+	"example/wxr"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService starts a span around every call to the wrapped
+// service.Service.
+type tracedService struct {
+	next service.Service
+}
+
+// NewService decorates next with one span per method call.
+func NewService(next service.Service) service.Service {
+	return &tracedService{next: next}
+}
+
+func start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "service."+method, trace.WithAttributes(attrs...))
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func postID(id uint) attribute.KeyValue {
+	return attribute.Int64("post.id", int64(id))
+}
+
+func (s *tracedService) Create(ctx context.Context, req models.CreateBlogRequest) (uint, error) {
+	ctx, span := start(ctx, "Create")
+	id, err := s.next.Create(ctx, req)
+	span.SetAttributes(postID(id))
+	end(span, err)
+	return id, err
+}
+
+func (s *tracedService) GetAll(ctx context.Context) ([]models.BlogPost, error) {
+	ctx, span := start(ctx, "GetAll")
+	posts, err := s.next.GetAll(ctx)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	end(span, err)
+	return posts, err
+}
+
+func (s *tracedService) GetByID(ctx context.Context, id uint) (*models.BlogPost, error) {
+	ctx, span := start(ctx, "GetByID", postID(id))
+	post, err := s.next.GetByID(ctx, id)
+	end(span, err)
+	return post, err
+}
+
+func (s *tracedService) Update(ctx context.Context, id uint, req *models.UpdateBlogRequest) (*models.BlogPost, error) {
+	ctx, span := start(ctx, "Update", postID(id))
+	post, err := s.next.Update(ctx, id, req)
+	end(span, err)
+	return post, err
+}
+
+func (s *tracedService) Delete(ctx context.Context, id uint) error {
+	ctx, span := start(ctx, "Delete", postID(id))
+	err := s.next.Delete(ctx, id)
+	end(span, err)
+	return err
+}
+
+func batchAttrs(n int, mode models.BulkMode) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("batch.size", n),
+		attribute.String("batch.mode", string(mode)),
+	}
+}
+
+func (s *tracedService) CreateBatch(ctx context.Context, reqs []models.CreateBlogRequest, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	ctx, span := start(ctx, "CreateBatch", batchAttrs(len(reqs), mode)...)
+	out, err := s.next.CreateBatch(ctx, reqs, mode)
+	end(span, err)
+	return out, err
+}
+
+func (s *tracedService) UpdateBatch(ctx context.Context, items []models.BulkUpdateItem, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	ctx, span := start(ctx, "UpdateBatch", batchAttrs(len(items), mode)...)
+	out, err := s.next.UpdateBatch(ctx, items, mode)
+	end(span, err)
+	return out, err
+}
+
+func (s *tracedService) DeleteBatch(ctx context.Context, ids []uint, mode models.BulkMode) ([]models.BulkOutcome, error) {
+	ctx, span := start(ctx, "DeleteBatch", batchAttrs(len(ids), mode)...)
+	out, err := s.next.DeleteBatch(ctx, ids, mode)
+	end(span, err)
+	return out, err
+}
+
+func (s *tracedService) Export(ctx context.Context, w io.Writer, f transfer.Format) (int, error) {
+	ctx, span := start(ctx, "Export", attribute.String("transfer.format", string(f)))
+	n, err := s.next.Export(ctx, w, f)
+	span.SetAttributes(attribute.Int("post.count", n))
+	end(span, err)
+	return n, err
+}
+
+func (s *tracedService) Import(ctx context.Context, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error) {
+	ctx, span := start(ctx, "Import",
+		attribute.String("transfer.format", string(f)),
+		attribute.Bool("transfer.dry_run", opts.DryRun),
+		attribute.String("transfer.ids", string(opts.IDs)),
+		attribute.String("transfer.conflict", string(opts.Conflict)),
+	)
+	report, err := s.next.Import(ctx, rd, f, opts)
+	if report != nil {
+		span.SetAttributes(
+			attribute.Int("transfer.created", report.Created),
+			attribute.Int("transfer.updated", report.Updated),
+			attribute.Int("transfer.skipped", report.Skipped),
+			attribute.Int("transfer.failed", report.Failed),
+		)
+	}
+	end(span, err)
+	return report, err
+}
+
+func (s *tracedService) ImportWordPress(ctx context.Context, rd io.Reader, name string, opts wxr.Options) (*wxr.Report, error) {
+	ctx, span := start(ctx, "ImportWordPress", attribute.String("wxr.import", name))
+	report, err := s.next.ImportWordPress(ctx, rd, name, opts)
+	if report != nil {
+		span.SetAttributes(
+			attribute.Int("wxr.imported", report.Imported),
+			attribute.Int("wxr.resumed", report.Resumed),
+			attribute.Int("wxr.skipped", report.Skipped),
+			attribute.Int("wxr.failed", report.Failed),
+		)
+	}
+	end(span, err)
+	return report, err
+}
+
+func (s *tracedService) Seed(ctx context.Context, ds *seed.Dataset) (*seed.Report, error) {
+	ctx, span := start(ctx, "Seed",
+		attribute.String("seed.profile", ds.Profile.Name),
+		attribute.Int("seed.posts", len(ds.Posts)),
+	)
+	report, err := s.next.Seed(ctx, ds)
+	end(span, err)
+	return report, err
+}
+
+func (s *tracedService) GetLock(ctx context.Context, id uint) (*models.PostLock, error) {
+	ctx, span := start(ctx, "GetLock", postID(id))
+	lock, err := s.next.GetLock(ctx, id)
+	end(span, err)
+	return lock, err
+}
+
+func (s *tracedService) Lock(ctx context.Context, id uint, ttl time.Duration) (*models.PostLock, error) {
+	ctx, span := start(ctx, "Lock", postID(id))
+	lock, err := s.next.Lock(ctx, id, ttl)
+	end(span, err)
+	return lock, err
+}
+
+func (s *tracedService) Unlock(ctx context.Context, id uint, force bool) error {
+	ctx, span := start(ctx, "Unlock", postID(id), attribute.Bool("lock.force", force))
+	err := s.next.Unlock(ctx, id, force)
+	end(span, err)
+	return err
+}
+
+func (s *tracedService) GetTranslations(ctx context.Context, id uint) ([]models.PostTranslation, error) {
+	ctx, span := start(ctx, "GetTranslations", postID(id))
+	translations, err := s.next.GetTranslations(ctx, id)
+	end(span, err)
+	return translations, err
+}
+
+func (s *tracedService) PutTranslation(ctx context.Context, id uint, locale string, req models.TranslationRequest) (*models.PostTranslation, bool, error) {
+	ctx, span := start(ctx, "PutTranslation", postID(id), attribute.String("post.locale", locale))
+	t, created, err := s.next.PutTranslation(ctx, id, locale, req)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("translation.created", created))
+	}
+	end(span, err)
+	return t, created, err
+}
+
+func (s *tracedService) UpdateTranslation(ctx context.Context, id uint, locale string, req *models.UpdateTranslationRequest) (*models.PostTranslation, error) {
+	ctx, span := start(ctx, "UpdateTranslation", postID(id), attribute.String("post.locale", locale))
+	t, err := s.next.UpdateTranslation(ctx, id, locale, req)
+	end(span, err)
+	return t, err
+}
+
+func (s *tracedService) DeleteTranslation(ctx context.Context, id uint, locale string) error {
+	ctx, span := start(ctx, "DeleteTranslation", postID(id), attribute.String("post.locale", locale))
+	err := s.next.DeleteTranslation(ctx, id, locale)
+	end(span, err)
+	return err
+}
+
+func (s *tracedService) Localize(ctx context.Context, posts []models.BlogPost, prefs []string) error {
+	ctx, span := start(ctx, "Localize", attribute.Int("post.count", len(posts)),
+		attribute.StringSlice("locale.preferences", prefs))
+	err := s.next.Localize(ctx, posts, prefs)
+	end(span, err)
+	return err
+}
+
+func (s *tracedService) ListPosts(ctx context.Context, f models.PostFilter) (*models.PostList, error) {
+	ctx, span := start(ctx, "ListPosts", attribute.Int("list.offset", f.Offset), attribute.Int("list.limit", f.Limit))
+	list, err := s.next.ListPosts(ctx, f)
+	if err == nil {
+		span.SetAttributes(attribute.Int("post.count", len(list.Posts)))
+	}
+	end(span, err)
+	return list, err
+}
+
+func (s *tracedService) GetTag(ctx context.Context, slug string) (*models.Tag, error) {
+	ctx, span := start(ctx, "GetTag", attribute.String("tag.slug", slug))
+	tag, err := s.next.GetTag(ctx, slug)
+	end(span, err)
+	return tag, err
+}
+
+func (s *tracedService) GetRevisions(ctx context.Context, id uint) ([]models.PostRevision, error) {
+	ctx, span := start(ctx, "GetRevisions", postID(id))
+	revs, err := s.next.GetRevisions(ctx, id)
+	end(span, err)
+	return revs, err
+}
+
+func (s *tracedService) RestoreRevision(ctx context.Context, id, revisionID uint) (*models.BlogPost, error) {
+	ctx, span := start(ctx, "RestoreRevision", postID(id), attribute.Int("revision.id", int(revisionID)))
+	post, err := s.next.RestoreRevision(ctx, id, revisionID)
+	end(span, err)
+	return post, err
+}
+
+func (s *tracedService) ListTrash(ctx context.Context) ([]models.BlogPost, error) {
+	ctx, span := start(ctx, "ListTrash")
+	posts, err := s.next.ListTrash(ctx)
+	if err == nil {
+		span.SetAttributes(attribute.Int("post.count", len(posts)))
+	}
+	end(span, err)
+	return posts, err
+}
+
+func (s *tracedService) RestorePost(ctx context.Context, id uint) (*models.BlogPost, error) {
+	ctx, span := start(ctx, "RestorePost", postID(id))
+	post, err := s.next.RestorePost(ctx, id)
+	end(span, err)
+	return post, err
+}
+
+func (s *tracedService) PurgePost(ctx context.Context, id uint) error {
+	ctx, span := start(ctx, "PurgePost", postID(id))
+	err := s.next.PurgePost(ctx, id)
+	end(span, err)
+	return err
+}
@@ -0,0 +1,67 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"example/controller"
+	dbMock "example/database/mocks"
+	"example/repo"
+	"example/service"
+	"example/tracing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanTree(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	db, dbmock := dbMock.NewGormMock(t)
+	require.NoError(t, db.Use(tracing.GormPlugin{}))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_posts" WHERE "blog_posts"."id" = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow(7, "title", "published"))
+	dbmock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "post_translations" WHERE post_id IN ($1)`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	con := controller.NewController(tracing.NewService(service.NewService(repo.NewRepo(db))))
+	app := fiber.New()
+	app.Use(tracing.Middleware())
+	app.Get("/blog-post/:id", con.GetPost)
+
+	req := httptest.NewRequest(http.MethodGet, "/blog-post/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 5)
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		if _, ok := byName[s.Name()]; !ok {
+			byName[s.Name()] = s
+		}
+	}
+	server, svc, query := byName["GET /blog-post/:id"], byName["service.GetByID"], byName["gorm.query"]
+	require.NotNil(t, server)
+	require.NotNil(t, svc)
+	require.NotNil(t, query)
+	require.NotNil(t, byName["service.Localize"])
+	assert.Equal(t, server.SpanContext().SpanID(), byName["service.Localize"].Parent().SpanID())
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "incoming traceparent must be continued")
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), svc.Parent().SpanID())
+	assert.Equal(t, svc.SpanContext().SpanID(), query.Parent().SpanID())
+}
@@ -0,0 +1,168 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"example/models"
+	"example/repo"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exportBatchSize is the number of posts loaded per query while exporting.
+const exportBatchSize = 500
+
+// frontmatter is the YAML header of an exported Markdown file.
+type frontmatter struct {
+	ID          uint       `yaml:"id"`
+	Title       string     `yaml:"title"`
+	Description string     `yaml:"description"`
+	CreatedAt   time.Time  `yaml:"created_at"`
+	UpdatedAt   time.Time  `yaml:"updated_at"`
+	Status      string     `yaml:"status,omitempty"`
+	PublishedAt *time.Time `yaml:"published_at,omitempty"`
+	Locale      string     `yaml:"locale,omitempty"`
+}
+
+// postWriter encodes posts in one of the export formats.
+type postWriter interface {
+	Write(post models.BlogPost) error
+	Close() error
+}
+
+// Export streams every post, in ID order, to w in the given format and
+// returns the number of posts written.
+func Export(ctx context.Context, r repo.Repository, w io.Writer, f Format) (int, error) {
+	pw, err := newWriter(w, f)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = r.FindInBatches(ctx, exportBatchSize, func(posts []models.BlogPost) error {
+		for _, post := range posts {
+			if err := pw.Write(post); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return n, fmt.Errorf("unable to export posts: %w", err)
+	}
+	return n, pw.Close()
+}
+
+func newWriter(w io.Writer, f Format) (postWriter, error) {
+	switch f {
+	case JSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case Markdown:
+		return &markdownWriter{zw: zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", f)
+	}
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(post models.BlogPost) error {
+	return j.enc.Encode(post)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(post models.BlogPost) error {
+	return c.w.Write([]string{
+		strconv.FormatUint(uint64(post.ID), 10),
+		post.Title,
+		post.Description,
+		post.Body,
+		post.CreatedAt.UTC().Format(time.RFC3339Nano),
+		post.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		post.Status,
+		formatTime(post.PublishedAt),
+		post.Locale,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type markdownWriter struct {
+	zw *zip.Writer
+}
+
+func (m *markdownWriter) Write(post models.BlogPost) error {
+	f, err := m.zw.CreateHeader(&zip.FileHeader{
+		Name:     fmt.Sprintf("%06d-%s.md", post.ID, slug(post.Title)),
+		Method:   zip.Deflate,
+		Modified: post.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(markdownFile(post))
+	return err
+}
+
+func (m *markdownWriter) Close() error {
+	return m.zw.Close()
+}
+
+// formatTime formats an optional timestamp, leaving the field empty when
+// there is none.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// markdownFile renders a post as YAML frontmatter followed by its body.
+func markdownFile(post models.BlogPost) []byte {
+	if post.PublishedAt != nil {
+		at := post.PublishedAt.UTC()
+		post.PublishedAt = &at
+	}
+	meta, _ := yaml.Marshal(frontmatter{
+		ID:          post.ID,
+		Title:       post.Title,
+		Description: post.Description,
+		CreatedAt:   post.CreatedAt.UTC(),
+		UpdatedAt:   post.UpdatedAt.UTC(),
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		Locale:      post.Locale,
+	})
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(meta)
+	buf.WriteString("---\n\n")
+	buf.WriteString(post.Body)
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,441 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// IDMode selects what happens to the IDs in an import file.
+type IDMode string
+
+const (
+	// PreserveIDs inserts posts under the IDs found in the file.
+	PreserveIDs IDMode = "preserve"
+	// RemapIDs ignores the IDs in the file and lets the database assign new
+	// ones, so every record becomes a new post.
+	RemapIDs IDMode = "remap"
+)
+
+// ConflictPolicy decides what happens when a preserved ID is already taken.
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictFail      ConflictPolicy = "fail"
+)
+
+// Options configures an import.
+type Options struct {
+	// DryRun reports what an import would do. The caller rolls back its
+	// writes.
+	DryRun   bool
+	IDs      IDMode
+	Conflict ConflictPolicy
+	// MaxSize caps the size of a Markdown archive and of each file in it,
+	// in bytes. Zero means DefaultMaxSize.
+	MaxSize int64
+}
+
+// DefaultMaxSize is the size cap of Markdown archives when Options.MaxSize
+// is not set.
+const DefaultMaxSize = 64 << 20
+
+// Report summarises an import.
+type Report struct {
+	DryRun  bool          `json:"dry_run"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Failed  int           `json:"failed"`
+	Errors  []RecordError `json:"errors,omitempty"`
+}
+
+// RecordError explains why a record was not imported. Record counts from 1
+// in file order.
+type RecordError struct {
+	Record int    `json:"record"`
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error"`
+}
+
+// ErrConflict is returned when a preserved ID is taken and the conflict
+// policy is ConflictFail. Nothing is imported in that case.
+var ErrConflict = errors.New("post already exists")
+
+// errUnavailableID is reported for records whose preserved ID is taken by a
+// post that can't be overwritten.
+var errUnavailableID = errors.New("ID is taken by a post that can't be overwritten")
+
+// ErrInvalidFile is returned when the import file can't be parsed.
+var ErrInvalidFile = errors.New("invalid import file")
+
+// ErrTooLarge is returned, wrapped in ErrInvalidFile, for a Markdown archive
+// or file over Options.MaxSize.
+var ErrTooLarge = errors.New("file too large")
+
+// ParseOptions validates option values given as strings, as they arrive from
+// query parameters and command-line flags. Empty values take the defaults:
+// preserve IDs and skip conflicts.
+func ParseOptions(dryRun bool, ids, conflict string) (Options, error) {
+	opts := Options{DryRun: dryRun, IDs: PreserveIDs, Conflict: ConflictSkip}
+	switch IDMode(ids) {
+	case "":
+	case PreserveIDs, RemapIDs:
+		opts.IDs = IDMode(ids)
+	default:
+		return opts, fmt.Errorf("unknown ID mode %q, expected preserve or remap", ids)
+	}
+	switch ConflictPolicy(conflict) {
+	case "":
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		opts.Conflict = ConflictPolicy(conflict)
+	default:
+		return opts, fmt.Errorf("unknown conflict policy %q, expected skip, overwrite or fail", conflict)
+	}
+	return opts, nil
+}
+
+// Store is where Import writes posts. Its calls are expected to share a
+// transaction, which the caller rolls back if Import fails, and after a dry
+// run.
+type Store interface {
+	GetByID(ctx context.Context, id uint) (*models.BlogPost, error)
+	// IDTaken reports whether id belongs to a post, including one GetByID
+	// doesn't return, such as a post in the trash or of another blog.
+	IDTaken(ctx context.Context, id uint) (bool, error)
+	// CreatePost creates post, under its ID if it has one, and sets the ID.
+	CreatePost(ctx context.Context, post *models.BlogPost) error
+	// ReplacePost overwrites the stored post before with post.
+	ReplacePost(ctx context.Context, before, post *models.BlogPost) error
+	// SyncIDSequence moves the ID sequence past posts created under their
+	// own IDs.
+	SyncIDSequence(ctx context.Context) error
+}
+
+// Import reads posts in the given format from rd and writes them to store.
+// Records that fail validation are reported and skipped; a file that can't
+// be parsed aborts the import.
+func Import(ctx context.Context, store Store, rd io.Reader, f Format, opts Options) (*Report, error) {
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	dec, err := newDecoder(rd, f, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	report := &Report{DryRun: opts.DryRun}
+	preserved := false
+	for n := 1; ; n++ {
+		post, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %w", ErrInvalidFile, n, err)
+		}
+		if err := validatePost(&post); err != nil {
+			report.fail(n, post.ID, err)
+			continue
+		}
+
+		if opts.IDs == RemapIDs || post.ID == 0 {
+			post.ID = 0
+			if err := store.CreatePost(ctx, &post); err != nil {
+				return nil, fmt.Errorf("record %d: %w", n, err)
+			}
+			report.Created++
+			continue
+		}
+
+		before, err := store.GetByID(ctx, post.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The ID may still be taken by a post that can't be read, which
+			// conflicts like any other but can't be overwritten.
+			var taken bool
+			if taken, err = store.IDTaken(ctx, post.ID); err == nil && !taken {
+				err = gorm.ErrRecordNotFound
+			}
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := store.CreatePost(ctx, &post); err != nil {
+				return nil, fmt.Errorf("record %d: %w", n, err)
+			}
+			preserved = true
+			report.Created++
+		case err != nil:
+			return nil, fmt.Errorf("record %d: %w", n, err)
+		case opts.Conflict == ConflictFail:
+			return nil, fmt.Errorf("record %d: post %d: %w", n, post.ID, ErrConflict)
+		case opts.Conflict == ConflictOverwrite && before == nil:
+			report.fail(n, post.ID, errUnavailableID)
+		case opts.Conflict == ConflictOverwrite:
+			if err := store.ReplacePost(ctx, before, &post); err != nil {
+				return nil, fmt.Errorf("record %d: %w", n, err)
+			}
+			report.Updated++
+		default:
+			report.Skipped++
+		}
+	}
+
+	if preserved {
+		if err := store.SyncIDSequence(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func (r *Report) fail(record int, id uint, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, RecordError{Record: record, ID: id, Error: err.Error()})
+}
+
+// validatePost applies the API's normalisation and validation rules to an
+// imported post. Posts without a status or locale, such as those exported
+// before either existed, are published and in DefaultPostLocale.
+func validatePost(post *models.BlogPost) error {
+	req := models.CreateBlogRequest{Title: post.Title, Description: post.Description, Body: post.Body,
+		Status: post.Status, Locale: post.Locale}
+	req.Normalize()
+	if err := models.Validate.Struct(req); err != nil {
+		var msgs []string
+		for _, fe := range models.FieldErrors(err, models.DefaultLocale) {
+			msgs = append(msgs, fe.Message)
+		}
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	post.Title, post.Description, post.Body, post.Locale = req.Title, req.Description, req.Body, req.Locale
+	if post.Locale == "" {
+		post.Locale = models.DefaultPostLocale
+	}
+	if post.Status == "" {
+		post.Status = models.StatusPublished
+	}
+	if post.Status == models.StatusPublished && post.PublishedAt == nil && !post.CreatedAt.IsZero() {
+		published := post.CreatedAt
+		post.PublishedAt = &published
+	}
+	return nil
+}
+
+// postDecoder reads posts one at a time, returning io.EOF after the last.
+type postDecoder interface {
+	Next() (models.BlogPost, error)
+}
+
+func newDecoder(rd io.Reader, f Format, maxSize int64) (postDecoder, error) {
+	switch f {
+	case JSONL:
+		sc := bufio.NewScanner(rd)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		return &jsonlDecoder{sc: sc}, nil
+	case CSV:
+		return newCSVDecoder(rd)
+	case Markdown:
+		return newMarkdownDecoder(rd, maxSize)
+	default:
+		return nil, fmt.Errorf("unknown format %q", f)
+	}
+}
+
+type jsonlDecoder struct {
+	sc *bufio.Scanner
+}
+
+func (j *jsonlDecoder) Next() (models.BlogPost, error) {
+	for j.sc.Scan() {
+		line := bytes.TrimSpace(j.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var post models.BlogPost
+		err := json.Unmarshal(line, &post)
+		return post, err
+	}
+	if err := j.sc.Err(); err != nil {
+		return models.BlogPost{}, err
+	}
+	return models.BlogPost{}, io.EOF
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVDecoder(rd io.Reader) (*csvDecoder, error) {
+	r := csv.NewReader(rd)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"title", "description", "body"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %q column", name)
+		}
+	}
+	r.FieldsPerRecord = len(header)
+	return &csvDecoder{r: r, columns: columns}, nil
+}
+
+func (c *csvDecoder) Next() (models.BlogPost, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		return models.BlogPost{}, err
+	}
+	get := func(name string) string {
+		if i, ok := c.columns[name]; ok {
+			return row[i]
+		}
+		return ""
+	}
+
+	post := models.BlogPost{Title: get("title"), Description: get("description"), Body: get("body")}
+	if id := get("id"); id != "" {
+		n, err := strconv.ParseUint(id, 10, 0)
+		if err != nil {
+			return post, fmt.Errorf("invalid id %q", id)
+		}
+		post.ID = uint(n)
+	}
+	if post.CreatedAt, err = parseTime(get("created_at")); err != nil {
+		return post, err
+	}
+	if post.UpdatedAt, err = parseTime(get("updated_at")); err != nil {
+		return post, err
+	}
+	post.Status = get("status")
+	post.Locale = get("locale")
+	if published, err := parseTime(get("published_at")); err != nil {
+		return post, err
+	} else if !published.IsZero() {
+		post.PublishedAt = &published
+	}
+	return post, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return t, nil
+}
+
+type markdownDecoder struct {
+	files   []*zip.File
+	maxSize int64
+}
+
+// newMarkdownDecoder reads the whole archive, since zip needs random access
+// to its central directory. Files are imported in name order.
+func newMarkdownDecoder(rd io.Reader, maxSize int64) (*markdownDecoder, error) {
+	data, err := readAll(rd, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open zip archive: %w", err)
+	}
+	var files []*zip.File
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() && strings.EqualFold(path.Ext(f.Name), ".md") {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return &markdownDecoder{files: files, maxSize: maxSize}, nil
+}
+
+func (m *markdownDecoder) Next() (models.BlogPost, error) {
+	if len(m.files) == 0 {
+		return models.BlogPost{}, io.EOF
+	}
+	f := m.files[0]
+	m.files = m.files[1:]
+
+	rc, err := f.Open()
+	if err != nil {
+		return models.BlogPost{}, err
+	}
+	defer rc.Close()
+	data, err := readAll(rc, m.maxSize)
+	if err != nil {
+		return models.BlogPost{}, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	post, err := parseMarkdown(data)
+	if err != nil {
+		return post, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return post, nil
+}
+
+// readAll reads rd to the end, failing with ErrTooLarge rather than reading
+// more than maxSize bytes, so that a small archive can't inflate into more
+// than the server can hold.
+func readAll(rd io.Reader, maxSize int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(rd, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrTooLarge, maxSize)
+	}
+	return data, nil
+}
+
+// parseMarkdown splits a file written by markdownFile back into a post.
+func parseMarkdown(data []byte) (models.BlogPost, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return models.BlogPost{}, errors.New("missing YAML frontmatter")
+	}
+	meta, body, ok := bytes.Cut(data[len("---\n"):], []byte("\n---\n"))
+	if !ok {
+		return models.BlogPost{}, errors.New("unterminated YAML frontmatter")
+	}
+	var fm frontmatter
+	if err := yaml.Unmarshal(meta, &fm); err != nil {
+		return models.BlogPost{}, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return models.BlogPost{
+		ID:          fm.ID,
+		Title:       fm.Title,
+		Description: fm.Description,
+		Body:        strings.TrimSpace(string(body)),
+		CreatedAt:   fm.CreatedAt,
+		UpdatedAt:   fm.UpdatedAt,
+		Status:      fm.Status,
+		PublishedAt: fm.PublishedAt,
+		Locale:      fm.Locale,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package transfer
+
+import (
+	"example/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	post, err := parseMarkdown(markdownFile(models.BlogPost{
+		ID: 3, Title: "Hello, world", Description: "First post", Body: "# Hi\n\nSome *markdown*.",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world", post.Title)
+	assert.Equal(t, "# Hi\n\nSome *markdown*.", post.Body)
+
+	_, err = parseMarkdown([]byte("# no frontmatter"))
+	assert.Error(t, err)
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "hello-world", slug("Hello, World!"))
+	assert.Equal(t, "post", slug("日本語"))
+}
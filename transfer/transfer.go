@@ -0,0 +1,75 @@
+package transfer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Format is a file format posts can be exported to and imported from.
+type Format string
+
+const (
+	// JSONL writes one JSON-encoded post per line.
+	JSONL Format = "jsonl"
+	// CSV writes a header row followed by one row per post.
+	CSV Format = "csv"
+	// Markdown writes a zip archive holding one Markdown file per post, with
+	// the metadata in YAML frontmatter.
+	Markdown Format = "markdown"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{JSONL, CSV, Markdown}
+
+// ParseFormat validates a format name.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, expected one of jsonl, csv or markdown", s)
+}
+
+// ContentType is the media type of an export in this format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case Markdown:
+		return "application/zip"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Extension is the file extension, including the dot, of an export in this
+// format.
+func (f Format) Extension() string {
+	switch f {
+	case CSV:
+		return ".csv"
+	case Markdown:
+		return ".zip"
+	default:
+		return ".jsonl"
+	}
+}
+
+// csvHeader is the column order used by CSV exports and expected by imports.
+var csvHeader = []string{"id", "title", "description", "body", "created_at", "updated_at", "status", "published_at", "locale"}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns a title into a file-name friendly string.
+func slug(title string) string {
+	s := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(s) > 60 {
+		s = strings.TrimRight(s[:60], "-")
+	}
+	if s == "" {
+		return "post"
+	}
+	return s
+}
@@ -0,0 +1,325 @@
+package transfer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"example/mocks"
+	"example/models"
+	"example/transfer"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memPosts keeps posts in memory. It is the Store of imports, which
+// importInto runs with the same transaction semantics as the service, and
+// backs the repository exports read from.
+type memPosts struct {
+	posts map[uint]models.BlogPost
+	// trashed holds the IDs of posts GetByID doesn't return.
+	trashed map[uint]bool
+	nextID  uint
+}
+
+func newMemPosts(posts ...models.BlogPost) *memPosts {
+	m := &memPosts{posts: map[uint]models.BlogPost{}, trashed: map[uint]bool{}, nextID: 1}
+	for _, p := range posts {
+		m.posts[p.ID] = p
+		m.nextID = max(m.nextID, p.ID+1)
+	}
+	return m
+}
+
+func (m *memPosts) GetByID(_ context.Context, id uint) (*models.BlogPost, error) {
+	post, ok := m.posts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &post, nil
+}
+
+func (m *memPosts) IDTaken(_ context.Context, id uint) (bool, error) {
+	_, ok := m.posts[id]
+	return ok || m.trashed[id], nil
+}
+
+func (m *memPosts) CreatePost(ctx context.Context, post *models.BlogPost) error {
+	if post.ID == 0 {
+		post.ID = m.nextID
+		m.nextID++
+	}
+	if taken, _ := m.IDTaken(ctx, post.ID); taken {
+		return errors.New("duplicate key")
+	}
+	m.posts[post.ID] = *post
+	return nil
+}
+
+func (m *memPosts) ReplacePost(_ context.Context, _, post *models.BlogPost) error {
+	m.posts[post.ID] = *post
+	return nil
+}
+
+func (m *memPosts) SyncIDSequence(context.Context) error {
+	return nil
+}
+
+// ids returns the IDs of the posts in order.
+func (m *memPosts) ids() []uint {
+	ids := slices.Collect(maps.Keys(m.posts))
+	slices.Sort(ids)
+	return ids
+}
+
+// importInto imports into m, which it leaves untouched if the import fails
+// or is a dry run.
+func importInto(m *memPosts, rd io.Reader, f transfer.Format, opts transfer.Options) (*transfer.Report, error) {
+	saved, savedID := maps.Clone(m.posts), m.nextID
+	report, err := transfer.Import(context.Background(), m, rd, f, opts)
+	if err != nil || opts.DryRun {
+		m.posts, m.nextID = saved, savedID
+	}
+	return report, err
+}
+
+// newMemRepo returns a repository holding posts to export. Any call the
+// export doesn't make fails the test.
+func newMemRepo(t *testing.T, posts ...models.BlogPost) *mocks.Repository {
+	m := newMemPosts(posts...)
+	r := mocks.NewRepository(t)
+	r.On("FindInBatches", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, size int, fn func([]models.BlogPost) error) error {
+		ids := m.ids()
+		for len(ids) > 0 {
+			n := min(size, len(ids))
+			batch := make([]models.BlogPost, n)
+			for i, id := range ids[:n] {
+				batch[i] = m.posts[id]
+			}
+			if err := fn(batch); err != nil {
+				return err
+			}
+			ids = ids[n:]
+		}
+		return nil
+	}).Maybe()
+	return r
+}
+
+var (
+	t1 = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	t2 = time.Date(2024, 3, 2, 11, 30, 0, 0, time.UTC)
+)
+
+func samplePosts() []models.BlogPost {
+	return []models.BlogPost{
+		{ID: 3, Title: "Hello, world", Description: "First post", Body: "# Hi\n\nSome *markdown*.", CreatedAt: t1, UpdatedAt: t2},
+		{ID: 7, Title: "Quotes \"and\", commas", Description: "Second: post", Body: "line one\nline two", Locale: "de", CreatedAt: t2, UpdatedAt: t2},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, f := range transfer.Formats {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			src := newMemRepo(t, samplePosts()...)
+			n, err := transfer.Export(context.Background(), src, &buf, f)
+			require.NoError(t, err)
+			assert.Equal(t, 2, n)
+
+			m := newMemPosts()
+			report, err := importInto(m, &buf, f, transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictFail})
+			require.NoError(t, err)
+			assert.Equal(t, &transfer.Report{Created: 2}, report)
+
+			require.Equal(t, []uint{3, 7}, m.ids())
+			for _, want := range samplePosts() {
+				got := m.posts[want.ID]
+				assert.Equal(t, want.Title, got.Title)
+				assert.Equal(t, want.Description, got.Description)
+				assert.Equal(t, want.Body, got.Body)
+				if want.Locale == "" {
+					want.Locale = models.DefaultPostLocale
+				}
+				assert.Equal(t, want.Locale, got.Locale)
+				assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+				assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt))
+			}
+		})
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	existing := models.BlogPost{ID: 3, Title: "Old", Description: "Old", Body: "Old", CreatedAt: t1, UpdatedAt: t1}
+	var file bytes.Buffer
+	src := newMemRepo(t, samplePosts()...)
+	_, err := transfer.Export(context.Background(), src, &file, transfer.JSONL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		opts        transfer.Options
+		wantReport  *transfer.Report
+		wantErr     error
+		wantTitle3  string
+		wantIDs     []uint
+	}{
+		{
+			description: "skip keeps the existing post",
+			opts:        transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictSkip},
+			wantReport:  &transfer.Report{Created: 1, Skipped: 1},
+			wantTitle3:  "Old",
+			wantIDs:     []uint{3, 7},
+		},
+		{
+			description: "overwrite replaces the existing post",
+			opts:        transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictOverwrite},
+			wantReport:  &transfer.Report{Created: 1, Updated: 1},
+			wantTitle3:  "Hello, world",
+			wantIDs:     []uint{3, 7},
+		},
+		{
+			description: "fail imports nothing",
+			opts:        transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictFail},
+			wantErr:     transfer.ErrConflict,
+			wantTitle3:  "Old",
+			wantIDs:     []uint{3},
+		},
+		{
+			description: "remap creates new posts",
+			opts:        transfer.Options{IDs: transfer.RemapIDs, Conflict: transfer.ConflictFail},
+			wantReport:  &transfer.Report{Created: 2},
+			wantTitle3:  "Old",
+			wantIDs:     []uint{3, 4, 5},
+		},
+		{
+			description: "dry run writes nothing",
+			opts:        transfer.Options{DryRun: true, IDs: transfer.PreserveIDs, Conflict: transfer.ConflictOverwrite},
+			wantReport:  &transfer.Report{DryRun: true, Created: 1, Updated: 1},
+			wantTitle3:  "Old",
+			wantIDs:     []uint{3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			m := newMemPosts(existing)
+			report, err := importInto(m, bytes.NewReader(file.Bytes()), transfer.JSONL, test.opts)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.wantReport, report)
+
+			assert.Equal(t, test.wantTitle3, m.posts[3].Title)
+			assert.Equal(t, test.wantIDs, m.ids())
+		})
+	}
+}
+
+// A preserved ID taken by a post in the trash conflicts, but the post isn't
+// overwritten.
+func TestImportTrashedID(t *testing.T) {
+	var file bytes.Buffer
+	src := newMemRepo(t, samplePosts()...)
+	_, err := transfer.Export(context.Background(), src, &file, transfer.JSONL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		conflict   transfer.ConflictPolicy
+		wantReport *transfer.Report
+		wantErr    error
+	}{
+		{conflict: transfer.ConflictSkip, wantReport: &transfer.Report{Created: 1, Skipped: 1}},
+		{conflict: transfer.ConflictOverwrite, wantReport: &transfer.Report{Created: 1, Failed: 1, Errors: []transfer.RecordError{
+			{Record: 1, ID: 3, Error: "ID is taken by a post that can't be overwritten"},
+		}}},
+		{conflict: transfer.ConflictFail, wantErr: transfer.ErrConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.conflict), func(t *testing.T) {
+			m := newMemPosts()
+			m.trashed[3] = true
+			report, err := importInto(m, bytes.NewReader(file.Bytes()), transfer.JSONL,
+				transfer.Options{IDs: transfer.PreserveIDs, Conflict: test.conflict})
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				assert.Empty(t, m.ids())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantReport, report)
+			assert.Equal(t, []uint{7}, m.ids())
+		})
+	}
+}
+
+func TestImportReportsInvalidRecords(t *testing.T) {
+	file := "id,title,description,body\n" +
+		"1,  Spaced   title ,d,b\n" +
+		"2,,d,b\n"
+	m := newMemPosts()
+	report, err := importInto(m, strings.NewReader(file), transfer.CSV, transfer.Options{IDs: transfer.PreserveIDs, Conflict: transfer.ConflictSkip})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 2, report.Errors[0].Record)
+	assert.Equal(t, uint(2), report.Errors[0].ID)
+	assert.Equal(t, "Spaced title", m.posts[1].Title)
+}
+
+func TestImportInvalidFile(t *testing.T) {
+	tests := []struct {
+		format transfer.Format
+		file   string
+	}{
+		{transfer.JSONL, "{\"title\":\"t\"}\nnot json\n"},
+		{transfer.CSV, "id,name\n1,x\n"},
+		{transfer.CSV, "title,description,body\nt,d\n"},
+		{transfer.Markdown, "not a zip"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			m := newMemPosts()
+			_, err := importInto(m, strings.NewReader(test.file), test.format, transfer.Options{IDs: transfer.PreserveIDs})
+			assert.ErrorIs(t, err, transfer.ErrInvalidFile)
+			assert.Empty(t, m.posts)
+		})
+	}
+}
+
+func TestImportTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	src := newMemRepo(t, samplePosts()...)
+	_, err := transfer.Export(context.Background(), src, &buf, transfer.Markdown)
+	require.NoError(t, err)
+
+	m := newMemPosts()
+	_, err = importInto(m, &buf, transfer.Markdown, transfer.Options{IDs: transfer.PreserveIDs, MaxSize: int64(buf.Len()) - 1})
+	assert.ErrorIs(t, err, transfer.ErrInvalidFile)
+	assert.ErrorIs(t, err, transfer.ErrTooLarge)
+	assert.Empty(t, m.posts)
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := transfer.ParseOptions(true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, transfer.Options{DryRun: true, IDs: transfer.PreserveIDs, Conflict: transfer.ConflictSkip}, opts)
+
+	_, err = transfer.ParseOptions(false, "renumber", "")
+	assert.Error(t, err)
+	_, err = transfer.ParseOptions(false, "", "replace")
+	assert.Error(t, err)
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"example/models"
+	"example/poll"
+	"example/repo"
+	"example/tenant"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Dispatcher sends pending webhook deliveries. A failed delivery is retried
+// with exponential backoff until it succeeds or runs out of attempts, and a
+// subscription failing too many times in a row is disabled. Several
+// dispatchers can run against the same database; each delivery is leased to
+// one of them at a time.
+type Dispatcher struct {
+	Repo repo.Repository
+	// Client sends the requests. Its timeout bounds each attempt; redirects
+	// are not followed.
+	Client *http.Client
+
+	// BatchSize is the number of deliveries claimed at a time, and
+	// Concurrency how many of them are sent at once.
+	BatchSize   int
+	Concurrency int
+	// Interval is how long to wait before polling again once no delivery is
+	// due.
+	Interval time.Duration
+	// Lease is how long claimed deliveries are hidden from other
+	// dispatchers. It must exceed the time taken to send a batch.
+	Lease time.Duration
+	// MinBackoff and MaxBackoff bound the delay before retrying a failed
+	// delivery, which doubles with every attempt.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is how many times a delivery is tried before it is marked
+	// failed.
+	MaxAttempts int
+	// DisableAfter is the number of consecutive failed attempts after which
+	// a subscription is disabled. Zero never disables it.
+	DisableAfter int
+	// Retention is how long finished deliveries are kept. Zero keeps them.
+	Retention time.Duration
+
+	now func() time.Time
+}
+
+// Defaults for a zero-valued Dispatcher field.
+const (
+	DefaultBatchSize   = 50
+	DefaultConcurrency = 10
+	DefaultInterval    = time.Second
+	DefaultLease       = 5 * time.Minute
+	DefaultMinBackoff  = 30 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	DefaultMaxAttempts = 10
+	DefaultTimeout     = 10 * time.Second
+)
+
+// maxErrorLength caps the error stored with a failed attempt.
+const maxErrorLength = 1000
+
+// Run sends deliveries until ctx is cancelled. The dispatcher serves every
+// blog.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ctx = tenant.AllTenants(ctx)
+	d.defaults()
+	p := poll.Poller{
+		Name: "webhook dispatcher", Batch: d.RunOnce, BatchSize: d.BatchSize, Interval: d.Interval,
+		Purge: d.Repo.PurgeWebhookDeliveries, Retention: d.Retention, Now: d.now,
+	}
+	p.Run(ctx)
+	return nil
+}
+
+// RunOnce claims one batch of due deliveries and sends them, returning how
+// many were claimed.
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	ctx = tenant.AllTenants(ctx)
+	d.defaults()
+	deliveries, err := d.Repo.ClaimWebhookDeliveries(ctx, d.now(), d.Lease, d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, d.Concurrency)
+	)
+	for i := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *models.WebhookDelivery) {
+			defer func() { <-sem; wg.Done() }()
+			if err := d.attempt(ctx, delivery); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(&deliveries[i])
+	}
+	wg.Wait()
+	return len(deliveries), errors.Join(errs...)
+}
+
+// attempt sends a delivery once and records the outcome. Only a failure to
+// record it is returned.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := d.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	status, err := d.send(ctx, delivery, now)
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = truncate(err.Error())
+	default:
+		delivery.NextAttemptAt = now.Add(poll.Backoff(d.MinBackoff, d.MaxBackoff, delivery.Attempts))
+		delivery.LastError = truncate(err.Error())
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "unable to deliver webhook", "delivery_id", delivery.ID,
+			"subscription_id", delivery.SubscriptionID, "event_type", delivery.EventType,
+			"attempt", delivery.Attempts, "status", delivery.Status, "error", err)
+	}
+
+	disabled, err := d.Repo.SaveWebhookAttempt(context.WithoutCancel(ctx), delivery, d.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		slog.WarnContext(ctx, "webhook subscription disabled after repeated failures",
+			"subscription_id", delivery.SubscriptionID, "failures", d.DisableAfter)
+	}
+	return nil
+}
+
+// send POSTs the signed payload, returning the response status, if any, and
+// an error unless the endpoint answered 2xx.
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	sub := delivery.Subscription
+	if sub == nil {
+		return 0, errors.New("subscription not loaded")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	ts := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-webhooks/1")
+	req.Header.Set(HeaderID, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, ts, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) defaults() {
+	if d.Client == nil {
+		d.Client = NewClient(DefaultTimeout)
+	}
+	if d.BatchSize <= 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.Concurrency <= 0 {
+		d.Concurrency = DefaultConcurrency
+	}
+	if d.Interval <= 0 {
+		d.Interval = DefaultInterval
+	}
+	if d.Lease <= 0 {
+		d.Lease = DefaultLease
+	}
+	if d.MinBackoff <= 0 {
+		d.MinBackoff = DefaultMinBackoff
+	}
+	if d.MaxBackoff <= 0 {
+		d.MaxBackoff = DefaultMaxBackoff
+	}
+	if d.MaxAttempts <= 0 {
+		d.MaxAttempts = DefaultMaxAttempts
+	}
+	if d.now == nil {
+		d.now = time.Now
+	}
+}
+
+// NewClient returns an HTTP client for deliveries, which gives up after
+// timeout and treats redirects as failures. It refuses to connect to
+// private, loopback and link-local addresses, so that subscriptions can't
+// reach into the network the server runs in.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, checkPublic)
+}
+
+func newClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled instead of the endpoint, escaping the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ErrPrivateAddress is returned for endpoints resolving to an address that
+// is not public.
+var ErrPrivateAddress = errors.New("refusing to connect to a non-public address")
+
+// checkPublic is a net.Dialer Control function refusing private, loopback
+// and link-local addresses. It sees the address dialled, once the host name
+// is resolved, so a name can't be pointed at one after the fact.
+func checkPublic(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := ap.Addr().Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
+	}
+	return nil
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// signatureVersion prefixes signatures so that the scheme can change.
+const signatureVersion = "v1="
+
+// DefaultTolerance is how old a delivery's timestamp may be for Verify to
+// accept it.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned by Verify for a missing or wrong
+	// signature.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrTimestampOutOfRange is returned by Verify for deliveries signed too
+	// long ago, or too far in the future, which may be replays.
+	ErrTimestampOutOfRange = errors.New("webhook timestamp outside the tolerance")
+)
+
+// Sign returns the Webhook-Signature of a body sent at the given Unix time:
+// "v1=" followed by the hex HMAC-SHA256, keyed by the secret, of the
+// timestamp, a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery, as a
+// receiver should before trusting it. Timestamps further than tolerance from
+// now are rejected; receivers also guarding against replays within the
+// tolerance should remember the Webhook-Id of recent deliveries.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	want := Sign(secret, ts, body)
+	got := strings.TrimSpace(header.Get(HeaderSignature))
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrTimestampOutOfRange
+	}
+	return nil
+}
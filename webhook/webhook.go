@@ -0,0 +1,176 @@
+// Package webhook manages webhook subscriptions and sends them signed
+// deliveries of post events.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"example/audit"
+	"example/auth"
+	"example/models"
+	"example/repo"
+	"example/service"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// secretPrefix starts every generated secret so that leaked ones are easy to
+// spot.
+const secretPrefix = "whsec_"
+
+// Create stores a new subscription, generating its secret unless one is
+// given. The secret is only ever returned here.
+func Create(ctx context.Context, r repo.Repository, req models.CreateWebhookRequest) (*models.CreatedWebhook, error) {
+	secret := req.Secret
+	if secret == "" {
+		secret = newSecret()
+	}
+	sub := &models.WebhookSubscription{URL: req.URL, Secret: secret, Events: req.Events, Active: true}
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+	err := r.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.CreateWebhookSubscription(ctx, sub); err != nil {
+			return err
+		}
+		return record(ctx, tx, models.AuditWebhookCreated, sub.ID, nil, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.CreatedWebhook{WebhookSubscription: *sub, Secret: secret}, nil
+}
+
+// Get returns a subscription.
+func Get(ctx context.Context, r repo.Repository, id uint) (*models.WebhookSubscription, error) {
+	sub, err := r.GetWebhookSubscription(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFound(id, err)
+	}
+	return sub, err
+}
+
+// Update changes the fields of a subscription present in req. Activating a
+// disabled subscription clears its failures, and its pending deliveries
+// resume.
+func Update(ctx context.Context, r repo.Repository, id uint, req *models.UpdateWebhookRequest) (*models.WebhookSubscription, error) {
+	sub, err := Get(ctx, r, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *sub
+	if req.URL != nil {
+		sub.URL = *req.URL
+	}
+	if req.Events != nil {
+		sub.Events = *req.Events
+	}
+	if req.Active != nil && *req.Active != sub.Active {
+		sub.Active = *req.Active
+		if sub.Active {
+			sub.ConsecutiveFailures = 0
+			sub.DisabledAt = nil
+		} else {
+			now := time.Now()
+			sub.DisabledAt = &now
+		}
+	}
+	err = r.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.UpdateWebhookSubscription(ctx, sub); err != nil {
+			return err
+		}
+		return record(ctx, tx, models.AuditWebhookUpdated, id, &before, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Delete removes a subscription and its delivery history.
+func Delete(ctx context.Context, r repo.Repository, id uint) error {
+	sub, err := Get(ctx, r, id)
+	if err != nil {
+		return err
+	}
+	return r.Transaction(ctx, func(tx repo.Repository) error {
+		err := tx.DeleteWebhookSubscription(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound(id, err)
+		}
+		if err != nil {
+			return err
+		}
+		return record(ctx, tx, models.AuditWebhookDeleted, id, sub, nil)
+	})
+}
+
+// record audits a change to a subscription, leaving its secret out.
+func record(ctx context.Context, r repo.Repository, action string, id uint, before, after *models.WebhookSubscription) error {
+	fields := func(sub *models.WebhookSubscription) map[string]any {
+		if sub == nil {
+			return nil
+		}
+		return map[string]any{"url": sub.URL, "events": sub.Events, "active": sub.Active}
+	}
+	return audit.Record(ctx, r, auth.User(ctx), &models.AuditEntry{
+		Action: action, Entity: "webhook", EntityID: id, Changes: audit.Diff(fields(before), fields(after)),
+	})
+}
+
+// Deliveries returns the delivery history of a subscription, newest first.
+func Deliveries(ctx context.Context, r repo.Repository, id, before uint, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := Get(ctx, r, id); err != nil {
+		return nil, err
+	}
+	return r.ListWebhookDeliveries(ctx, id, before, limit)
+}
+
+// Redeliver queues a new delivery of the same event, whatever became of the
+// original, so that the history keeps both. The subscription must be active.
+func Redeliver(ctx context.Context, r repo.Repository, id, deliveryID uint) (*models.WebhookDelivery, error) {
+	sub, err := Get(ctx, r, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.Active {
+		return nil, service.Conflict("webhook_disabled", fmt.Sprintf("webhook %d is disabled", id), nil)
+	}
+	orig, err := r.GetWebhookDelivery(ctx, id, deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, service.NotFound("delivery_not_found", fmt.Sprintf("delivery %d not found", deliveryID), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := &models.WebhookDelivery{
+		SubscriptionID: id,
+		EventID:        orig.EventID,
+		EventType:      orig.EventType,
+		Payload:        orig.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := r.CreateWebhookDeliveries(ctx, []*models.WebhookDelivery{d}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func notFound(id uint, err error) *service.Error {
+	return service.NotFound("webhook_not_found", fmt.Sprintf("webhook %d not found", id), err)
+}
+
+// newSecret returns a random signing secret.
+func newSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,376 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/mocks"
+	"example/models"
+	"example/repo"
+	"example/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memDeliveries keeps subscriptions and deliveries in memory. Unused
+// Repository methods panic.
+type memDeliveries struct {
+	repo.Repository
+	mu         sync.Mutex
+	subs       map[uint]*models.WebhookSubscription
+	deliveries []models.WebhookDelivery
+}
+
+func newMemDeliveries(subs ...*models.WebhookSubscription) *memDeliveries {
+	m := &memDeliveries{subs: map[uint]*models.WebhookSubscription{}}
+	for _, s := range subs {
+		m.subs[s.ID] = s
+	}
+	return m
+}
+
+func (m *memDeliveries) add(subID uint, payload string) {
+	m.deliveries = append(m.deliveries, models.WebhookDelivery{
+		ID: uint(len(m.deliveries) + 1), SubscriptionID: subID, EventType: models.EventPostCreated,
+		Payload: json.RawMessage(payload), Status: models.DeliveryPending,
+	})
+}
+
+func (m *memDeliveries) ClaimWebhookDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.WebhookDelivery
+	for i := range m.deliveries {
+		d := &m.deliveries[i]
+		sub := m.subs[d.SubscriptionID]
+		if d.Status == models.DeliveryPending && sub.Active && !d.NextAttemptAt.After(now) && len(out) < limit {
+			d.NextAttemptAt = now.Add(lease)
+			claimed := *d
+			s := *sub
+			claimed.Subscription = &s
+			out = append(out, claimed)
+		}
+	}
+	return out, nil
+}
+
+func (m *memDeliveries) SaveWebhookAttempt(_ context.Context, d *models.WebhookDelivery, disableAfter int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *d
+	saved.Subscription = nil
+	m.deliveries[d.ID-1] = saved
+	sub := m.subs[d.SubscriptionID]
+	if d.Status == models.DeliverySucceeded {
+		sub.ConsecutiveFailures = 0
+		return false, nil
+	}
+	sub.ConsecutiveFailures++
+	if disableAfter > 0 && sub.Active && sub.ConsecutiveFailures >= disableAfter {
+		sub.Active, sub.DisabledAt = false, d.LastAttemptAt
+		return true, nil
+	}
+	return false, nil
+}
+
+// localClient is a delivery client allowed to reach the receivers, which
+// listen on the loopback interface.
+var localClient = newClient(DefaultTimeout, nil)
+
+// receiver is an httptest endpoint verifying the signature of what it is
+// sent, and answering with status.
+type receiver struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int
+	got    []http.Header
+	bodies []string
+	errs   []error
+}
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	r := &receiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.got = append(r.got, req.Header.Clone())
+		r.bodies = append(r.bodies, string(body))
+		r.errs = append(r.errs, Verify(secret, req.Header, body, DefaultTolerance, time.Now()))
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) answer(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Unix(1700000000, 0)
+	header := http.Header{}
+	header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	header.Set(HeaderSignature, Sign("secret", now.Unix(), body))
+
+	assert.NoError(t, Verify("secret", header, body, time.Minute, now.Add(30*time.Second)))
+	assert.ErrorIs(t, Verify("other", header, body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header, []byte(`{"id":2}`), time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header, body, time.Minute, now.Add(2*time.Minute)), ErrTimestampOutOfRange, "replayed later")
+
+	// The timestamp is signed, so it can't be refreshed to replay a body.
+	header.Set(HeaderTimestamp, strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10))
+	assert.ErrorIs(t, Verify("secret", header, body, time.Minute, now.Add(2*time.Minute)), ErrInvalidSignature)
+}
+
+func TestDispatcher(t *testing.T) {
+	rcv := newReceiver(t, "s3cret")
+	store := newMemDeliveries(&models.WebhookSubscription{ID: 1, URL: rcv.URL, Secret: "s3cret", Active: true})
+	store.add(1, `{"id":10,"type":"post.created"}`)
+	store.add(1, `{"id":11,"type":"post.created"}`)
+	d := &Dispatcher{Repo: store, Client: localClient}
+
+	n, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	require.Len(t, rcv.got, 2)
+	for i, err := range rcv.errs {
+		assert.NoError(t, err, "signature of delivery %d", i)
+	}
+	assert.ElementsMatch(t, []string{`{"id":10,"type":"post.created"}`, `{"id":11,"type":"post.created"}`}, rcv.bodies)
+	assert.Equal(t, models.EventPostCreated, rcv.got[0].Get(HeaderEvent))
+	assert.ElementsMatch(t, []string{"1", "2"}, []string{rcv.got[0].Get(HeaderID), rcv.got[1].Get(HeaderID)})
+	for _, delivery := range store.deliveries {
+		assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+		assert.NotNil(t, delivery.DeliveredAt)
+	}
+
+	n, _ = d.RunOnce(context.Background())
+	assert.Equal(t, 0, n, "nothing left")
+}
+
+func TestDispatcher_retriesWithBackoff(t *testing.T) {
+	rcv := newReceiver(t, "s3cret")
+	rcv.answer(http.StatusServiceUnavailable)
+	store := newMemDeliveries(&models.WebhookSubscription{ID: 1, URL: rcv.URL, Secret: "s3cret", Active: true})
+	store.add(1, `{}`)
+	now := time.Now()
+	d := &Dispatcher{Repo: store, Client: localClient, MinBackoff: time.Second, MaxBackoff: 3 * time.Second, MaxAttempts: 4,
+		now: func() time.Time { return now }}
+
+	for attempt, wait := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		_, err := d.RunOnce(context.Background())
+		require.NoError(t, err)
+		delivery := store.deliveries[0]
+		assert.Equal(t, models.DeliveryPending, delivery.Status)
+		assert.Equal(t, attempt+1, delivery.Attempts)
+		assert.Equal(t, now.Add(wait), delivery.NextAttemptAt, "attempt %d", attempt+1)
+		assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseStatus)
+		assert.Contains(t, delivery.LastError, "503")
+
+		n, _ := d.RunOnce(context.Background())
+		assert.Equal(t, 0, n, "not due before its backoff")
+		now = now.Add(wait)
+	}
+
+	_, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryFailed, store.deliveries[0].Status, "out of attempts")
+	now = now.Add(time.Hour)
+	n, _ := d.RunOnce(context.Background())
+	assert.Equal(t, 0, n, "failed deliveries aren't retried")
+	assert.Len(t, rcv.got, 4)
+}
+
+func TestDispatcher_successAfterFailure(t *testing.T) {
+	rcv := newReceiver(t, "s3cret")
+	rcv.answer(http.StatusInternalServerError)
+	sub := &models.WebhookSubscription{ID: 1, URL: rcv.URL, Secret: "s3cret", Active: true}
+	store := newMemDeliveries(sub)
+	store.add(1, `{}`)
+	now := time.Now()
+	d := &Dispatcher{Repo: store, Client: localClient, now: func() time.Time { return now }}
+
+	_, err := d.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sub.ConsecutiveFailures)
+
+	rcv.answer(http.StatusNoContent)
+	now = now.Add(DefaultMinBackoff)
+	_, err = d.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliverySucceeded, store.deliveries[0].Status)
+	assert.Equal(t, 2, store.deliveries[0].Attempts)
+	assert.Empty(t, store.deliveries[0].LastError)
+	assert.Equal(t, 0, sub.ConsecutiveFailures, "reset by a success")
+}
+
+func TestDispatcher_disablesFailingSubscription(t *testing.T) {
+	rcv := newReceiver(t, "s3cret")
+	rcv.answer(http.StatusGone)
+	sub := &models.WebhookSubscription{ID: 1, URL: rcv.URL, Secret: "s3cret", Active: true}
+	store := newMemDeliveries(sub)
+	for range 3 {
+		store.add(1, `{}`)
+	}
+	d := &Dispatcher{Repo: store, Client: localClient, DisableAfter: 2, Concurrency: 1, BatchSize: 1}
+
+	for range 2 {
+		_, err := d.RunOnce(context.Background())
+		require.NoError(t, err)
+	}
+	assert.False(t, sub.Active)
+	assert.NotNil(t, sub.DisabledAt)
+
+	n, _ := d.RunOnce(context.Background())
+	assert.Equal(t, 0, n, "deliveries to a disabled subscription wait")
+	assert.Equal(t, models.DeliveryPending, store.deliveries[2].Status)
+}
+
+func TestDispatcher_redirectsFail(t *testing.T) {
+	target := newReceiver(t, "s3cret")
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	t.Cleanup(redirect.Close)
+	store := newMemDeliveries(&models.WebhookSubscription{ID: 1, URL: redirect.URL, Secret: "s3cret", Active: true})
+	store.add(1, `{}`)
+
+	_, err := (&Dispatcher{Repo: store, Client: localClient}).RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, store.deliveries[0].ResponseStatus)
+	assert.Empty(t, target.got)
+}
+
+// audited makes r run transactions in place and returns the audit entries
+// appended.
+func audited(r *mocks.Repository) *[]*models.AuditEntry {
+	var entries []*models.AuditEntry
+	r.On("Transaction", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(repo.Repository) error) error {
+		return fn(r)
+	})
+	r.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(func(_ context.Context, e *models.AuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return &entries
+}
+
+func TestCreate(t *testing.T) {
+	r := new(mocks.Repository)
+	entries := audited(r)
+	r.On("CreateWebhookSubscription", mock.Anything, mock.Anything).Return(func(_ context.Context, sub *models.WebhookSubscription) error {
+		sub.ID = 3
+		return nil
+	})
+
+	created, err := Create(context.Background(), r, models.CreateWebhookRequest{URL: "https://example.com/hook"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), created.ID)
+	assert.True(t, created.Active)
+	assert.Equal(t, []string{}, created.Events)
+	assert.Regexp(t, `^whsec_[A-Za-z0-9_-]{32}$`, created.Secret)
+
+	given, err := Create(context.Background(), r, models.CreateWebhookRequest{URL: "https://example.com/hook", Secret: "a-secret-of-my-own"})
+	require.NoError(t, err)
+	assert.Equal(t, "a-secret-of-my-own", given.Secret)
+
+	require.Len(t, *entries, 2)
+	e := (*entries)[1]
+	assert.Equal(t, models.AuditWebhookCreated, e.Action)
+	assert.Equal(t, "https://example.com/hook", e.Changes["url"].After)
+	assert.NotContains(t, e.Changes, "secret")
+}
+
+func TestUpdate_reenablingClearsFailures(t *testing.T) {
+	r := new(mocks.Repository)
+	disabledAt := time.Now()
+	r.On("GetWebhookSubscription", mock.Anything, uint(1)).Return(&models.WebhookSubscription{
+		ID: 1, ConsecutiveFailures: 20, DisabledAt: &disabledAt,
+	}, nil)
+	r.On("UpdateWebhookSubscription", mock.Anything, mock.Anything).Return(nil)
+	entries := audited(r)
+
+	active := true
+	sub, err := Update(context.Background(), r, 1, &models.UpdateWebhookRequest{Active: &active})
+	require.NoError(t, err)
+	assert.True(t, sub.Active)
+	assert.Zero(t, sub.ConsecutiveFailures)
+	assert.Nil(t, sub.DisabledAt)
+	require.Len(t, *entries, 1)
+	assert.Equal(t, models.Changes{"active": {Before: false, After: true}}, (*entries)[0].Changes)
+}
+
+func TestDelete(t *testing.T) {
+	r := new(mocks.Repository)
+	r.On("GetWebhookSubscription", mock.Anything, uint(1)).Return(&models.WebhookSubscription{ID: 1, URL: "https://example.com/hook"}, nil)
+	r.On("DeleteWebhookSubscription", mock.Anything, uint(1)).Return(nil)
+	entries := audited(r)
+
+	require.NoError(t, Delete(context.Background(), r, 1))
+	require.Len(t, *entries, 1)
+	assert.Equal(t, models.AuditWebhookDeleted, (*entries)[0].Action)
+	assert.Equal(t, "https://example.com/hook", (*entries)[0].Changes["url"].Before)
+}
+
+func TestRedeliver(t *testing.T) {
+	tests := []struct {
+		description string
+		active      bool
+		deliveryErr error
+		wantErr     error
+	}{
+		{description: "success case", active: true},
+		{description: "failure case - disabled", active: false, wantErr: service.ErrConflict},
+		{description: "failure case - unknown delivery", active: true, deliveryErr: gorm.ErrRecordNotFound, wantErr: service.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			r := new(mocks.Repository)
+			r.On("GetWebhookSubscription", mock.Anything, uint(1)).Return(&models.WebhookSubscription{ID: 1, Active: tt.active}, nil)
+			r.On("GetWebhookDelivery", mock.Anything, uint(1), uint(9)).Return(&models.WebhookDelivery{
+				ID: 9, SubscriptionID: 1, EventID: 42, EventType: models.EventPostDeleted,
+				Payload: json.RawMessage(`{"id":42}`), Status: models.DeliveryFailed, Attempts: 10,
+			}, tt.deliveryErr).Maybe()
+			r.On("CreateWebhookDeliveries", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			d, err := Redeliver(context.Background(), r, 1, 9)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				r.AssertNotCalled(t, "CreateWebhookDeliveries", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint(42), d.EventID)
+			assert.Equal(t, models.DeliveryPending, d.Status)
+			assert.Zero(t, d.Attempts)
+			assert.JSONEq(t, `{"id":42}`, string(d.Payload))
+		})
+	}
+}
+
+func TestNewClient_refusesPrivateAddresses(t *testing.T) {
+	target := newReceiver(t, "secret")
+	_, err := NewClient(time.Second).Post(target.URL, "application/json", http.NoBody)
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+	assert.Empty(t, target.got)
+
+	for _, addr := range []string{"10.0.0.1:80", "192.168.1.1:443", "169.254.169.254:80", "[::1]:80", "[::ffff:127.0.0.1]:80", "[fe80::1]:80", "0.0.0.0:80"} {
+		assert.ErrorIs(t, checkPublic("tcp", addr, nil), ErrPrivateAddress, addr)
+	}
+	assert.NoError(t, checkPublic("tcp", "93.184.216.34:443", nil))
+}
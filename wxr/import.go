@@ -0,0 +1,447 @@
+package wxr
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultLinkTemplate is where rewritten internal links point to. {id} is
+// replaced by the ID of the imported post.
+const DefaultLinkTemplate = "/posts/{id}"
+
+// descriptionLength is the length, in runes, of descriptions derived from
+// the post content when it has no excerpt.
+const descriptionLength = 200
+
+// Options configures an import.
+type Options struct {
+	// LinkTemplate is the target of rewritten internal links. It defaults to
+	// DefaultLinkTemplate.
+	LinkTemplate string
+	// Statuses lists the WordPress post statuses to import. It defaults to
+	// "publish" only, since imported posts are public.
+	Statuses []string
+	// Progress, if set, is called after each item.
+	Progress func(Progress)
+}
+
+// Progress describes what happened to one item of the export.
+type Progress struct {
+	Item        int    // 1-based position in the export
+	WordPressID int64  // wp:post_id
+	Title       string // title in the export
+	Action      string // "imported", "resumed", "skipped" or "failed"
+	PostID      uint   // ID of the imported post, if any
+	Err         error  // why the item failed
+}
+
+// State records which WordPress posts have been imported, so that an
+// interrupted import can be resumed without creating duplicates.
+type State struct {
+	// Posts maps WordPress post IDs to the IDs of the imported posts.
+	Posts map[int64]uint `json:"posts"`
+	// Rewritten holds the imported posts whose links have been rewritten.
+	Rewritten map[int64]bool `json:"rewritten"`
+}
+
+// NewState returns the state of an import that hasn't started.
+func NewState() *State {
+	return &State{Posts: map[int64]uint{}, Rewritten: map[int64]bool{}}
+}
+
+// Store is where an import writes. Every change to a post is saved along
+// with the state of the import after it, in a single transaction, so that
+// the state never claims a post that wasn't written nor misses one that was.
+type Store interface {
+	// FirstOrCreateTag loads the tag with the same slug, creating it if
+	// there is none.
+	FirstOrCreateTag(ctx context.Context, tag *models.Tag) error
+	// FirstOrCreateCategory loads the category with the same slug, creating
+	// it if there is none.
+	FirstOrCreateCategory(ctx context.Context, category *models.Category) error
+	GetByID(ctx context.Context, id uint) (*models.BlogPost, error)
+	// CreatePost creates a post with its tags, categories and comments, and
+	// saves the state returned by imported, which is called once the post
+	// has its ID.
+	CreatePost(ctx context.Context, post *models.BlogPost, imported func() *State) error
+	// UpdateBody replaces the body of a post, keeping its timestamps, and
+	// saves st.
+	UpdateBody(ctx context.Context, id uint, body string, st *State) error
+	// SaveState saves st.
+	SaveState(ctx context.Context, st *State) error
+}
+
+// Report summarises an import.
+type Report struct {
+	Imported       int `json:"imported"`
+	Resumed        int `json:"resumed"`
+	Skipped        int `json:"skipped"`
+	Failed         int `json:"failed"`
+	Comments       int `json:"comments"`
+	LinksRewritten int `json:"links_rewritten"`
+}
+
+// importer holds what is needed while importing a single export.
+type importer struct {
+	store Store
+	state *State
+	opts  Options
+	site  *Site
+
+	report     Report
+	tags       map[string]models.Tag
+	categories map[string]models.Category
+	// links maps the path of every post permalink to its WordPress ID.
+	links map[string]int64
+}
+
+// Import reads a WXR export from rd and creates a post, with its tags,
+// categories and comments, for every WordPress post whose status is one of
+// Options.Statuses. Pages, attachments and other statuses are skipped. Posts
+// already recorded in st are not imported again, and st is updated and saved
+// as posts are imported. Once every post exists, links between them are
+// rewritten to point to the imported posts.
+func Import(ctx context.Context, store Store, rd io.Reader, st *State, opts Options) (*Report, error) {
+	if opts.LinkTemplate == "" {
+		opts.LinkTemplate = DefaultLinkTemplate
+	}
+	if len(opts.Statuses) == 0 {
+		opts.Statuses = []string{"publish"}
+	}
+	dec := NewDecoder(rd)
+	im := &importer{
+		store:      store,
+		state:      st,
+		opts:       opts,
+		site:       &dec.Site,
+		tags:       map[string]models.Tag{},
+		categories: map[string]models.Category{},
+		links:      map[string]int64{},
+	}
+
+	for n := 1; ; n++ {
+		it, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return &im.report, err
+		}
+		if err := ctx.Err(); err != nil {
+			return &im.report, err
+		}
+		if err := im.item(ctx, n, it); err != nil {
+			return &im.report, err
+		}
+	}
+
+	if err := im.rewriteLinks(ctx); err != nil {
+		return &im.report, err
+	}
+	return &im.report, nil
+}
+
+func (im *importer) item(ctx context.Context, n int, it *Item) error {
+	p := Progress{Item: n, WordPressID: it.PostID, Title: it.Title}
+	defer func() {
+		if im.opts.Progress != nil {
+			im.opts.Progress(p)
+		}
+	}()
+
+	if it.PostType != "post" || !slices.Contains(im.opts.Statuses, it.Status) {
+		p.Action = "skipped"
+		im.report.Skipped++
+		return nil
+	}
+	if path := linkPath(it.Link); path != "" {
+		im.links[path] = it.PostID
+	}
+	if id, ok := im.state.Posts[it.PostID]; ok {
+		p.Action, p.PostID = "resumed", id
+		im.report.Resumed++
+		return nil
+	}
+
+	post, err := im.post(ctx, it)
+	if err == nil {
+		err = im.store.CreatePost(ctx, post, func() *State {
+			im.state.Posts[it.PostID] = post.ID
+			return im.state
+		})
+	}
+	var verr validationError
+	if errors.As(err, &verr) {
+		p.Action, p.Err = "failed", err
+		im.report.Failed++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("post %d: %w", it.PostID, err)
+	}
+
+	p.Action, p.PostID = "imported", post.ID
+	im.report.Imported++
+	im.report.Comments += len(post.Comments)
+	return nil
+}
+
+// validationError is a post that the API would reject.
+type validationError struct {
+	msg string
+}
+
+func (e validationError) Error() string {
+	return e.msg
+}
+
+// post maps an item to a blog post, resolving its terms to stored tags and
+// categories.
+func (im *importer) post(ctx context.Context, it *Item) (*models.BlogPost, error) {
+	title := it.Title
+	if strings.TrimSpace(title) == "" {
+		title = "Untitled"
+	}
+	description := plainText(it.Excerpt())
+	if description == "" {
+		description = truncate(plainText(it.Content()), descriptionLength)
+	}
+
+	req := models.CreateBlogRequest{Title: html.UnescapeString(title), Description: description, Body: it.Content()}
+	req.Normalize()
+	if err := models.Validate.Struct(req); err != nil {
+		var msgs []string
+		for _, fe := range models.FieldErrors(err, models.DefaultLocale) {
+			msgs = append(msgs, fe.Message)
+		}
+		return nil, validationError{strings.Join(msgs, "; ")}
+	}
+
+	post := &models.BlogPost{
+		Title:       req.Title,
+		Description: req.Description,
+		Body:        req.Body,
+		Status:      models.StatusDraft,
+		CreatedAt:   it.Published(),
+		UpdatedAt:   it.Modified(),
+	}
+	if it.Status == "publish" {
+		published := it.Published()
+		post.Status, post.PublishedAt = models.StatusPublished, &published
+	}
+	for _, term := range it.Terms {
+		switch term.Domain {
+		case "post_tag":
+			tag, err := im.tag(ctx, term.Slug, term.Name)
+			if err != nil {
+				return nil, err
+			}
+			post.Tags = append(post.Tags, tag)
+		case "category":
+			cat, err := im.category(ctx, term.Slug, term.Name, 0)
+			if err != nil {
+				return nil, err
+			}
+			post.Categories = append(post.Categories, cat)
+		}
+	}
+	for _, c := range it.Comments {
+		// Pingbacks, trackbacks, spam and trashed comments are left behind.
+		if (c.Type != "" && c.Type != "comment") || (c.Approved != "1" && c.Approved != "0") {
+			continue
+		}
+		post.Comments = append(post.Comments, models.Comment{
+			Author:      c.Author,
+			AuthorEmail: c.AuthorEmail,
+			AuthorURL:   c.AuthorURL,
+			Body:        c.Content,
+			Approved:    c.Approved == "1",
+			CreatedAt:   Date(c.DateGMT),
+		})
+	}
+	return post, nil
+}
+
+func (im *importer) tag(ctx context.Context, slug, name string) (models.Tag, error) {
+	if tag, ok := im.tags[slug]; ok {
+		return tag, nil
+	}
+	for _, t := range im.site.Tags {
+		if t.Slug == slug && t.Name != "" {
+			name = t.Name
+		}
+	}
+	tag := models.Tag{Slug: slug, Name: html.UnescapeString(name)}
+	if err := im.store.FirstOrCreateTag(ctx, &tag); err != nil {
+		return tag, fmt.Errorf("tag %q: %w", slug, err)
+	}
+	im.tags[slug] = tag
+	return tag, nil
+}
+
+// category stores a category, and its parents first. depth guards against
+// parent cycles in malformed exports.
+func (im *importer) category(ctx context.Context, slug, name string, depth int) (models.Category, error) {
+	if cat, ok := im.categories[slug]; ok {
+		return cat, nil
+	}
+	var parent string
+	for _, c := range im.site.Categories {
+		if c.Slug == slug {
+			parent = c.Parent
+			if c.Name != "" {
+				name = c.Name
+			}
+		}
+	}
+
+	cat := models.Category{Slug: slug, Name: html.UnescapeString(name)}
+	if parent != "" && parent != slug && depth < 16 {
+		p, err := im.category(ctx, parent, parent, depth+1)
+		if err != nil {
+			return cat, err
+		}
+		cat.ParentID = &p.ID
+	}
+	if err := im.store.FirstOrCreateCategory(ctx, &cat); err != nil {
+		return cat, fmt.Errorf("category %q: %w", slug, err)
+	}
+	im.categories[slug] = cat
+	return cat, nil
+}
+
+var (
+	tagPattern  = regexp.MustCompile(`<[^>]*>`)
+	hrefPattern = regexp.MustCompile(`(?i)(href\s*=\s*)(["'])([^"']*)(["'])`)
+)
+
+// plainText strips the markup from HTML content.
+func plainText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(tagPattern.ReplaceAllString(s, " "))), " ")
+}
+
+// truncate shortens s to at most n runes, cutting at a word boundary.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)[:n-1]
+	cut := string(runes)
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// rewriteLinks points links between imported posts to their new location.
+func (im *importer) rewriteLinks(ctx context.Context) error {
+	ids := make([]int64, 0, len(im.state.Posts))
+	for wpID := range im.state.Posts {
+		if !im.state.Rewritten[wpID] {
+			ids = append(ids, wpID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, wpID := range ids {
+		id := im.state.Posts[wpID]
+		post, err := im.store.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("post %d: %w", id, err)
+		}
+		body, n := im.rewrite(post.Body)
+		im.state.Rewritten[wpID] = true
+		if n > 0 {
+			err = im.store.UpdateBody(ctx, id, body, im.state)
+		} else {
+			err = im.store.SaveState(ctx, im.state)
+		}
+		if err != nil {
+			return fmt.Errorf("post %d: %w", id, err)
+		}
+		im.report.LinksRewritten += n
+	}
+	return nil
+}
+
+// rewrite replaces the href of every link to an imported post and returns
+// the number of links replaced.
+func (im *importer) rewrite(body string) (string, int) {
+	n := 0
+	body = hrefPattern.ReplaceAllStringFunc(body, func(m string) string {
+		parts := hrefPattern.FindStringSubmatch(m)
+		target, ok := im.target(html.UnescapeString(parts[3]))
+		if !ok {
+			return m
+		}
+		n++
+		return parts[1] + parts[2] + target + parts[4]
+	})
+	return body, n
+}
+
+// target resolves a link found in a post to its new location, if it points
+// to an imported post.
+func (im *importer) target(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || !im.internal(u) {
+		return "", false
+	}
+
+	var wpID int64
+	for _, key := range []string{"p", "page_id"} {
+		if v := u.Query().Get(key); v != "" {
+			wpID, _ = strconv.ParseInt(v, 10, 64)
+		}
+	}
+	if wpID == 0 {
+		wpID = im.links[strings.TrimSuffix(u.EscapedPath(), "/")]
+	}
+	id, ok := im.state.Posts[wpID]
+	if !ok {
+		return "", false
+	}
+
+	target := strings.ReplaceAll(im.opts.LinkTemplate, "{id}", strconv.FormatUint(uint64(id), 10))
+	if u.Fragment != "" {
+		target += "#" + u.Fragment
+	}
+	return target, true
+}
+
+// internal reports whether u is relative or points to the exported site.
+func (im *importer) internal(u *url.URL) bool {
+	if u.Host == "" {
+		return u.Scheme == "" && strings.HasPrefix(u.Path, "/") || u.Path == "" && u.RawQuery != ""
+	}
+	for _, site := range []string{im.site.Link, im.site.BaseSiteURL} {
+		if s, err := url.Parse(site); err == nil && s.Host != "" && sameHost(s.Host, u.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameHost(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "www."), strings.TrimPrefix(b, "www."))
+}
+
+// linkPath is the path of a permalink, without its trailing slash.
+func linkPath(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.RawQuery != "" {
+		return ""
+	}
+	return strings.TrimSuffix(u.EscapedPath(), "/")
+}
@@ -0,0 +1,196 @@
+// Package wxr reads WordPress eXtended RSS (WXR) exports and imports their
+// posts, with tags, categories and comments, as blog posts.
+package wxr
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Site is the channel-level metadata of an export. It is filled in as the
+// decoder reads past it, so it is complete once the first item is returned.
+type Site struct {
+	Title       string
+	Link        string
+	BaseSiteURL string
+	Categories  []Category
+	Tags        []Tag
+}
+
+// Category is a category declared by the site. Parent is the parent's slug.
+type Category struct {
+	Slug   string `xml:"category_nicename"`
+	Name   string `xml:"cat_name"`
+	Parent string `xml:"category_parent"`
+}
+
+// Tag is a tag declared by the site.
+type Tag struct {
+	Slug string `xml:"tag_slug"`
+	Name string `xml:"tag_name"`
+}
+
+// Item is a post, page, attachment or any other WordPress post type.
+type Item struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	GUID        string    `xml:"guid"`
+	Creator     string    `xml:"creator"`
+	Encoded     []encoded `xml:"encoded"`
+	PostID      int64     `xml:"post_id"`
+	PostDate    string    `xml:"post_date"`
+	PostDateGMT string    `xml:"post_date_gmt"`
+	ModifiedGMT string    `xml:"post_modified_gmt"`
+	PostName    string    `xml:"post_name"`
+	Status      string    `xml:"status"`
+	PostType    string    `xml:"post_type"`
+	Terms       []Term    `xml:"category"`
+	Comments    []Comment `xml:"comment"`
+}
+
+// encoded holds content:encoded and excerpt:encoded, which share a local
+// name and differ only by namespace.
+type encoded struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+// Term assigns an item to a category or tag.
+type Term struct {
+	Domain string `xml:"domain,attr"`
+	Slug   string `xml:"nicename,attr"`
+	Name   string `xml:",chardata"`
+}
+
+// Comment is a comment, pingback or trackback on an item.
+type Comment struct {
+	ID          int64  `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	AuthorURL   string `xml:"comment_author_url"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+}
+
+// Content is the post body.
+func (it *Item) Content() string {
+	for _, e := range it.Encoded {
+		if !strings.Contains(e.XMLName.Space, "excerpt") {
+			return e.Text
+		}
+	}
+	return ""
+}
+
+// Excerpt is the hand-written summary, if the post has one.
+func (it *Item) Excerpt() string {
+	for _, e := range it.Encoded {
+		if strings.Contains(e.XMLName.Space, "excerpt") {
+			return e.Text
+		}
+	}
+	return ""
+}
+
+// Published is when the item was published, in UTC.
+func (it *Item) Published() time.Time {
+	if t, ok := parseDate(it.PostDateGMT); ok {
+		return t
+	}
+	// Drafts have no GMT date; the local date is the best there is.
+	t, _ := parseDate(it.PostDate)
+	return t
+}
+
+// Modified is when the item was last changed, in UTC. It falls back to the
+// publication date.
+func (it *Item) Modified() time.Time {
+	if t, ok := parseDate(it.ModifiedGMT); ok {
+		return t
+	}
+	return it.Published()
+}
+
+// Date parses a WordPress GMT date such as a comment's.
+func Date(s string) time.Time {
+	t, _ := parseDate(s)
+	return t
+}
+
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "0000-00-00") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.DateTime, s)
+	return t, err == nil
+}
+
+// Decoder reads the items of an export one at a time, so that exports
+// larger than memory can be imported.
+type Decoder struct {
+	d    *xml.Decoder
+	Site Site
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	d := xml.NewDecoder(r)
+	// Exports declare UTF-8, but some old ones claim other charsets while
+	// still being UTF-8 in practice.
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	return &Decoder{d: d}
+}
+
+// Next returns the next item, or io.EOF after the last one.
+func (dec *Decoder) Next() (*Item, error) {
+	for {
+		tok, err := dec.d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid WXR file: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var err error
+			switch t.Name.Local {
+			case "rss", "channel":
+				continue
+			case "item":
+				var it Item
+				if err := dec.d.DecodeElement(&it, &t); err != nil {
+					return nil, fmt.Errorf("invalid WXR item: %w", err)
+				}
+				return &it, nil
+			case "title":
+				err = dec.d.DecodeElement(&dec.Site.Title, &t)
+			case "link":
+				err = dec.d.DecodeElement(&dec.Site.Link, &t)
+			case "base_site_url":
+				err = dec.d.DecodeElement(&dec.Site.BaseSiteURL, &t)
+			case "category":
+				var c Category
+				err = dec.d.DecodeElement(&c, &t)
+				dec.Site.Categories = append(dec.Site.Categories, c)
+			case "tag":
+				var tag Tag
+				err = dec.d.DecodeElement(&tag, &t)
+				dec.Site.Tags = append(dec.Site.Tags, tag)
+			default:
+				err = dec.d.Skip()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid WXR file: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package wxr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memStore keeps posts, tags, categories and the saved state of the import
+// in memory. Like the service, it keeps a post only if the state after it is
+// saved too.
+type memStore struct {
+	posts      map[uint]models.BlogPost
+	tags       map[string]models.Tag
+	categories map[string]models.Category
+	saved      []byte
+	// fail, if set, is called before saving a state and fails the write
+	// with its error.
+	fail func(*State) error
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		posts:      map[uint]models.BlogPost{},
+		tags:       map[string]models.Tag{},
+		categories: map[string]models.Category{},
+	}
+}
+
+func (s *memStore) FirstOrCreateTag(_ context.Context, tag *models.Tag) error {
+	if t, ok := s.tags[tag.Slug]; ok {
+		*tag = t
+		return nil
+	}
+	tag.ID = uint(len(s.tags) + 1)
+	s.tags[tag.Slug] = *tag
+	return nil
+}
+
+func (s *memStore) FirstOrCreateCategory(_ context.Context, cat *models.Category) error {
+	if c, ok := s.categories[cat.Slug]; ok {
+		*cat = c
+		return nil
+	}
+	cat.ID = uint(len(s.categories) + 1)
+	s.categories[cat.Slug] = *cat
+	return nil
+}
+
+func (s *memStore) GetByID(_ context.Context, id uint) (*models.BlogPost, error) {
+	post, ok := s.posts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &post, nil
+}
+
+func (s *memStore) CreatePost(_ context.Context, post *models.BlogPost, imported func() *State) error {
+	post.ID = uint(len(s.posts) + 1)
+	if err := s.save(imported()); err != nil {
+		post.ID = 0
+		return err
+	}
+	s.posts[post.ID] = *post
+	return nil
+}
+
+func (s *memStore) UpdateBody(_ context.Context, id uint, body string, st *State) error {
+	if err := s.save(st); err != nil {
+		return err
+	}
+	post := s.posts[id]
+	post.Body = body
+	s.posts[id] = post
+	return nil
+}
+
+func (s *memStore) SaveState(_ context.Context, st *State) error {
+	return s.save(st)
+}
+
+func (s *memStore) save(st *State) error {
+	if s.fail != nil {
+		if err := s.fail(st); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(st)
+	s.saved = data
+	return err
+}
+
+// state returns the last state saved, as a resumed import would load it.
+func (s *memStore) state(t *testing.T) *State {
+	t.Helper()
+	st := NewState()
+	if s.saved != nil {
+		require.NoError(t, json.Unmarshal(s.saved, st))
+	}
+	return st
+}
+
+func openFixture(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/export.xml")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(openFixture(t))
+	var items []*Item
+	for {
+		it, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		items = append(items, it)
+	}
+
+	assert.Equal(t, "Legacy Blog", dec.Site.Title)
+	assert.Equal(t, "https://www.legacy.example/blog", dec.Site.Link)
+	assert.Equal(t, []Category{
+		{Slug: "engineering", Name: "Engineering"},
+		{Slug: "golang", Name: "Go &amp; friends", Parent: "engineering"},
+	}, dec.Site.Categories)
+	assert.Equal(t, []Tag{{Slug: "release", Name: "Release"}}, dec.Site.Tags)
+
+	require.Len(t, items, 5)
+	first := items[0]
+	assert.Equal(t, int64(10), first.PostID)
+	assert.Equal(t, "The <em>first</em> post.", first.Excerpt())
+	assert.True(t, strings.HasPrefix(first.Content(), "<p>Welcome!"))
+	assert.Equal(t, time.Date(2019, 5, 1, 9, 30, 0, 0, time.UTC), first.Published())
+	assert.Equal(t, time.Date(2019, 5, 3, 8, 0, 0, 0, time.UTC), first.Modified())
+	assert.Len(t, first.Terms, 2)
+	assert.Len(t, first.Comments, 3)
+
+	// A zero modification date falls back to the publication date.
+	assert.Equal(t, items[1].Published(), items[1].Modified())
+	// Drafts have no GMT date.
+	assert.Equal(t, time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC), items[3].Published())
+}
+
+func TestImport(t *testing.T) {
+	r := newMemStore()
+	var progress []string
+	report, err := Import(context.Background(), r, openFixture(t), NewState(), Options{
+		Progress: func(p Progress) { progress = append(progress, p.Action) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &Report{Imported: 2, Skipped: 2, Failed: 1, Comments: 1, LinksRewritten: 3}, report)
+	assert.Equal(t, []string{"imported", "imported", "skipped", "skipped", "failed"}, progress)
+
+	first, second := r.posts[1], r.posts[2]
+	assert.Equal(t, "Hello world", first.Title)
+	assert.Equal(t, "The first post.", first.Description)
+	assert.Equal(t, `<p>Welcome! Read <a href="/posts/2#more">the second post</a>, <a href="/posts/2">again</a> or <a href="https://elsewhere.example/2019/06/second-post/">elsewhere</a>.</p>`, first.Body)
+	assert.Equal(t, time.Date(2019, 5, 1, 9, 30, 0, 0, time.UTC), first.CreatedAt)
+	assert.Equal(t, time.Date(2019, 5, 3, 8, 0, 0, 0, time.UTC), first.UpdatedAt)
+
+	require.Len(t, first.Comments, 1)
+	assert.Equal(t, "Ada", first.Comments[0].Author)
+	assert.True(t, first.Comments[0].Approved)
+	assert.Equal(t, time.Date(2019, 5, 2, 8, 15, 0, 0, time.UTC), first.Comments[0].CreatedAt)
+
+	assert.Contains(t, second.Body, `<a href='/posts/1'>the first one</a>`)
+	assert.True(t, strings.HasSuffix(second.Description, "…"))
+	assert.LessOrEqual(t, len([]rune(second.Description)), descriptionLength)
+
+	assert.Len(t, r.tags, 2)
+	assert.Equal(t, "Release", r.tags["release"].Name)
+	require.Len(t, r.categories, 2)
+	golang := r.categories["golang"]
+	assert.Equal(t, "Go & friends", golang.Name)
+	require.NotNil(t, golang.ParentID)
+	assert.Equal(t, r.categories["engineering"].ID, *golang.ParentID)
+	assert.Equal(t, []models.Tag{r.tags["release"], r.tags["new-tag"]}, second.Tags)
+}
+
+func TestImportStatuses(t *testing.T) {
+	r := newMemStore()
+	report, err := Import(context.Background(), r, openFixture(t), NewState(), Options{Statuses: []string{"draft"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, "Work in progress", r.posts[1].Title)
+}
+
+func TestImportResume(t *testing.T) {
+	r := newMemStore()
+	errStop := errors.New("interrupted")
+	r.fail = func(st *State) error {
+		if len(st.Posts) == 2 {
+			return errStop
+		}
+		return nil
+	}
+	_, err := Import(context.Background(), r, openFixture(t), NewState(), Options{})
+	require.ErrorIs(t, err, errStop)
+	assert.Len(t, r.posts, 1)
+	assert.Equal(t, map[int64]uint{10: 1}, r.state(t).Posts)
+
+	r.fail = nil
+	st := r.state(t)
+	report, err := Import(context.Background(), r, openFixture(t), st, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Resumed)
+	assert.Equal(t, 1, report.Imported)
+	assert.Len(t, r.posts, 2)
+	assert.Equal(t, map[int64]uint{10: 1, 11: 2}, r.state(t).Posts)
+	assert.Contains(t, r.posts[1].Body, `href="/posts/2#more"`)
+
+	// Nothing is left to do on a third run.
+	report, err = Import(context.Background(), r, openFixture(t), r.state(t), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Resumed)
+	assert.Zero(t, report.LinksRewritten)
+}
+
+func TestInvalidFile(t *testing.T) {
+	_, err := Import(context.Background(), newMemStore(), strings.NewReader("<rss><channel><item><title>x</item>"), NewState(), Options{})
+	assert.Error(t, err)
+}